@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Google accounts can own several channels (brand accounts), but an OAuth
+// token is bound to the channel picked on the consent screen. Tokens of
+// additional channels are stored next to the default credentials file as
+// <credentials>_<channel ID>.json and selected with --channel-id.
+
+// channelsIndexFile maps stored channel IDs to their titles for the picker.
+const channelsIndexFile = "channels.json"
+
+// selectedChannelFile remembers the channel picked at the prompt, so the
+// prompt only appears once; 'accounts use' changes it.
+const selectedChannelFile = "selected-channel"
+
+// defaultChannel selects the default token in selectedChannelFile and
+// 'accounts use'.
+const defaultChannel = "default"
+
+var channelCredentialsPattern = regexp.MustCompile(`_(UC[0-9A-Za-z_-]{22})\.json$`)
+
+// channelCredentialsPath returns the token file of channelID.
+func channelCredentialsPath(credentials, channelID string) string {
+	return strings.TrimSuffix(credentials, ".json") + "_" + channelID + ".json"
+}
+
+func channelsIndexPath(config Config) string {
+	return filepath.Join(filepath.Dir(config.Credentials), channelsIndexFile)
+}
+
+func readChannelsIndex(config Config) map[string]string {
+	index := make(map[string]string)
+	if data, err := os.ReadFile(channelsIndexPath(config)); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			warnf("Failed to read channel index: %v", err)
+		}
+	}
+	return index
+}
+
+func saveChannelTitle(config Config, channelID, title string) {
+	index := readChannelsIndex(config)
+	index[channelID] = title
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err == nil {
+		err = os.WriteFile(channelsIndexPath(config), data, 0600)
+	}
+	if err != nil {
+		warnf("Failed to update channel index: %v", err)
+	}
+}
+
+func selectedChannelPath(config Config) string {
+	return filepath.Join(filepath.Dir(config.Credentials), selectedChannelFile)
+}
+
+// readSelectedChannel returns the remembered channel; "" with ok selects
+// the default token.
+func readSelectedChannel(config Config) (id string, ok bool) {
+	data, err := os.ReadFile(selectedChannelPath(config))
+	if err != nil {
+		return "", false
+	}
+	id = strings.TrimSpace(string(data))
+	if id == defaultChannel {
+		return "", true
+	}
+	return id, id != ""
+}
+
+func saveSelectedChannel(config Config, id string) error {
+	if id == "" {
+		id = defaultChannel
+	}
+	return os.WriteFile(selectedChannelPath(config), []byte(id+"\n"), 0600)
+}
+
+// storedChannels returns the IDs of the channels with a stored token,
+// sorted.
+func storedChannels(config Config) []string {
+	matches, _ := filepath.Glob(strings.TrimSuffix(config.Credentials, ".json") + "_UC*.json")
+	var ids []string
+	for _, match := range matches {
+		if m := channelCredentialsPattern.FindStringSubmatch(match); m != nil {
+			ids = append(ids, m[1])
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// selectChannel points config at the token of the channel chosen with
+// --channel-id or remembered from an earlier prompt. Otherwise it asks which
+// stored channel to use when several exist and a terminal is attached, and
+// remembers the answer.
+func selectChannel(config *Config) error {
+	if config.OnBehalfOf != "" {
+		// Content owners act for their channels with one token
+		return nil
+	}
+	if config.ChannelID == "" {
+		channels := storedChannels(*config)
+		if len(channels) == 0 {
+			return nil
+		}
+		if id, ok := readSelectedChannel(*config); ok && (id == "" || slices.Contains(channels, id)) {
+			config.ChannelID = id
+		} else if stdinIsTerminal() {
+			id, err := pickChannel(*config, channels)
+			if err != nil {
+				return err
+			}
+			if err := saveSelectedChannel(*config, id); err != nil {
+				warnf("Failed to remember the channel: %v", err)
+			} else {
+				infof("Remembered this choice; change it with 'ytdata accounts use'")
+			}
+			config.ChannelID = id
+		}
+	}
+	if config.ChannelID != "" {
+		config.Credentials = channelCredentialsPath(config.Credentials, config.ChannelID)
+	}
+	return nil
+}
+
+// stdinIsTerminal reports whether someone can answer prompts. /dev/null,
+// the usual stdin of cron jobs, is a character device too.
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(stat, null)
+}
+
+// pickChannel asks which channel to use; "" selects the default token.
+func pickChannel(config Config, channels []string) (string, error) {
+	index := readChannelsIndex(config)
+	// The choices belong to the prompt, so they go to the terminal, not the log
+	fmt.Fprintln(os.Stderr, "Several channels are authorized:")
+	fmt.Fprintln(os.Stderr, "  0) default account")
+	for i, id := range channels {
+		fmt.Fprintf(os.Stderr, "  %d) %s %s\n", i+1, id, index[id])
+	}
+	answer := promptUser("Channel to use [0]: ")
+	if answer == "" {
+		return "", nil
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 0 || n > len(channels) {
+		return "", withKind(ErrInvalidConfig, fmt.Errorf("invalid choice %q", answer))
+	}
+	if n == 0 {
+		return "", nil
+	}
+	return channels[n-1], nil
+}
+
+// tokenChannels returns the channels a token grants access to.
+func tokenChannels(ctx context.Context, oauthConfig *oauth2.Config, token *oauth2.Token) ([]*youtube.Channel, error) {
+	service, err := youtube.NewService(ctx, option.WithHTTPClient(oauthConfig.Client(ctx, token)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create youtube service: %w", err)
+	}
+	response, err := service.Channels.List([]string{"id", "snippet"}).Mine(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch authorized channels: %w", err)
+	}
+	return response.Items, nil
+}
+
+// verifyTokenChannel checks that a newly granted token belongs to the
+// channel requested with --channel-id, since the channel is picked in the
+// browser.
+func verifyTokenChannel(ctx context.Context, oauthConfig *oauth2.Config, token *oauth2.Token, config Config) error {
+	channels, err := tokenChannels(ctx, oauthConfig, token)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, channel := range channels {
+		if channel.Id == config.ChannelID {
+			saveChannelTitle(config, channel.Id, channel.Snippet.Title)
+			return nil
+		}
+		ids = append(ids, channel.Id)
+	}
+	return withKind(ErrAuth, fmt.Errorf("authorized channel %s instead of %s; pick %s on the consent screen", strings.Join(ids, ", "), config.ChannelID, config.ChannelID))
+}
+
+// contentOwnerResources are the Data API resources whose methods accept
+// onBehalfOfContentOwner; the API rejects it everywhere else, e.g. on
+// comments.
+var contentOwnerResources = []string{
+	"captions", "channelSections", "channels", "liveBroadcasts", "liveStreams",
+	"playlistImages", "playlistItems", "playlists", "search", "thumbnails",
+	"videos", "watermarks",
+}
+
+// contentOwnerChannelResources are the resources whose inserts also accept
+// onBehalfOfContentOwnerChannel.
+var contentOwnerChannelResources = []string{
+	"channelSections", "liveBroadcasts", "liveStreams", "playlistImages",
+	"playlists", "videos",
+}
+
+// contentOwnerParams reports whether a request accepts
+// onBehalfOfContentOwner and onBehalfOfContentOwnerChannel.
+func contentOwnerParams(method, path string) (owner, channel bool) {
+	_, rest, ok := strings.Cut(path, "/youtube/v3/")
+	if !ok {
+		return false, false
+	}
+	resource, action, _ := strings.Cut(rest, "/")
+	switch {
+	case resource == "subscriptions":
+		// Only subscriptions.list, not insert and delete
+		return method == http.MethodGet, false
+	case resource == "videos" && action == "rate":
+		return false, false
+	case slices.Contains(contentOwnerResources, resource):
+		return true, method == http.MethodPost && action == "" && slices.Contains(contentOwnerChannelResources, resource)
+	}
+	return false, false
+}
+
+// contentOwnerTransport adds the content owner selected with --on-behalf-of
+// to the Data API requests that accept it, and the channel selected with
+// --channel-id to inserts.
+type contentOwnerTransport struct {
+	owner   string
+	channel string
+	base    http.RoundTripper
+}
+
+func (t *contentOwnerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	owner, channel := contentOwnerParams(req.Method, req.URL.Path)
+	if !owner {
+		return t.base.RoundTrip(req)
+	}
+	clone := req.Clone(req.Context())
+	query := clone.URL.Query()
+	query.Set("onBehalfOfContentOwner", t.owner)
+	if t.channel != "" && channel {
+		query.Set("onBehalfOfContentOwnerChannel", t.channel)
+	}
+	clone.URL.RawQuery = query.Encode()
+	return t.base.RoundTrip(clone)
+}
+
+// withContentOwner wraps client for --on-behalf-of; without it, client is
+// returned unchanged.
+func withContentOwner(client *http.Client, config Config) *http.Client {
+	if config.OnBehalfOf == "" {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	owned := *client
+	owned.Transport = &contentOwnerTransport{owner: config.OnBehalfOf, channel: config.ChannelID, base: base}
+	return &owned
+}
+
+// accountRecord describes one authorized channel.
+type accountRecord struct {
+	ChannelID   string `json:"channelId"`
+	Title       string `json:"title,omitempty"`
+	Credentials string `json:"credentials"`
+	Default     bool   `json:"default"`
+	Selected    bool   `json:"selected,omitempty"`
+}
+
+func newAccountsCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "List the channels you authorized",
+		Long: `List the channels with a stored token: the default account and every
+channel added with 'accounts add'. Use --channel-id to run any command as
+one of them.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{accountsAnnotation: "true"},
+		Example: `  ytdata accounts
+  ytdata accounts add
+  ytdata liked --channel-id UCxxxxxxxxxxxxxxxxxxxxxx`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, listAccounts)
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write accounts to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add",
+		Short: "Authorize another channel of your Google account",
+		Long: `Authorize another channel, e.g. a brand account. Pick the channel on the
+consent screen; its token is stored separately, so it can be selected with
+--channel-id without replacing the default account.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{accountsAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, addAccount)
+		},
+	}, &cobra.Command{
+		Use:   "use CHANNEL_ID|default",
+		Short: "Choose the channel commands run as without --channel-id",
+		Long: `Choose the authorized channel commands run as when --channel-id is not
+given, replacing the choice made at the prompt.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{accountsAnnotation: "true", localAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return useAccount(config, args[0])
+			})
+		},
+	})
+
+	return cmd
+}
+
+func addAccount(config Config) error {
+	if config.AuthMode != authModeOAuth {
+		return withKind(ErrInvalidConfig, fmt.Errorf("adding channels requires --auth-mode %s", authModeOAuth))
+	}
+	oauthConfig, err := getOAuthConfig(config.ClientSecret, config.Scopes)
+	if err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("failed to get oauth config: %w", err))
+	}
+	token, err := performOAuthFlow(oauthConfig)
+	if err != nil {
+		return withKind(ErrAuth, fmt.Errorf("oauth flow failed: %w", err))
+	}
+	channels, err := tokenChannels(context.Background(), oauthConfig, token)
+	if err != nil {
+		return err
+	}
+	if len(channels) == 0 {
+		return withKind(ErrAuth, fmt.Errorf("the authorized account has no YouTube channel"))
+	}
+	for _, channel := range channels {
+		if err := saveCredentials(channelCredentialsPath(config.Credentials, channel.Id), token); err != nil {
+			return err
+		}
+		saveChannelTitle(config, channel.Id, channel.Snippet.Title)
+		infof("Added channel %s (%s); use --channel-id %s", channel.Snippet.Title, channel.Id, channel.Id)
+	}
+	return nil
+}
+
+func useAccount(config Config, id string) error {
+	if id == defaultChannel {
+		id = ""
+	} else if !slices.Contains(storedChannels(config), id) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("channel %s is not authorized; add it with 'ytdata accounts add'", id))
+	}
+	if err := saveSelectedChannel(config, id); err != nil {
+		return fmt.Errorf("failed to save the channel: %w", err)
+	}
+	if id == "" {
+		infof("Commands run as the default account")
+	} else {
+		infof("Commands run as channel %s", id)
+	}
+	return nil
+}
+
+func listAccounts(config Config) (err error) {
+	var records []accountRecord
+	selected, _ := readSelectedChannel(config)
+	if _, statErr := os.Stat(config.Credentials); statErr == nil {
+		record := accountRecord{Credentials: config.Credentials, Default: true, Selected: selected == ""}
+		record.ChannelID = accountChannelID(config)
+		records = append(records, record)
+	}
+	index := readChannelsIndex(config)
+	for _, id := range storedChannels(config) {
+		records = append(records, accountRecord{
+			ChannelID:   id,
+			Title:       index[id],
+			Credentials: channelCredentialsPath(config.Credentials, id),
+			Selected:    id == selected,
+		})
+	}
+
+	out, err := openOutput(config, "accounts")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	for _, record := range records {
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write account data: %w", err)
+		}
+	}
+	if len(records) == 0 {
+		infof("No accounts authorized yet")
+	}
+	return nil
+}
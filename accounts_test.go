@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestContentOwnerParams(t *testing.T) {
+	for _, tt := range []struct {
+		method, path   string
+		owner, channel bool
+	}{
+		{http.MethodGet, "/youtube/v3/videos", true, false},
+		{http.MethodPost, "/upload/youtube/v3/videos", true, true},
+		{http.MethodPost, "/youtube/v3/playlists", true, true},
+		{http.MethodPost, "/youtube/v3/playlistItems", true, false},
+		{http.MethodPost, "/youtube/v3/videos/rate", false, false},
+		{http.MethodGet, "/youtube/v3/subscriptions", true, false},
+		{http.MethodPost, "/youtube/v3/subscriptions", false, false},
+		{http.MethodGet, "/youtube/v3/commentThreads", false, false},
+		{http.MethodGet, "/oauth2/v3/userinfo", false, false},
+	} {
+		owner, channel := contentOwnerParams(tt.method, tt.path)
+		if owner != tt.owner || channel != tt.channel {
+			t.Errorf("contentOwnerParams(%s %s) = %t, %t, want %t, %t", tt.method, tt.path, owner, channel, tt.owner, tt.channel)
+		}
+	}
+}
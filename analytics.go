@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtubeanalytics/v2"
+)
+
+const analyticsDateLayout = "2006-01-02"
+
+var dailyMetrics = []string{
+	"views", "estimatedMinutesWatched", "averageViewDuration",
+	"subscribersGained", "subscribersLost", "likes", "comments", "shares",
+}
+
+type AnalyticsQuery struct {
+	StartDate  string
+	EndDate    string
+	Metrics    []string
+	Dimensions []string
+	Filters    string
+	Sort       string
+	MaxResults int64
+}
+
+func newAnalyticsCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Query YouTube Analytics for your channel",
+		Long: `Query the YouTube Analytics API for your channel and export the report
+as JSONL or CSV.
+
+Analytics needs the additional yt-analytics.readonly scope; the first run
+asks for this access and stores the token in its own credentials file.`,
+	}
+
+	var daily AnalyticsQuery
+	dailyCmd := &cobra.Command{
+		Use:          "daily",
+		Short:        "Views, watch time and subscriber change per day",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata analytics daily
+  ytdata analytics daily --start 2024-01-01 --end 2024-12-31 -f csv -o 2024.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			daily.Metrics = dailyMetrics
+			daily.Dimensions = []string{"day"}
+			daily.Sort = "day"
+			return runAnalytics(cmd, config, daily)
+		},
+	}
+	addDateRangeFlags(dailyCmd, &daily)
+
+	var query AnalyticsQuery
+	queryCmd := &cobra.Command{
+		Use:          "query",
+		Short:        "Run a custom analytics report",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata analytics query --metrics views,likes --dimensions video --sort -views --max 25
+  ytdata analytics query --metrics views --dimensions country -f csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnalytics(cmd, config, query)
+		},
+	}
+	addDateRangeFlags(queryCmd, &query)
+	queryCmd.Flags().StringSliceVar(&query.Metrics, "metrics", []string{"views"}, "Metrics to report")
+	queryCmd.Flags().StringSliceVar(&query.Dimensions, "dimensions", nil, "Dimensions to group by (e.g. day, video, country)")
+	queryCmd.Flags().StringVar(&query.Filters, "filters", "", "Report filters (e.g. country==US)")
+	queryCmd.Flags().StringVar(&query.Sort, "sort", "", "Sort order (prefix with - for descending)")
+	queryCmd.Flags().Int64Var(&query.MaxResults, "max", 0, "Maximum number of rows (required by some dimensions, e.g. video)")
+
+	for _, sub := range []*cobra.Command{dailyCmd, queryCmd} {
+		addOutputFlag(sub, "", "Write report to stdout (or file with -o)")
+		addFormatFlag(sub, formatJSONL, formatCSV)
+		cobra.CheckErr(sub.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"jsonl", "csv"}, cobra.ShellCompDirectiveFilterFileExt
+		}))
+	}
+
+	cmd.AddCommand(dailyCmd, queryCmd)
+	return cmd
+}
+
+func addDateRangeFlags(cmd *cobra.Command, query *AnalyticsQuery) {
+	today := time.Now()
+	cmd.Flags().StringVar(&query.StartDate, "start", today.AddDate(0, 0, -28).Format(analyticsDateLayout), "Start date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&query.EndDate, "end", today.Format(analyticsDateLayout), "End date (YYYY-MM-DD)")
+}
+
+func (q AnalyticsQuery) validate() error {
+	start, err := time.Parse(analyticsDateLayout, q.StartDate)
+	if err != nil {
+		return fmt.Errorf("invalid start date %q: expected YYYY-MM-DD", q.StartDate)
+	}
+	end, err := time.Parse(analyticsDateLayout, q.EndDate)
+	if err != nil {
+		return fmt.Errorf("invalid end date %q: expected YYYY-MM-DD", q.EndDate)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("end date %s is before start date %s", q.EndDate, q.StartDate)
+	}
+	if len(q.Metrics) == 0 {
+		return fmt.Errorf("at least one metric is required")
+	}
+	return nil
+}
+
+func runAnalytics(cmd *cobra.Command, config *Config, query AnalyticsQuery) error {
+	if err := query.validate(); err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+	return createCommandHandler(cmd, config, func(config Config) error {
+		config = withScopes(config, "analytics", youtubeanalytics.YtAnalyticsReadonlyScope)
+		return fetchAnalytics(config, query)
+	})
+}
+
+func fetchAnalytics(config Config, query AnalyticsQuery) (err error) {
+	client, err := authenticateClient(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	service, err := youtubeanalytics.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("failed to create analytics service: %w", err)
+	}
+
+	call := service.Reports.Query().
+		Ids("channel==MINE").
+		StartDate(query.StartDate).
+		EndDate(query.EndDate).
+		Metrics(strings.Join(query.Metrics, ","))
+	if len(query.Dimensions) > 0 {
+		call = call.Dimensions(strings.Join(query.Dimensions, ","))
+	}
+	if query.Filters != "" {
+		call = call.Filters(query.Filters)
+	}
+	if query.Sort != "" {
+		call = call.Sort(query.Sort)
+	}
+	if query.MaxResults > 0 {
+		call = call.MaxResults(query.MaxResults)
+	}
+
+	response, err := call.Do()
+	if err != nil {
+		return fmt.Errorf("failed to query analytics: %w", err)
+	}
+
+	writer, err := createOutputWriter(config)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(writer, &err)
+
+	if config.Format == formatCSV {
+		return writeAnalyticsCSV(writer, response)
+	}
+	return writeAnalyticsJSONL(writer, response)
+}
+
+func analyticsColumns(response *youtubeanalytics.QueryResponse) []string {
+	columns := make([]string, len(response.ColumnHeaders))
+	for i, header := range response.ColumnHeaders {
+		columns[i] = header.Name
+	}
+	return columns
+}
+
+func writeAnalyticsJSONL(w io.Writer, response *youtubeanalytics.QueryResponse) error {
+	columns := analyticsColumns(response)
+	encoder := json.NewEncoder(w)
+	for _, row := range response.Rows {
+		record := make(map[string]any, len(columns))
+		for i, value := range row {
+			if i < len(columns) {
+				record[columns[i]] = value
+			}
+		}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write analytics data: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeAnalyticsCSV(w io.Writer, response *youtubeanalytics.QueryResponse) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(analyticsColumns(response)); err != nil {
+		return fmt.Errorf("failed to write analytics data: %w", err)
+	}
+	for _, row := range response.Rows {
+		fields := make([]string, len(row))
+		for i, value := range row {
+			fields[i] = fmt.Sprint(value)
+		}
+		if err := writer.Write(fields); err != nil {
+			return fmt.Errorf("failed to write analytics data: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
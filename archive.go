@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const archiveManifestFile = "manifest.json"
+
+const (
+	archiveStatusDownloaded = "downloaded"
+	archiveStatusFailed     = "failed"
+)
+
+type ArchiveOptions struct {
+	From        string
+	Dest        string
+	YtDlp       string
+	Concurrency int
+	ExtraArgs   []string
+}
+
+type archiveEntry struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Attempts  int    `json:"attempts"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// archiveManifest tracks the download state of every video in an archive
+// directory so interrupted runs can resume where they left off.
+type archiveManifest struct {
+	path    string
+	mu      sync.Mutex
+	Entries map[string]*archiveEntry `json:"videos"`
+}
+
+func newArchiveCmd() *cobra.Command {
+	var opts ArchiveOptions
+
+	cmd := &cobra.Command{
+		Use:   "archive [-- yt-dlp args...]",
+		Short: "Download videos from an export with yt-dlp",
+		Long: `Download every video referenced in a JSONL export using yt-dlp.
+
+Download state is tracked in a manifest inside the destination directory,
+so already archived videos are skipped and failed ones are retried on the
+next run.`,
+		Args: cobra.ArbitraryArgs,
+		Example: `  ytdata archive --from liked.jsonl --dest ./archive
+  ytdata archive --from liked.jsonl --dest ./archive --concurrency 4
+  ytdata archive --from liked.jsonl --dest ./archive -- --format mp4`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ExtraArgs = args
+			return runArchive(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.From, "from", "", "JSONL export to read video IDs from")
+	cmd.Flags().StringVar(&opts.Dest, "dest", "archive", "Directory to download videos into")
+	cmd.Flags().StringVar(&opts.YtDlp, "yt-dlp", "", "Path to yt-dlp binary (auto-detected on PATH if not specified)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 2, "Number of parallel downloads")
+	cobra.CheckErr(cmd.MarkFlagRequired("from"))
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("from", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("dest", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}))
+
+	return cmd
+}
+
+func findYtDlp(configured string) (string, error) {
+	if configured == "" {
+		configured = os.Getenv("YTDATA_YT_DLP")
+	}
+	if configured != "" {
+		if _, err := os.Stat(configured); err != nil {
+			return exec.LookPath(configured)
+		}
+		return configured, nil
+	}
+	path, err := exec.LookPath("yt-dlp")
+	if err != nil {
+		return "", withKind(ErrInvalidConfig, fmt.Errorf("yt-dlp not found on PATH (install it or pass --yt-dlp)"))
+	}
+	return path, nil
+}
+
+func loadArchiveManifest(dest string) (*archiveManifest, error) {
+	manifest := &archiveManifest{
+		path:    filepath.Join(dest, archiveManifestFile),
+		Entries: make(map[string]*archiveEntry),
+	}
+
+	data, err := os.ReadFile(manifest.path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse archive manifest: %w", err)
+	}
+	if manifest.Entries == nil {
+		manifest.Entries = make(map[string]*archiveEntry)
+	}
+	return manifest, nil
+}
+
+func (m *archiveManifest) isArchived(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Entries[id]
+	return ok && entry.Status == archiveStatusDownloaded
+}
+
+// record updates the state of a video and persists the manifest, writing
+// to a temporary file first so a crash never leaves it truncated.
+func (m *archiveManifest) record(id string, downloadErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.Entries[id]
+	if !ok {
+		entry = &archiveEntry{}
+		m.Entries[id] = entry
+	}
+	entry.Attempts++
+	entry.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if downloadErr != nil {
+		entry.Status = archiveStatusFailed
+		entry.Error = downloadErr.Error()
+	} else {
+		entry.Status = archiveStatusDownloaded
+		entry.Error = ""
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize archive manifest: %w", err)
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	return nil
+}
+
+func downloadVideo(ytDlp, dest, id string, extraArgs []string) error {
+	args := []string{
+		"--no-progress",
+		"--output", filepath.Join(dest, "%(id)s.%(ext)s"),
+	}
+	args = append(args, extraArgs...)
+	args = append(args, "--", id)
+
+	output, err := exec.Command(ytDlp, args...).CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		return fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+	}
+	return nil
+}
+
+func runArchive(opts ArchiveOptions) error {
+	if opts.Concurrency < 1 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("concurrency must be at least 1"))
+	}
+
+	ytDlp, err := findYtDlp(opts.YtDlp)
+	if err != nil {
+		return err
+	}
+
+	ids, err := readVideoIDs(opts.From)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(opts.Dest, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	manifest, err := loadArchiveManifest(opts.Dest)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, id := range ids {
+		if !manifest.isArchived(id) {
+			pending = append(pending, id)
+		}
+	}
+	infof("%d videos in export, %d already archived, %d to download",
+		len(ids), len(ids)-len(pending), len(pending))
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		done     int
+		failures []string
+	)
+	sem := make(chan struct{}, opts.Concurrency)
+
+	for _, id := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			downloadErr := downloadVideo(ytDlp, opts.Dest, id, opts.ExtraArgs)
+			if err := manifest.record(id, downloadErr); err != nil {
+				warnf("%v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if downloadErr != nil {
+				failures = append(failures, id)
+				warnf("[%d/%d] %s failed: %v", done, len(pending), id, downloadErr)
+			} else {
+				infof("[%d/%d] %s archived", done, len(pending), id)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return withKind(ErrPartial, fmt.Errorf("%d of %d downloads failed (re-run to retry): %s",
+			len(failures), len(pending), strings.Join(failures, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultAssetsDir  = "assets"
+	assetManifestFile = "manifest.jsonl"
+)
+
+// assetEntry is one line of the assets manifest.
+type assetEntry struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	File string `json:"file"`
+}
+
+func addAssetFlags(cmd *cobra.Command, config *Config) {
+	cmd.Flags().BoolVar(&config.DownloadBanners, "download-banners", false, "Download channel banners and playlist thumbnails to --assets-dir")
+	cmd.Flags().StringVar(&config.AssetsDir, "assets-dir", defaultAssetsDir, "Directory downloaded images and their manifest are written to")
+	cobra.CheckErr(cmd.MarkFlagDirname("assets-dir"))
+}
+
+// assetWriter downloads the channel banners and playlist thumbnails
+// referenced by the records passing through it. The manifest mapping
+// records to files is written on Close.
+type assetWriter struct {
+	output.Writer
+	dir      string
+	client   *http.Client
+	manifest []assetEntry
+}
+
+func newAssetWriter(out output.Writer, dir string) (*assetWriter, error) {
+	if dir == "" {
+		dir = defaultAssetsDir
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create assets directory: %w", err)
+	}
+	return &assetWriter{Writer: out, dir: dir, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// recordAsset returns the image referenced by a channel or playlist record
+// and its type, or an empty URL for other records.
+func recordAsset(record map[string]any) (assetType, url string) {
+	switch lookupString(record, "kind") {
+	case "youtube#channel":
+		return "banner", lookupString(record, "brandingSettings", "image", "bannerExternalUrl")
+	case "youtube#playlist":
+		for _, size := range []string{"maxres", "standard", "high", "medium", "default"} {
+			if url := lookupString(record, "snippet", "thumbnails", size, "url"); url != "" {
+				return "thumbnail", url
+			}
+		}
+	}
+	return "", ""
+}
+
+// imageExtension picks a file extension for an image content type.
+func imageExtension(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/png"):
+		return ".png"
+	case strings.HasPrefix(contentType, "image/webp"):
+		return ".webp"
+	case strings.HasPrefix(contentType, "image/gif"):
+		return ".gif"
+	default:
+		return ".jpg"
+	}
+}
+
+func (w *assetWriter) Write(record any) error {
+	if err := w.Writer.Write(record); err != nil {
+		return err
+	}
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	assetType, url := recordAsset(m)
+	id := lookupString(m, "id")
+	if url == "" || id == "" {
+		return nil
+	}
+
+	file, err := w.download(url, id+"_"+assetType)
+	if err != nil {
+		warnf("Failed to download %s of %s: %v", assetType, id, err)
+		return nil
+	}
+	w.manifest = append(w.manifest, assetEntry{
+		ID:   id,
+		Kind: lookupString(m, "kind"),
+		Type: assetType,
+		URL:  url,
+		File: file,
+	})
+	return nil
+}
+
+// download stores url as name in the assets directory and returns the file
+// name including the extension matching its content type.
+func (w *assetWriter) download(url, name string) (string, error) {
+	resp, err := w.client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	file := name + imageExtension(resp.Header.Get("Content-Type"))
+	f, err := os.Create(filepath.Join(w.dir, file))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return file, f.Close()
+}
+
+func (w *assetWriter) Close() error {
+	err := w.writeManifest()
+	if closeErr := w.Writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (w *assetWriter) writeManifest() error {
+	f, err := os.Create(filepath.Join(w.dir, assetManifestFile))
+	if err != nil {
+		return fmt.Errorf("failed to create assets manifest: %w", err)
+	}
+	encoder := json.NewEncoder(f)
+	for _, entry := range w.manifest {
+		if err := encoder.Encode(entry); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to write assets manifest: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write assets manifest: %w", err)
+	}
+	infof("Downloaded %d images to %s", len(w.manifest), w.dir)
+	return nil
+}
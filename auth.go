@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// testingRefreshTokenTTL is how long refresh tokens of OAuth apps whose
+// consent screen is in Testing mode stay valid.
+const testingRefreshTokenTTL = 7 * 24 * time.Hour
+
+// storedToken is the credentials file format: the OAuth token plus when its
+// refresh token was issued, which the token itself does not record. Older
+// files without these fields still load.
+type storedToken struct {
+	oauth2.Token
+	RefreshTokenIssuedAt  time.Time `json:"refresh_token_issued_at,omitzero"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at,omitzero"`
+}
+
+func readStoredToken(path string) (*storedToken, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stored storedToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// tokenRecord returns what to store for token at path, carrying over when
+// its refresh token was issued unless Google issued a new one.
+func tokenRecord(path string, token *oauth2.Token) storedToken {
+	record := storedToken{Token: *token}
+	previous, err := readStoredToken(path)
+	if err == nil && previous.RefreshToken == token.RefreshToken {
+		record.RefreshTokenIssuedAt = previous.RefreshTokenIssuedAt
+		record.RefreshTokenExpiresAt = previous.RefreshTokenExpiresAt
+		return record
+	}
+	if token.RefreshToken == "" {
+		return record
+	}
+	record.RefreshTokenIssuedAt = time.Now().UTC()
+	// Google reports the lifetime of refresh tokens that expire, e.g. for
+	// apps in Testing mode
+	if seconds, ok := token.Extra("refresh_token_expires_in").(float64); ok && seconds > 0 {
+		record.RefreshTokenExpiresAt = record.RefreshTokenIssuedAt.Add(time.Duration(seconds) * time.Second)
+	}
+	return record
+}
+
+// invalidGrantHelp explains the usual causes of an invalid_grant error when
+// refreshing a token.
+const invalidGrantHelp = `The stored refresh token was rejected (invalid_grant). Common causes:
+  - The OAuth consent screen of your Google Cloud project is in Testing mode,
+    where refresh tokens expire after 7 days. Publish the app under
+    'APIs & Services' > 'OAuth consent screen' > 'Publish app' to get
+    long-lived tokens (unverified apps show a warning on consent).
+  - Access was revoked at https://myaccount.google.com/permissions.
+  - The token was unused for 6 months, or your password was changed.
+Authorize again to get a new token.`
+
+// isInvalidGrant reports whether err is the token endpoint rejecting a
+// refresh token.
+func isInvalidGrant(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant"
+}
+
+// confirmReauthorization explains an invalid_grant error and asks whether
+// to authorize again right away. Without a terminal nobody could complete
+// the browser flow, so it fails with the explanation instead.
+func confirmReauthorization(err error) error {
+	warnf("%s", invalidGrantHelp)
+	if !stdinIsTerminal() {
+		return withKind(ErrAuth, fmt.Errorf("refresh token expired or revoked; run ytdata interactively to authorize again: %w", err))
+	}
+	if answer := strings.ToLower(promptUser("Authorize again now? [Y/n]: ")); answer != "" && answer != "y" && answer != "yes" {
+		return withKind(ErrAuth, fmt.Errorf("refresh token expired or revoked: %w", err))
+	}
+	return nil
+}
+
+type AuthRefreshOptions struct {
+	WarnWithin time.Duration
+	TestingApp bool
+}
+
+func newAuthCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the stored OAuth token",
+	}
+
+	var opts AuthRefreshOptions
+	refreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Refresh the stored token and report how long it stays valid",
+		Long: `Refresh the access token now and save it, so scheduled jobs notice broken
+authorization before an export fails.
+
+Refresh tokens of OAuth apps whose consent screen is in Testing mode expire
+after 7 days. The remaining validity is reported when Google announced it
+or, with --testing-app, assuming the 7-day limit. With --warn-within, the
+command fails (exit code 3) when the refresh token expires within that
+time, so cron jobs can alert in advance.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata auth refresh
+  ytdata auth refresh --testing-app --warn-within 48h
+  ytdata auth refresh --channel-id UCxxxxxxxxxxxxxxxxxxxxxx`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return refreshAuth(config, opts)
+			})
+		},
+	}
+	refreshCmd.Flags().DurationVar(&opts.WarnWithin, "warn-within", 0, "Fail when the refresh token expires within this duration, e.g. 48h")
+	refreshCmd.Flags().BoolVar(&opts.TestingApp, "testing-app", false, "Assume the OAuth consent screen is in Testing mode (7-day refresh tokens)")
+
+	cmd.AddCommand(refreshCmd)
+	return cmd
+}
+
+func refreshAuth(config Config, opts AuthRefreshOptions) error {
+	if config.AuthMode != authModeOAuth {
+		return withKind(ErrInvalidConfig, fmt.Errorf("auth refresh requires --auth-mode %s", authModeOAuth))
+	}
+	stored, err := readStoredToken(config.Credentials)
+	if err != nil {
+		return withKind(ErrAuth, fmt.Errorf("no stored token in %s; run any command to authorize: %w", config.Credentials, err))
+	}
+	if stored.RefreshToken == "" {
+		return withKind(ErrAuth, fmt.Errorf("the token in %s has no refresh token; delete it and authorize again", config.Credentials))
+	}
+
+	oauthConfig, err := getOAuthConfig(config.ClientSecret, config.Scopes)
+	if err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("failed to get oauth config: %w", err))
+	}
+	// Drop the access token so the refresh token is always exercised
+	expired := stored.Token
+	expired.AccessToken = ""
+	expired.Expiry = time.Now().Add(-time.Minute)
+	token, err := oauthConfig.TokenSource(context.Background(), &expired).Token()
+	if isInvalidGrant(err) {
+		warnf("%s", invalidGrantHelp)
+		return withKind(ErrAuth, fmt.Errorf("refresh token expired or revoked: %w", err))
+	}
+	if err != nil {
+		return withKind(ErrAuth, fmt.Errorf("failed to refresh token: %w", err))
+	}
+	if err := saveCredentials(config.Credentials, token); err != nil {
+		return err
+	}
+
+	fmt.Printf("Token refreshed; access token valid until %s\n", token.Expiry.Local().Format(time.RFC3339))
+	saved, err := readStoredToken(config.Credentials)
+	if err != nil {
+		return fmt.Errorf("failed to read saved token: %w", err)
+	}
+	if !saved.RefreshTokenIssuedAt.IsZero() {
+		fmt.Printf("Refresh token issued %s\n", saved.RefreshTokenIssuedAt.Local().Format(time.RFC3339))
+	}
+
+	expiresAt := saved.RefreshTokenExpiresAt
+	if expiresAt.IsZero() && opts.TestingApp {
+		if saved.RefreshTokenIssuedAt.IsZero() {
+			fmt.Println("Refresh token issue time unknown (token predates this version); authorize again to track it")
+			return nil
+		}
+		expiresAt = saved.RefreshTokenIssuedAt.Add(testingRefreshTokenTTL)
+	}
+	if expiresAt.IsZero() {
+		fmt.Println("Refresh token has no announced expiry (pass --testing-app if your consent screen is in Testing mode)")
+		return nil
+	}
+
+	remaining := time.Until(expiresAt).Round(time.Minute)
+	fmt.Printf("Refresh token expires %s (in %s)\n", expiresAt.Local().Format(time.RFC3339), remaining)
+	if opts.WarnWithin > 0 && remaining < opts.WarnWithin {
+		return withKind(ErrAuth, fmt.Errorf("refresh token expires in %s; authorize again before it does", remaining))
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+const bigQueryBatchSize = 500
+
+// bigQueryInsertAttempts is how often rows are sent before they count as
+// rejected.
+const bigQueryInsertAttempts = 3
+
+type ExportOptions struct {
+	To string
+}
+
+// bigQueryTable is a table reference parsed from bigquery://project.dataset.table.
+type bigQueryTable struct {
+	Project, Dataset, Table string
+}
+
+func (t bigQueryTable) String() string {
+	return t.Project + "." + t.Dataset + "." + t.Table
+}
+
+func parseBigQueryURL(raw string) (bigQueryTable, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "bigquery" {
+		return bigQueryTable{}, fmt.Errorf("unsupported destination %q (expected bigquery://project.dataset.table)", raw)
+	}
+	parts := strings.Split(u.Host+strings.TrimSuffix(u.Path, "/"), ".")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return bigQueryTable{}, fmt.Errorf("invalid BigQuery table %q (expected bigquery://project.dataset.table)", raw)
+	}
+	return bigQueryTable{Project: parts[0], Dataset: parts[1], Table: parts[2]}, nil
+}
+
+func newExportCmd(config *Config) *cobra.Command {
+	var opts ExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export " + strings.Join(datasetNames(), "|"),
+		Short: "Export a dataset directly into a warehouse",
+		Long: `Run an exporter and stream its records into a warehouse table instead of a
+file. Supported destinations:
+
+  bigquery://project.dataset.table
+
+The table is created from the dataset's schema (see 'ytdata schema') when
+it does not exist; the dataset must exist. Records are streamed with their
+ID as insert ID, so rows of a retried export are deduplicated by BigQuery
+on a best-effort basis. Fields not in the table schema are ignored.
+
+Streaming needs the bigquery scope; with OAuth, the first run asks for this
+access and stores the token in its own credentials file.`,
+		Args:         cobra.ExactArgs(1),
+		ValidArgs:    datasetNames(),
+		SilenceUsage: true,
+		Example: `  ytdata export liked --to bigquery://my-project.youtube.liked
+  ytdata export subscriptions --to bigquery://my-project.youtube.subscriptions --provenance
+  ytdata export liked --to bigquery://my-project.youtube.liked --auth-mode adc`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return exportToBigQuery(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.To, "to", "", "Destination table, e.g. bigquery://project.dataset.table")
+	addFilterFlag(cmd, "Only export records matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// bigQuerySchema converts a JSON Schema built by buildSchema into BigQuery
+// fields. Objects without fixed properties (e.g. localizations) become JSON
+// columns.
+func bigQuerySchema(properties map[string]any) []*bigquery.TableFieldSchema {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]*bigquery.TableFieldSchema, 0, len(names))
+	for _, name := range names {
+		node := properties[name].(map[string]any)
+		field := &bigquery.TableFieldSchema{Name: name, Mode: "NULLABLE"}
+		if items, ok := node["items"].(map[string]any); ok && node["type"] == "array" {
+			field.Mode = "REPEATED"
+			node = items
+		}
+		field.Description, _ = node["description"].(string)
+		switch node["type"] {
+		case "string":
+			field.Type = "STRING"
+		case "integer":
+			field.Type = "INTEGER"
+		case "number":
+			field.Type = "FLOAT"
+		case "boolean":
+			field.Type = "BOOLEAN"
+		default:
+			if children, ok := node["properties"].(map[string]any); ok && len(children) > 0 {
+				field.Type = "RECORD"
+				field.Fields = bigQuerySchema(children)
+			} else {
+				field.Type = "JSON"
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// bigQueryRow shapes a record for the streaming API: JSON columns are sent
+// as encoded strings, nested records follow their field schema.
+func bigQueryRow(record map[string]any, fields []*bigquery.TableFieldSchema) map[string]bigquery.JsonValue {
+	row := make(map[string]bigquery.JsonValue, len(record))
+	byName := make(map[string]*bigquery.TableFieldSchema, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	for name, value := range record {
+		field, ok := byName[name]
+		if !ok || value == nil {
+			row[name] = value
+			continue
+		}
+		row[name] = bigQueryValue(value, field)
+	}
+	return row
+}
+
+func bigQueryValue(value any, field *bigquery.TableFieldSchema) any {
+	if list, ok := value.([]any); ok && field.Mode == "REPEATED" {
+		values := make([]any, len(list))
+		for i, item := range list {
+			values[i] = bigQueryValue(item, &bigquery.TableFieldSchema{Type: field.Type, Fields: field.Fields})
+		}
+		return values
+	}
+	switch field.Type {
+	case "JSON":
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil
+		}
+		return string(data)
+	case "RECORD":
+		if m, ok := value.(map[string]any); ok {
+			return bigQueryRow(m, field.Fields)
+		}
+	}
+	return value
+}
+
+// bigQueryWriter streams records into a table in batches.
+type bigQueryWriter struct {
+	ctx     context.Context
+	service *bigquery.Service
+	table   bigQueryTable
+	fields  []*bigquery.TableFieldSchema
+	rows    []*bigquery.TableDataInsertAllRequestRows
+	written int
+	// rejected counts the rows BigQuery refused
+	rejected int
+}
+
+// newBigQueryWriter creates the table with the given schema unless it
+// already exists.
+func newBigQueryWriter(ctx context.Context, service *bigquery.Service, table bigQueryTable, fields []*bigquery.TableFieldSchema) (*bigQueryWriter, error) {
+	_, err := service.Tables.Get(table.Project, table.Dataset, table.Table).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == 404 {
+		_, err = service.Tables.Insert(table.Project, table.Dataset, &bigquery.Table{
+			TableReference: &bigquery.TableReference{ProjectId: table.Project, DatasetId: table.Dataset, TableId: table.Table},
+			Schema:         &bigquery.TableSchema{Fields: fields},
+		}).Context(ctx).Do()
+		if err == nil {
+			infof("Created table %s", table)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare table %s: %w", table, err)
+	}
+	return &bigQueryWriter{ctx: ctx, service: service, table: table, fields: fields}, nil
+}
+
+func (w *bigQueryWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	w.rows = append(w.rows, &bigquery.TableDataInsertAllRequestRows{
+		InsertId: lookupString(m, "id"),
+		Json:     bigQueryRow(m, w.fields),
+	})
+	if len(w.rows) >= bigQueryBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush streams the buffered rows. BigQuery refuses a whole request when
+// one row is invalid, so the rows it stopped at are sent again; invalid
+// rows are reported and dropped.
+func (w *bigQueryWriter) flush() error {
+	rows := w.rows
+	w.rows = nil
+	for attempt := 1; len(rows) > 0; attempt++ {
+		response, err := w.service.Tabledata.InsertAll(w.table.Project, w.table.Dataset, w.table.Table, &bigquery.TableDataInsertAllRequest{
+			Rows:                rows,
+			IgnoreUnknownValues: true,
+		}).Context(w.ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to stream rows to %s: %w", w.table, err)
+		}
+		var retry []*bigquery.TableDataInsertAllRequestRows
+		for _, insertErr := range response.InsertErrors {
+			if insertErr.Index < 0 || insertErr.Index >= int64(len(rows)) {
+				continue
+			}
+			row := rows[insertErr.Index]
+			if reason, invalid := invalidRow(insertErr); invalid {
+				w.rejected++
+				warnf("%s rejected the row with insert ID %q: %s", w.table, row.InsertId, reason)
+				continue
+			}
+			retry = append(retry, row)
+		}
+		w.written += len(rows) - len(response.InsertErrors)
+		if len(retry) > 0 && attempt == bigQueryInsertAttempts {
+			w.rejected += len(retry)
+			warnf("%s did not accept %d rows after %d attempts", w.table, len(retry), attempt)
+			break
+		}
+		rows = retry
+	}
+	return nil
+}
+
+// invalidRow reports whether an insert error is caused by the row itself,
+// rather than by another row of the request or a transient failure.
+func invalidRow(insertErr *bigquery.TableDataInsertAllResponseInsertErrors) (string, bool) {
+	for _, e := range insertErr.Errors {
+		if e.Reason == "invalid" {
+			return e.Message, true
+		}
+	}
+	return "", false
+}
+
+func (w *bigQueryWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	infof("Streamed %d rows to %s", w.written, w.table)
+	if w.rejected > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d rows were rejected by %s", w.rejected, w.table))
+	}
+	return nil
+}
+
+func exportToBigQuery(config Config, name string, opts ExportOptions) (err error) {
+	dataset, ok := datasetSchemas[name]
+	if !ok {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unknown dataset %q (supported: %s)", name, strings.Join(datasetNames(), ", ")))
+	}
+	if opts.To == "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--to is required"))
+	}
+	table, err := parseBigQueryURL(opts.To)
+	if err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+
+	var exporter Exporter
+	for _, factory := range exporters {
+		if e := factory(config); e.Name() == name {
+			exporter = e
+		}
+	}
+	if v, ok := exporter.(validator); ok {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	var extra []string
+	for _, scope := range exporter.Scopes() {
+		if !slices.Contains(scopes, scope) {
+			extra = append(extra, scope)
+		}
+	}
+	config = withScopes(config, "bigquery", append(extra, bigquery.BigqueryScope)...)
+	client, err := authenticateClient(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+	youtubeService, err := youtube.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("failed to create youtube service: %w", err)
+	}
+	bigqueryService, err := bigquery.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("failed to create bigquery service: %w", err)
+	}
+
+	schema := buildSchema(name, dataset)
+	base, err := newBigQueryWriter(ctx, bigqueryService, table, bigQuerySchema(schema["properties"].(map[string]any)))
+	if err != nil {
+		return err
+	}
+	out, err := wrapOutput(config, base)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	return exporter.Fetch(ctx, youtubeService, out)
+}
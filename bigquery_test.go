@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+func TestBigQueryResendsOnlyStoppedRows(t *testing.T) {
+	var requests [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request bigquery.TableDataInsertAllRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		var ids []string
+		for _, row := range request.Rows {
+			ids = append(ids, row.InsertId)
+		}
+		requests = append(requests, ids)
+
+		// The row "b" is invalid, which stops the whole request
+		var response bigquery.TableDataInsertAllResponse
+		if slices.Contains(ids, "b") {
+			for i, id := range ids {
+				reason := "stopped"
+				if id == "b" {
+					reason = "invalid"
+				}
+				response.InsertErrors = append(response.InsertErrors, &bigquery.TableDataInsertAllResponseInsertErrors{
+					Index:  int64(i),
+					Errors: []*bigquery.ErrorProto{{Reason: reason, Message: reason}},
+				})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	service, err := bigquery.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &bigQueryWriter{ctx: ctx, service: service, table: bigQueryTable{Project: "p", Dataset: "d", Table: "t"}}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := w.Write(map[string]any{"id": id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if classifyError(err) != ErrPartial {
+		t.Errorf("Close() = %v, want a partial failure", err)
+	}
+	if want := [][]string{{"a", "b", "c"}, {"a", "c"}}; !slices.EqualFunc(requests, want, slices.Equal) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+	if w.written != 2 || w.rejected != 1 {
+		t.Errorf("written %d, rejected %d, want 2 and 1", w.written, w.rejected)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+// volatileFields change between two exports of unchanged data.
+var volatileFields = []string{"etag"}
+
+// canonicalWriter rewrites records so two exports of unchanged data are
+// byte-identical: object keys are sorted (records are written as maps),
+// volatile fields are dropped and timestamps are normalized to UTC.
+type canonicalWriter struct {
+	output.Writer
+}
+
+func (w canonicalWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		// Not an object (e.g. a scalar emitted by --transform)
+		return w.Writer.Write(record)
+	}
+	return w.Writer.Write(canonicalValue(m))
+}
+
+// statisticsWriter drops the statistics counts of records for
+// --no-statistics, as they change with every fetch.
+type statisticsWriter struct {
+	output.Writer
+}
+
+func (w statisticsWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return w.Writer.Write(record)
+	}
+	delete(m, "statistics")
+	return w.Writer.Write(m)
+}
+
+func canonicalValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, field := range volatileFields {
+			delete(v, field)
+		}
+		for key, child := range v {
+			v[key] = canonicalValue(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = canonicalValue(child)
+		}
+		return v
+	case string:
+		return canonicalTimestamp(v)
+	default:
+		return v
+	}
+}
+
+// canonicalTimestamp rewrites RFC 3339 timestamps in UTC without trailing
+// zero fractions, e.g. 2024-01-01T13:00:00.000+01:00 becomes
+// 2024-01-01T12:00:00Z. Other strings are returned unchanged.
+func canonicalTimestamp(value string) string {
+	// Cheap check before parsing: timestamps start with a date
+	if len(value) < len("2006-01-02T15:04:05Z") || value[4] != '-' || value[10] != 'T' {
+		return value
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+// recordSink collects the records written to it.
+type recordSink struct {
+	records []any
+}
+
+func (s *recordSink) Write(record any) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *recordSink) Close() error {
+	return nil
+}
+
+func TestNoStatisticsWithoutCanonical(t *testing.T) {
+	sink := &recordSink{}
+	w := statisticsWriter{Writer: sink}
+	record := map[string]any{
+		"etag":       "abc",
+		"statistics": map[string]any{"viewCount": "1"},
+		"snippet":    map[string]any{"publishedAt": "2024-01-01T13:00:00.000+01:00"},
+	}
+	if err := w.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	got := sink.records[0].(map[string]any)
+	if _, ok := got["statistics"]; ok {
+		t.Error("statistics were kept")
+	}
+	if got["etag"] != "abc" || got["snippet"].(map[string]any)["publishedAt"] != "2024-01-01T13:00:00.000+01:00" {
+		t.Errorf("--no-statistics canonicalized the record: %v", got)
+	}
+}
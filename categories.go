@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	// defaultCategoryRegion is used when no --region is set; category IDs
+	// are global, only their availability differs between regions.
+	defaultCategoryRegion = "US"
+	categoryCacheTTL      = 30 * 24 * time.Hour
+)
+
+// categoryCache is the on-disk cache of category names for one region and
+// language.
+type categoryCache struct {
+	FetchedAt string            `json:"fetchedAt"`
+	Names     map[string]string `json:"names"`
+}
+
+func categoryRegion(config Config) string {
+	if config.Region != "" {
+		return strings.ToUpper(config.Region)
+	}
+	return defaultCategoryRegion
+}
+
+func categoryCachePath(config Config) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = getConfigDir()
+	}
+	name := "categories_" + categoryRegion(config)
+	if config.Language != "" {
+		name += "_" + config.Language
+	}
+	return filepath.Join(dir, "ytdata", name+".json")
+}
+
+func fetchVideoCategories(service *youtube.Service, config Config) ([]*youtube.VideoCategory, error) {
+	call := service.VideoCategories.List([]string{"snippet"}).
+		RegionCode(categoryRegion(config))
+	if config.Language != "" {
+		call = call.Hl(config.Language)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch video categories: %w", err)
+	}
+	return response.Items, nil
+}
+
+// videoCategoryNames maps category IDs to their names, using the cache when
+// it is younger than categoryCacheTTL.
+func videoCategoryNames(service *youtube.Service, config Config) (map[string]string, error) {
+	path := categoryCachePath(config)
+	if data, err := os.ReadFile(path); err == nil {
+		var cache categoryCache
+		if err := json.Unmarshal(data, &cache); err == nil {
+			if fetched, err := time.Parse(time.RFC3339, cache.FetchedAt); err == nil && time.Since(fetched) < categoryCacheTTL {
+				return cache.Names, nil
+			}
+		}
+	}
+
+	categories, err := fetchVideoCategories(service, config)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(categories))
+	for _, category := range categories {
+		if category.Snippet != nil {
+			names[category.Id] = category.Snippet.Title
+		}
+	}
+
+	cache := categoryCache{FetchedAt: time.Now().UTC().Format(time.RFC3339), Names: names}
+	if data, err := json.Marshal(cache); err == nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			warnf("Failed to cache video categories: %v", err)
+		}
+	}
+	return names, nil
+}
+
+func newCategoriesCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "categories",
+		Short: "List video categories",
+		Long: `List the video categories of a region (--region, default US) with their
+IDs, so the categoryId of exported videos can be mapped to a name.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata categories
+  ytdata categories --region DE --hl de
+  ytdata categories --format csv -o categories.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, listCategories)
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write categories to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only list categories matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+func listCategories(config Config) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	categories, err := fetchVideoCategories(service, config)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "categories")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, category := range categories {
+		if err := out.Write(category); err != nil {
+			return fmt.Errorf("failed to write category data: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+var channelSectionParts = []string{"snippet", "contentDetails"}
+
+func newChannelCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "channel CHANNEL_ID|@handle...",
+		Short: "Fetch public channels and their sections",
+		Long: `Fetch the public metadata of one or more channels, each followed by its
+channel sections (the curated shelves of the channel page).
+
+Section records carry snippet.channelId to link them to their channel.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata channel UC_x5XG1OV2P6uZZ5FSM9Ttw
+  ytdata channel @GoogleDevelopers -o channel.jsonl
+  ytdata channel @GoogleDevelopers --api-key $YOUTUBE_API_KEY`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := idsFromArgs(args, refChannel)
+				if err != nil {
+					return err
+				}
+				return fetchPublicChannels(config, ids)
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write channels to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export records matching this expression")
+	addTransformFlag(cmd)
+	addAssetFlags(cmd, config)
+	addLinksFlag(cmd, config)
+
+	return cmd
+}
+
+// fetchChannelSections returns the sections of a channel, or of the
+// authenticated user's channel when channelID is empty.
+func fetchChannelSections(service *youtube.Service, config Config, channelID string) ([]*youtube.ChannelSection, error) {
+	call := service.ChannelSections.List(channelSectionParts)
+	if channelID == "" {
+		call = call.Mine(true)
+	} else {
+		call = call.ChannelId(channelID)
+	}
+	if config.Language != "" {
+		call = call.Hl(config.Language)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+// resolveChannels fetches channels given as IDs or @handles, keeping the
+// order of the arguments.
+func resolveChannels(service *youtube.Service, config Config, args []string) ([]*youtube.Channel, error) {
+	var ids []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			ids = append(ids, arg)
+		}
+	}
+	byID := make(map[string]*youtube.Channel)
+	batchSize := 50
+	for i := 0; i < len(ids); i += batchSize {
+		end := min(i+batchSize, len(ids))
+		channels, err := fetchChannelBatch(service, config, ids[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch channels: %w", err)
+		}
+		for _, channel := range channels {
+			byID[channel.Id] = channel
+		}
+	}
+
+	var result []*youtube.Channel
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			if channel, ok := byID[arg]; ok {
+				result = append(result, channel)
+			} else {
+				warnf("channel %s not found", arg)
+			}
+			continue
+		}
+		call := service.Channels.List(channelParts).ForHandle(arg)
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch channel %s: %w", arg, err)
+		}
+		if len(response.Items) == 0 {
+			warnf("channel %s not found", arg)
+			continue
+		}
+		result = append(result, response.Items[0])
+	}
+	return result, nil
+}
+
+// resolveHandles replaces the @handles among ids with their channel IDs,
+// for lookups that only take IDs. Handles without a channel are dropped
+// with a warning.
+func resolveHandles(service *youtube.Service, ids []string) ([]string, error) {
+	resolved := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !strings.HasPrefix(id, "@") {
+			resolved = append(resolved, id)
+			continue
+		}
+		response, err := service.Channels.List([]string{"id"}).ForHandle(id).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch channel %s: %w", id, err)
+		}
+		if len(response.Items) == 0 {
+			warnf("channel %s not found", id)
+			continue
+		}
+		resolved = append(resolved, response.Items[0].Id)
+	}
+	return resolved, nil
+}
+
+func fetchPublicChannels(config Config, args []string) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	channels, err := resolveChannels(service, config, args)
+	if err != nil {
+		return err
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("none of the given channels were found")
+	}
+
+	out, err := openOutput(config, "channels")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, channel := range channels {
+		if err := out.Write(channel); err != nil {
+			return fmt.Errorf("failed to write channel data: %w", err)
+		}
+		sections, err := fetchChannelSections(service, config, channel.Id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch channel sections of %s: %w", channel.Id, err)
+		}
+		for _, section := range sections {
+			if err := out.Write(section); err != nil {
+				return fmt.Errorf("failed to write channel section data: %w", err)
+			}
+		}
+	}
+	return nil
+}
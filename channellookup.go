@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type ChannelsOptions struct {
+	IDsFile string
+}
+
+func newChannelsCmd(config *Config) *cobra.Command {
+	var opts ChannelsOptions
+
+	cmd := &cobra.Command{
+		Use:   "channels [CHANNEL_ID...]",
+		Short: "Fetch the metadata of any channels",
+		Long: `Look up channels by ID, in batches of 50, and write their metadata. IDs are
+given as arguments or read from --ids-file, one per line ("-" reads stdin).
+Channels that do not exist produce a warning.
+
+--parts selects the channel parts to request (default: all public parts).
+With --continue-on-error, failed batches are logged to the errors file and
+can be re-run with 'ytdata retry'.`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata channels UC_x5XG1OV2P6uZZ5FSM9Ttw UCuAXFkgsw1L7xaCfnd5JJOw
+  ytdata channels --ids-file channels.txt --parts snippet,statistics -f csv
+  ytdata channels --ids-file channels.txt --continue-on-error -o channels.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				for _, part := range config.ChannelParts {
+					if !slices.Contains(channelParts, part) && part != "id" {
+						return withKind(ErrInvalidConfig, fmt.Errorf("unsupported channel part %q (supported: id, %s)", part, strings.Join(channelParts, ", ")))
+					}
+				}
+				ids, err := lookupIDs(args, opts.IDsFile, refChannel)
+				if err != nil {
+					return err
+				}
+				return lookupChannels(config, ids)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.IDsFile, "ids-file", "", `File with one channel ID per line ("-" for stdin)`)
+	cmd.Flags().StringSliceVar(&config.ChannelParts, "parts", nil, "Channel parts to request (default: "+strings.Join(channelParts, ",")+")")
+	cmd.Flags().BoolVar(&config.ContinueOnError, "continue-on-error", false, "Log failed requests to the errors file and continue")
+	cmd.Flags().StringVar(&config.ErrorsFile, "errors-file", defaultErrorsFile, "File failed requests are logged to with --continue-on-error")
+	addOutputFlag(cmd, "", "Write channels to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only write channels matching this expression")
+	addTransformFlag(cmd)
+	addFieldsFlag(cmd)
+	addLinksFlag(cmd, config)
+
+	return cmd
+}
+
+func lookupChannels(config Config, ids []string) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if ids, err = resolveHandles(service, ids); err != nil {
+		return err
+	}
+
+	failures := newErrorLog(config.ErrorsFile, "channels")
+	found := make(map[string]*youtube.Channel)
+	failed := make(map[string]bool)
+	for i := 0; i < len(ids); i += 50 {
+		batch := ids[i:min(i+50, len(ids))]
+		channels, err := fetchChannelBatch(service, config, batch)
+		if err != nil {
+			if !config.ContinueOnError {
+				return fmt.Errorf("failed to fetch channels: %w", err)
+			}
+			params := requestParams{IDs: batch, Hl: config.Language, Parts: config.ChannelParts}
+			if err := failures.record(operationChannelsList, params, err); err != nil {
+				return err
+			}
+			for _, id := range batch {
+				failed[id] = true
+			}
+			continue
+		}
+		for _, channel := range channels {
+			found[channel.Id] = channel
+		}
+	}
+
+	out, err := openOutput(config, "channels")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, id := range ids {
+		channel, ok := found[id]
+		if !ok {
+			// Channels of failed batches are in the errors file instead
+			if !failed[id] {
+				warnf("Channel %s not found", id)
+			}
+			continue
+		}
+		if err := out.Write(channel); err != nil {
+			return fmt.Errorf("failed to write channel data: %w", err)
+		}
+	}
+	return failures.close()
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// minChapters is the number of timestamps YouTube requires before it shows
+// chapters, the first at 0:00.
+const minChapters = 3
+
+// chapter is a section of a video parsed from a timestamp line of its
+// description, such as "01:30 Setup".
+type chapter struct {
+	Start        string `json:"start"`
+	StartSeconds int64  `json:"startSeconds"`
+	// EndSeconds is the start of the next chapter, or the end of the video
+	// for the last one when the duration is known
+	EndSeconds int64  `json:"endSeconds,omitempty"`
+	Title      string `json:"title"`
+}
+
+var (
+	timestampPattern = `\d{1,2}(?::\d{2}){1,2}`
+	// "0:00 Intro", "- 00:00 - Intro", "[1:02:03] Part"
+	leadingTimestamp = regexp.MustCompile(`^[-*•▶►\s]*[\[(]?(` + timestampPattern + `)[\])]?\s*[-–—:|.)]?\s*(.+?)\s*$`)
+	// "Intro - 0:00", "Intro (0:00)"
+	trailingTimestamp = regexp.MustCompile(`^[-*•▶►\s]*(.+?)\s*[-–—:|]?\s*[\[(]?(` + timestampPattern + `)[\])]?\s*$`)
+)
+
+// parseTimestamp converts m:ss or h:mm:ss into seconds.
+func parseTimestamp(s string) (int64, bool) {
+	var seconds int64
+	for i, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, true
+}
+
+// parseChapters parses the chapters of a description, following the rules
+// YouTube applies: at least three timestamps in ascending order, the first
+// at 0:00. It also returns the indexes of the description lines the
+// chapters were taken from. A duration of 0 leaves the end of the last
+// chapter open.
+func parseChapters(description string, duration time.Duration) ([]chapter, map[int]bool) {
+	var chapters []chapter
+	lines := make(map[int]bool)
+	for i, line := range strings.Split(description, "\n") {
+		start, title := "", ""
+		if m := leadingTimestamp.FindStringSubmatch(line); m != nil {
+			start, title = m[1], m[2]
+		} else if m := trailingTimestamp.FindStringSubmatch(line); m != nil {
+			start, title = m[2], m[1]
+		} else {
+			continue
+		}
+		seconds, ok := parseTimestamp(start)
+		if !ok {
+			continue
+		}
+		if len(chapters) > 0 && seconds <= chapters[len(chapters)-1].StartSeconds {
+			if seconds != 0 {
+				// Timestamps out of order are not part of the list
+				continue
+			}
+			if chapters[0].StartSeconds == 0 {
+				// A second list, e.g. of highlights, after the chapters
+				break
+			}
+			// The timestamps so far were stray ones, e.g. "see 5:30" above
+			// the real list, which starts here
+			chapters, lines = nil, make(map[int]bool)
+		}
+		chapters = append(chapters, chapter{Start: start, StartSeconds: seconds, Title: title})
+		lines[i] = true
+	}
+	if len(chapters) < minChapters || chapters[0].StartSeconds != 0 {
+		return nil, nil
+	}
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].EndSeconds = chapters[i+1].StartSeconds
+		} else if end := int64(duration / time.Second); end > chapters[i].StartSeconds {
+			chapters[i].EndSeconds = end
+		}
+	}
+	return chapters, lines
+}
+
+var (
+	linkPattern       = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// cleanDescription returns the text of a description without links and
+// without the given chapter lines, for text corpora. Lines that only held
+// links are dropped and runs of blank lines collapsed.
+func cleanDescription(description string, chapterLines map[int]bool) string {
+	var kept []string
+	for i, line := range strings.Split(description, "\n") {
+		if chapterLines[i] {
+			continue
+		}
+		cleaned := strings.TrimSpace(linkPattern.ReplaceAllString(line, ""))
+		if cleaned == "" && strings.TrimSpace(line) != "" {
+			continue
+		}
+		kept = append(kept, strings.Join(strings.Fields(cleaned), " "))
+	}
+	text := blankLinesPattern.ReplaceAllString(strings.Join(kept, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+func newChaptersCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chapters VIDEO_ID...",
+		Short: "Show the chapters of videos",
+		Long: `Look up videos and write the chapters parsed from the timestamp lines of
+their descriptions, one record per chapter with the video ID. Videos without
+chapters produce a warning.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata chapters dQw4w9WgXcQ
+  ytdata chapters dQw4w9WgXcQ 9bZkp7q19f0 -f csv -o chapters.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := idsFromArgs(args, refVideo)
+				if err != nil {
+					return err
+				}
+				return videoChapters(config, ids)
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write chapters to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only write chapters matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// videoChapter is a chapter record of the chapters command.
+type videoChapter struct {
+	VideoID string `json:"videoId"`
+	chapter
+}
+
+func videoChapters(config Config, ids []string) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	videos, err := fetchVideosByID(service, config, ids, []string{"snippet", "contentDetails"})
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "chapters")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, id := range ids {
+		video, ok := videos[id]
+		if !ok || video.Snippet == nil {
+			warnf("Video %s not found", id)
+			continue
+		}
+		duration, _ := videoDuration(video)
+		chapters, _ := parseChapters(video.Snippet.Description, duration)
+		if chapters == nil {
+			warnf("Video %s has no chapters", id)
+			continue
+		}
+		for _, c := range chapters {
+			if err := out.Write(videoChapter{VideoID: id, chapter: c}); err != nil {
+				return fmt.Errorf("failed to write chapter: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseChapters(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		description string
+		want        []string
+	}{
+		{"list", "0:00 Intro\n1:30 Setup\n10:15 Outro", []string{"Intro", "Setup", "Outro"}},
+		{"too short", "0:00 Intro\n1:30 Outro", nil},
+		{"not from 0:00", "0:10 Intro\n1:30 Setup\n10:15 Outro", nil},
+		{"stray timestamp first", "5:30 best part\n\n0:00 Intro\n1:30 Setup\n10:15 Outro", []string{"Intro", "Setup", "Outro"}},
+		{"second list", "0:00 Intro\n1:30 Setup\n10:15 Outro\n\nHighlights:\n0:00 Start\n2:00 Fail", []string{"Intro", "Setup", "Outro"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			chapters, _ := parseChapters(tt.description, 0)
+			var titles []string
+			for _, chapter := range chapters {
+				titles = append(titles, chapter.Title)
+			}
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("chapters = %q, want %q", titles, tt.want)
+			}
+		})
+	}
+}
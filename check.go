@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	availabilityOK            = "available"
+	availabilityUnavailable   = "unavailable"
+	availabilityPrivate       = "private"
+	availabilityRejected      = "rejected"
+	availabilityRegionBlocked = "region_blocked"
+	availabilityRegionLimited = "region_restricted"
+	videoLookupBatchSize      = 50
+)
+
+type CheckOptions struct {
+	All bool
+}
+
+// availabilityReport describes the state of one video from an export.
+type availabilityReport struct {
+	ID      string `json:"id"`
+	Title   string `json:"title,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func newCheckCmd(config *Config) *cobra.Command {
+	var opts CheckOptions
+
+	cmd := &cobra.Command{
+		Use:   "check FILE",
+		Short: "Check whether exported videos are still available",
+		Long: `Re-query every video in a JSONL export and report the ones that are now
+deleted, private, rejected or blocked in a region.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata check liked.jsonl
+  ytdata check liked.jsonl --region DE
+  ytdata check liked.jsonl --all -o report.jsonl
+  ytdata check liked.jsonl --api-key $YOUTUBE_API_KEY`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return checkVideos(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Include available videos in the report")
+	addOutputFlag(cmd, "", "Write report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report videos matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	return cmd
+}
+
+// regionBlocked reports whether a video cannot be watched in region.
+func regionBlocked(video *youtube.Video, region string) bool {
+	if video.ContentDetails == nil || video.ContentDetails.RegionRestriction == nil || region == "" {
+		return false
+	}
+	restriction := video.ContentDetails.RegionRestriction
+	region = strings.ToUpper(region)
+	if len(restriction.Allowed) > 0 && !slices.Contains(restriction.Allowed, region) {
+		return true
+	}
+	return slices.Contains(restriction.Blocked, region)
+}
+
+func classifyVideo(video *youtube.Video, region string) (string, string) {
+	if video.Status != nil {
+		switch video.Status.UploadStatus {
+		case "rejected", "deleted", "failed":
+			reason := video.Status.RejectionReason
+			if reason == "" {
+				reason = video.Status.FailureReason
+			}
+			return availabilityRejected, strings.TrimSuffix(video.Status.UploadStatus+": "+reason, ": ")
+		}
+		if video.Status.PrivacyStatus == "private" {
+			return availabilityPrivate, ""
+		}
+	}
+
+	if region != "" {
+		if regionBlocked(video, region) {
+			return availabilityRegionBlocked, "blocked in " + strings.ToUpper(region)
+		}
+	} else if video.ContentDetails != nil && video.ContentDetails.RegionRestriction != nil {
+		restriction := video.ContentDetails.RegionRestriction
+		if len(restriction.Allowed) > 0 {
+			return availabilityRegionLimited, "allowed only in " + strings.Join(restriction.Allowed, ",")
+		}
+		if len(restriction.Blocked) > 0 {
+			return availabilityRegionLimited, "blocked in " + strings.Join(restriction.Blocked, ",")
+		}
+	}
+
+	return availabilityOK, ""
+}
+
+func checkVideos(config Config, path string, opts CheckOptions) (err error) {
+	records, err := readJSONLRecords(path)
+	if err != nil {
+		return err
+	}
+
+	// Keep titles from the export so deleted videos are still identifiable
+	exported := make(map[string]availabilityReport)
+	var ids []string
+	for _, record := range records {
+		id := recordVideoID(record)
+		if id == "" {
+			continue
+		}
+		if _, seen := exported[id]; seen {
+			continue
+		}
+		exported[id] = availabilityReport{
+			ID:      id,
+			Title:   lookupString(record, "snippet", "title"),
+			Channel: lookupString(record, "snippet", "channelTitle"),
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no video IDs found in %s", path)
+	}
+
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	found, err := fetchVideosByID(service, config, ids, []string{"snippet", "status", "contentDetails"})
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "availability")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	counts := make(map[string]int)
+	for _, id := range ids {
+		report := exported[id]
+		if video, ok := found[id]; ok {
+			report.Status, report.Detail = classifyVideo(video, config.Region)
+		} else {
+			report.Status = availabilityUnavailable
+			report.Detail = "deleted or made private by the owner"
+		}
+		counts[report.Status]++
+
+		if report.Status == availabilityOK && !opts.All {
+			continue
+		}
+		if err := out.Write(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	infof("Checked %d videos: %d available, %d unavailable, %d private, %d rejected, %d region blocked, %d region restricted",
+		len(ids), counts[availabilityOK], counts[availabilityUnavailable], counts[availabilityPrivate],
+		counts[availabilityRejected], counts[availabilityRegionBlocked], counts[availabilityRegionLimited])
+
+	return nil
+}
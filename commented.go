@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type CommentedOptions struct {
+	Author string
+}
+
+// myComment is one comment of the user, from a comments export or Takeout.
+type myComment struct {
+	ID        string
+	VideoID   string
+	CreatedAt string
+}
+
+// commentedVideo is what the user's comments tell about one video.
+type commentedVideo struct {
+	VideoID    string
+	Comments   int
+	First      string
+	Last       string
+	CommentIDs []string
+}
+
+func newCommentedCmd(config *Config) *cobra.Command {
+	var opts CommentedOptions
+
+	cmd := &cobra.Command{
+		Use:   "commented FILE...",
+		Short: "Export the videos you commented on",
+		Long: `Derive the videos you engaged with from your comments and export them
+with their current metadata. The API cannot list a user's comments, so the
+comments are read from exports:
+
+  - comment threads written by video-comments (JSONL); only comments by
+    --author count, which defaults to the authenticated channel
+  - the comments CSV of Google Takeout (YouTube and YouTube Music >
+    comments > comments.csv), which only holds your own comments
+
+Each video record gets myCommentCount, firstCommentedAt, lastCommentedAt and
+myCommentIds fields, and records are sorted by the last comment, newest
+first. Videos that are no longer available are written with their ID and
+comment fields only.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata commented Takeout/YouTube*/comments/comments.csv -o commented.jsonl
+  ytdata commented comments.jsonl
+  ytdata commented comments.jsonl --author UCxxxxxxxxxxxxxxxxxxxxxx --api-key $YOUTUBE_API_KEY`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return exportCommented(config, args, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Author, "author", "", "Channel ID whose comments count in JSONL exports (default: the authenticated channel)")
+	addOutputFlag(cmd, "", "Write videos to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export videos matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// exportedComments returns the comments by author in a video-comments
+// export, replies included.
+func exportedComments(path, author string) ([]myComment, error) {
+	threads, err := readCommentThreads(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var comments []myComment
+	for _, thread := range threads {
+		for _, comment := range threadComments(thread) {
+			snippet := comment.Snippet
+			if snippet == nil || snippet.AuthorChannelId == nil || snippet.AuthorChannelId.Value != author {
+				continue
+			}
+			videoID := snippet.VideoId
+			if videoID == "" && thread.Snippet != nil {
+				videoID = thread.Snippet.VideoId
+			}
+			if videoID == "" {
+				continue
+			}
+			comments = append(comments, myComment{ID: comment.Id, VideoID: videoID, CreatedAt: snippet.PublishedAt})
+		}
+	}
+	return comments, nil
+}
+
+// groupComments collects the comments per video, most recently commented
+// video first.
+func groupComments(comments []myComment) []*commentedVideo {
+	byVideo := make(map[string]*commentedVideo)
+	var videos []*commentedVideo
+	for _, comment := range comments {
+		video, ok := byVideo[comment.VideoID]
+		if !ok {
+			video = &commentedVideo{VideoID: comment.VideoID}
+			byVideo[comment.VideoID] = video
+			videos = append(videos, video)
+		}
+		video.Comments++
+		if comment.ID != "" {
+			video.CommentIDs = append(video.CommentIDs, comment.ID)
+		}
+		if video.First == "" || comment.CreatedAt != "" && comment.CreatedAt < video.First {
+			video.First = comment.CreatedAt
+		}
+		if comment.CreatedAt > video.Last {
+			video.Last = comment.CreatedAt
+		}
+	}
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].Last > videos[j].Last
+	})
+	return videos
+}
+
+func exportCommented(config Config, paths []string, opts CommentedOptions) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var comments []myComment
+	for _, path := range paths {
+		var found []myComment
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			found, err = readTakeoutComments(path)
+		} else {
+			if opts.Author == "" {
+				if opts.Author = accountChannelID(config); opts.Author == "" {
+					return withKind(ErrInvalidConfig, fmt.Errorf("--author is required to pick your comments from %s", path))
+				}
+			}
+			found, err = exportedComments(path, opts.Author)
+		}
+		if err != nil {
+			return err
+		}
+		comments = append(comments, found...)
+	}
+	videos := groupComments(comments)
+	if len(videos) == 0 {
+		return fmt.Errorf("no comments on videos found in %s", strings.Join(paths, ", "))
+	}
+
+	ids := make([]string, len(videos))
+	for i, video := range videos {
+		ids[i] = video.VideoID
+	}
+	found, err := fetchVideosByID(service, config, ids, defaultVideoParts)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "commented videos")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	enrichment := newVideoEnrichment(service, config)
+	missing := 0
+	for _, commented := range videos {
+		record := map[string]any{"kind": "youtube#video", "id": commented.VideoID}
+		if video, ok := found[commented.VideoID]; ok {
+			if record, err = enrichment.enrichVideo(video); err != nil {
+				return fmt.Errorf("failed to process video data: %w", err)
+			}
+		} else {
+			missing++
+		}
+		record["myCommentCount"] = commented.Comments
+		if commented.First != "" {
+			record["firstCommentedAt"] = commented.First
+			record["lastCommentedAt"] = commented.Last
+		}
+		if len(commented.CommentIDs) > 0 {
+			record["myCommentIds"] = commented.CommentIDs
+		}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write video data: %w", err)
+		}
+	}
+
+	infof("Found %d comments on %d videos (%d no longer available)", len(comments), len(videos), missing)
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+type VideoCommentsOptions struct {
+	AllUploads bool
+	Dest       string
+}
+
+func newVideoCommentsCmd(config *Config) *cobra.Command {
+	var opts VideoCommentsOptions
+
+	cmd := &cobra.Command{
+		Use:   "video-comments [VIDEO_ID...]",
+		Short: "Fetch the comments on videos",
+		Long: `Fetch every comment thread, including all replies, on the given videos or,
+with --all-uploads, on every video you uploaded.
+
+Threads are written as one stream (snippet.videoId names the video) or,
+with --dest instead of -o, to one file per video named after the video ID
+with the extension of the format (e.g. VIDEO_ID.jsonl, VIDEO_ID.db for
+sqlite). Videos with comments disabled are skipped with a warning.`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata video-comments dQw4w9WgXcQ
+  ytdata video-comments --all-uploads -o comments.jsonl
+  ytdata video-comments --all-uploads --dest comments`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := idsFromArgs(args, refVideo)
+				if err != nil {
+					return err
+				}
+				return fetchVideoComments(config, ids, opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.AllUploads, "all-uploads", false, "Fetch the comments on all your uploaded videos")
+	cmd.Flags().StringVar(&opts.Dest, "dest", "", "Write one file per video to this directory instead of a single stream")
+	addOutputFlag(cmd, "", "Write comment threads to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export comment threads matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.MarkFlagDirname("dest"))
+
+	return cmd
+}
+
+// fetchUploadIDs returns the IDs of the videos uploaded by the
+// authenticated user, newest first.
+func fetchUploadIDs(ctx context.Context, service *youtube.Service, config Config) ([]string, error) {
+	special, err := fetchSpecialPlaylists(service, config, []string{"id"})
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, s := range special {
+		if s.Kind != "uploads" {
+			continue
+		}
+		items, err := fetchPlaylistItems(ctx, service, s.Playlist.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if id := playlistItemVideoID(item); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// fetchCommentThreads fetches all comment threads on a video. Threads only
+// include the first few replies, so threads with more replies are completed
+// with the full reply list.
+func fetchCommentThreads(ctx context.Context, service *youtube.Service, videoID string) ([]*youtube.CommentThread, error) {
+	var threads []*youtube.CommentThread
+	pageToken := ""
+	for {
+		call := service.CommentThreads.List([]string{"snippet", "replies"}).
+			Context(ctx).
+			VideoId(videoID).
+			MaxResults(100)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, response.Items...)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	for _, thread := range threads {
+		// Threads without replies have no replies part, and the inline
+		// replies may already be all of them
+		if thread.Snippet == nil || thread.Snippet.TotalReplyCount == 0 ||
+			thread.Replies != nil && int64(len(thread.Replies.Comments)) >= thread.Snippet.TotalReplyCount {
+			continue
+		}
+		replies, err := fetchCommentReplies(ctx, service, thread.Id)
+		if err != nil {
+			return nil, err
+		}
+		thread.Replies = &youtube.CommentThreadReplies{Comments: replies}
+	}
+	return threads, nil
+}
+
+func fetchCommentReplies(ctx context.Context, service *youtube.Service, parentID string) ([]*youtube.Comment, error) {
+	var replies []*youtube.Comment
+	pageToken := ""
+	for {
+		call := service.Comments.List([]string{"snippet"}).
+			Context(ctx).
+			ParentId(parentID).
+			MaxResults(100)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, response.Items...)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+	return replies, nil
+}
+
+// commentsUnavailable reports whether err means a video's comments cannot
+// be read, e.g. because they are disabled or the video is gone.
+func commentsUnavailable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == 404 {
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "commentsDisabled" {
+			return true
+		}
+	}
+	return false
+}
+
+func fetchVideoComments(config Config, videoIDs []string, opts VideoCommentsOptions) (err error) {
+	switch {
+	case opts.AllUploads && len(videoIDs) > 0:
+		return withKind(ErrInvalidConfig, fmt.Errorf("pass either video IDs or --all-uploads, not both"))
+	case !opts.AllUploads && len(videoIDs) == 0:
+		return withKind(ErrInvalidConfig, fmt.Errorf("video IDs or --all-uploads are required"))
+	case opts.AllUploads && config.APIKey != "":
+		return withKind(ErrInvalidConfig, fmt.Errorf("--all-uploads reads your channel and requires OAuth"))
+	case opts.Dest != "" && config.OutputFile != "":
+		return withKind(ErrInvalidConfig, fmt.Errorf("pass either --dest or -o, not both"))
+	}
+
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	if opts.AllUploads {
+		if videoIDs, err = fetchUploadIDs(ctx, service, config); err != nil {
+			return err
+		}
+		infof("Fetching comments on %d uploads", len(videoIDs))
+	}
+
+	var stream output.Writer
+	if opts.Dest == "" {
+		if stream, err = openOutput(config, "comments"); err != nil {
+			return err
+		}
+		defer closeOutput(stream, &err)
+	} else if err := os.MkdirAll(opts.Dest, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	total := 0
+	for _, videoID := range videoIDs {
+		threads, err := fetchCommentThreads(ctx, service, videoID)
+		if commentsUnavailable(err) {
+			warnf("Skipping %s: comments are disabled or the video is unavailable", videoID)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch comments on %s: %w", videoID, err)
+		}
+		total += len(threads)
+
+		if stream != nil {
+			if err := writeCommentThreads(stream, threads); err != nil {
+				return err
+			}
+		} else if len(threads) > 0 {
+			fileConfig := config
+			fileConfig.OutputFile = filepath.Join(opts.Dest, videoID+formatExtension(config.Format))
+			if err := writeCommentsFile(fileConfig, videoID, threads); err != nil {
+				return err
+			}
+		}
+	}
+
+	infof("Fetched %d comment threads on %d videos", total, len(videoIDs))
+	return nil
+}
+
+func writeCommentThreads(out output.Writer, threads []*youtube.CommentThread) error {
+	for _, thread := range threads {
+		if err := out.Write(thread); err != nil {
+			return fmt.Errorf("failed to write comment data: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeCommentsFile writes the comment threads of one video to
+// config.OutputFile.
+func writeCommentsFile(config Config, videoID string, threads []*youtube.CommentThread) (err error) {
+	out, err := openOutput(config, "comments on "+videoID)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	return writeCommentThreads(out, threads)
+}
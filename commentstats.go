@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type CommentStatsOptions struct {
+	Top int
+}
+
+// commentStatsEntry is one row of the comments report. Type is video,
+// commenter, comment (the most liked comments), hour, weekday or month.
+// Commenters are counted per channel; Name is the display name they
+// commented under first.
+type commentStatsEntry struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	ChannelID string `json:"channelId,omitempty"`
+	VideoID   string `json:"videoId,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Comments  int    `json:"comments"`
+	Likes     int64  `json:"likes"`
+}
+
+func newCommentStatsCmd(config *Config) *cobra.Command {
+	var opts CommentStatsOptions
+
+	cmd := &cobra.Command{
+		Use:   "comments FILE",
+		Short: "Summarize a comments export",
+		Long: `Summarize a comments export written by video-comments: comments per video,
+top commenters, the most liked comments, and when comments were posted
+(per hour and weekday in UTC, and per month). Replies count as comments.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata stats comments comments.jsonl
+  ytdata stats comments comments.jsonl --top 20 -f csv -o comment-stats.csv
+  ytdata stats comments comments.jsonl | jq 'select(.type == "hour")'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return commentStats(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Top, "top", 10, "Number of top commenters and comments to report (0 = all)")
+	addOutputFlag(cmd, "", "Write report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only count comment threads matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// readCommentThreads reads the comment threads of a comments export,
+// keeping the threads matching filter when it is not nil.
+func readCommentThreads(path string, filter filterExpr) ([]*youtube.CommentThread, error) {
+	records, err := readJSONLRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	var threads []*youtube.CommentThread
+	for _, record := range records {
+		if lookupString(record, "kind") != "youtube#commentThread" {
+			continue
+		}
+		if filter != nil && !matchFilter(filter, record) {
+			continue
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		var thread youtube.CommentThread
+		if err := json.Unmarshal(data, &thread); err != nil {
+			return nil, fmt.Errorf("invalid comment thread in %s: %w", path, err)
+		}
+		threads = append(threads, &thread)
+	}
+	return threads, nil
+}
+
+// threadComments returns the top-level comment and the replies of a thread.
+func threadComments(thread *youtube.CommentThread) []*youtube.Comment {
+	var comments []*youtube.Comment
+	if thread.Snippet != nil && thread.Snippet.TopLevelComment != nil {
+		comments = append(comments, thread.Snippet.TopLevelComment)
+	}
+	if thread.Replies != nil {
+		comments = append(comments, thread.Replies.Comments...)
+	}
+	return comments
+}
+
+// rankEntries sorts entries by comments, then likes, and keeps the first
+// top entries (all when top is 0).
+func rankEntries(entries []commentStatsEntry, top int) []commentStatsEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Comments != entries[j].Comments {
+			return entries[i].Comments > entries[j].Comments
+		}
+		if entries[i].Likes != entries[j].Likes {
+			return entries[i].Likes > entries[j].Likes
+		}
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].ChannelID < entries[j].ChannelID
+	})
+	if top > 0 && len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+func summarizeComments(threads []*youtube.CommentThread, top int) []commentStatsEntry {
+	videos := make(map[string]*commentStatsEntry)
+	commenters := make(map[string]*commentStatsEntry)
+	var comments []commentStatsEntry
+	var hours [24]commentStatsEntry
+	var weekdays [7]commentStatsEntry
+	months := make(map[string]*commentStatsEntry)
+
+	add := func(counts map[string]*commentStatsEntry, kind, name string, likes int64) *commentStatsEntry {
+		entry, ok := counts[name]
+		if !ok {
+			entry = &commentStatsEntry{Type: kind, Name: name}
+			counts[name] = entry
+		}
+		entry.Comments++
+		entry.Likes += likes
+		return entry
+	}
+
+	for _, thread := range threads {
+		videoID := ""
+		if thread.Snippet != nil {
+			videoID = thread.Snippet.VideoId
+		}
+		for _, comment := range threadComments(thread) {
+			if comment.Snippet == nil {
+				continue
+			}
+			snippet := comment.Snippet
+			add(videos, "video", videoID, snippet.LikeCount)
+			author := snippet.AuthorDisplayName
+			if author == "" {
+				author = "unknown"
+			}
+			// Display names change and are not unique, so commenters are
+			// told apart by channel, and by name only without one
+			channelID, key := "", "name:"+author
+			if snippet.AuthorChannelId != nil && snippet.AuthorChannelId.Value != "" {
+				channelID = snippet.AuthorChannelId.Value
+				key = channelID
+			}
+			if commenter := add(commenters, "commenter", key, snippet.LikeCount); commenter.Comments == 1 {
+				commenter.Name, commenter.ChannelID = author, channelID
+			}
+			comments = append(comments, commentStatsEntry{
+				Type:      "comment",
+				Name:      author,
+				ChannelID: channelID,
+				VideoID:   videoID,
+				Text:      snippet.TextOriginal,
+				Comments:  1,
+				Likes:     snippet.LikeCount,
+			})
+			if comments[len(comments)-1].Text == "" {
+				comments[len(comments)-1].Text = snippet.TextDisplay
+			}
+
+			published, ok := parsePublishedAt(snippet.PublishedAt)
+			if !ok {
+				continue
+			}
+			published = published.UTC()
+			hours[published.Hour()].Comments++
+			hours[published.Hour()].Likes += snippet.LikeCount
+			weekdays[published.Weekday()].Comments++
+			weekdays[published.Weekday()].Likes += snippet.LikeCount
+			add(months, "month", published.Format("2006-01"), snippet.LikeCount)
+		}
+	}
+
+	var entries []commentStatsEntry
+	collect := func(counts map[string]*commentStatsEntry) []commentStatsEntry {
+		list := make([]commentStatsEntry, 0, len(counts))
+		for _, entry := range counts {
+			list = append(list, *entry)
+		}
+		return list
+	}
+	entries = append(entries, rankEntries(collect(videos), 0)...)
+	entries = append(entries, rankEntries(collect(commenters), top)...)
+
+	// Like-weighted: the most liked comments first
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Likes > comments[j].Likes
+	})
+	if top > 0 && len(comments) > top {
+		comments = comments[:top]
+	}
+	entries = append(entries, comments...)
+
+	for hour, entry := range hours {
+		entry.Type, entry.Name = "hour", fmt.Sprintf("%02d", hour)
+		entries = append(entries, entry)
+	}
+	// Weekdays start on Monday
+	for i := range weekdays {
+		day := time.Weekday((i + 1) % 7)
+		entry := weekdays[day]
+		entry.Type, entry.Name = "weekday", day.String()
+		entries = append(entries, entry)
+	}
+	monthEntries := collect(months)
+	sort.Slice(monthEntries, func(i, j int) bool {
+		return monthEntries[i].Name < monthEntries[j].Name
+	})
+	return append(entries, monthEntries...)
+}
+
+func commentStats(config Config, path string, opts CommentStatsOptions) (err error) {
+	// The filter selects comment threads, not the aggregated entries
+	var filter filterExpr
+	if config.Filter != "" {
+		if filter, err = parseFilter(config.Filter); err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		config.Filter = ""
+	}
+
+	threads, err := readCommentThreads(path, filter)
+	if err != nil {
+		return err
+	}
+	if len(threads) == 0 {
+		return fmt.Errorf("no comment threads found in %s", path)
+	}
+
+	out, err := openOutput(config, "comment stats")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, entry := range summarizeComments(threads, opts.Top) {
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	}
+	return nil
+}
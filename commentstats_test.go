@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestSummarizeCommentsGroupsCommentersByChannel(t *testing.T) {
+	comment := func(name, channelID string, likes int64) *youtube.Comment {
+		snippet := &youtube.CommentSnippet{AuthorDisplayName: name, LikeCount: likes, TextOriginal: "hi"}
+		if channelID != "" {
+			snippet.AuthorChannelId = &youtube.CommentSnippetAuthorChannelId{Value: channelID}
+		}
+		return &youtube.Comment{Snippet: snippet}
+	}
+	thread := func(comments ...*youtube.Comment) *youtube.CommentThread {
+		return &youtube.CommentThread{
+			Snippet: &youtube.CommentThreadSnippet{VideoId: "vid1", TopLevelComment: comments[0]},
+			Replies: &youtube.CommentThreadReplies{Comments: comments[1:]},
+		}
+	}
+	threads := []*youtube.CommentThread{
+		// The same channel under two names, and two channels sharing a name
+		thread(comment("Alice", "UCalice", 1), comment("Alice (renamed)", "UCalice", 2)),
+		thread(comment("Sam", "UCsam1", 0), comment("Sam", "UCsam2", 5)),
+		thread(comment("Guest", "", 0), comment("Guest", "", 0)),
+	}
+
+	var commenters []commentStatsEntry
+	for _, entry := range summarizeComments(threads, 0) {
+		if entry.Type == "commenter" {
+			commenters = append(commenters, entry)
+		}
+	}
+	want := []commentStatsEntry{
+		{Type: "commenter", Name: "Alice", ChannelID: "UCalice", Comments: 2, Likes: 3},
+		{Type: "commenter", Name: "Guest", Comments: 2},
+		{Type: "commenter", Name: "Sam", ChannelID: "UCsam2", Comments: 1, Likes: 5},
+		{Type: "commenter", Name: "Sam", ChannelID: "UCsam1", Comments: 1},
+	}
+	if len(commenters) != len(want) {
+		t.Fatalf("commenters = %+v, want %+v", commenters, want)
+	}
+	for i := range want {
+		if commenters[i] != want[i] {
+			t.Errorf("commenter %d = %+v, want %+v", i, commenters[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	compareBoth  = "both"
+	compareAOnly = "a_only"
+	compareBOnly = "b_only"
+)
+
+// defaultProfile selects the default token in --profile-a and --profile-b.
+const defaultProfile = "default"
+
+type CompareOptions struct {
+	ProfileA string
+	ProfileB string
+}
+
+// compareEntry is an item of one or both profiles. Playlists are matched by
+// title, as the same playlist has a different ID on each account, so both
+// IDs are reported.
+type compareEntry struct {
+	Dataset string `json:"dataset"`
+	Status  string `json:"status"`
+	ID      string `json:"id"`
+	Title   string `json:"title,omitempty"`
+	IDA     string `json:"idA,omitempty"`
+	IDB     string `json:"idB,omitempty"`
+}
+
+// compareDataset describes how the items of a dataset are matched.
+type compareDataset struct {
+	exporter func(config Config) Exporter
+	key      func(record map[string]any) string
+	id       func(record map[string]any) string
+}
+
+var compareDatasets = map[string]compareDataset{
+	"subscriptions": {
+		exporter: func(config Config) Exporter { return subscriptionsExporter{config} },
+		key:      recordChannelID,
+		id:       recordChannelID,
+	},
+	"liked": {
+		exporter: func(config Config) Exporter { return likedExporter{config} },
+		key:      recordVideoID,
+		id:       recordVideoID,
+	},
+	"playlists": {
+		exporter: func(config Config) Exporter { return playlistsExporter{config} },
+		key: func(record map[string]any) string {
+			return strings.ToLower(strings.Join(strings.Fields(lookupString(record, "snippet", "title")), " "))
+		},
+		id: func(record map[string]any) string { return lookupString(record, "id") },
+	},
+}
+
+func compareDatasetNames() []string {
+	return []string{"subscriptions", "liked", "playlists"}
+}
+
+func newCompareCmd(config *Config) *cobra.Command {
+	var opts CompareOptions
+
+	cmd := &cobra.Command{
+		Use:   "compare " + strings.Join(compareDatasetNames(), "|"),
+		Short: "Compare the data of two accounts",
+		Long: `Compare a dataset of two accounts, e.g. when consolidating them, and write
+every item with status both, a_only or b_only: the channels both accounts
+subscribe to, the liked videos they share, or the playlists they both have.
+Playlists are matched by title, ignoring case and spacing.
+
+A profile is a channel authorized with 'ytdata accounts add' (its ID,
+or its title as listed by 'ytdata accounts'), "default" for the default
+account, or an existing export of the dataset, which is loaded instead of
+fetched. With two exports no credentials are needed. A summary is printed to
+stderr.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: staticCompletion(compareDatasetNames()...),
+		SilenceUsage:      true,
+		Annotations:       map[string]string{accountsAnnotation: "true"},
+		Example: `  ytdata compare --profile-a default --profile-b UCxxxxxxxxxxxxxxxxxxxxxx subscriptions
+  ytdata compare --profile-a personal.jsonl --profile-b work.jsonl subscriptions --filter 'status == "both"'
+  ytdata compare --profile-a Personal --profile-b Work playlists -f csv -o playlists.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if isExportFile(opts.ProfileA) && isExportFile(opts.ProfileB) {
+				cmd.Annotations[localAnnotation] = "true"
+			}
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return compareProfiles(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ProfileA, "profile-a", "", "First account: channel ID or title, default, or an export file")
+	cmd.Flags().StringVar(&opts.ProfileB, "profile-b", "", "Second account: channel ID or title, default, or an export file")
+	addOutputFlag(cmd, "", "Write the comparison to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report items matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.MarkFlagRequired("profile-a"))
+	cobra.CheckErr(cmd.MarkFlagRequired("profile-b"))
+
+	return cmd
+}
+
+// isExportFile reports whether a profile names an existing file.
+func isExportFile(profile string) bool {
+	info, err := os.Stat(profile)
+	return err == nil && !info.IsDir()
+}
+
+var channelIDPattern = regexp.MustCompile(`^UC[0-9A-Za-z_-]{22}$`)
+
+// profileChannel resolves a profile to the ID of a stored channel, or ""
+// for the default account.
+func profileChannel(config Config, profile string) (string, error) {
+	if strings.EqualFold(profile, defaultProfile) {
+		return "", nil
+	}
+	if channelIDPattern.MatchString(profile) {
+		// Channels not authorized yet are authorized on first use
+		return profile, nil
+	}
+	index := readChannelsIndex(config)
+	for _, id := range storedChannels(config) {
+		if id == profile || strings.EqualFold(index[id], profile) {
+			return id, nil
+		}
+	}
+	return "", withKind(ErrInvalidConfig, fmt.Errorf("unknown profile %q: not an export file, \"default\" or an authorized channel (see 'ytdata accounts')", profile))
+}
+
+// profileConfig points config at the token of a profile.
+func profileConfig(config Config, profile string) (Config, error) {
+	channelID, err := profileChannel(config, profile)
+	if err != nil {
+		return config, err
+	}
+	if channelID != "" {
+		config.ChannelID = channelID
+		config.Credentials = channelCredentialsPath(config.Credentials, channelID)
+	}
+	return config, nil
+}
+
+// profileRecords loads the dataset of a profile from its export, or fetches
+// it with the profile's token.
+func profileRecords(config Config, dataset compareDataset, profile string) ([]map[string]any, error) {
+	if isExportFile(profile) {
+		return readExportRecords(profile)
+	}
+	config, err := profileConfig(config, profile)
+	if err != nil {
+		return nil, err
+	}
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed for %s: %w", profile, err)
+	}
+	collector := &recordCollector{}
+	if err := dataset.exporter(config).Fetch(context.Background(), service, collector); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", profile, err)
+	}
+	return collector.records, nil
+}
+
+// compareRecords matches the records of both profiles by key, in the order
+// of profile A followed by the items only in profile B.
+func compareRecords(name string, dataset compareDataset, a, b []map[string]any) []compareEntry {
+	inB := make(map[string]map[string]any, len(b))
+	for _, record := range b {
+		if key := dataset.key(record); key != "" {
+			if _, ok := inB[key]; !ok {
+				inB[key] = record
+			}
+		}
+	}
+
+	var entries []compareEntry
+	seen := make(map[string]bool)
+	add := func(record map[string]any, status string) {
+		key := dataset.key(record)
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		entry := compareEntry{Dataset: name, Status: status, ID: dataset.id(record), Title: lookupString(record, "snippet", "title")}
+		if name == "playlists" {
+			entry.ID = key
+			switch status {
+			case compareBoth:
+				entry.IDA, entry.IDB = dataset.id(record), dataset.id(inB[key])
+			case compareAOnly:
+				entry.IDA = dataset.id(record)
+			case compareBOnly:
+				entry.IDB = dataset.id(record)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	for _, record := range a {
+		if _, ok := inB[dataset.key(record)]; ok {
+			add(record, compareBoth)
+		} else {
+			add(record, compareAOnly)
+		}
+	}
+	for _, record := range b {
+		add(record, compareBOnly)
+	}
+	return entries
+}
+
+func compareProfiles(config Config, name string, opts CompareOptions) (err error) {
+	dataset, ok := compareDatasets[name]
+	if !ok {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unknown dataset %q (supported: %s)", name, strings.Join(compareDatasetNames(), ", ")))
+	}
+	if opts.ProfileA == opts.ProfileB {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--profile-a and --profile-b are the same"))
+	}
+	a, err := profileRecords(config, dataset, opts.ProfileA)
+	if err != nil {
+		return err
+	}
+	b, err := profileRecords(config, dataset, opts.ProfileB)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "comparison")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	counts := make(map[string]int)
+	for _, entry := range compareRecords(name, dataset, a, b) {
+		counts[entry.Status]++
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write comparison: %w", err)
+		}
+	}
+	infof("%s: %d in both, %d only in %s, %d only in %s", name, counts[compareBoth], counts[compareAOnly], opts.ProfileA, counts[compareBOnly], opts.ProfileB)
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// convertExtensions are the file extensions of formats that cannot be
+// written to stdout, used to name their output after the input.
+var convertExtensions = map[string]string{
+	"parquet": ".parquet",
+	"sqlite":  ".db",
+}
+
+type ConvertOptions struct {
+	To string
+}
+
+func newConvertCmd(config *Config) *cobra.Command {
+	var opts ConvertOptions
+
+	cmd := &cobra.Command{
+		Use:   "convert FILE",
+		Short: "Convert an export to another format",
+		Long: `Convert a JSONL (or JSON array) export to another output format offline,
+so changing the format does not need a new export. Records are written
+through the same writers as every export, so --filter and --transform work
+as well.
+
+Formats that cannot be written to stdout (parquet, sqlite) are written next
+to the input, e.g. liked.jsonl becomes liked.parquet, unless -o is given.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata convert liked.jsonl --to csv -o liked.csv
+  ytdata convert liked.jsonl --to parquet
+  ytdata convert subscriptions.jsonl --to sqlite -o youtube.db`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return convertExport(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.To, "to", "", "Target format ("+strings.Join(recordFormats(), ", ")+")")
+	addOutputFlag(cmd, "", "Write converted records to stdout (or file with -o)")
+	addFilterFlag(cmd, "Only convert records matching this expression")
+	addTransformFlag(cmd)
+	addExtractFlag(cmd)
+
+	cobra.CheckErr(cmd.MarkFlagRequired("to"))
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("to", staticCompletion(recordFormats()...)))
+
+	return cmd
+}
+
+// readExportRecords reads a JSONL export, or a JSON array as written by the
+// json format.
+func readExportRecords(path string) ([]map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	reader := bufio.NewReader(f)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			// Empty or whitespace only
+			return nil, nil
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		if b != '[' {
+			return readJSONLRecords(path)
+		}
+		break
+	}
+	if err := reader.UnreadByte(); err != nil {
+		return nil, err
+	}
+	var records []map[string]any
+	if err := json.NewDecoder(reader).Decode(&records); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	return records, nil
+}
+
+func convertExport(config Config, path string, opts ConvertOptions) (err error) {
+	format := strings.ToLower(opts.To)
+	if !slices.Contains(recordFormats(), format) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported format %q (supported: %s)", opts.To, strings.Join(recordFormats(), ", ")))
+	}
+	config.Format = format
+
+	dataset := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if ext, ok := convertExtensions[format]; ok && config.OutputFile == "" {
+		config.OutputFile = strings.TrimSuffix(path, filepath.Ext(path)) + ext
+	}
+	if config.OutputFile != "" {
+		if same, _ := sameFile(path, config.OutputFile); same {
+			return withKind(ErrInvalidConfig, fmt.Errorf("output %s would overwrite the input", config.OutputFile))
+		}
+	}
+
+	records, err := readExportRecords(path)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, dataset)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, record := range records {
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	if config.OutputFile != "" {
+		infof("Converted %d records of %s to %s", len(records), path, config.OutputFile)
+	}
+	return nil
+}
+
+// sameFile reports whether both paths name the same existing file.
+func sameFile(path, other string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	otherInfo, err := os.Stat(other)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(info, otherInfo), nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rtzll/ytdata/output"
+	"google.golang.org/api/youtube/v3"
+)
+
+// counter is implemented by exporters that can count their records without
+// fetching them, for --count.
+type counter interface {
+	count(ctx context.Context, service *youtube.Service) (int64, error)
+}
+
+// recordCounter is an output writer that only counts the records written
+// to it.
+type recordCounter struct {
+	n int64
+}
+
+func (c *recordCounter) Write(record any) error {
+	c.n++
+	return nil
+}
+
+func (c *recordCounter) Close() error {
+	return nil
+}
+
+// runCount prints the number of records an exporter would write. Exporters
+// that implement counter are asked directly unless a filter has to be
+// applied to the records first.
+func runCount(config Config, exporter Exporter, service *youtube.Service) error {
+	ctx := context.Background()
+
+	var n int64
+	if c, ok := exporter.(counter); ok && config.Filter == "" {
+		var err error
+		if n, err = c.count(ctx, service); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if n, err = countRecords(ctx, config, exporter, service); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(n)
+	return nil
+}
+
+// countRecords fetches every record of an exporter and counts the ones
+// matching --filter.
+func countRecords(ctx context.Context, config Config, exporter Exporter, service *youtube.Service) (int64, error) {
+	records := &recordCounter{}
+	var sink output.Writer = records
+	if config.Filter != "" {
+		filter, err := parseFilter(config.Filter)
+		if err != nil {
+			return 0, withKind(ErrInvalidConfig, err)
+		}
+		sink = filterWriter{sink, filter}
+	}
+	if err := exporter.Fetch(ctx, service, sink); err != nil {
+		return 0, err
+	}
+	return records.n, nil
+}
+
+// countPages returns pageInfo.totalResults of the first page, or counts the
+// items of every page when the API does not report a total. fetch returns
+// one page for pageToken.
+func countPages(fetch func(pageToken string) (*youtube.PageInfo, int, string, error)) (int64, error) {
+	info, items, next, err := fetch("")
+	if err != nil {
+		return 0, err
+	}
+	if info != nil && info.TotalResults >= int64(items) {
+		return info.TotalResults, nil
+	}
+
+	total := int64(items)
+	for next != "" {
+		if _, items, next, err = fetch(next); err != nil {
+			return 0, err
+		}
+		total += int64(items)
+	}
+	return total, nil
+}
+
+func (e likedExporter) count(ctx context.Context, service *youtube.Service) (int64, error) {
+	// Video and language filters need the video details
+	if e.config.Videos.active() || len(e.config.Languages) > 0 {
+		return countRecords(ctx, e.config, e, service)
+	}
+
+	n, err := countPages(func(pageToken string) (*youtube.PageInfo, int, string, error) {
+		call := service.Videos.List([]string{"id"}).Context(ctx).MyRating("like").MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return response.PageInfo, len(response.Items), response.NextPageToken, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count liked videos: %w", err)
+	}
+	return n, nil
+}
+
+func (e subscriptionsExporter) count(ctx context.Context, service *youtube.Service) (int64, error) {
+	// Channel filters need the channel details
+	if e.config.Subscriptions != (SubscriptionFilter{}) {
+		return countRecords(ctx, e.config, e, service)
+	}
+
+	n, err := countPages(func(pageToken string) (*youtube.PageInfo, int, string, error) {
+		call := service.Subscriptions.List([]string{"id"}).Context(ctx).Mine(true).MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return response.PageInfo, len(response.Items), response.NextPageToken, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+	return n, nil
+}
+
+func (e playlistsExporter) count(ctx context.Context, service *youtube.Service) (int64, error) {
+	n, err := countPages(func(pageToken string) (*youtube.PageInfo, int, string, error) {
+		call := service.Playlists.List([]string{"id"}).Context(ctx).Mine(true).MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return response.PageInfo, len(response.Items), response.NextPageToken, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count playlists: %w", err)
+	}
+
+	if e.config.IncludeSpecial {
+		special, err := fetchSpecialPlaylists(service, e.config, []string{"id"})
+		if err != nil {
+			return 0, err
+		}
+		n += int64(len(special))
+	}
+	return n, nil
+}
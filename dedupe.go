@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type DedupeOptions struct {
+	Remove   bool
+	Yes      bool
+	Delay    time.Duration
+	UndoFile string
+}
+
+// duplicateVideo is one line of the dedupe-playlists report: a video that
+// appears more than once across your playlists.
+type duplicateVideo struct {
+	VideoID     string               `json:"videoId"`
+	Title       string               `json:"title,omitempty"`
+	Occurrences []playlistOccurrence `json:"occurrences"`
+}
+
+type playlistOccurrence struct {
+	PlaylistID     string `json:"playlistId"`
+	PlaylistTitle  string `json:"playlistTitle,omitempty"`
+	PlaylistItemID string `json:"playlistItemId"`
+	Position       int64  `json:"position"`
+}
+
+func newDedupePlaylistsCmd(config *Config) *cobra.Command {
+	var opts DedupeOptions
+
+	cmd := &cobra.Command{
+		Use:   "dedupe-playlists",
+		Short: "Find videos that appear in several of your playlists",
+		Long: `Fetch the items of all your playlists and report every video that appears
+in more than one playlist, or more than once in the same playlist.
+
+With --remove, the duplicates are removed interactively: the first
+occurrence (in playlist order) is kept, and each further one is confirmed
+unless --yes is given. Answer y to remove, n to skip, a to remove all
+remaining duplicates, or q to stop. Removed items are appended to the undo
+file, so 'ytdata undo' can add them back at their original position.
+Removing requires write access to your account.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata dedupe-playlists
+  ytdata dedupe-playlists -o duplicates.jsonl
+  ytdata dedupe-playlists --remove`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return dedupePlaylists(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Remove, "remove", false, "Remove duplicates after confirmation, keeping the first occurrence")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().DurationVar(&opts.Delay, "delay", 200*time.Millisecond, "Pause between removals to stay below rate limits")
+	cmd.Flags().StringVar(&opts.UndoFile, "undo-file", defaultUndoFile, "File removed playlist items are appended to")
+	addOutputFlag(cmd, "", "Write the duplicates report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report and remove duplicates matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// findDuplicates groups playlist items by video, in the order the videos
+// first appear, and returns the videos with more than one occurrence.
+func findDuplicates(playlists []map[string]any, items map[string][]*youtube.PlaylistItem) []duplicateVideo {
+	byVideo := make(map[string]*duplicateVideo)
+	var order []string
+	for _, playlist := range playlists {
+		playlistID := lookupString(playlist, "id")
+		for _, item := range items[playlistID] {
+			videoID := playlistItemVideoID(item)
+			if videoID == "" {
+				continue
+			}
+			duplicate, ok := byVideo[videoID]
+			if !ok {
+				duplicate = &duplicateVideo{VideoID: videoID}
+				byVideo[videoID] = duplicate
+				order = append(order, videoID)
+			}
+			if duplicate.Title == "" && item.Snippet != nil {
+				duplicate.Title = item.Snippet.Title
+			}
+			occurrence := playlistOccurrence{
+				PlaylistID:     playlistID,
+				PlaylistTitle:  lookupString(playlist, "snippet", "title"),
+				PlaylistItemID: item.Id,
+			}
+			if item.Snippet != nil {
+				occurrence.Position = item.Snippet.Position
+			}
+			duplicate.Occurrences = append(duplicate.Occurrences, occurrence)
+		}
+	}
+
+	var duplicates []duplicateVideo
+	for _, videoID := range order {
+		if duplicate := byVideo[videoID]; len(duplicate.Occurrences) > 1 {
+			duplicates = append(duplicates, *duplicate)
+		}
+	}
+	return duplicates
+}
+
+func dedupePlaylists(config Config, opts DedupeOptions) error {
+	if opts.Remove {
+		config = withScopes(config, "write", youtube.YoutubeForceSslScope)
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	collector := &recordCollector{}
+	if err := (playlistsExporter{config}).Fetch(ctx, service, collector); err != nil {
+		return err
+	}
+	items := make(map[string][]*youtube.PlaylistItem, len(collector.records))
+	total := 0
+	for _, playlist := range collector.records {
+		playlistID := lookupString(playlist, "id")
+		if items[playlistID], err = fetchPlaylistItems(ctx, service, playlistID); err != nil {
+			return err
+		}
+		total += len(items[playlistID])
+	}
+
+	duplicates, err := filterDuplicates(config.Filter, findDuplicates(collector.records, items))
+	if err != nil {
+		return err
+	}
+	if err := writeDuplicates(config, duplicates); err != nil {
+		return err
+	}
+	infof("%d of %d playlist items in %d playlists are duplicates of %d videos",
+		countExtraOccurrences(duplicates), total, len(collector.records), len(duplicates))
+
+	if !opts.Remove || len(duplicates) == 0 {
+		return nil
+	}
+	return removeDuplicates(service, duplicates, opts)
+}
+
+// filterDuplicates keeps the duplicates matching the --filter expression,
+// so --remove only removes what the report lists.
+func filterDuplicates(expression string, duplicates []duplicateVideo) ([]duplicateVideo, error) {
+	if expression == "" {
+		return duplicates, nil
+	}
+	filter, err := parseFilter(expression)
+	if err != nil {
+		return nil, withKind(ErrInvalidConfig, err)
+	}
+	var matching []duplicateVideo
+	for _, duplicate := range duplicates {
+		record, err := toRecord(duplicate)
+		if err != nil {
+			return nil, err
+		}
+		if matchFilter(filter, record) {
+			matching = append(matching, duplicate)
+		}
+	}
+	return matching, nil
+}
+
+func countExtraOccurrences(duplicates []duplicateVideo) int {
+	n := 0
+	for _, duplicate := range duplicates {
+		n += len(duplicate.Occurrences) - 1
+	}
+	return n
+}
+
+func writeDuplicates(config Config, duplicates []duplicateVideo) (err error) {
+	out, err := openOutput(config, "duplicates")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, duplicate := range duplicates {
+		if err := out.Write(duplicate); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return nil
+}
+
+func removeDuplicates(service *youtube.Service, duplicates []duplicateVideo, opts DedupeOptions) error {
+	undo, err := openUndoLog(opts.UndoFile)
+	if err != nil {
+		return err
+	}
+	defer undo.close()
+
+	confirmAll := opts.Yes
+	removed, failed := 0, 0
+loop:
+	for _, duplicate := range duplicates {
+		for _, occurrence := range duplicate.Occurrences[1:] {
+			if !confirmAll {
+				answer := strings.ToLower(promptUser(fmt.Sprintf("Remove %s from %s (position %d)? [y/N/a/q]: ",
+					duplicate.Title, occurrence.PlaylistTitle, occurrence.Position)))
+				if answer == "q" {
+					break loop
+				}
+				if answer == "a" {
+					confirmAll = true
+				} else if answer != "y" {
+					continue
+				}
+			}
+
+			if removed+failed > 0 && opts.Delay > 0 {
+				time.Sleep(opts.Delay)
+			}
+			if err := service.PlaylistItems.Delete(occurrence.PlaylistItemID).Do(); err != nil {
+				if isQuotaError(err) {
+					return withKind(ErrQuota, fmt.Errorf("stopped after %d removals: %w", removed, err))
+				}
+				warnf("Failed to remove %s from %s: %v", duplicate.Title, occurrence.PlaylistTitle, err)
+				failed++
+				continue
+			}
+			removed++
+			if err := undo.record(undoEntry{
+				Action:     undoActionRemoveFromPlaylist,
+				VideoID:    duplicate.VideoID,
+				Title:      duplicate.Title,
+				PlaylistID: occurrence.PlaylistID,
+				Position:   occurrence.Position,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	infof("Removed %d duplicates; undo with 'ytdata undo %s'", removed, undo.path)
+	if failed > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d removals failed", failed))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestDedupeRemovesOnlyFilteredDuplicates(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		deleted = append(deleted, r.URL.Query().Get("id"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	service, err := youtube.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates := []duplicateVideo{
+		{VideoID: "v1", Title: "Keep", Occurrences: []playlistOccurrence{
+			{PlaylistID: "PL1", PlaylistItemID: "item-1a"},
+			{PlaylistID: "PL2", PlaylistItemID: "item-1b"},
+		}},
+		{VideoID: "v2", Title: "Remove", Occurrences: []playlistOccurrence{
+			{PlaylistID: "PL1", PlaylistItemID: "item-2a"},
+			{PlaylistID: "PL2", PlaylistItemID: "item-2b"},
+			{PlaylistID: "PL3", PlaylistItemID: "item-2c"},
+		}},
+	}
+	duplicates, err = filterDuplicates(`title == "Remove"`, duplicates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DedupeOptions{Remove: true, Yes: true, UndoFile: filepath.Join(t.TempDir(), "undo.jsonl")}
+	if err := removeDuplicates(service, duplicates, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"item-2b", "item-2c"}; !slices.Equal(deleted, want) {
+		t.Errorf("deleted %q, want %q", deleted, want)
+	}
+}
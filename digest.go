@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// digestFeedWorkers bounds the channel feeds fetched at the same time.
+const digestFeedWorkers = 8
+
+type DigestOptions struct {
+	Since         string
+	SendVia       string
+	From          string
+	To            []string
+	Subject       string
+	ChannelsFile  string
+	MaxPerChannel int
+}
+
+// digest is the data of a digest email.
+type digest struct {
+	Since    time.Time
+	Videos   int
+	Channels []digestChannel
+}
+
+type digestChannel struct {
+	ID     string
+	Title  string
+	URL    string
+	Videos []digestVideo
+}
+
+type digestVideo struct {
+	ID        string
+	Title     string
+	URL       string
+	Thumbnail string
+	Published time.Time
+}
+
+func newDigestCmd(config *Config) *cobra.Command {
+	var opts DigestOptions
+
+	cmd := &cobra.Command{
+		Use:   "digest",
+		Short: "Email a digest of new uploads from your subscriptions",
+		Long: `Collect the videos your subscriptions uploaded since --since from their
+public channel feeds and compose an HTML email of them, grouped by channel.
+With --send-via the email is sent through an SMTP server; otherwise, or with
+-o, the HTML is written to stdout or a file.
+
+Listing the subscriptions costs 1 quota unit per 50 channels; the feeds cost
+none, but only hold the latest 15 uploads of each channel. --channels reads
+channel IDs from a file instead, in the format of 'ytdata watch uploads'.
+
+The SMTP URL is smtp://[user[:password]@]host[:port] (port 587, STARTTLS
+when offered) or smtps:// for implicit TLS (port 465). The password can also
+be given in YTDATA_SMTP_PASSWORD. No email is sent when there are no new
+videos.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata digest --since 7d -o digest.html
+  ytdata digest --since 1d --send-via smtps://me%40example.com@smtp.example.com --to me@example.com`,
+		Annotations: map[string]string{},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.ChannelsFile != "" {
+				// Feeds are public and need neither credentials nor setup
+				cmd.Annotations[localAnnotation] = "true"
+			}
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runDigest(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Since, "since", "7d", "Include videos published in this period, e.g. 1d, 2w")
+	cmd.Flags().StringVar(&opts.SendVia, "send-via", "", "Send the digest through this SMTP server, e.g. smtps://user@smtp.example.com")
+	cmd.Flags().StringVar(&opts.From, "from", "", "Sender address (default: the SMTP user if it is an address)")
+	cmd.Flags().StringSliceVar(&opts.To, "to", nil, "Recipient addresses (required with --send-via)")
+	cmd.Flags().StringVar(&opts.Subject, "subject", "", `Subject of the email (default: "YouTube digest: N videos since DATE")`)
+	cmd.Flags().StringVar(&opts.ChannelsFile, "channels", "", "File with the channel IDs to include instead of your subscriptions")
+	cmd.Flags().IntVar(&opts.MaxPerChannel, "max-per-channel", 5, "Most videos listed per channel (0 = no limit)")
+	addOutputFlag(cmd, "", "Write the HTML to stdout (or file with -o) instead of sending it")
+
+	cobra.CheckErr(cmd.MarkFlagFilename("channels"))
+
+	return cmd
+}
+
+func runDigest(config Config, opts DigestOptions) (err error) {
+	since, err := parseAge(opts.Since, time.Now())
+	if err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid --since: %w", err))
+	}
+	var server *url.URL
+	if opts.SendVia != "" {
+		if server, err = parseSMTPURL(opts.SendVia); err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		if len(opts.To) == 0 {
+			return withKind(ErrInvalidConfig, fmt.Errorf("--send-via requires --to"))
+		}
+		if opts.From == "" {
+			if user := server.User.Username(); strings.Contains(user, "@") {
+				opts.From = user
+			} else {
+				return withKind(ErrInvalidConfig, fmt.Errorf("--send-via requires --from unless the SMTP user is an address"))
+			}
+		}
+	}
+
+	channels, err := digestChannels(config, opts)
+	if err != nil {
+		return err
+	}
+	result, err := collectDigest(config, channels, since, opts.MaxPerChannel)
+	if err != nil {
+		return err
+	}
+
+	var html bytes.Buffer
+	if err := digestHTML.Execute(&html, result); err != nil {
+		return fmt.Errorf("failed to compose digest: %w", err)
+	}
+	if server == nil || config.OutputFile != "" {
+		w, err := createOutputWriter(config)
+		if err != nil {
+			return err
+		}
+		defer closeOutput(w, &err)
+		if _, err := w.Write(html.Bytes()); err != nil {
+			return fmt.Errorf("failed to write digest: %w", err)
+		}
+	}
+	if server == nil {
+		return nil
+	}
+	if result.Videos == 0 {
+		infof("No new videos since %s, not sending a digest", since.Format(time.DateOnly))
+		return nil
+	}
+
+	var text bytes.Buffer
+	if err := digestText.Execute(&text, result); err != nil {
+		return fmt.Errorf("failed to compose digest: %w", err)
+	}
+	subject := opts.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("YouTube digest: %s since %s", countNote(result.Videos), since.Format("2 Jan"))
+	}
+	message, err := composeEmail(opts.From, opts.To, subject, text.Bytes(), html.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to compose digest: %w", err)
+	}
+	if err := sendEmail(server, opts.From, opts.To, message); err != nil {
+		return withKind(ErrNetwork, fmt.Errorf("failed to send digest: %w", err))
+	}
+	infof("Sent a digest of %s from %d channels to %s", countNote(result.Videos), len(result.Channels), strings.Join(opts.To, ", "))
+	return nil
+}
+
+// digestChannels returns the channels of the digest, from --channels or the
+// user's subscriptions, with their titles when known.
+func digestChannels(config Config, opts DigestOptions) ([]digestChannel, error) {
+	var channels []digestChannel
+	if opts.ChannelsFile != "" {
+		watched, err := readWatchedChannels(opts.ChannelsFile, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, channel := range watched {
+			if !strings.HasPrefix(channel.ID, "UC") {
+				return nil, withKind(ErrInvalidConfig, fmt.Errorf("--channels needs channel IDs, not %q", channel.ID))
+			}
+			channels = append(channels, digestChannel{ID: channel.ID})
+		}
+		return channels, nil
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	subscriptions, err := fetchSubscriptionList(context.Background(), service, newPageLimit(config))
+	if err != nil {
+		return nil, err
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Snippet == nil || subscription.Snippet.ResourceId == nil {
+			continue
+		}
+		channels = append(channels, digestChannel{ID: subscription.Snippet.ResourceId.ChannelId, Title: subscription.Snippet.Title})
+	}
+	return channels, nil
+}
+
+// collectDigest fetches the feeds of the channels and keeps the videos
+// published after since. Channels without new videos are left out.
+func collectDigest(config Config, channels []digestChannel, since time.Time, maxPerChannel int) (digest, error) {
+	client := feedClient
+	if config.APIEndpoint != "" {
+		client = &http.Client{Timeout: feedClient.Timeout, Transport: &endpointTransport{endpoint: config.APIEndpoint, base: http.DefaultTransport}}
+	}
+
+	var mu sync.Mutex
+	var failed int
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, digestFeedWorkers)
+	for i := range channels {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(channel *digestChannel) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			uploads, err := fetchChannelFeed(context.Background(), client, channel.ID)
+			if err != nil {
+				warnf("Failed to fetch the feed of channel %s: %v", channel.ID, err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return
+			}
+			for _, video := range uploads {
+				published, ok := parsePublishedAt(video.PublishedAt)
+				if !ok || !published.After(since) {
+					continue
+				}
+				if channel.Title == "" {
+					channel.Title = video.ChannelTitle
+				}
+				channel.Videos = append(channel.Videos, digestVideo{
+					ID:        video.VideoID,
+					Title:     video.Title,
+					URL:       videoURL(video.VideoID),
+					Thumbnail: "https://i.ytimg.com/vi/" + video.VideoID + "/mqdefault.jpg",
+					Published: published,
+				})
+			}
+		}(&channels[i])
+	}
+	wg.Wait()
+	if failed > 0 && failed == len(channels) {
+		return digest{}, fmt.Errorf("failed to fetch the feeds of all %d channels", failed)
+	}
+
+	result := digest{Since: since}
+	for _, channel := range channels {
+		if len(channel.Videos) == 0 {
+			continue
+		}
+		slices.SortFunc(channel.Videos, func(a, b digestVideo) int {
+			return b.Published.Compare(a.Published)
+		})
+		if maxPerChannel > 0 && len(channel.Videos) > maxPerChannel {
+			channel.Videos = channel.Videos[:maxPerChannel]
+		}
+		channel.URL = channelURL(channel.ID)
+		result.Videos += len(channel.Videos)
+		result.Channels = append(result.Channels, channel)
+	}
+	// Channels with the latest upload first
+	slices.SortStableFunc(result.Channels, func(a, b digestChannel) int {
+		return b.Videos[0].Published.Compare(a.Videos[0].Published)
+	})
+	return result, nil
+}
+
+// parseSMTPURL validates --send-via and fills in the default port.
+func parseSMTPURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid --send-via %q: expected smtp://[user@]host[:port] or smtps://...", raw)
+	}
+	switch u.Scheme {
+	case "smtp":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "587")
+		}
+	case "smtps":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "465")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported --send-via scheme %q (supported: smtp, smtps)", u.Scheme)
+	}
+	if u.User == nil {
+		u.User = url.User("")
+	}
+	return u, nil
+}
+
+// composeEmail builds a multipart/alternative message with a plain text and
+// an HTML version.
+func composeEmail(from string, to []string, subject string, text, html []byte) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{{"text/plain; charset=utf-8", text}, {"text/html; charset=utf-8", html}} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "ytdata"
+	if _, host, ok := strings.Cut(from, "@"); ok {
+		domain = host
+	}
+	var message bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(id) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	} {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// sendEmail delivers message through the SMTP server, authenticating when
+// the URL has a user.
+func sendEmail(server *url.URL, from string, to []string, message []byte) error {
+	host := server.Hostname()
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if server.Scheme == "smtps" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", server.Host, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", server.Host)
+	}
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(2 * time.Minute)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if server.Scheme == "smtp" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+	}
+	if user := server.User.Username(); user != "" {
+		password, ok := server.User.Password()
+		if !ok {
+			password = os.Getenv("YTDATA_SMTP_PASSWORD")
+		}
+		if err := client.Auth(smtp.PlainAuth("", user, password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+var digestText = template.Must(template.New("digest").Parse(`New videos since {{.Since.Format "Mon, 2 Jan 2006"}}
+{{range .Channels}}
+{{.Title}}
+{{range .Videos}}- {{.Title}} ({{.Published.Format "2 Jan"}})
+  {{.URL}}
+{{end}}{{else}}
+No new videos.
+{{end}}`))
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>YouTube digest</title>
+</head>
+<body style="font-family: system-ui, sans-serif; max-width: 40rem; margin: 0 auto; padding: 1rem; color: #222;">
+<h1 style="font-size: 1.4rem;">New videos since {{.Since.Format "Mon, 2 Jan 2006"}}</h1>
+{{range .Channels}}<h2 style="font-size: 1.1rem; margin-top: 1.5rem;"><a href="{{.URL}}" style="color: #222;">{{.Title}}</a></h2>
+{{range .Videos}}<table role="presentation" style="margin-bottom: .75rem;"><tr>
+<td style="width: 160px; vertical-align: top;"><a href="{{.URL}}"><img src="{{.Thumbnail}}" width="160" height="90" alt="" style="display: block; border-radius: 4px;"></a></td>
+<td style="vertical-align: top; padding-left: .75rem;"><a href="{{.URL}}" style="color: #065fd4; text-decoration: none;">{{.Title}}</a><br><span style="color: #666; font-size: .85rem;">{{.Published.Format "Mon, 2 Jan 15:04"}}</span></td>
+</tr></table>
+{{end}}{{else}}<p>No new videos.</p>
+{{end}}<p style="color: #666; font-size: .8rem; margin-top: 2rem;">Generated by ytdata</p>
+</body>
+</html>
+`))
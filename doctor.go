@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Outcomes of a doctor check.
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+const (
+	doctorEndpoint  = "https://youtube.googleapis.com/"
+	tokenInfoURL    = "https://oauth2.googleapis.com/tokeninfo"
+	defaultAuthPort = "8080"
+	maxClockSkew    = 5 * time.Minute
+)
+
+// doctorResult is the outcome of one check, with how to fix it.
+type doctorResult struct {
+	Check  string
+	Status string
+	Detail string
+	Fix    string
+}
+
+// doctor runs the checks in order; later checks use what earlier ones
+// found.
+type doctor struct {
+	config    Config
+	results   []doctorResult
+	secrets   []byte
+	reachable bool
+}
+
+func (d *doctor) report(check, status, detail, fix string) {
+	d.results = append(d.results, doctorResult{Check: check, Status: status, Detail: detail, Fix: fix})
+}
+
+func newDoctorCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the setup and environment",
+		Long: `Check everything ytdata depends on and explain how to fix what is wrong:
+permissions of the config directory, the client secrets, the stored token
+(expiry, refresh and granted scopes), whether googleapis.com is reachable,
+whether the OAuth callback port is free, and the clock skew to Google.
+
+Exits with code 1 when a check failed; warnings alone do not fail.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata doctor
+  ytdata doctor --channel-id UCxxxxxxxxxxxxxxxxxxxxxx
+  ytdata doctor --proxy http://proxy.example.com:8080`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, runDoctor)
+		},
+	}
+
+	return cmd
+}
+
+func runDoctor(config Config) error {
+	if config.ChannelID != "" {
+		config.Credentials = channelCredentialsPath(config.Credentials, config.ChannelID)
+	}
+	d := &doctor{config: config}
+	d.checkConfigDir()
+	d.checkClientSecrets()
+	serverDate := d.checkNetwork()
+	d.checkClock(serverDate)
+	d.checkToken()
+	d.checkCallbackPort()
+
+	failed := 0
+	for _, result := range d.results {
+		fmt.Printf("%-4s  %-16s %s\n", strings.ToUpper(result.Status), result.Check, result.Detail)
+		if result.Fix != "" && result.Status != doctorOK {
+			fmt.Printf("%-4s  %-16s Fix: %s\n", "", "", result.Fix)
+		}
+		if result.Status == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(d.results))
+	}
+	return nil
+}
+
+func (d *doctor) checkConfigDir() {
+	const check = "Config directory"
+	dir := getConfigDir()
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		d.report(check, doctorWarn, dir+" does not exist yet", "Run 'ytdata init'; it is created when you first authorize")
+		return
+	}
+	if err != nil || !info.IsDir() {
+		d.report(check, doctorFail, fmt.Sprintf("%s is not a usable directory: %v", dir, err), "Remove it, or point XDG_CONFIG_HOME to another directory")
+		return
+	}
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		d.report(check, doctorFail, dir+" is not writable", fmt.Sprintf("Make it writable for your user, e.g. chown -R %s %s", currentUser(), dir))
+		return
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+
+	// Report every permission problem, not just the first
+	warned := false
+	if runtime.GOOS != "windows" {
+		if info.Mode().Perm()&0022 != 0 {
+			d.report(check, doctorWarn, fmt.Sprintf("%s is writable by other users (%v)", dir, info.Mode().Perm()), "chmod 700 "+dir)
+			warned = true
+		}
+		if token, err := os.Stat(d.config.Credentials); err == nil && token.Mode().Perm()&0077 != 0 {
+			d.report(check, doctorWarn, fmt.Sprintf("%s is readable by other users (%v)", d.config.Credentials, token.Mode().Perm()), "chmod 600 "+d.config.Credentials)
+			warned = true
+		}
+	}
+	if !warned {
+		d.report(check, doctorOK, dir+" is writable", "")
+	}
+}
+
+func currentUser() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "$USER"
+}
+
+func (d *doctor) checkClientSecrets() {
+	const check = "Client secrets"
+	if d.config.AuthMode != authModeOAuth {
+		d.report(check, doctorOK, "not needed for --auth-mode "+d.config.AuthMode, "")
+		return
+	}
+
+	source := d.config.ClientSecret
+	var data []byte
+	var err error
+	switch {
+	case source != "":
+		data, err = os.ReadFile(source)
+	default:
+		if data = readKeyringClientSecrets(); data != nil {
+			source = "OS keyring"
+		} else if source, err = findClientSecretsFile(); err != nil {
+			d.report(check, doctorFail, "no client secrets file found", "Run 'ytdata init' for guided setup, or pass --client-secret")
+			return
+		} else {
+			data, err = os.ReadFile(source)
+		}
+	}
+	if err != nil {
+		d.report(check, doctorFail, fmt.Sprintf("cannot read %s: %v", source, err), "Check the --client-secret path, or run 'ytdata init'")
+		return
+	}
+	if err := validateClientSecrets(data); err != nil {
+		d.report(check, doctorFail, fmt.Sprintf("%s is invalid: %v", source, err), "Download the OAuth client (Desktop app) JSON again under 'APIs & Services' > 'Credentials'")
+		return
+	}
+	d.secrets = data
+	if source != "OS keyring" {
+		d.config.ClientSecret = source
+	}
+	d.report(check, doctorOK, source+" is valid", "")
+}
+
+// checkNetwork requests googleapis.com and returns the server's Date, if
+// it answered.
+func (d *doctor) checkNetwork() time.Time {
+	const check = "Network"
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, doctorEndpoint, nil)
+	if err != nil {
+		d.report(check, doctorFail, err.Error(), "")
+		return time.Time{}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		d.report(check, doctorFail, "googleapis.com is unreachable: "+err.Error(), "Check your connection and firewall; behind a proxy set HTTPS_PROXY or pass --proxy")
+		return time.Time{}
+	}
+	_ = resp.Body.Close()
+	d.reachable = true
+	d.report(check, doctorOK, "googleapis.com is reachable", "")
+	date, _ := http.ParseTime(resp.Header.Get("Date"))
+	return date
+}
+
+func (d *doctor) checkClock(serverDate time.Time) {
+	const check = "Clock"
+	if serverDate.IsZero() {
+		d.report(check, doctorWarn, "skew unknown without a response from Google", "")
+		return
+	}
+	// Date has a resolution of one second
+	skew := time.Since(serverDate).Round(time.Second)
+	detail := fmt.Sprintf("local clock differs from Google's by %s", skew)
+	fix := "Enable time synchronization (NTP), e.g. timedatectl set-ntp true"
+	switch {
+	case skew.Abs() > maxClockSkew:
+		d.report(check, doctorFail, detail+"; OAuth tokens will be rejected", fix)
+	case skew.Abs() > 30*time.Second:
+		d.report(check, doctorWarn, detail, fix)
+	default:
+		d.report(check, doctorOK, detail, "")
+	}
+}
+
+func (d *doctor) checkToken() {
+	const check = "Token"
+	if d.config.AuthMode != authModeOAuth {
+		d.report(check, doctorOK, "not needed for --auth-mode "+d.config.AuthMode, "")
+		return
+	}
+	stored, err := readStoredToken(d.config.Credentials)
+	if os.IsNotExist(err) {
+		d.report(check, doctorWarn, "no token in "+d.config.Credentials, "Run any command (e.g. 'ytdata me') to authorize in the browser")
+		return
+	}
+	if err != nil {
+		d.report(check, doctorFail, fmt.Sprintf("cannot read %s: %v", d.config.Credentials, err), "Delete the file and authorize again")
+		return
+	}
+	if stored.RefreshToken == "" {
+		d.report(check, doctorFail, d.config.Credentials+" has no refresh token", "Delete the file and authorize again")
+		return
+	}
+	reauthorize := "Run 'ytdata auth refresh' and authorize again when asked"
+	if expiresAt := stored.RefreshTokenExpiresAt; !expiresAt.IsZero() {
+		switch remaining := time.Until(expiresAt); {
+		case remaining <= 0:
+			d.report(check, doctorFail, "refresh token expired "+expiresAt.Local().Format(time.RFC3339), reauthorize+"; publish the OAuth app to get tokens that do not expire")
+			return
+		case remaining < 48*time.Hour:
+			d.report(check, doctorWarn, fmt.Sprintf("refresh token expires in %s", remaining.Round(time.Minute)), "Publish the OAuth app under 'APIs & Services' > 'OAuth consent screen' to get tokens that do not expire")
+			return
+		}
+	}
+	if d.secrets == nil || !d.reachable {
+		d.report(check, doctorWarn, "stored, but not verified without valid client secrets and network", "")
+		return
+	}
+
+	oauthConfig, err := getOAuthConfig(d.config.ClientSecret, d.config.Scopes)
+	if err != nil {
+		d.report(check, doctorFail, err.Error(), "")
+		return
+	}
+	token, err := oauthConfig.TokenSource(context.Background(), &stored.Token).Token()
+	if isInvalidGrant(err) {
+		d.report(check, doctorFail, "refresh token expired or revoked (invalid_grant)", reauthorize)
+		return
+	}
+	if err != nil {
+		d.report(check, doctorFail, "failed to refresh the token: "+err.Error(), "Check that the token was issued for these client secrets, or delete it and authorize again")
+		return
+	}
+	if token.AccessToken != stored.AccessToken {
+		if err := saveCredentials(d.config.Credentials, token); err != nil {
+			warnf("Failed to save refreshed token: %v", err)
+		}
+	}
+
+	granted, err := grantedScopes(token.AccessToken)
+	if err != nil {
+		d.report(check, doctorWarn, "valid, but its scopes could not be read: "+err.Error(), "")
+		return
+	}
+	required := d.config.Scopes
+	if len(required) == 0 {
+		required = scopes
+	}
+	var missing []string
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		d.report(check, doctorFail, "missing scopes: "+strings.Join(missing, " "), "Delete "+d.config.Credentials+" and authorize again, granting all requested permissions")
+		return
+	}
+	d.report(check, doctorOK, fmt.Sprintf("valid, scopes: %s", strings.Join(granted, " ")), "")
+}
+
+// grantedScopes asks Google which scopes an access token carries.
+func grantedScopes(accessToken string) ([]string, error) {
+	// In a form body, the token stays out of proxy and access logs
+	resp, err := http.PostForm(tokenInfoURL, url.Values{"access_token": {accessToken}})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token info returned %s", resp.Status)
+	}
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return strings.Fields(info.Scope), nil
+}
+
+// checkCallbackPort checks the port the OAuth callback listens on: the
+// port of a web client's registered redirect URI, or 8080, which web
+// clients and 'websub serve' use by default.
+func (d *doctor) checkCallbackPort() {
+	const check = "Callback port"
+	port, required := defaultAuthPort, false
+	if d.secrets != nil {
+		if oauthConfig, err := getOAuthConfig(d.config.ClientSecret, d.config.Scopes); err == nil {
+			if u, err := url.Parse(oauthConfig.RedirectURL); err == nil && u.Port() != "" {
+				port, required = u.Port(), true
+			}
+		}
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err == nil {
+		_ = listener.Close()
+		d.report(check, doctorOK, "port "+port+" is free", "")
+		return
+	}
+	fix := fmt.Sprintf("Stop the program using port %s (e.g. lsof -i :%s)", port, port)
+	if required {
+		d.report(check, doctorFail, fmt.Sprintf("port %s of the registered redirect URI is in use", port), fix)
+		return
+	}
+	d.report(check, doctorWarn, fmt.Sprintf("port %s is in use; desktop clients pick a free port, but web clients and 'websub serve' need it", port), fix)
+}
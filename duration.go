@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// shortMaxDuration is the length below which a video counts as a Short.
+const shortMaxDuration = 60 * time.Second
+
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISODuration parses the ISO-8601 durations of the API, e.g. PT1H2M3S
+// or P1DT2H for long streams.
+func parseISODuration(s string) (time.Duration, error) {
+	m := isoDurationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "PT" {
+		return 0, fmt.Errorf("invalid ISO-8601 duration %q", s)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(m[i+1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO-8601 duration %q", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[5] != "" {
+		seconds, err := strconv.ParseFloat(m[5], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO-8601 duration %q", s)
+		}
+		d += time.Duration(seconds * float64(time.Second))
+	}
+	return d, nil
+}
+
+// videoDuration returns the length of a video, or false when the API did
+// not report a valid one (e.g. for upcoming streams).
+func videoDuration(video *youtube.Video) (time.Duration, bool) {
+	if video.ContentDetails == nil || video.ContentDetails.Duration == "" {
+		return 0, false
+	}
+	d, err := parseISODuration(video.ContentDetails.Duration)
+	return d, err == nil
+}
+
+// VideoFilter holds the local filtering applied to videos after they have
+// been fetched.
+type VideoFilter struct {
+	MinDuration   time.Duration
+	MaxDuration   time.Duration
+	ShortsOnly    bool
+	ExcludeShorts bool
+	Types         []string
+}
+
+// active reports whether any video filter is set.
+func (f VideoFilter) active() bool {
+	return f.MinDuration != 0 || f.MaxDuration != 0 || f.ShortsOnly || f.ExcludeShorts || len(f.Types) > 0
+}
+
+func (f VideoFilter) validate() error {
+	if f.ShortsOnly && f.ExcludeShorts {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--shorts-only and --exclude-shorts cannot be combined"))
+	}
+	if f.MinDuration < 0 || f.MaxDuration < 0 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("durations must not be negative"))
+	}
+	if f.MaxDuration > 0 && f.MinDuration > f.MaxDuration {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--min-duration is longer than --max-duration"))
+	}
+	for _, t := range f.Types {
+		if !slices.Contains(videoTypes, strings.TrimSpace(t)) {
+			return withKind(ErrInvalidConfig, fmt.Errorf("unknown video type %q (supported: %s)", t, strings.Join(videoTypes, ", ")))
+		}
+	}
+	return nil
+}
+
+// matches reports whether video passes the filter. Videos without a known
+// duration only pass when no duration filter is set.
+func (f VideoFilter) matches(video *youtube.Video) bool {
+	if len(f.Types) > 0 {
+		kind := videoType(video)
+		if !slices.ContainsFunc(f.Types, func(t string) bool { return strings.TrimSpace(t) == kind }) {
+			return false
+		}
+	}
+	if f.MinDuration == 0 && f.MaxDuration == 0 && !f.ShortsOnly && !f.ExcludeShorts {
+		return true
+	}
+	d, ok := videoDuration(video)
+	if !ok {
+		return false
+	}
+	short := videoType(video) == videoTypeShort
+	return (f.MinDuration == 0 || d >= f.MinDuration) &&
+		(f.MaxDuration == 0 || d <= f.MaxDuration) &&
+		(!f.ShortsOnly || short) &&
+		(!f.ExcludeShorts || !short)
+}
@@ -0,0 +1,898 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary stand in for ytdata: runYtdata starts it
+// again with YTDATA_TEST_MAIN set, which runs main instead of the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("YTDATA_TEST_MAIN") == "1" {
+		main()
+		os.Exit(exitOK)
+	}
+	os.Exit(m.Run())
+}
+
+// ytdataRun is the outcome of one ytdata invocation.
+type ytdataRun struct {
+	dir      string
+	stdout   string
+	stderr   string
+	exitCode int
+}
+
+// runYtdata runs ytdata with args against api in dir, with a config
+// directory of its own and none of the caller's YTDATA_ variables.
+func runYtdata(t *testing.T, api *fakeYouTube, dir string, args ...string) ytdataRun {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"--api-endpoint", api.URL}, args...)...)
+	cmd.Dir = dir
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "YTDATA_") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+	home := filepath.Join(dir, ".home")
+	cmd.Env = append(cmd.Env, "YTDATA_TEST_MAIN=1", "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	run := ytdataRun{dir: dir}
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		run.exitCode = exitErr.ExitCode()
+	case err != nil:
+		t.Fatalf("failed to run ytdata: %v", err)
+	}
+	run.stdout, run.stderr = stdout.String(), stderr.String()
+	return run
+}
+
+// expectExit fails the test unless the run ended with code.
+func (r ytdataRun) expectExit(t *testing.T, code int) {
+	t.Helper()
+	if r.exitCode != code {
+		t.Fatalf("exit code %d, want %d\nstderr:\n%s", r.exitCode, code, r.stderr)
+	}
+}
+
+// readJSONL reads the records of a JSONL file in the run's directory.
+func (r ytdataRun) readJSONL(t *testing.T, name string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(filepath.Join(r.dir, name))
+	if err != nil {
+		t.Fatalf("failed to open output: %v", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var records []map[string]any
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid JSONL record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	return records
+}
+
+func recordIDs(records []map[string]any, key string) []string {
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, lookupString(record, key))
+	}
+	return ids
+}
+
+var likedIDs = []string{"vid00000001", "vid00000002", "vid00000003", "vid00000004", "vid00000005"}
+
+func TestLikedPagination(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.setPageSize(2)
+
+	run := runYtdata(t, api, t.TempDir(), "liked", "-o", "liked.jsonl")
+	run.expectExit(t, exitOK)
+
+	records := run.readJSONL(t, "liked.jsonl")
+	if ids := recordIDs(records, "id"); !slices.Equal(ids, likedIDs) {
+		t.Errorf("liked IDs = %v, want %v", ids, likedIDs)
+	}
+	requests := api.requestsTo("videos")
+	if len(requests) != 3 {
+		t.Fatalf("%d video requests, want 3 pages", len(requests))
+	}
+	for i, want := range []string{"", "2", "4"} {
+		query := requests[i].Query()
+		if query.Get("myRating") != "like" || query.Get("pageToken") != want {
+			t.Errorf("request %d = %s, want myRating=like and pageToken %q", i, requests[i], want)
+		}
+	}
+}
+
+func TestLikedMaxStopsPaging(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.setPageSize(2)
+
+	run := runYtdata(t, api, t.TempDir(), "liked", "--max", "3", "-o", "liked.jsonl")
+	run.expectExit(t, exitOK)
+
+	if records := run.readJSONL(t, "liked.jsonl"); len(records) != 3 {
+		t.Errorf("%d liked videos, want 3", len(records))
+	}
+	if requests := api.requestsTo("videos"); len(requests) != 2 {
+		t.Errorf("%d video requests, want 2", len(requests))
+	}
+}
+
+func TestQuotaExceededExitCode(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.fail("videos", 1, http.StatusForbidden, "quotaExceeded")
+
+	run := runYtdata(t, api, t.TempDir(), "liked", "-o", "liked.jsonl")
+	run.expectExit(t, exitQuota)
+}
+
+func TestMeQuotaExceededIsNotRetriedWithoutAuditDetails(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.fail("channels", 1, http.StatusForbidden, "quotaExceeded")
+
+	run := runYtdata(t, api, t.TempDir(), "me", "-o", "me.jsonl")
+	run.expectExit(t, exitQuota)
+	if strings.Contains(run.stderr, "auditDetails") {
+		t.Errorf("quota error handled as a refused auditDetails part:\n%s", run.stderr)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	api := newFakeYouTube(t)
+
+	t.Run("json", func(t *testing.T) {
+		run := runYtdata(t, api, t.TempDir(), "liked", "-f", "json")
+		run.expectExit(t, exitOK)
+		var records []map[string]any
+		if err := json.Unmarshal([]byte(run.stdout), &records); err != nil {
+			t.Fatalf("invalid JSON output: %v", err)
+		}
+		if ids := recordIDs(records, "id"); !slices.Equal(ids, likedIDs) {
+			t.Errorf("IDs = %v, want %v", ids, likedIDs)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		run := runYtdata(t, api, t.TempDir(), "liked", "-f", "csv")
+		run.expectExit(t, exitOK)
+		rows, err := csv.NewReader(strings.NewReader(run.stdout)).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV output: %v", err)
+		}
+		if len(rows) != len(likedIDs)+1 {
+			t.Fatalf("%d CSV rows, want a header and %d videos", len(rows), len(likedIDs))
+		}
+		column := slices.Index(rows[0], "id")
+		if column < 0 {
+			t.Fatalf("CSV header %v has no id column", rows[0])
+		}
+		for i, id := range likedIDs {
+			if rows[i+1][column] != id {
+				t.Errorf("row %d has id %q, want %q", i+1, rows[i+1][column], id)
+			}
+		}
+	})
+
+	t.Run("ids-only", func(t *testing.T) {
+		run := runYtdata(t, api, t.TempDir(), "liked", "--ids-only")
+		run.expectExit(t, exitOK)
+		if ids := strings.Fields(run.stdout); !slices.Equal(ids, likedIDs) {
+			t.Errorf("IDs = %v, want %v", ids, likedIDs)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		run := runYtdata(t, api, t.TempDir(), "liked", "--filter", `snippet.channelTitle == "Alpha Channel"`, "-o", "alpha.jsonl")
+		run.expectExit(t, exitOK)
+		want := []string{"vid00000001", "vid00000003"}
+		if ids := recordIDs(run.readJSONL(t, "alpha.jsonl"), "id"); !slices.Equal(ids, want) {
+			t.Errorf("IDs = %v, want %v", ids, want)
+		}
+	})
+}
+
+func TestVideosLookupWarnsAboutMissingVideos(t *testing.T) {
+	api := newFakeYouTube(t)
+
+	run := runYtdata(t, api, t.TempDir(), "videos", "vid00000002", "https://youtu.be/vid00000004", "gone0000000", "-o", "videos.jsonl")
+	run.expectExit(t, exitOK)
+
+	want := []string{"vid00000002", "vid00000004"}
+	if ids := recordIDs(run.readJSONL(t, "videos.jsonl"), "id"); !slices.Equal(ids, want) {
+		t.Errorf("IDs = %v, want %v", ids, want)
+	}
+	if !strings.Contains(run.stderr, "Video gone0000000 not found") {
+		t.Errorf("stderr %q lacks a warning about the missing video", run.stderr)
+	}
+}
+
+func TestChannelsContinueOnErrorAndRetry(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.fail("channels", 1, http.StatusInternalServerError, "backendError")
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "channels", "UCaaaaaaaaaaaaaaaaaaaaaa", "UCbbbbbbbbbbbbbbbbbbbbbb",
+		"--continue-on-error", "--errors-file", "errors.jsonl", "-o", "channels.jsonl")
+	run.expectExit(t, exitPartial)
+	if records := run.readJSONL(t, "channels.jsonl"); len(records) != 0 {
+		t.Errorf("%d channels written from a failed batch", len(records))
+	}
+	if failures := run.readJSONL(t, "errors.jsonl"); len(failures) != 1 {
+		t.Fatalf("%d logged failures, want 1", len(failures))
+	}
+
+	run = runYtdata(t, api, dir, "retry", "errors.jsonl", "-o", "recovered.jsonl")
+	run.expectExit(t, exitOK)
+	want := []string{"UCaaaaaaaaaaaaaaaaaaaaaa", "UCbbbbbbbbbbbbbbbbbbbbbb"}
+	if ids := recordIDs(run.readJSONL(t, "recovered.jsonl"), "id"); !slices.Equal(ids, want) {
+		t.Errorf("recovered IDs = %v, want %v", ids, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "errors.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("errors file kept after a successful retry: %v", err)
+	}
+}
+
+func TestSubscriptionsContinueOnFailedPage(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.setPageSize(1)
+	api.failAfter("subscriptions", 1, http.StatusInternalServerError, "backendError")
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "subscriptions", "--continue-on-error", "-o", "subscriptions.jsonl")
+	run.expectExit(t, exitPartial)
+	if ids := recordIDs(run.readJSONL(t, "subscriptions.jsonl"), "id"); !slices.Equal(ids, []string{"UCaaaaaaaaaaaaaaaaaaaaaa"}) {
+		t.Errorf("IDs before the failed page = %v", ids)
+	}
+	failures := run.readJSONL(t, "errors.jsonl")
+	if len(failures) != 1 || failures[0]["operation"] != "subscriptions.list" || lookupString(failures[0], "params", "pageToken") != "1" {
+		t.Fatalf("logged failures = %v, want the second subscriptions page", failures)
+	}
+
+	// Channel batches are answered in one response again
+	api.setPageSize(50)
+	run = runYtdata(t, api, dir, "retry", "errors.jsonl", "-o", "recovered.jsonl")
+	run.expectExit(t, exitOK)
+	want := []string{"UCbbbbbbbbbbbbbbbbbbbbbb", "UCcccccccccccccccccccccc"}
+	if ids := recordIDs(run.readJSONL(t, "recovered.jsonl"), "id"); !slices.Equal(ids, want) {
+		t.Errorf("recovered IDs = %v, want %v", ids, want)
+	}
+}
+
+func TestPlaylistVideosAcrossPlaylists(t *testing.T) {
+	api := newFakeYouTube(t)
+
+	run := runYtdata(t, api, t.TempDir(), "playlist-videos", "--all", "-o", "videos.jsonl")
+	run.expectExit(t, exitOK)
+
+	records := run.readJSONL(t, "videos.jsonl")
+	want := []string{"vid00000001", "vid00000002", "vid00000003", "vid00000004"}
+	if ids := recordIDs(records, "videoId"); !slices.Equal(ids, want) {
+		t.Fatalf("video IDs = %v, want %v", ids, want)
+	}
+	// vid00000003 is in both playlists
+	if playlists, _ := records[2]["playlists"].([]any); len(playlists) != 2 {
+		t.Errorf("vid00000003 found in %d playlists, want 2", len(playlists))
+	}
+}
+
+func TestJSONLogFile(t *testing.T) {
+	api := newFakeYouTube(t)
+
+	run := runYtdata(t, api, t.TempDir(), "videos", "gone0000000", "--log-format", "json", "--log-file", "ytdata.log")
+	run.expectExit(t, exitOK)
+
+	if run.stderr != "" {
+		t.Errorf("stderr = %q, want the warning in the log file only", run.stderr)
+	}
+	entries := run.readJSONL(t, "ytdata.log")
+	if len(entries) != 1 || entries[0]["level"] != "WARN" || entries[0]["msg"] != "Video gone0000000 not found" {
+		t.Errorf("log entries = %v, want one warning about the missing video", entries)
+	}
+
+	// Status lines of a cron run are log entries as well
+	run = runYtdata(t, api, t.TempDir(), "all", "--dest", "exports", "--log-format", "json", "--log-file", "ytdata.log")
+	run.expectExit(t, exitOK)
+	if run.stderr != "" {
+		t.Errorf("stderr = %q, want the status lines in the log file only", run.stderr)
+	}
+	found := false
+	for _, entry := range run.readJSONL(t, "ytdata.log") {
+		found = found || entry["level"] == "INFO" && strings.HasPrefix(entry["msg"].(string), "Exported liked to ")
+	}
+	if !found {
+		t.Error("the log lacks the status line of the liked export")
+	}
+}
+
+func TestRunJobsFile(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+	jobs := `parallel: 2
+defaults:
+  dest: exports
+jobs:
+  - name: liked
+    type: liked
+    schedule: daily
+  - name: alpha
+    type: liked
+    filter: snippet.channelTitle == "Alpha Channel"
+    format: csv
+  - type: subscriptions
+`
+	if err := os.WriteFile(filepath.Join(dir, "jobs.yaml"), []byte(jobs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := runYtdata(t, api, dir, "run", "jobs.yaml", "-o", "report.jsonl")
+	run.expectExit(t, exitOK)
+	report := run.readJSONL(t, "report.jsonl")
+	if len(report) != 3 {
+		t.Fatalf("%d report records, want 3", len(report))
+	}
+	for i, want := range []struct {
+		job     string
+		records float64
+	}{{"liked", 5}, {"alpha", 2}, {"subscriptions", 3}} {
+		if report[i]["job"] != want.job || report[i]["status"] != "ok" || report[i]["records"] != want.records {
+			t.Errorf("report %d = %v, want job %s ok with %v records", i, report[i], want.job, want.records)
+		}
+	}
+	if liked := run.readJSONL(t, "exports/liked.jsonl"); len(liked) != 5 {
+		t.Errorf("%d liked videos exported, want 5", len(liked))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "exports", "alpha.csv")); err != nil {
+		t.Errorf("alpha job wrote no CSV: %v", err)
+	}
+
+	// The daily job is not due again yet
+	run = runYtdata(t, api, dir, "run", "jobs.yaml", "-o", "report.jsonl")
+	run.expectExit(t, exitOK)
+	if status := run.readJSONL(t, "report.jsonl")[0]["status"]; status != "skipped" {
+		t.Errorf("daily job status on the second run = %v, want skipped", status)
+	}
+}
+
+func TestExportHooks(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+	hooks := `pre_export:
+  - echo {{.Command}} {{.OutputFile}} > pre.txt
+post_export:
+  - echo {{.Status}} {{.Records}} "$YTDATA_OUTPUT_FILE" > post.txt
+  - cat > summary.json
+`
+	if err := os.WriteFile(filepath.Join(dir, "hooks.yaml"), []byte(hooks), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := runYtdata(t, api, dir, "liked", "--hooks", "hooks.yaml", "-o", "liked.jsonl")
+	run.expectExit(t, exitOK)
+	for name, want := range map[string]string{"pre.txt": "liked liked.jsonl\n", "post.txt": "ok 5 liked.jsonl\n"} {
+		if got, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(got) != want {
+			t.Errorf("%s = %q (%v), want %q", name, got, err, want)
+		}
+	}
+	var summary map[string]any
+	if data, err := os.ReadFile(filepath.Join(dir, "summary.json")); err != nil || json.Unmarshal(data, &summary) != nil {
+		t.Fatalf("hook got no JSON summary on stdin: %v", err)
+	}
+	if summary["records"] != 5.0 || summary["exitCode"] != 0.0 {
+		t.Errorf("summary = %v, want 5 records and exit code 0", summary)
+	}
+
+	// Interpolated values are quoted, so file names reach the shell as one
+	// word and never as code
+	run = runYtdata(t, api, dir, "liked", "--hooks", "hooks.yaml", "-o", "my $(touch pwned) 'liked'.jsonl")
+	run.expectExit(t, exitOK)
+	if got, err := os.ReadFile(filepath.Join(dir, "pre.txt")); err != nil || string(got) != "liked my $(touch pwned) 'liked'.jsonl\n" {
+		t.Errorf("pre.txt = %q (%v), want the file name as is", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Error("a command in the output file name was run by a hook")
+	}
+
+	// A failing pre-export hook cancels the export
+	hooks = "pre_export:\n  - exit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "hooks.yaml"), []byte(hooks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	requests := len(api.requestsTo("videos"))
+	run = runYtdata(t, api, dir, "liked", "--hooks", "hooks.yaml", "-o", "liked.jsonl")
+	run.expectExit(t, exitGeneral)
+	if len(api.requestsTo("videos")) != requests {
+		t.Error("liked videos were fetched after the pre-export hook failed")
+	}
+}
+
+func TestEncryptedOutput(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+	// A stand-in for age that records its arguments and reverses the bytes
+	// it is given, so plaintext never reaches the output file
+	bin := filepath.Join(dir, "bin")
+	script := "#!/bin/sh\necho \"$@\" > \"$AGE_ARGS\"\nrev\n"
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "age"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("AGE_ARGS", filepath.Join(dir, "args.txt"))
+
+	run := runYtdata(t, api, dir, "liked", "--encrypt", "age:age1alice,age1bob", "-o", "liked.jsonl.age")
+	run.expectExit(t, exitOK)
+	if args, err := os.ReadFile(filepath.Join(dir, "args.txt")); err != nil || string(args) != "-r age1alice -r age1bob\n" {
+		t.Errorf("age arguments = %q (%v), want both recipients", args, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "liked.jsonl.age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 5 || !strings.HasPrefix(lines[0], "}") {
+		t.Errorf("output is not the 5 encrypted records:\n%s", data)
+	}
+
+	// Commands writing documents rather than records are encrypted too
+	run = runYtdata(t, api, dir, "schema", "liked", "--encrypt", "age:age1alice", "-o", "schema.json.age")
+	run.expectExit(t, exitOK)
+	if data, err := os.ReadFile(filepath.Join(dir, "schema.json.age")); err != nil || !strings.HasPrefix(string(data), "{") || strings.Contains(string(data), `"properties"`) {
+		t.Errorf("schema is not encrypted (%v):\n%s", err, data)
+	}
+
+	run = runYtdata(t, api, dir, "liked", "--encrypt", "rot13:alice", "-o", "liked.jsonl")
+	run.expectExit(t, exitInvalidConfig)
+}
+
+func TestEncryptedOutputFormats(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+	// A stand-in for age that shifts lower case letters, which works on
+	// binary formats as well
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "age"), []byte("#!/bin/sh\ntr a-z n-za-m\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Every format either encrypts or is refused; none writes plaintext
+	for _, format := range recordFormats() {
+		t.Run(format, func(t *testing.T) {
+			name := "liked." + format + ".age"
+			run := runYtdata(t, api, dir, "liked", "--encrypt", "age:age1alice", "-f", format, "-o", name)
+			if run.exitCode != exitOK && run.exitCode != exitInvalidConfig {
+				t.Fatalf("exit code %d, want %d or %d\nstderr:\n%s", run.exitCode, exitOK, exitInvalidConfig, run.stderr)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if run.exitCode == exitOK && (err != nil || len(data) == 0) {
+				t.Fatalf("no encrypted output (%v)", err)
+			}
+			if strings.Contains(string(data)+run.stdout, "vid0000000") {
+				t.Errorf("the %s output holds plaintext", format)
+			}
+		})
+	}
+}
+
+func TestAllWritesManifest(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "all", "--dest", "exports")
+	run.expectExit(t, exitOK)
+	data, err := os.ReadFile(filepath.Join(dir, "exports", "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest exportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.Complete || len(manifest.Exports) != len(exporters) {
+		t.Fatalf("manifest = %s, want %d complete exports", data, len(exporters))
+	}
+	liked := manifest.Exports[0]
+	if liked.Dataset != "liked" || liked.Records != 5 || len(liked.Files) != 1 || liked.Files[0].Path != "liked.jsonl" {
+		t.Errorf("liked export = %+v, want 5 records in liked.jsonl", liked)
+	}
+
+	run = runYtdata(t, api, dir, "verify", "exports")
+	run.expectExit(t, exitOK)
+
+	if err := os.WriteFile(filepath.Join(dir, "exports", "liked.jsonl"), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run = runYtdata(t, api, dir, "verify", "exports")
+	run.expectExit(t, exitGeneral)
+	if !strings.Contains(run.stderr, "liked.jsonl has changed") {
+		t.Errorf("stderr does not report the changed file:\n%s", run.stderr)
+	}
+}
+
+// sqliteLayout describes the columns of a table: name, type, NOT NULL,
+// default and primary key position.
+func sqliteLayout(t *testing.T, db *sql.DB, table string) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info(?)`, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var name, typ, dflt string
+		var notNull, pk int
+		if err := rows.Scan(&name, &typ, &notNull, &dflt, &pk); err != nil {
+			t.Fatal(err)
+		}
+		columns = append(columns, fmt.Sprintf("%s %s notnull=%d default=%s pk=%d", name, typ, notNull, dflt, pk))
+	}
+	return columns
+}
+
+func TestSQLiteMigratesOldDatabase(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "liked.db")
+
+	// The layout written before schema versioning
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE "videos" (id TEXT PRIMARY KEY, kind TEXT, data TEXT NOT NULL, exported_at TEXT NOT NULL);
+INSERT INTO "videos" VALUES ('vid00000001', 'youtube#video', '{"statistics": {"viewCount": "7"}}', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	run := runYtdata(t, api, dir, "liked", "-f", "sqlite", "-o", "liked.db")
+	run.expectExit(t, exitOK)
+
+	var versions []int
+	rows, err := db.Query(`SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, version)
+	}
+	if !slices.Equal(versions, []int{1, 2, 3}) {
+		t.Errorf("applied migrations %v, want [1 2 3]", versions)
+	}
+
+	var count int
+	var first string
+	if err := db.QueryRow(`SELECT COUNT(*) FROM videos`).Scan(&count); err != nil || count != 5 {
+		t.Errorf("%d videos (%v), want 5", count, err)
+	}
+	if err := db.QueryRow(`SELECT first_exported_at FROM videos WHERE id = 'vid00000001'`).Scan(&first); err != nil || first != "2024-01-01T00:00:00Z" {
+		t.Errorf("first_exported_at = %q (%v), want the original export time", first, err)
+	}
+
+	// Migrated tables have the layout of new ones
+	run = runYtdata(t, api, dir, "liked", "-f", "sqlite", "-o", "fresh.db")
+	run.expectExit(t, exitOK)
+	fresh, err := sql.Open("sqlite", filepath.Join(dir, "fresh.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = fresh.Close()
+	}()
+	for _, table := range []string{"videos", "videos_statistics"} {
+		if migrated, created := sqliteLayout(t, db, table), sqliteLayout(t, fresh, table); !slices.Equal(migrated, created) {
+			t.Errorf("migrated %s columns %q, want %q", table, migrated, created)
+		}
+	}
+
+	// The statistics of the old row were kept as its first snapshot
+	var views []string
+	rows, err = db.Query(`SELECT json_extract(statistics, '$.viewCount') FROM videos_statistics WHERE id = 'vid00000001' ORDER BY captured_at`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var view string
+		if err := rows.Scan(&view); err != nil {
+			t.Fatal(err)
+		}
+		views = append(views, view)
+	}
+	if len(views) != 2 || views[0] != "7" {
+		t.Errorf("view count history %v, want the old and the new count", views)
+	}
+
+	// A database from a newer ytdata is left alone
+	if _, err := db.Exec(`INSERT INTO schema_migrations VALUES (99, 'future', '2030-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+	run = runYtdata(t, api, dir, "liked", "-f", "sqlite", "-o", "liked.db")
+	run.expectExit(t, exitInvalidConfig)
+	if !strings.Contains(run.stderr, "newer than this ytdata") {
+		t.Errorf("stderr does not explain the version mismatch:\n%s", run.stderr)
+	}
+}
+
+func TestChannelLinks(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "channels", "UCaaaaaaaaaaaaaaaaaaaaaa", "--links", "-o", "channels.jsonl")
+	run.expectExit(t, exitOK)
+	records := run.readJSONL(t, "channels.jsonl")
+	if len(records) != 1 {
+		t.Fatalf("%d channels, want 1", len(records))
+	}
+	var links []string
+	for _, link := range records[0]["links"].([]any) {
+		link := link.(map[string]any)
+		links = append(links, link["platform"].(string)+" "+link["url"].(string))
+	}
+	want := []string{
+		"patreon https://www.patreon.com/alpha",
+		"website https://www.example.com",
+		"twitter https://x.com/alpha",
+		"email mailto:alpha@example.com",
+	}
+	if !slices.Equal(links, want) {
+		t.Errorf("links = %q, want %q", links, want)
+	}
+
+	// Links are extracted before --fields drops the descriptions, and
+	// --fields links selects them on its own
+	for _, fields := range []string{"id,snippet.title", "id,links"} {
+		run = runYtdata(t, api, dir, "channels", "UCaaaaaaaaaaaaaaaaaaaaaa", "--links", "--fields", fields, "-o", "channels.jsonl")
+		run.expectExit(t, exitOK)
+		record := run.readJSONL(t, "channels.jsonl")[0]
+		if got, _ := record["links"].([]any); len(got) != len(want) {
+			t.Errorf("--fields %s: links = %v, want %d links", fields, record["links"], len(want))
+		}
+		if snippet, _ := record["snippet"].(map[string]any); snippet["description"] != nil {
+			t.Errorf("--fields %s kept the description", fields)
+		}
+	}
+}
+
+func TestChannelsResolvesHandles(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "channels", "https://www.youtube.com/@Beta", "UCaaaaaaaaaaaaaaaaaaaaaa", "-o", "channels.jsonl")
+	run.expectExit(t, exitOK)
+	var ids []string
+	for _, record := range run.readJSONL(t, "channels.jsonl") {
+		ids = append(ids, record["id"].(string))
+	}
+	if want := []string{"UCbbbbbbbbbbbbbbbbbbbbbb", "UCaaaaaaaaaaaaaaaaaaaaaa"}; !slices.Equal(ids, want) {
+		t.Errorf("channels = %q, want %q", ids, want)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "digest", "--since", "50y", "--max-per-channel", "1", "-o", "digest.html")
+	run.expectExit(t, exitOK)
+	html, err := os.ReadFile(filepath.Join(dir, "digest.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Channels with the latest upload first, each with its newest video
+	for _, title := range []string{"Fifth video", "Fourth video", "Third video"} {
+		if !bytes.Contains(html, []byte(title)) {
+			t.Errorf("digest lacks %q", title)
+		}
+	}
+	if i, j := bytes.Index(html, []byte("Beta Channel")), bytes.Index(html, []byte("Alpha Channel")); i < 0 || j < i {
+		t.Errorf("Beta Channel should come before Alpha Channel")
+	}
+	if bytes.Contains(html, []byte("First video")) {
+		t.Errorf("digest lists more than --max-per-channel videos")
+	}
+	if got := len(api.requestsTo("/feeds/videos.xml")); got != 3 {
+		t.Errorf("%d feed requests, want 3", got)
+	}
+
+	// Sending goes to a fake SMTP server
+	smtpServer, messages := newFakeSMTP(t)
+	run = runYtdata(t, api, dir, "digest", "--since", "50y", "--send-via", "smtp://"+smtpServer, "--from", "ytdata@example.com", "--to", "me@example.com")
+	run.expectExit(t, exitOK)
+	message := <-messages
+	for _, want := range []string{"Subject: YouTube digest: 5 videos since ", "To: me@example.com", "multipart/alternative", "Fifth video"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message lacks %q:\n%s", want, message)
+		}
+	}
+
+	run = runYtdata(t, api, dir, "digest", "--send-via", "smtp://"+smtpServer, "--to", "me@example.com")
+	run.expectExit(t, exitInvalidConfig)
+}
+
+// newFakeSMTP accepts one message on a local SMTP server, without TLS or
+// authentication, and returns its address and the received message.
+func newFakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 fake")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				messages <- strings.Join(data, "\n")
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				return
+			default:
+				_ = text.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestFailedSQLiteExportKeepsDatabase(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+
+	run := runYtdata(t, api, dir, "all", "--dest", "exports", "-f", "sqlite")
+	run.expectExit(t, exitOK)
+	api.fail("videos", 1, http.StatusForbidden, "forbidden")
+	run = runYtdata(t, api, dir, "all", "--dest", "exports", "-f", "sqlite")
+	run.expectExit(t, exitPartial)
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, "exports", "liked.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	var videos, snapshots int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM videos`).Scan(&videos); err != nil || videos != 5 {
+		t.Errorf("%d videos (%v), want the 5 of the first export", videos, err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM videos_statistics`).Scan(&snapshots); err != nil || snapshots == 0 {
+		t.Errorf("%d statistics snapshots (%v), want the history of the first export", snapshots, err)
+	}
+}
+
+func TestOutputDirManifest(t *testing.T) {
+	api := newFakeYouTube(t)
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "pages")
+	if err := os.MkdirAll(pages, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A stale file and the manifest entry of another export
+	if err := os.WriteFile(filepath.Join(pages, "stale.md"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	earlier := `{"manifestVersion": 1, "complete": true, "exports": [{"dataset": "notes", "status": "ok", "files": []}]}`
+	if err := os.WriteFile(filepath.Join(pages, "manifest.json"), []byte(earlier), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := runYtdata(t, api, dir, "playlists", "-f", "markdown", "--output-dir", "pages")
+	run.expectExit(t, exitOK)
+	data, err := os.ReadFile(filepath.Join(pages, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest exportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	var datasets, files []string
+	for _, export := range manifest.Exports {
+		datasets = append(datasets, export.Dataset)
+		if export.Dataset == "playlists" {
+			for _, file := range export.Files {
+				files = append(files, file.Path)
+			}
+		}
+	}
+	if !slices.Equal(datasets, []string{"notes", "playlists"}) || !manifest.Complete {
+		t.Errorf("manifest exports %q (complete %v), want the earlier and the new one", datasets, manifest.Complete)
+	}
+	if !slices.Contains(files, "index.md") || slices.Contains(files, "stale.md") {
+		t.Errorf("playlists files = %q, want the pages written, without stale.md", files)
+	}
+
+	run = runYtdata(t, api, dir, "verify", "pages")
+	run.expectExit(t, exitOK)
+}
+
+func TestVideoCommentsDest(t *testing.T) {
+	api := newFakeYouTube(t)
+	api.resources["commentThreads"] = []map[string]any{
+		{"kind": "youtube#commentThread", "id": "thread1", "snippet": map[string]any{"videoId": "vid00000001", "topLevelComment": map[string]any{"id": "c1", "snippet": map[string]any{"textOriginal": "first"}}}},
+		{"kind": "youtube#commentThread", "id": "thread2", "snippet": map[string]any{"videoId": "vid00000002", "topLevelComment": map[string]any{"id": "c2", "snippet": map[string]any{"textOriginal": "second"}}}},
+	}
+	dir := t.TempDir()
+
+	// Files carry the extension of the format, not its name
+	run := runYtdata(t, api, dir, "video-comments", "vid00000001", "vid00000002", "--dest", "comments", "-f", "ids")
+	run.expectExit(t, exitOK)
+	for _, name := range []string{"vid00000001.txt", "vid00000002.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, "comments", name)); err != nil {
+			t.Errorf("missing per-video file: %v", err)
+		}
+	}
+	run = runYtdata(t, api, dir, "video-comments", "vid00000001", "--dest", "comments", "-f", "sqlite")
+	run.expectExit(t, exitOK)
+	if _, err := os.Stat(filepath.Join(dir, "comments", "vid00000001.db")); err != nil {
+		t.Errorf("missing sqlite file: %v", err)
+	}
+
+	// -o would be ignored with --dest
+	run = runYtdata(t, api, dir, "video-comments", "vid00000001", "--dest", "comments", "-o", "comments.jsonl")
+	run.expectExit(t, exitInvalidConfig)
+	if _, err := os.Stat(filepath.Join(dir, "comments.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("comments.jsonl was written: %v", err)
+	}
+}
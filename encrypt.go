@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Tools of --encrypt.
+const (
+	encryptAge = "age"
+	encryptGPG = "gpg"
+)
+
+// encryption streams output through age or gpg for --encrypt
+// TOOL:RECIPIENT[,RECIPIENT...].
+type encryption struct {
+	tool       string
+	path       string
+	recipients []string
+}
+
+// parseEncryption parses --encrypt and finds the tool in PATH, so a
+// missing tool fails before anything is fetched.
+func parseEncryption(spec string) (*encryption, error) {
+	tool, list, ok := strings.Cut(spec, ":")
+	if !ok || list == "" {
+		return nil, fmt.Errorf("invalid --encrypt %q: expected age:RECIPIENT or gpg:RECIPIENT", spec)
+	}
+	if tool != encryptAge && tool != encryptGPG {
+		return nil, fmt.Errorf("unsupported --encrypt tool %q (supported: %s, %s)", tool, encryptAge, encryptGPG)
+	}
+	e := &encryption{tool: tool}
+	for _, recipient := range strings.Split(list, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			e.recipients = append(e.recipients, recipient)
+		}
+	}
+	if len(e.recipients) == 0 {
+		return nil, fmt.Errorf("invalid --encrypt %q: no recipient", spec)
+	}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, fmt.Errorf("--encrypt %s needs %s in PATH: %w", tool, tool, err)
+	}
+	e.path = path
+	return e, nil
+}
+
+// args returns the command line encrypting stdin to stdout. Recipients of
+// age that name a file are read as a recipients file (-R).
+func (e *encryption) args() []string {
+	var args []string
+	switch e.tool {
+	case encryptAge:
+		for _, recipient := range e.recipients {
+			if info, err := os.Stat(recipient); err == nil && !info.IsDir() {
+				args = append(args, "-R", recipient)
+			} else {
+				args = append(args, "-r", recipient)
+			}
+		}
+	case encryptGPG:
+		args = []string{"--batch", "--yes", "--encrypt"}
+		for _, recipient := range e.recipients {
+			args = append(args, "--recipient", recipient)
+		}
+		args = append(args, "--output", "-")
+	}
+	return args
+}
+
+// wrap starts the tool with its output going to w. Only ciphertext reaches
+// w; the records are streamed to the tool's stdin.
+func (e *encryption) wrap(w io.Writer) (io.WriteCloser, error) {
+	cmd := exec.Command(e.path, e.args()...)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", e.tool, err)
+	}
+	return &encryptWriter{WriteCloser: stdin, cmd: cmd, tool: e.tool}, nil
+}
+
+// encryptWriter writes to the stdin of the encryption tool; Close waits for
+// it to finish the ciphertext.
+type encryptWriter struct {
+	io.WriteCloser
+	cmd  *exec.Cmd
+	tool string
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to encrypt output: %w", err)
+	}
+	return n, nil
+}
+
+func (w *encryptWriter) Close() error {
+	closeErr := w.WriteCloser.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed to encrypt output: %w", w.tool, err)
+	}
+	return closeErr
+}
+
+// getEncryptFlag validates --encrypt for the command's output. Formats not
+// known here to be unencryptable are refused by output.New when they do
+// not apply the encryption.
+func getEncryptFlag(config *Config) error {
+	if config.Encrypt == "" {
+		return nil
+	}
+	switch {
+	case config.Append:
+		return withKind(ErrInvalidConfig, fmt.Errorf("--encrypt cannot be combined with --append"))
+	case config.Format == "sqlite" || config.Format == formatMarkdown:
+		return withKind(ErrInvalidConfig, fmt.Errorf("--encrypt does not support the %s format", config.Format))
+	}
+	e, err := parseEncryption(config.Encrypt)
+	if err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+	config.encryption = e
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrorKind classifies failures so scripts can react to the exit code
+// instead of parsing error messages.
+type ErrorKind int
+
+const (
+	ErrGeneral ErrorKind = iota
+	ErrInvalidConfig
+	ErrAuth
+	ErrQuota
+	ErrNetwork
+	ErrPartial
+)
+
+// Exit codes returned by ytdata, one per ErrorKind.
+const (
+	exitOK            = 0
+	exitGeneral       = 1
+	exitInvalidConfig = 2
+	exitAuth          = 3
+	exitQuota         = 4
+	exitNetwork       = 5
+	exitPartial       = 6
+)
+
+// Error attaches an ErrorKind to an error.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// withKind wraps err with kind unless it is nil.
+func withKind(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// isQuotaError reports whether an API error was caused by exhausted quota
+// or rate limiting.
+func isQuotaError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == 429 {
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "quotaExceeded", "dailyLimitExceeded", "rateLimitExceeded", "userRateLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+// classifyError determines the kind of an error. Explicitly tagged errors
+// win; otherwise the kind is derived from API, OAuth and network errors in
+// the chain.
+func classifyError(err error) ErrorKind {
+	var tagged *Error
+	if errors.As(err, &tagged) && tagged.Kind != ErrGeneral {
+		return tagged.Kind
+	}
+	if isQuotaError(err) {
+		return ErrQuota
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == 401 {
+		return ErrAuth
+	}
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return ErrAuth
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return ErrNetwork
+	}
+	return ErrGeneral
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	switch classifyError(err) {
+	case ErrInvalidConfig:
+		return exitInvalidConfig
+	case ErrAuth:
+		return exitAuth
+	case ErrQuota:
+		return exitQuota
+	case ErrNetwork:
+		return exitNetwork
+	case ErrPartial:
+		return exitPartial
+	default:
+		return exitGeneral
+	}
+}
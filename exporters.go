@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Exporter fetches one kind of data and writes its records to a sink.
+type Exporter interface {
+	// Name identifies the exporter, e.g. "liked". `ytdata all` uses it as
+	// the output file name.
+	Name() string
+	// Scopes lists the OAuth scopes the exporter needs.
+	Scopes() []string
+	Fetch(ctx context.Context, service *youtube.Service, sink output.Writer) error
+}
+
+// validator is implemented by exporters that check their options before
+// authenticating.
+type validator interface {
+	validate() error
+}
+
+// exporters holds the registered exporter constructors in the order
+// `ytdata all` runs them.
+var exporters []func(config Config) Exporter
+
+func registerExporter(factory func(config Config) Exporter) {
+	exporters = append(exporters, factory)
+}
+
+func init() {
+	registerExporter(func(config Config) Exporter { return likedExporter{config} })
+	registerExporter(func(config Config) Exporter { return subscriptionsExporter{config} })
+	registerExporter(func(config Config) Exporter { return playlistsExporter{config} })
+	registerExporter(func(config Config) Exporter { return meExporter{config} })
+}
+
+// exporterScopes switches config to separate credentials when the exporters
+// need scopes beyond the default read-only access.
+func exporterScopes(config Config, name string, list ...Exporter) Config {
+	var extra []string
+	for _, exporter := range list {
+		for _, scope := range exporter.Scopes() {
+			if !slices.Contains(scopes, scope) && !slices.Contains(extra, scope) {
+				extra = append(extra, scope)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return config
+	}
+	return withScopes(config, name, extra...)
+}
+
+// runExporter writes the records of a single exporter to the configured
+// output.
+func runExporter(config Config, exporter Exporter) error {
+	if err := validateLimits(config); err != nil {
+		return err
+	}
+	if v, ok := exporter.(validator); ok {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	config = exporterScopes(config, exporter.Name(), exporter)
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if config.Count {
+		return runCount(config, exporter, service)
+	}
+	return runExport(config, exporter, service)
+}
+
+// recordCollector is an output writer that keeps the records in memory, for
+// commands that process exporter results instead of writing them.
+type recordCollector struct {
+	records []map[string]any
+}
+
+func (c *recordCollector) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	c.records = append(c.records, m)
+	return nil
+}
+
+func (c *recordCollector) Close() error {
+	return nil
+}
+
+type AllOptions struct {
+	Dest string
+}
+
+func newAllCmd(config *Config) *cobra.Command {
+	var opts AllOptions
+
+	cmd := &cobra.Command{
+		Use:   "all",
+		Short: "Run every exporter",
+		Long: `Run every exporter (liked, subscriptions, playlists, me) and write each to
+its own file in --dest, named after the exporter and format (e.g.
+liked.jsonl).
+
+An exporter that fails does not stop the others; the command reports the
+failures at the end. A manifest.json in --dest lists every file with its
+SHA-256 checksum and record count, and whether all exports completed
+(check it with 'ytdata verify').`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata all
+  ytdata all --dest exports --format csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runAllExporters(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dest, "dest", ".", "Directory the exports are written to")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export records matching this expression")
+	addTransformFlag(cmd)
+	addLimitFlags(cmd, config)
+	addOldestFirstFlag(cmd, config)
+
+	cobra.CheckErr(cmd.MarkFlagDirname("dest"))
+
+	return cmd
+}
+
+func runAllExporters(config Config, opts AllOptions) error {
+	if err := validateLimits(config); err != nil {
+		return err
+	}
+	list := make([]Exporter, 0, len(exporters))
+	for _, factory := range exporters {
+		exporter := factory(config)
+		if v, ok := exporter.(validator); ok {
+			if err := v.validate(); err != nil {
+				return err
+			}
+		}
+		list = append(list, exporter)
+	}
+
+	if err := os.MkdirAll(opts.Dest, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	config = exporterScopes(config, "all", list...)
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	manifest := newManifest(config)
+	var failed []string
+	var lastErr error
+	for _, exporter := range list {
+		exportConfig := config
+		exportConfig.OutputFile = filepath.Join(opts.Dest, exporter.Name()+"."+config.Format)
+		exportConfig.recordCount = &atomic.Int64{}
+		entry := manifestExport{Dataset: exporter.Name(), Format: config.Format, StartedAt: time.Now().UTC()}
+		err := runExport(exportConfig, exporter, service)
+		var partial *Error
+		switch {
+		case err == nil:
+			infof("Exported %s to %s", exporter.Name(), exportConfig.OutputFile)
+		case errors.As(err, &partial) && partial.Kind == ErrPartial:
+			warnf("%s export is incomplete: %v", exporter.Name(), err)
+			failed = append(failed, exporter.Name())
+		default:
+			warnf("%s export failed: %v", exporter.Name(), err)
+			failed = append(failed, exporter.Name())
+			lastErr = err
+			removeFailedOutput(exportConfig, exportConfig.OutputFile)
+		}
+		entry.Records = exportConfig.recordCount.Load()
+		if err := manifest.add(opts.Dest, entry, outputFiles(config, exportConfig.OutputFile), err); err != nil {
+			return err
+		}
+	}
+	if err := manifest.write(opts.Dest); err != nil {
+		return err
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	kind := ErrPartial
+	if len(failed) == len(list) && lastErr != nil {
+		kind = classifyError(lastErr)
+	}
+	return withKind(kind, fmt.Errorf("%d of %d exports failed: %s", len(failed), len(list), strings.Join(failed, ", ")))
+}
+
+// removeFailedOutput removes the output of a failed export, so no truncated
+// file is mistaken for a complete one. SQLite databases and --append files
+// are kept: the writer's transaction was rolled back, or the file holds the
+// records of earlier exports.
+func removeFailedOutput(config Config, path string) {
+	if config.Format == "sqlite" || config.Append {
+		return
+	}
+	if output.IsS3(path) {
+		if err := output.RemoveS3(path); err != nil {
+			warnf("Failed to remove %s: %v", path, err)
+		}
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		warnf("Failed to remove %s: %v", path, err)
+	}
+}
+
+// runExport writes the records of exporter to config.OutputFile.
+func runExport(config Config, exporter Exporter, service *youtube.Service) (err error) {
+	out, err := openOutput(config, exporter.Name())
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	return exporter.Fetch(context.Background(), service, out)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+)
+
+const extractDescriptions = "descriptions"
+
+var extractModes = []string{extractDescriptions}
+
+// addExtractFlag adds --extract to commands writing video records.
+func addExtractFlag(cmd *cobra.Command) {
+	cmd.Flags().String("extract", "", "Only write part of each record: descriptions (ID, cleaned description and chapters)")
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("extract", staticCompletion(extractModes...)))
+}
+
+// getExtractFlag validates --extract and sets it in config.
+func getExtractFlag(cmd *cobra.Command, config *Config) error {
+	flag := cmd.Flags().Lookup("extract")
+	if flag == nil {
+		return nil
+	}
+	config.Extract = strings.ToLower(flag.Value.String())
+	if config.Extract != "" && !slices.Contains(extractModes, config.Extract) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported --extract mode %q (supported: %s)", config.Extract, strings.Join(extractModes, ", ")))
+	}
+	return nil
+}
+
+// extractWriter implements --extract descriptions: records are reduced to
+// the video ID, the description without links and chapter lines, and the
+// chapters parsed from it, as a corpus for NLP workflows.
+type extractWriter struct {
+	output.Writer
+}
+
+func (w extractWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	description := lookupString(m, "snippet", "description")
+	var duration time.Duration
+	if seconds, ok := m["durationSeconds"].(float64); ok {
+		duration = time.Duration(seconds) * time.Second
+	} else if d, err := parseISODuration(lookupString(m, "contentDetails", "duration")); err == nil {
+		duration = d
+	}
+	chapters, lines := parseChapters(description, duration)
+
+	extracted := map[string]any{
+		"id":          recordVideoID(m),
+		"description": cleanDescription(description, lines),
+	}
+	if chapters != nil {
+		extracted["chapters"] = chapters
+	}
+	return w.Writer.Write(extracted)
+}
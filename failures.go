@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultErrorsFile = "errors.jsonl"
+
+	operationChannelsList         = "channels.list"
+	operationChannelPlaylistsList = "playlists.list"
+	operationSubscriptionsList    = "subscriptions.list"
+)
+
+// failedRequest is one line of the error sidecar file. It records enough of
+// the failing request to re-run it with `ytdata retry`.
+type failedRequest struct {
+	Time      string        `json:"time"`
+	Command   string        `json:"command"`
+	Operation string        `json:"operation"`
+	Params    requestParams `json:"params"`
+	Error     string        `json:"error"`
+}
+
+type requestParams struct {
+	IDs       []string `json:"id,omitempty"`
+	ChannelID string   `json:"channelId,omitempty"`
+	Hl        string   `json:"hl,omitempty"`
+	Parts     []string `json:"part,omitempty"`
+	// PageToken is the failed page of a list; retry resumes there
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+// errorLog appends failed requests to the sidecar file, creating it on the
+// first failure so successful runs leave no file behind.
+type errorLog struct {
+	path    string
+	command string
+	file    *os.File
+	encoder *json.Encoder
+	count   int
+}
+
+func newErrorLog(path, command string) *errorLog {
+	if path == "" {
+		path = defaultErrorsFile
+	}
+	return &errorLog{path: path, command: command}
+}
+
+func (l *errorLog) record(operation string, params requestParams, failure error) error {
+	if l.file == nil {
+		f, err := os.Create(l.path)
+		if err != nil {
+			return fmt.Errorf("failed to create errors file: %w", err)
+		}
+		l.file = f
+		l.encoder = json.NewEncoder(f)
+	}
+	l.count++
+	metrics.addFailure()
+	warnf("%s failed, logged to %s: %v", operation, l.path, failure)
+	return l.encoder.Encode(failedRequest{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		Command:   l.command,
+		Operation: operation,
+		Params:    params,
+		Error:     failure.Error(),
+	})
+}
+
+// close closes the sidecar file and reports a partial export if anything
+// was logged.
+func (l *errorLog) close() error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.file.Close(); err != nil {
+		warnf("Failed to close file: %v", err)
+	}
+	return withKind(ErrPartial, fmt.Errorf("%d requests failed; re-run them with 'ytdata retry %s'", l.count, l.path))
+}
+
+func readFailedRequests(path string) ([]failedRequest, error) {
+	records, err := readJSONLRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]failedRequest, 0, len(records))
+	for _, record := range records {
+		var request failedRequest
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &request); err != nil {
+			return nil, fmt.Errorf("invalid entry in %s: %w", path, err)
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+func newRetryCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retry ERRORS_FILE",
+		Short: "Re-run requests that failed with --continue-on-error",
+		Long: `Re-run the requests logged to an errors file by --continue-on-error and
+write the recovered records to stdout (or file with -o).
+
+Requests that fail again are written back to the errors file; it is removed
+once every request succeeded. A failed page of a list is retried from that
+page on.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Example: `  ytdata retry errors.jsonl
+  ytdata retry errors.jsonl -o recovered.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return retryFailedRequests(config, args[0])
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write recovered records to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only write recovered records matching this expression")
+	addTransformFlag(cmd)
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	return cmd
+}
+
+func retryFailedRequests(config Config, path string) (err error) {
+	requests, err := readFailedRequests(path)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		infof("Nothing to retry")
+		return nil
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	out, err := openOutput(config, "recovered")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	ctx := context.Background()
+	var remaining []failedRequest
+	for _, request := range requests {
+		requestConfig := config
+		requestConfig.Language = request.Params.Hl
+		requestConfig.ChannelParts = request.Params.Parts
+
+		// Records fetched before a failed page are kept; the request is
+		// retried from that page next time
+		var records []any
+		var failure error
+		switch request.Operation {
+		case operationChannelsList:
+			channels, err := fetchChannelBatch(service, requestConfig, request.Params.IDs)
+			failure = err
+			for _, channel := range channels {
+				records = append(records, channel)
+			}
+		case operationChannelPlaylistsList:
+			playlists, failedPage, err := fetchChannelPlaylists(service, requestConfig, request.Params.ChannelID, request.Params.PageToken)
+			failure = err
+			request.Params.PageToken = failedPage
+			for _, playlist := range playlists {
+				records = append(records, playlist)
+			}
+		case operationSubscriptionsList:
+			subscriptions, failedPage, err := fetchSubscriptionPages(ctx, service, nil, request.Params.PageToken)
+			failure = err
+			request.Params.PageToken = failedPage
+			var channelIDs []string
+			for _, sub := range subscriptions {
+				channelIDs = append(channelIDs, sub.Snippet.ResourceId.ChannelId)
+			}
+			for batch := range slices.Chunk(channelIDs, 50) {
+				channels, err := fetchChannelBatch(service, requestConfig, batch)
+				if err != nil {
+					warnf("%s failed: %v", operationChannelsList, err)
+					remaining = append(remaining, failedRequest{
+						Time:      time.Now().UTC().Format(time.RFC3339),
+						Command:   request.Command,
+						Operation: operationChannelsList,
+						Params:    requestParams{IDs: batch, Hl: request.Params.Hl},
+						Error:     err.Error(),
+					})
+					continue
+				}
+				for _, channel := range channels {
+					records = append(records, channel)
+				}
+			}
+		default:
+			failure = fmt.Errorf("unknown operation %q", request.Operation)
+		}
+
+		for _, record := range records {
+			if err := out.Write(record); err != nil {
+				return fmt.Errorf("failed to write data: %w", err)
+			}
+		}
+		if failure != nil {
+			warnf("%s failed again: %v", request.Operation, failure)
+			request.Time = time.Now().UTC().Format(time.RFC3339)
+			request.Error = failure.Error()
+			remaining = append(remaining, request)
+		}
+	}
+
+	if len(remaining) == 0 {
+		if err := os.Remove(path); err != nil {
+			warnf("Failed to remove %s: %v", path, err)
+		}
+		infof("All %d requests succeeded", len(requests))
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to update errors file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			warnf("Failed to close file: %v", err)
+		}
+	}()
+	errorEncoder := json.NewEncoder(f)
+	for _, request := range remaining {
+		if err := errorEncoder.Encode(request); err != nil {
+			return fmt.Errorf("failed to update errors file: %w", err)
+		}
+	}
+	return withKind(ErrPartial, fmt.Errorf("%d of %d requests failed again; they remain in %s", len(remaining), len(requests), path))
+}
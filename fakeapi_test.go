@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeYouTube is an in-memory YouTube Data API serving the resources in
+// testdata/fakeapi. It implements the list endpoints the exporters use,
+// with pagination, and can be told to fail requests.
+type fakeYouTube struct {
+	*httptest.Server
+	t         *testing.T
+	resources map[string][]map[string]any
+
+	mu sync.Mutex
+	// pageSize caps maxResults, so small fixtures still span several pages
+	pageSize int
+	requests []*url.URL
+	failures map[string][]fakeFailure
+}
+
+// fakeFailure is an API error returned instead of the next response of a
+// resource.
+type fakeFailure struct {
+	status int
+	reason string
+	// after is the number of requests still answered before failing
+	after int
+}
+
+// fakeResources are the fixture files, named after their endpoint.
+var fakeResources = []string{"videos", "videoCategories", "channels", "playlists", "playlistItems", "subscriptions", "channelSections"}
+
+func newFakeYouTube(t *testing.T) *fakeYouTube {
+	t.Helper()
+	f := &fakeYouTube{
+		t:         t,
+		pageSize:  50,
+		resources: make(map[string][]map[string]any),
+		failures:  make(map[string][]fakeFailure),
+	}
+	for _, name := range fakeResources {
+		data, err := os.ReadFile(filepath.Join("testdata", "fakeapi", name+".json"))
+		if err != nil {
+			t.Fatalf("failed to read fixture: %v", err)
+		}
+		var items []map[string]any
+		if err := json.Unmarshal(data, &items); err != nil {
+			t.Fatalf("invalid fixture %s: %v", name, err)
+		}
+		f.resources[name] = items
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// setPageSize caps the number of items per response to size.
+func (f *fakeYouTube) setPageSize(size int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pageSize = size
+}
+
+// fail makes the next count requests to resource fail with status and
+// reason, e.g. http.StatusForbidden and "quotaExceeded".
+func (f *fakeYouTube) fail(resource string, count, status int, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for range count {
+		f.failures[resource] = append(f.failures[resource], fakeFailure{status: status, reason: reason})
+	}
+}
+
+// failAfter makes a request to resource fail with status and reason once
+// ok more requests to it were answered, e.g. to fail a later page.
+func (f *fakeYouTube) failAfter(resource string, ok, status int, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[resource] = append(f.failures[resource], fakeFailure{status: status, reason: reason, after: ok})
+}
+
+// requestsTo returns the requests made to resource so far.
+func (f *fakeYouTube) requestsTo(resource string) []*url.URL {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var requests []*url.URL
+	for _, u := range f.requests {
+		if strings.TrimPrefix(u.Path, "/youtube/v3/") == resource {
+			requests = append(requests, u)
+		}
+	}
+	return requests
+}
+
+func (f *fakeYouTube) serve(w http.ResponseWriter, r *http.Request) {
+	resource := strings.TrimPrefix(r.URL.Path, "/youtube/v3/")
+
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL)
+	size := f.pageSize
+	var failure *fakeFailure
+	if queued := f.failures[resource]; len(queued) > 0 {
+		if queued[0].after > 0 {
+			queued[0].after--
+		} else {
+			failure = &queued[0]
+			f.failures[resource] = queued[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	if failure != nil {
+		writeFakeError(w, failure.status, failure.reason)
+		return
+	}
+	if r.URL.Path == "/feeds/videos.xml" {
+		f.serveFeed(w, r.URL.Query().Get("channel_id"))
+		return
+	}
+	items, ok := f.resources[resource]
+	if r.Method != http.MethodGet || !ok {
+		f.t.Errorf("unexpected request to the fake API: %s %s", r.Method, r.URL)
+		writeFakeError(w, http.StatusNotFound, "notFound")
+		return
+	}
+
+	query := r.URL.Query()
+	items = filterFakeItems(resource, items, query)
+	start, _ := strconv.Atoi(query.Get("pageToken"))
+	if n, err := strconv.Atoi(query.Get("maxResults")); err == nil && n < size {
+		size = n
+	}
+	end := min(start+size, len(items))
+	page := items[min(start, end):end]
+
+	kind := strings.TrimSuffix(resource, "s")
+	if strings.HasSuffix(kind, "ie") {
+		kind = strings.TrimSuffix(kind, "ie") + "y"
+	}
+	response := map[string]any{
+		"kind":     "youtube#" + kind + "ListResponse",
+		"items":    page,
+		"pageInfo": map[string]int{"totalResults": len(items), "resultsPerPage": size},
+	}
+	if end < len(items) {
+		response["nextPageToken"] = strconv.Itoa(end)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		f.t.Errorf("failed to write fake response: %v", err)
+	}
+}
+
+// fakeFeedEntry is an entry of a channel's public Atom feed.
+type fakeFeedEntry struct {
+	VideoID   string `xml:"yt:videoId"`
+	Title     string `xml:"title"`
+	Published string `xml:"published"`
+	Author    string `xml:"author>name"`
+}
+
+// serveFeed serves the public feed of a channel, built from the fixture
+// videos uploaded by it.
+func (f *fakeYouTube) serveFeed(w http.ResponseWriter, channelID string) {
+	feed := struct {
+		XMLName xml.Name        `xml:"feed"`
+		Xmlns   string          `xml:"xmlns,attr"`
+		XmlnsYT string          `xml:"xmlns:yt,attr"`
+		Entries []fakeFeedEntry `xml:"entry"`
+	}{Xmlns: "http://www.w3.org/2005/Atom", XmlnsYT: "http://www.youtube.com/xml/schemas/2015"}
+	for _, video := range f.resources["videos"] {
+		snippet, _ := video["snippet"].(map[string]any)
+		if snippet["channelId"] != channelID {
+			continue
+		}
+		feed.Entries = append(feed.Entries, fakeFeedEntry{
+			VideoID:   fmt.Sprint(video["id"]),
+			Title:     fmt.Sprint(snippet["title"]),
+			Published: fmt.Sprint(snippet["publishedAt"]),
+			Author:    fmt.Sprint(snippet["channelTitle"]),
+		})
+	}
+	if len(feed.Entries) == 0 {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml")
+	if err := xml.NewEncoder(w).Encode(feed); err != nil {
+		f.t.Errorf("failed to write fake feed: %v", err)
+	}
+}
+
+// filterFakeItems selects the items a list request asks for. Requests for
+// the authorized user's resources (mine, myRating) get all of them.
+func filterFakeItems(resource string, items []map[string]any, query url.Values) []map[string]any {
+	var ids []string
+	for _, value := range query["id"] {
+		ids = append(ids, strings.Split(value, ",")...)
+	}
+	playlistID, handle, videoID := query.Get("playlistId"), query.Get("forHandle"), query.Get("videoId")
+	if len(ids) == 0 && playlistID == "" && handle == "" && videoID == "" {
+		return items
+	}
+
+	var selected []map[string]any
+	for _, item := range items {
+		switch {
+		case resource == "playlistItems":
+			if snippet, ok := item["snippet"].(map[string]any); ok && snippet["playlistId"] == playlistID {
+				selected = append(selected, item)
+			}
+		case resource == "commentThreads":
+			if snippet, ok := item["snippet"].(map[string]any); ok && snippet["videoId"] == videoID {
+				selected = append(selected, item)
+			}
+		case handle != "":
+			if snippet, ok := item["snippet"].(map[string]any); ok && strings.EqualFold(fmt.Sprint(snippet["customUrl"]), handle) {
+				selected = append(selected, item)
+			}
+		case slices.Contains(ids, fmt.Sprint(item["id"])):
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+func writeFakeError(w http.ResponseWriter, status int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": "fake " + reason,
+			"errors": []map[string]string{
+				{"domain": "youtube.fake", "reason": reason, "message": "fake " + reason},
+			},
+		},
+	})
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Derived fields are added to records by ytdata rather than returned by the
+// API. They map to the API fields they are computed from, which are
+// requested in their place.
+var (
+	videoLanguageSources = []string{"snippet.defaultAudioLanguage", "snippet.defaultLanguage", "snippet.title", "snippet.description"}
+	videoTypeSources     = []string{"contentDetails.duration", "snippet.liveBroadcastContent", "liveStreamingDetails", "player.embedHeight", "player.embedWidth"}
+
+	videoDerivedFields = map[string][]string{
+		"durationSeconds":       {"contentDetails.duration"},
+		"categoryName":          {"snippet.categoryId"},
+		"videoType":             videoTypeSources,
+		"chapters":              {"snippet.description", "contentDetails.duration"},
+		"streamDurationSeconds": {"liveStreamingDetails.actualStartTime", "liveStreamingDetails.actualEndTime"},
+		"contentLanguage":       videoLanguageSources,
+		"contentLanguageSource": videoLanguageSources,
+		"regionBlocked":         {"contentDetails.regionRestriction"},
+	}
+	playlistDerivedFields = map[string][]string{
+		"specialPlaylist": nil,
+	}
+	channelDerivedFields = map[string][]string{
+		linksField: linkSourceFields(),
+	}
+)
+
+// addFieldsFlag adds --fields to the exporters of API resources.
+func addFieldsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("fields", nil, "Only write these fields, e.g. id,snippet.title,statistics.viewCount (also sent to the API to shrink responses)")
+}
+
+// getFieldsFlag validates --fields and sets it in config.
+func getFieldsFlag(cmd *cobra.Command, config *Config) error {
+	if cmd.Flags().Lookup("fields") == nil {
+		return nil
+	}
+	fields, err := cmd.Flags().GetStringSlice("fields")
+	if err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+	config.Fields = nil
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if slices.Contains(strings.Split(field, "."), "") {
+			return withKind(ErrInvalidConfig, fmt.Errorf("invalid field %q", field))
+		}
+		config.Fields = append(config.Fields, field)
+	}
+	if len(config.Fields) == 0 {
+		return nil
+	}
+	if config.IDsOnly {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--fields cannot be combined with --ids-only"))
+	}
+	if config.Extract != "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--fields cannot be combined with --extract"))
+	}
+	// Pages and feeds are rendered from whole resources
+	if slices.Contains([]string{formatMarkdown, formatRSS, formatAtom}, config.Format) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--fields cannot be combined with --format %s", config.Format))
+	}
+	return nil
+}
+
+// fieldsWriter implements --fields: records are reduced to the listed
+// paths. Keys match case-insensitively, so Go field names such as
+// Snippet.Title select the same data as snippet.title. Paths through lists
+// apply to every element.
+type fieldsWriter struct {
+	output.Writer
+	fields [][]string
+}
+
+func newFieldsWriter(out output.Writer, fields []string) fieldsWriter {
+	w := fieldsWriter{Writer: out}
+	for _, field := range fields {
+		w.fields = append(w.fields, strings.Split(field, "."))
+	}
+	return w
+}
+
+func (w fieldsWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	projected := make(map[string]any)
+	for _, path := range w.fields {
+		projectField(projected, m, path)
+	}
+	return w.Writer.Write(projected)
+}
+
+// projectField copies the value at path in src to the same place in dst.
+func projectField(dst, src map[string]any, path []string) {
+	key, ok := recordKey(src, path[0])
+	if !ok {
+		return
+	}
+	value := src[key]
+	if len(path) == 1 {
+		dst[key] = value
+		return
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		child, ok := dst[key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			dst[key] = child
+		}
+		projectField(child, value, path[1:])
+	case []any:
+		list, ok := dst[key].([]any)
+		if !ok || len(list) != len(value) {
+			list = make([]any, len(value))
+			for i := range list {
+				list[i] = make(map[string]any)
+			}
+			dst[key] = list
+		}
+		for i, element := range value {
+			element, ok := element.(map[string]any)
+			child, isMap := list[i].(map[string]any)
+			if ok && isMap {
+				projectField(child, element, path[1:])
+			}
+		}
+	}
+}
+
+// recordKey returns the key of m matching name, preferring an exact match.
+func recordKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// fieldMask is a tree of API field names. A nil subtree selects the whole
+// field.
+type fieldMask map[string]fieldMask
+
+func (m fieldMask) add(path []string) {
+	child, ok := m[path[0]]
+	if ok && child == nil {
+		return
+	}
+	if len(path) == 1 {
+		m[path[0]] = nil
+		return
+	}
+	if !ok {
+		child = make(fieldMask)
+		m[path[0]] = child
+	}
+	child.add(path[1:])
+}
+
+// String renders the mask in the syntax of the API fields parameter, e.g.
+// id,snippet(title,channelId).
+func (m fieldMask) String() string {
+	var parts []string
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if m[key] == nil {
+			parts = append(parts, key)
+		} else {
+			parts = append(parts, key+"("+m[key].String()+")")
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// apiFieldMask translates --fields into the fields parameter of a list
+// call returning resources like resource. Derived fields are replaced by
+// the fields they are computed from, and required lists the fields the
+// command itself needs, e.g. for sorting. It returns "" when nothing can
+// be pushed down: without --fields, and with --filter, whose expression
+// may read any field.
+func apiFieldMask(config Config, resource any, derived map[string][]string, required []string) (googleapi.Field, error) {
+	if len(config.Fields) == 0 || config.Filter != "" {
+		return "", nil
+	}
+	mask := fieldMask{"kind": nil, "id": nil}
+	t := reflect.TypeOf(resource)
+	add := func(field string) error {
+		path, err := resolveFieldPath(t, strings.Split(field, "."))
+		if err != nil {
+			return withKind(ErrInvalidConfig, fmt.Errorf("invalid --fields: %w", err))
+		}
+		mask.add(path)
+		return nil
+	}
+
+	fields := slices.Clone(required)
+	for _, field := range config.Fields {
+		name, _, _ := strings.Cut(field, ".")
+		if sources, ok := derivedSources(derived, name); ok {
+			fields = append(fields, sources...)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	for _, field := range fields {
+		if err := add(field); err != nil {
+			return "", err
+		}
+	}
+	return googleapi.Field("items(" + mask.String() + "),nextPageToken"), nil
+}
+
+// hasField reports whether fields select the top-level field name.
+func hasField(fields []string, name string) bool {
+	return slices.ContainsFunc(fields, func(field string) bool {
+		top, _, _ := strings.Cut(field, ".")
+		return strings.EqualFold(top, name)
+	})
+}
+
+func derivedSources(derived map[string][]string, name string) ([]string, bool) {
+	for field, sources := range derived {
+		if strings.EqualFold(field, name) {
+			return sources, true
+		}
+	}
+	return nil, false
+}
+
+// resolveFieldPath maps a path of Go field or JSON names to the JSON names
+// of the API, walking the client library types. Segments below a map, such
+// as the language of localizations, are kept as they are.
+func resolveFieldPath(t reflect.Type, path []string) ([]string, error) {
+	var resolved []string
+	for i, segment := range path {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			resolved = append(resolved, segment)
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+		default:
+			return nil, fmt.Errorf("%s has no field %s", strings.Join(path[:i], "."), segment)
+		}
+		field, ok := jsonField(t, segment)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", strings.Join(path[:i+1], "."))
+		}
+		resolved = append(resolved, jsonFieldName(field))
+		t = field.Type
+	}
+	return resolved, nil
+}
+
+// jsonField finds the field of struct type t named name in Go or in JSON.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		json := jsonFieldName(field)
+		if json == "-" || !field.IsExported() {
+			continue
+		}
+		if strings.EqualFold(json, name) || strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// likedRequiredFields lists the video fields the liked filters read.
+func likedRequiredFields(config Config) []string {
+	var fields []string
+	if config.Videos.active() {
+		fields = append(fields, videoTypeSources...)
+	}
+	if len(config.Languages) > 0 {
+		fields = append(fields, videoLanguageSources...)
+	}
+	return fields
+}
+
+// channelFieldMask is the field mask of channel lookups, keeping the fields
+// the subscription filters and sorting read.
+func channelFieldMask(config Config) (googleapi.Field, error) {
+	var required []string
+	f := config.Subscriptions
+	switch f.Sort {
+	case "subscribers":
+		required = append(required, "statistics.subscriberCount")
+	case "videos":
+		required = append(required, "statistics.videoCount")
+	case "title":
+		required = append(required, "snippet.title")
+	}
+	if f.MinSubscribers > 0 {
+		required = append(required, "statistics.subscriberCount", "statistics.hiddenSubscriberCount")
+	}
+	if f.Country != "" {
+		required = append(required, "snippet.country")
+	}
+	if f.Topic != "" {
+		required = append(required, "topicDetails.topicCategories")
+	}
+	if config.Links {
+		required = append(required, linkSourceFields()...)
+	}
+	return apiFieldMask(config, youtube.Channel{}, channelDerivedFields, required)
+}
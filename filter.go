@@ -0,0 +1,502 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+// A filter is a small boolean expression evaluated against a record:
+//
+//	statistics.videoCount == 0 && snippet.country != "DE"
+//	snippet.title =~ "(?i)podcast" || !(snippet.publishedAt >= "2020")
+//
+// Fields are dotted paths; literals are numbers, quoted strings, true, false
+// and null. Comparisons are ==, !=, <, <=, >, >= and =~ (regular expression
+// match), combined with &&, || and !. Numeric strings compare as numbers,
+// since the API returns counts as strings. The functions in filterFunctions
+// cover checks that comparisons cannot express.
+type filterExpr interface {
+	eval(record map[string]any) any
+}
+
+// parseFilter compiles a filter expression.
+func parseFilter(expression string) (filterExpr, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// filterWriter only passes records matching a filter to its writer.
+type filterWriter struct {
+	output.Writer
+	filter filterExpr
+}
+
+func (w filterWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	if !matchFilter(w.filter, m) {
+		return nil
+	}
+	return w.Writer.Write(record)
+}
+
+// matchFilter reports whether record satisfies expr.
+func matchFilter(expr filterExpr, record map[string]any) bool {
+	return truthy(expr.eval(record))
+}
+
+type filterTokenKind int
+
+const (
+	tokenIdent filterTokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for j < len(s) && rune(s[j]) != c {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, filterToken{tokenString, b.String()})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			j := i + 1
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, filterToken{tokenNumber, s[i:j]})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, filterToken{tokenIdent, s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")", ","} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q in filter", string(c))
+			}
+			tokens = append(tokens, filterToken{tokenOp, op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterExpr, error) {
+	if p.peekOp("!") != "" {
+		p.pos++
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peekOp("==", "!=", "<=", ">=", "<", ">", "=~")
+	if op == "" {
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if op == "=~" {
+		lit, ok := right.(literalExpr)
+		pattern, isString := lit.value.(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("=~ needs a quoted regular expression")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in filter: %w", err)
+		}
+		return matchExpr{left: left, re: re}, nil
+	}
+	return compareExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *filterParser) parseValue() (filterExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case tokenString:
+		return literalExpr{token.text}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in filter", token.text)
+		}
+		return literalExpr{n}, nil
+	case tokenIdent:
+		switch token.text {
+		case "true":
+			return literalExpr{true}, nil
+		case "false":
+			return literalExpr{false}, nil
+		case "null":
+			return literalExpr{nil}, nil
+		}
+		if p.peekOp("(") != "" {
+			return p.parseCall(token.text)
+		}
+		return fieldExpr{strings.Split(token.text, ".")}, nil
+	}
+	if token.text == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peekOp(")") == "" {
+			return nil, fmt.Errorf("missing ) in filter")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in filter", token.text)
+}
+
+func (p *filterParser) parseCall(name string) (filterExpr, error) {
+	fn, ok := filterFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q in filter", name)
+	}
+	p.pos++ // (
+	var args []filterExpr
+	if p.peekOp(")") == "" {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peekOp(",") == "" {
+				break
+			}
+			p.pos++
+		}
+	}
+	if p.peekOp(")") == "" {
+		return nil, fmt.Errorf("missing ) after arguments of %s", name)
+	}
+	p.pos++
+	if len(args) != fn.args {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name, fn.args, len(args))
+	}
+	return callExpr{fn: fn.call, args: args}, nil
+}
+
+type filterFunction struct {
+	args int
+	call func(args []any) any
+}
+
+// filterFunctions are the functions available in filters.
+var filterFunctions = map[string]filterFunction{
+	// has(field) reports whether a field is present and not null
+	"has": {1, func(args []any) any { return args[0] != nil }},
+	// len(value) is the length of a string, array or object
+	"len": {1, func(args []any) any {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v)))
+		case []any:
+			return float64(len(v))
+		case map[string]any:
+			return float64(len(v))
+		}
+		return float64(0)
+	}},
+	// lower(s) converts a string to lower case
+	"lower": {1, func(args []any) any {
+		if args[0] == nil {
+			return nil
+		}
+		return strings.ToLower(fmt.Sprint(args[0]))
+	}},
+	// contains(haystack, needle) checks for a substring or an array element
+	"contains": {2, func(args []any) any {
+		switch v := args[0].(type) {
+		case string:
+			return strings.Contains(v, fmt.Sprint(args[1]))
+		case []any:
+			for _, item := range v {
+				if compareValues("==", item, args[1]) {
+					return true
+				}
+			}
+		}
+		return false
+	}},
+	// startsWith(s, prefix) checks a string prefix
+	"startsWith": {2, func(args []any) any {
+		s, ok := args[0].(string)
+		return ok && strings.HasPrefix(s, fmt.Sprint(args[1]))
+	}},
+}
+
+type callExpr struct {
+	fn   func(args []any) any
+	args []filterExpr
+}
+
+func (e callExpr) eval(record map[string]any) any {
+	values := make([]any, len(e.args))
+	for i, arg := range e.args {
+		values[i] = arg.eval(record)
+	}
+	return e.fn(values)
+}
+
+type literalExpr struct {
+	value any
+}
+
+func (e literalExpr) eval(map[string]any) any {
+	return e.value
+}
+
+type fieldExpr struct {
+	path []string
+}
+
+func (e fieldExpr) eval(record map[string]any) any {
+	var current any = record
+	for _, key := range e.path {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
+type notExpr struct {
+	inner filterExpr
+}
+
+func (e notExpr) eval(record map[string]any) any {
+	return !truthy(e.inner.eval(record))
+}
+
+type logicalExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e logicalExpr) eval(record map[string]any) any {
+	left := truthy(e.left.eval(record))
+	if e.op == "&&" {
+		return left && truthy(e.right.eval(record))
+	}
+	return left || truthy(e.right.eval(record))
+}
+
+type matchExpr struct {
+	left filterExpr
+	re   *regexp.Regexp
+}
+
+func (e matchExpr) eval(record map[string]any) any {
+	value := e.left.eval(record)
+	if value == nil {
+		return false
+	}
+	return e.re.MatchString(fmt.Sprint(value))
+}
+
+type compareExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e compareExpr) eval(record map[string]any) any {
+	return compareValues(e.op, e.left.eval(record), e.right.eval(record))
+}
+
+func compareValues(op string, left, right any) bool {
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			return compareOrdered(op, l, r)
+		}
+	}
+	if left == nil || right == nil {
+		switch op {
+		case "==":
+			return left == right
+		case "!=":
+			return left != right
+		default:
+			return false
+		}
+	}
+	if l, ok := left.(bool); ok {
+		r, ok := right.(bool)
+		switch op {
+		case "==":
+			return ok && l == r
+		case "!=":
+			return !ok || l != r
+		default:
+			return false
+		}
+	}
+	return compareOrdered(op, fmt.Sprint(left), fmt.Sprint(right))
+}
+
+func compareOrdered[T float64 | string](op string, l, r T) bool {
+	switch op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
+
+// toNumber converts numbers and numeric strings to float64.
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func truthy(v any) bool {
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	case float64:
+		return b != 0
+	case string:
+		return b != ""
+	case []any:
+		return len(b) > 0
+	case map[string]any:
+		return len(b) > 0
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	var record map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"id": "vid1",
+		"snippet": {"title": "Go Podcast #12", "country": "DE", "tags": ["go", "podcast"], "publishedAt": "2021-03-04T05:06:07Z"},
+		"statistics": {"viewCount": "1500", "videoCount": "0"},
+		"status": {"madeForKids": false},
+		"empty": ""
+	}`), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		expression string
+		want       bool
+	}{
+		// Comparisons; numeric strings compare as numbers
+		{`statistics.viewCount > 999`, true},
+		{`statistics.viewCount == 1500`, true},
+		{`statistics.viewCount >= "200"`, true},
+		{`statistics.videoCount == 0`, true},
+		{`snippet.country != "DE"`, false},
+		{`snippet.publishedAt >= "2020"`, true},
+		{`snippet.publishedAt < '2021-01-01'`, false},
+		{`status.madeForKids == false`, true},
+		{`status.madeForKids != true`, true},
+		{`snippet.title =~ "(?i)podcast"`, true},
+		{`snippet.title =~ "^Podcast"`, false},
+		// Precedence: ! binds tighter than &&, && tighter than ||
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`!false && false`, false},
+		{`!(false && false)`, true},
+		{`snippet.country == "US" || statistics.viewCount > 1000 && has(snippet.tags)`, true},
+		// Quoting
+		{`snippet.title == "Go Podcast #12"`, true},
+		{`snippet.title == 'Go Podcast #12'`, true},
+		{`contains(snippet.title, "\"")`, false},
+		{`"it's" == 'it\'s'`, true},
+		// Missing fields are null, never equal to a value and never ordered
+		{`snippet.missing == null`, true},
+		{`missing.nested.field == null`, true},
+		{`snippet.missing != "x"`, true},
+		{`snippet.missing > 0`, false},
+		{`snippet.missing < 0`, false},
+		{`snippet.missing =~ ".*"`, false},
+		{`!snippet.missing`, true},
+		{`has(snippet.missing)`, false},
+		{`has(snippet.country)`, true},
+		// Type mismatches
+		{`status.madeForKids == "false"`, false},
+		{`status.madeForKids < true`, false},
+		{`snippet.country > 5`, true},
+		{`empty`, false},
+		{`snippet.tags`, true},
+		// Functions
+		{`len(snippet.tags) == 2`, true},
+		{`len(snippet.title) == 14`, true},
+		{`len(snippet) == 4`, true},
+		{`len(snippet.missing) == 0`, true},
+		{`lower(snippet.country) == "de"`, true},
+		{`lower(snippet.missing) == null`, true},
+		{`contains(snippet.tags, "go")`, true},
+		{`contains(snippet.tags, "rust")`, false},
+		{`contains(snippet.title, "Podcast")`, true},
+		{`contains(statistics, "viewCount")`, false},
+		{`startsWith(snippet.title, "Go")`, true},
+		{`startsWith(statistics.viewCount, 1)`, true},
+		{`startsWith(snippet.missing, "")`, false},
+	} {
+		filter, err := parseFilter(tt.expression)
+		if err != nil {
+			t.Errorf("parseFilter(%q): %v", tt.expression, err)
+			continue
+		}
+		if got := matchFilter(filter, record); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestFilterParseErrors(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`snippet.title ==`,
+		`== 1`,
+		`(true`,
+		`true)`,
+		`true false`,
+		`"unterminated`,
+		`snippet.title = "x"`,
+		`snippet.title =~ title`,
+		`snippet.title =~ "("`,
+		`unknown(snippet)`,
+		`has()`,
+		`has(a, b)`,
+		`contains(a`,
+		`a & b`,
+		`1.2.3 == 1`,
+		`$`,
+	} {
+		if _, err := parseFilter(expression); err == nil {
+			t.Errorf("parseFilter(%q) succeeded, want an error", expression)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type HistoryStatsOptions struct {
+	Top          int
+	SessionGap   time.Duration
+	DedupeWindow time.Duration
+	Timezone     string
+}
+
+// historyStatsEntry is one row of the watch history report. Type is total,
+// year, channel, video (the most rewatched videos) or heatmap (watches per
+// weekday and hour).
+type historyStatsEntry struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	VideoID string `json:"videoId,omitempty"`
+	Weekday string `json:"weekday,omitempty"`
+	Hour    *int   `json:"hour,omitempty"`
+	Watches int    `json:"watches"`
+	Videos  int    `json:"videos,omitempty"`
+	// Sessions and session lengths are only reported for totals and years
+	Sessions              int     `json:"sessions,omitempty"`
+	AverageSessionMinutes float64 `json:"averageSessionMinutes,omitempty"`
+	LongestSessionMinutes float64 `json:"longestSessionMinutes,omitempty"`
+	Duplicates            int     `json:"duplicates,omitempty"`
+	Rewatched             int     `json:"rewatched,omitempty"`
+}
+
+func newHistoryStatsCmd(config *Config) *cobra.Command {
+	var opts HistoryStatsOptions
+
+	cmd := &cobra.Command{
+		Use:   "history TAKEOUT_DIR|FILE",
+		Short: "Summarize the watch history of a Takeout export",
+		Long: `Summarize the watch-history.json of a Google Takeout export (the history
+has to be exported as JSON): watch sessions, rewatched videos, the channels
+watched most, watches per weekday and hour as heatmap data, and yearly
+totals. Ads are not counted.
+
+Takeout records a video again when a watch is resumed, so watches of the
+same video within --dedupe-window of each other count once. Watches less
+than --session-gap apart form a session; a session's length runs from its
+first to its last watch.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata stats history ~/Downloads/Takeout
+  ytdata stats history watch-history.json --timezone Europe/Berlin -f json -o history.json
+  ytdata stats history ~/Downloads/Takeout | jq 'select(.type == "heatmap")'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return historyStats(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Top, "top", 10, "Number of top channels and rewatched videos to report (0 = all)")
+	cmd.Flags().DurationVar(&opts.SessionGap, "session-gap", 30*time.Minute, "Break between watches that starts a new session")
+	cmd.Flags().DurationVar(&opts.DedupeWindow, "dedupe-window", 5*time.Minute, "Count watches of the same video this close together once (0 = only identical times)")
+	cmd.Flags().StringVar(&opts.Timezone, "timezone", "UTC", "Time zone for hours, weekdays and years, e.g. Local or Europe/Berlin")
+	addOutputFlag(cmd, "", "Write report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// dedupeWatches drops watches of the same video within window of its
+// previous watch. Watches are in time order; removed videos without an ID
+// are compared by title.
+func dedupeWatches(watches []takeoutWatch, window time.Duration) ([]takeoutWatch, int) {
+	last := make(map[string]time.Time)
+	kept := watches[:0:0]
+	for _, w := range watches {
+		key := w.VideoID
+		if key == "" {
+			key = "title:" + w.Title
+		}
+		if t, ok := last[key]; ok && w.Time.Sub(t) <= window {
+			last[key] = w.Time
+			continue
+		}
+		last[key] = w.Time
+		kept = append(kept, w)
+	}
+	return kept, len(watches) - len(kept)
+}
+
+// watchSessions returns the lengths of the sessions of watches, which are
+// in time order.
+func watchSessions(watches []takeoutWatch, gap time.Duration) []time.Duration {
+	var sessions []time.Duration
+	var start, end time.Time
+	for i, w := range watches {
+		if i > 0 && w.Time.Sub(end) <= gap {
+			end = w.Time
+			continue
+		}
+		if i > 0 {
+			sessions = append(sessions, end.Sub(start))
+		}
+		start, end = w.Time, w.Time
+	}
+	if len(watches) > 0 {
+		sessions = append(sessions, end.Sub(start))
+	}
+	return sessions
+}
+
+// summarizeWatches returns the totals of watches as an entry of the given
+// type and name.
+func summarizeWatches(kind, name string, watches []takeoutWatch, gap time.Duration) historyStatsEntry {
+	entry := historyStatsEntry{Type: kind, Name: name, Watches: len(watches)}
+	counts := make(map[string]int)
+	for _, w := range watches {
+		if w.VideoID != "" {
+			counts[w.VideoID]++
+		}
+	}
+	entry.Videos = len(counts)
+	for _, n := range counts {
+		if n > 1 {
+			entry.Rewatched++
+		}
+	}
+	sessions := watchSessions(watches, gap)
+	entry.Sessions = len(sessions)
+	var total, longest time.Duration
+	for _, s := range sessions {
+		total += s
+		longest = max(longest, s)
+	}
+	if len(sessions) > 0 {
+		entry.AverageSessionMinutes = roundMinutes(total / time.Duration(len(sessions)))
+		entry.LongestSessionMinutes = roundMinutes(longest)
+	}
+	return entry
+}
+
+func roundMinutes(d time.Duration) float64 {
+	return float64(d.Round(6*time.Second)) / float64(time.Minute)
+}
+
+// rankHistoryEntries sorts entries by watches and keeps the first top
+// entries (all when top is 0).
+func rankHistoryEntries(entries []historyStatsEntry, top int) []historyStatsEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Watches != entries[j].Watches {
+			return entries[i].Watches > entries[j].Watches
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if top > 0 && len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+func summarizeHistory(watches []takeoutWatch, duplicates int, opts HistoryStatsOptions, loc *time.Location) []historyStatsEntry {
+	total := summarizeWatches("total", "all", watches, opts.SessionGap)
+	total.Duplicates = duplicates
+	entries := []historyStatsEntry{total}
+
+	var years []string
+	byYear := make(map[string][]takeoutWatch)
+	channels := make(map[string]*historyStatsEntry)
+	channelVideos := make(map[string]map[string]bool)
+	videos := make(map[string]*historyStatsEntry)
+	var heatmap [7][24]int
+	for _, w := range watches {
+		t := w.Time.In(loc)
+		year := strconv.Itoa(t.Year())
+		if _, ok := byYear[year]; !ok {
+			years = append(years, year)
+		}
+		byYear[year] = append(byYear[year], w)
+		heatmap[t.Weekday()][t.Hour()]++
+
+		if w.Channel != "" {
+			channel, ok := channels[w.Channel]
+			if !ok {
+				channel = &historyStatsEntry{Type: "channel", Name: w.Channel}
+				channels[w.Channel] = channel
+				channelVideos[w.Channel] = make(map[string]bool)
+			}
+			channel.Watches++
+			if w.VideoID != "" && !channelVideos[w.Channel][w.VideoID] {
+				channelVideos[w.Channel][w.VideoID] = true
+				channel.Videos++
+			}
+		}
+		if w.VideoID != "" {
+			video, ok := videos[w.VideoID]
+			if !ok {
+				video = &historyStatsEntry{Type: "video", VideoID: w.VideoID}
+				videos[w.VideoID] = video
+			}
+			video.Watches++
+			// The latest title wins, as titles change
+			video.Name = w.Title
+		}
+	}
+
+	for _, year := range years {
+		entries = append(entries, summarizeWatches("year", year, byYear[year], opts.SessionGap))
+	}
+	var channelEntries []historyStatsEntry
+	for _, entry := range channels {
+		channelEntries = append(channelEntries, *entry)
+	}
+	entries = append(entries, rankHistoryEntries(channelEntries, opts.Top)...)
+	var rewatched []historyStatsEntry
+	for _, entry := range videos {
+		if entry.Watches > 1 {
+			rewatched = append(rewatched, *entry)
+		}
+	}
+	entries = append(entries, rankHistoryEntries(rewatched, opts.Top)...)
+	for day := range heatmap {
+		for hour := range heatmap[day] {
+			weekday := time.Weekday(day).String()
+			entries = append(entries, historyStatsEntry{
+				Type:    "heatmap",
+				Name:    fmt.Sprintf("%s %02d:00", weekday[:3], hour),
+				Weekday: weekday,
+				Hour:    &hour,
+				Watches: heatmap[day][hour],
+			})
+		}
+	}
+	return entries
+}
+
+func historyStats(config Config, path string, opts HistoryStatsOptions) (err error) {
+	loc, err := time.LoadLocation(opts.Timezone)
+	if err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid --timezone: %w", err))
+	}
+	if info, statErr := os.Stat(path); statErr == nil && info.IsDir() {
+		file, err := findTakeoutFile(path, func(name string) bool {
+			return strings.HasPrefix(name, "watch-history.")
+		})
+		if err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		if file == "" {
+			return withKind(ErrInvalidConfig, fmt.Errorf("no watch-history.json found in %s", path))
+		}
+		path = file
+	}
+	watches, err := readTakeoutWatchHistory(path)
+	if err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+	watches, duplicates := dedupeWatches(watches, opts.DedupeWindow)
+
+	out, err := openOutput(config, "watch history stats")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, entry := range summarizeHistory(watches, duplicates, opts, loc) {
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	}
+	infof("Summarized %d watches (%d duplicates removed)", len(watches), duplicates)
+	return nil
+}
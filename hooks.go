@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const hooksFileName = "hooks.yaml"
+
+// exportHooks are the shell commands run around each export, read from
+// hooks.yaml in the config directory or --hooks.
+type exportHooks struct {
+	PreExport  []string `yaml:"pre_export"`
+	PostExport []string `yaml:"post_export"`
+
+	pre, post []*template.Template
+}
+
+// hookSummary is the data hook commands are rendered with, e.g.
+// {{.OutputFile}} (shell-quoted), and receive as JSON on stdin. Pre-export
+// hooks see an empty Status.
+type hookSummary struct {
+	Command    string  `json:"command"`
+	Job        string  `json:"job,omitempty"`
+	OutputFile string  `json:"outputFile,omitempty"`
+	OutputDir  string  `json:"outputDir,omitempty"`
+	Format     string  `json:"format"`
+	Status     string  `json:"status,omitempty"`
+	Records    int64   `json:"records"`
+	Seconds    float64 `json:"seconds"`
+	Error      string  `json:"error,omitempty"`
+	ExitCode   int     `json:"exitCode"`
+}
+
+func defaultHooksFile() string {
+	return filepath.Join(getConfigDir(), hooksFileName)
+}
+
+// loadHooks reads the hooks file. Without --hooks a missing file in the
+// config directory means no hooks.
+func loadHooks(config Config) (*exportHooks, error) {
+	if config.NoHooks {
+		return nil, nil
+	}
+	path := config.HooksFile
+	if path == "" {
+		path = defaultHooksFile()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && config.HooksFile == "" {
+			return nil, nil
+		}
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to read hooks file: %w", err))
+	}
+
+	var hooks exportHooks
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&hooks); err != nil && !errors.Is(err, io.EOF) {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("invalid hooks file %s: %w", path, err))
+	}
+	if hooks.pre, err = parseHooks("pre_export", hooks.PreExport); err != nil {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("invalid hooks file %s: %w", path, err))
+	}
+	if hooks.post, err = parseHooks("post_export", hooks.PostExport); err != nil {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("invalid hooks file %s: %w", path, err))
+	}
+	if len(hooks.pre) == 0 && len(hooks.post) == 0 {
+		return nil, nil
+	}
+	return &hooks, nil
+}
+
+func parseHooks(name string, commands []string) ([]*template.Template, error) {
+	var templates []*template.Template
+	for i, command := range commands {
+		tmpl, err := template.New(fmt.Sprintf("%s[%d]", name, i)).Option("missingkey=error").Funcs(hookFuncs).Parse(command)
+		if err != nil {
+			return nil, err
+		}
+		quoteActions(tmpl.Tree, tmpl.Root)
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+var hookFuncs = template.FuncMap{"shquote": shquote}
+
+// quoteActions pipes every value a hook interpolates, e.g. {{.OutputFile}},
+// through shquote, so file names with spaces and error messages from the
+// API reach the shell as single words and never as code. Conditions such as
+// {{if eq .Status "ok"}} see the raw values.
+func quoteActions(tree *parse.Tree, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			quoteActions(tree, n)
+		}
+	case *parse.IfNode:
+		quoteActions(tree, node.List)
+		quoteActions(tree, node.ElseList)
+	case *parse.RangeNode:
+		quoteActions(tree, node.List)
+		quoteActions(tree, node.ElseList)
+	case *parse.WithNode:
+		quoteActions(tree, node.List)
+		quoteActions(tree, node.ElseList)
+	case *parse.ActionNode:
+		pipe := node.Pipe
+		if len(pipe.Decl) > 0 || len(pipe.Cmds) == 0 {
+			return
+		}
+		if last := pipe.Cmds[len(pipe.Cmds)-1]; len(last.Args) > 0 {
+			if ident, ok := last.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "shquote" {
+				return
+			}
+		}
+		quote := parse.NewIdentifier("shquote").SetTree(tree).SetPos(node.Pos)
+		pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: node.Pos, Args: []parse.Node{quote}})
+	}
+}
+
+// shquote quotes a value as a single word for the shell hooks run in.
+func shquote(value any) string {
+	s := fmt.Sprint(value)
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runsHooks reports whether cmd is an export hooks run around: an API
+// command writing records. 'ytdata run' runs them around each of its jobs
+// instead.
+func runsHooks(cmd *cobra.Command) bool {
+	return cmd.Annotations[localAnnotation] != "true" && cmd.Flags().Lookup("output") != nil && cmd.Name() != "run"
+}
+
+// around runs export between the pre- and post-export hooks. A failing
+// pre-export hook cancels the export; post-export hooks also run after a
+// failed export, with its status and error in the summary.
+func (h *exportHooks) around(config Config, job string, export func(Config) error) error {
+	summary := hookSummary{
+		Command:    config.Command,
+		Job:        job,
+		OutputFile: config.OutputFile,
+		OutputDir:  config.OutputDir,
+		Format:     config.Format,
+	}
+	if err := runHooks(h.pre, summary); err != nil {
+		return withKind(ErrGeneral, fmt.Errorf("pre_export hook failed: %w", err))
+	}
+
+	if config.recordCount == nil {
+		config.recordCount = &atomic.Int64{}
+	}
+	started := time.Now()
+	err := export(config)
+	summary.Records = config.recordCount.Load()
+	summary.Seconds = time.Since(started).Round(time.Millisecond).Seconds()
+	summary.ExitCode = exitCode(err)
+	switch {
+	case err == nil:
+		summary.Status = jobOK
+	case classifyError(err) == ErrPartial:
+		summary.Status, summary.Error = jobPartial, err.Error()
+	default:
+		summary.Status, summary.Error = jobFailed, err.Error()
+	}
+
+	if hookErr := runHooks(h.post, summary); hookErr != nil {
+		if err != nil {
+			warnf("post_export hook failed: %v", hookErr)
+			return err
+		}
+		return withKind(ErrGeneral, fmt.Errorf("post_export hook failed: %w", hookErr))
+	}
+	return err
+}
+
+// runHooks runs the commands in order and stops at the first failure.
+func runHooks(hooks []*template.Template, summary hookSummary) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		var command strings.Builder
+		if err := hook.Execute(&command, summary); err != nil {
+			return err
+		}
+		logger.Debug("Running hook", "hook", hook.Name())
+		if err := runHook(command.String(), data, summary); err != nil {
+			return fmt.Errorf("%s: %w", hook.Name(), err)
+		}
+	}
+	return nil
+}
+
+func runHook(command string, data []byte, summary hookSummary) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	// stdout may carry the records written by ytdata
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"YTDATA_COMMAND="+summary.Command,
+		"YTDATA_JOB="+summary.Job,
+		"YTDATA_OUTPUT_FILE="+summary.OutputFile,
+		"YTDATA_OUTPUT_DIR="+summary.OutputDir,
+		"YTDATA_FORMAT="+summary.Format,
+		"YTDATA_STATUS="+summary.Status,
+		"YTDATA_RECORDS="+strconv.FormatInt(summary.Records, 10),
+	)
+	return cmd.Run()
+}
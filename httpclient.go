@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultConnectTimeout matches the dial timeout of http.DefaultTransport.
+const defaultConnectTimeout = 30 * time.Second
+
+// proxySchemes are the proxy URL schemes net/http supports.
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true}
+
+// parseProxy parses a --proxy URL. A bare host:port is taken as an HTTP
+// proxy.
+func parseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		if u, err = url.Parse("http://" + proxy); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q (expected e.g. http://proxy.example.com:8080)", proxy)
+		}
+	}
+	if !proxySchemes[u.Scheme] {
+		return nil, fmt.Errorf("unsupported proxy scheme %q (supported: http, https, socks5)", u.Scheme)
+	}
+	return u, nil
+}
+
+// setHTTPTransport applies --connect-timeout, --http-timeout and --proxy to
+// every request of the process. The transport replaces
+// http.DefaultTransport, which the OAuth client (token refresh included),
+// the API key client, webhooks and feed requests all build on, so no client
+// bypasses the proxy. Without --proxy, HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+// from the environment apply.
+func setHTTPTransport(config Config) error {
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != "" {
+		u, err := parseProxy(config.Proxy)
+		if err != nil {
+			return err
+		}
+		proxy = http.ProxyURL(u)
+	}
+	if config.ConnectTimeout < 0 || config.HTTPTimeout < 0 {
+		return fmt.Errorf("--connect-timeout and --http-timeout must not be negative")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	if config.ConnectTimeout > 0 {
+		dialer := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = config.ConnectTimeout
+	}
+	http.DefaultTransport = transport
+	// OAuth token requests use http.DefaultClient
+	http.DefaultClient.Timeout = config.HTTPTimeout
+	return nil
+}
+
+// withHTTPTimeout applies --http-timeout to an API client.
+func withHTTPTimeout(client *http.Client, config Config) *http.Client {
+	client.Timeout = config.HTTPTimeout
+	return client
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// readIDList reads IDs from a text file, or from stdin for "-": the first
+// word of every line, skipping blank lines and # comments, so lists with
+// trailing notes work as they are.
+func readIDList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to open ID list: %w", err))
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		r = f
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		ids = append(ids, strings.Fields(text)[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ID list: %w", err)
+	}
+	return ids, nil
+}
+
+// lookupIDs combines the IDs of kind given as arguments with the ones read
+// from idsFile, dropping duplicates but keeping their order. Both may be
+// YouTube URLs.
+func lookupIDs(args []string, idsFile, kind string) ([]string, error) {
+	ids := slices.Clone(args)
+	if idsFile != "" {
+		listed, err := readIDList(idsFile)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, listed...)
+	}
+	ids, err := idsFromArgs(ids, kind)
+	if err != nil {
+		return nil, err
+	}
+	var unique []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("no IDs given; pass them as arguments or with --ids-file"))
+	}
+	return unique, nil
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Job statuses in the report of 'ytdata run'.
+const (
+	jobOK      = "ok"
+	jobPartial = "partial"
+	jobFailed  = "failed"
+	jobSkipped = "skipped"
+)
+
+type RunOptions struct {
+	Parallel int
+	State    string
+	Only     []string
+	Force    bool
+	Loop     bool
+}
+
+// jobsFile is the format of a jobs file.
+type jobsFile struct {
+	// Parallel is how many jobs run at once (default 1)
+	Parallel int `yaml:"parallel"`
+	// Defaults apply to every job that leaves a field empty
+	Defaults exportJob   `yaml:"defaults"`
+	Jobs     []exportJob `yaml:"jobs"`
+}
+
+// exportJob declares one export: what to fetch, how to shape it and where
+// to write it.
+type exportJob struct {
+	Name      string `yaml:"name"`
+	Type      string `yaml:"type"`
+	Filter    string `yaml:"filter"`
+	Transform string `yaml:"transform"`
+	Format    string `yaml:"format"`
+	// Output is a file, or a warehouse table like bigquery://p.d.t
+	Output string `yaml:"output"`
+	// Dest is the directory of Output when it is not given:
+	// <dest>/<name>.<format>
+	Dest     string `yaml:"dest"`
+	Max      int    `yaml:"max"`
+	Language string `yaml:"hl"`
+	// Schedule is how often the job is due: hourly, daily, weekly or a
+	// duration such as 6h
+	Schedule string `yaml:"schedule"`
+
+	interval time.Duration
+}
+
+// jobResult is a record of the report of 'ytdata run'.
+type jobResult struct {
+	Job     string  `json:"job"`
+	Type    string  `json:"type"`
+	Status  string  `json:"status"`
+	Records int64   `json:"records"`
+	Seconds float64 `json:"seconds"`
+	Output  string  `json:"output"`
+	Error   string  `json:"error,omitempty"`
+	NextRun string  `json:"nextRun,omitempty"`
+}
+
+// jobState remembers when each job last succeeded, for schedules.
+type jobState map[string]time.Time
+
+func newRunCmd(config *Config) *cobra.Command {
+	var opts RunOptions
+
+	cmd := &cobra.Command{
+		Use:   "run JOBS_FILE",
+		Short: "Run the export jobs declared in a YAML file",
+		Long: `Run the export jobs declared in a YAML file with one authorization, up to
+--parallel at a time, and write a report with the status, record count and
+duration of every job. A job that fails does not stop the others.
+
+  parallel: 2
+  defaults:
+    dest: exports
+    format: jsonl
+  jobs:
+    - name: liked
+      type: liked
+      schedule: daily
+    - name: music
+      type: liked
+      filter: categoryName == "Music"
+      format: csv
+    - name: subscriptions
+      type: subscriptions
+      output: bigquery://my-project.youtube.subscriptions
+
+type is an exporter (liked, subscriptions, playlists, me). filter,
+transform, format, max and hl work like the flags of the same name. output
+is a file or a BigQuery table and defaults to <dest>/<name>.<format>;
+relative paths are relative to the jobs file. Jobs with a schedule (hourly,
+daily, weekly or a duration such as 6h) are skipped until they are due
+again, tracked in --state; --force runs them anyway. With --loop the
+command keeps running and starts jobs when they are due.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Example: `  ytdata run jobs.yaml
+  ytdata run jobs.yaml --only liked,music -f table
+  ytdata run jobs.yaml --loop --log-file ytdata.log`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runJobsFile(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Parallel, "parallel", 0, "Run this many jobs at once (default: parallel of the jobs file, or 1)")
+	cmd.Flags().StringVar(&opts.State, "state", "", "File the last successful runs are kept in (default: <jobs file>.state.json)")
+	cmd.Flags().StringSliceVar(&opts.Only, "only", nil, "Only run these jobs (comma-separated names)")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Run jobs even when their schedule says they are not due")
+	cmd.Flags().BoolVar(&opts.Loop, "loop", false, "Keep running and start scheduled jobs when they are due")
+	addOutputFlag(cmd, "", "Write the report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+
+	return cmd
+}
+
+// readJobsFile parses and validates a jobs file, applying the defaults and
+// resolving paths relative to it.
+func readJobsFile(path string) (*jobsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to read jobs file: %w", err))
+	}
+	var file jobsFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("invalid jobs file %s: %w", path, err))
+	}
+	if len(file.Jobs) == 0 {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("no jobs in %s", path))
+	}
+	if file.Parallel < 0 {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("parallel must not be negative"))
+	}
+
+	types := make([]string, 0, len(exporters))
+	for _, factory := range exporters {
+		types = append(types, factory(Config{}).Name())
+	}
+	dir := filepath.Dir(path)
+	seen := make(map[string]bool)
+	for i := range file.Jobs {
+		job := &file.Jobs[i]
+		job.applyDefaults(file.Defaults)
+		if job.Name == "" {
+			job.Name = job.Type
+		}
+		invalid := func(format string, args ...any) error {
+			return withKind(ErrInvalidConfig, fmt.Errorf("job %d (%s): %s", i+1, job.Name, fmt.Sprintf(format, args...)))
+		}
+		switch {
+		case job.Type == "":
+			return nil, invalid("type is required")
+		case !slices.Contains(types, job.Type):
+			return nil, invalid("unknown type %q (supported: %s)", job.Type, strings.Join(types, ", "))
+		case seen[job.Name]:
+			return nil, invalid("duplicate job name; give the jobs distinct names")
+		case !slices.Contains(recordFormats(), job.Format):
+			return nil, invalid("unsupported format %q (supported: %s)", job.Format, strings.Join(recordFormats(), ", "))
+		case job.Max < 0:
+			return nil, invalid("max must not be negative")
+		}
+		seen[job.Name] = true
+		if job.Filter != "" {
+			if _, err := parseFilter(job.Filter); err != nil {
+				return nil, invalid("invalid filter: %v", err)
+			}
+		}
+		if job.Transform != "" {
+			if _, err := parseTransform(job.Transform); err != nil {
+				return nil, invalid("invalid transform: %v", err)
+			}
+		}
+		if job.Schedule != "" {
+			if job.interval, err = parseSchedule(job.Schedule); err != nil {
+				return nil, invalid("%v", err)
+			}
+		}
+		if job.Output == "" {
+			job.Output = filepath.Join(job.Dest, job.Name+"."+job.Format)
+		}
+		if !job.toWarehouse() && !filepath.IsAbs(job.Output) {
+			job.Output = filepath.Join(dir, job.Output)
+		}
+	}
+	return &file, nil
+}
+
+func (j *exportJob) applyDefaults(defaults exportJob) {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&j.Filter, defaults.Filter)
+	fill(&j.Transform, defaults.Transform)
+	fill(&j.Format, defaults.Format)
+	fill(&j.Format, formatJSONL)
+	fill(&j.Dest, defaults.Dest)
+	fill(&j.Language, defaults.Language)
+	fill(&j.Schedule, defaults.Schedule)
+	if j.Max == 0 {
+		j.Max = defaults.Max
+	}
+}
+
+func (j exportJob) toWarehouse() bool {
+	return strings.HasPrefix(j.Output, "bigquery://")
+}
+
+// parseSchedule parses hourly, daily, weekly or a duration like 6h.
+func parseSchedule(s string) (time.Duration, error) {
+	switch s {
+	case "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	case "weekly":
+		return 7 * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid schedule %q (hourly, daily, weekly or a duration such as 6h)", s)
+	}
+	return d, nil
+}
+
+func readJobState(path string) (jobState, error) {
+	state := make(jobState)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid job state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+func runJobsFile(config Config, path string, opts RunOptions) (err error) {
+	file, err := readJobsFile(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range opts.Only {
+		if !slices.ContainsFunc(file.Jobs, func(job exportJob) bool { return job.Name == name }) {
+			return withKind(ErrInvalidConfig, fmt.Errorf("no job named %q in %s", name, path))
+		}
+	}
+	if opts.Parallel < 0 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--parallel must not be negative"))
+	}
+	parallel := firstPositive(opts.Parallel, file.Parallel, 1)
+	if opts.Loop && !slices.ContainsFunc(file.Jobs, func(job exportJob) bool { return job.interval > 0 }) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--loop needs jobs with a schedule"))
+	}
+	if opts.State == "" {
+		opts.State = strings.TrimSuffix(path, filepath.Ext(path)) + ".state.json"
+	}
+	state, err := readJobState(opts.State)
+	if err != nil {
+		return err
+	}
+
+	// One authorization with the scopes of every job
+	var list []Exporter
+	for _, factory := range exporters {
+		exporter := factory(config)
+		if slices.ContainsFunc(file.Jobs, func(job exportJob) bool { return job.Type == exporter.Name() && !job.toWarehouse() }) {
+			list = append(list, exporter)
+		}
+	}
+	config = exporterScopes(config, "run", list...)
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	out, err := openOutput(config, "job report")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	runner := &jobRunner{config: config, service: service, state: state, statePath: opts.State, retryAt: make(map[string]time.Time)}
+	if !opts.Loop {
+		results := runner.runDue(file.Jobs, opts, parallel, false)
+		for _, result := range results {
+			if err := out.Write(result); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		}
+		return jobsError(results)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Jobs without a schedule only run in the first pass
+	for scheduledOnly := false; ; scheduledOnly = true {
+		results := runner.runDue(file.Jobs, opts, parallel, scheduledOnly)
+		for _, result := range results {
+			if result.Status == jobSkipped {
+				continue
+			}
+			if err := out.Write(result); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		}
+		opts.Force = false
+		next := runner.nextDue(file.Jobs, opts)
+		infof("Next job due %s", next.Local().Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// firstPositive returns the first positive value.
+func firstPositive(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// jobsError summarizes failed jobs like 'ytdata all' does.
+func jobsError(results []jobResult) error {
+	var failed []string
+	ran, allFailed := 0, true
+	for _, result := range results {
+		if result.Status == jobSkipped {
+			continue
+		}
+		ran++
+		switch result.Status {
+		case jobFailed, jobPartial:
+			failed = append(failed, result.Job)
+		}
+		if result.Status != jobFailed {
+			allFailed = false
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	kind := ErrPartial
+	if allFailed {
+		kind = ErrGeneral
+	}
+	return withKind(kind, fmt.Errorf("%d of %d jobs failed: %s", len(failed), ran, strings.Join(failed, ", ")))
+}
+
+// jobRunner runs jobs with a shared service and keeps their state.
+type jobRunner struct {
+	config    Config
+	service   *youtube.Service
+	mu        sync.Mutex
+	state     jobState
+	statePath string
+	// retryAt delays scheduled jobs that failed in --loop
+	retryAt map[string]time.Time
+}
+
+// runDue runs the selected jobs that are due, parallel at a time, and
+// returns their results in the order of the jobs file.
+func (r *jobRunner) runDue(jobs []exportJob, opts RunOptions, parallel int, scheduledOnly bool) []jobResult {
+	now := time.Now()
+	results := make([]jobResult, len(jobs))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		results[i] = jobResult{Job: job.Name, Type: job.Type, Output: job.Output, Status: jobSkipped}
+		if len(opts.Only) > 0 && !slices.Contains(opts.Only, job.Name) || scheduledOnly && job.interval == 0 {
+			continue
+		}
+		if due := r.dueAt(job); !opts.Force && due.After(now) {
+			results[i].NextRun = due.Format(time.RFC3339)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = r.run(job)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// dueAt returns when a job is due next; jobs without a schedule always
+// are.
+func (r *jobRunner) dueAt(job exportJob) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.interval == 0 {
+		return time.Time{}
+	}
+	var due time.Time
+	if last, ok := r.state[job.Name]; ok {
+		due = last.Add(job.interval)
+	}
+	if retry := r.retryAt[job.Name]; retry.After(due) {
+		due = retry
+	}
+	return due
+}
+
+// nextDue returns when the next scheduled job is due.
+func (r *jobRunner) nextDue(jobs []exportJob, opts RunOptions) time.Time {
+	var next time.Time
+	for _, job := range jobs {
+		if job.interval == 0 || len(opts.Only) > 0 && !slices.Contains(opts.Only, job.Name) {
+			continue
+		}
+		if due := r.dueAt(job); next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	return next
+}
+
+func (r *jobRunner) run(job exportJob) jobResult {
+	result := jobResult{Job: job.Name, Type: job.Type, Output: job.Output}
+	started := time.Now()
+	infof("Running job %s", job.Name)
+
+	config := r.config
+	config.Filter = job.Filter
+	config.Transform = job.Transform
+	config.Format = job.Format
+	config.OutputFile = job.Output
+	config.MaxResults = job.Max
+	if job.Language != "" {
+		config.Language = job.Language
+	}
+	config.recordCount = &atomic.Int64{}
+
+	var err error
+	if r.config.hooks != nil {
+		err = r.config.hooks.around(config, job.Name, func(config Config) error { return r.export(config, job) })
+	} else {
+		err = r.export(config, job)
+	}
+	result.Records = config.recordCount.Load()
+	result.Seconds = time.Since(started).Round(time.Millisecond).Seconds()
+
+	var partial *Error
+	switch {
+	case err == nil:
+		result.Status = jobOK
+		r.succeeded(job, started)
+	case errors.As(err, &partial) && partial.Kind == ErrPartial:
+		result.Status, result.Error = jobPartial, err.Error()
+		warnf("Job %s is incomplete: %v", job.Name, err)
+	default:
+		result.Status, result.Error = jobFailed, err.Error()
+		warnf("Job %s failed: %v", job.Name, err)
+	}
+	if result.Status != jobOK {
+		r.failed(job)
+	}
+	return result
+}
+
+func (r *jobRunner) export(config Config, job exportJob) error {
+	if job.toWarehouse() {
+		return exportToBigQuery(config, job.Type, ExportOptions{To: job.Output})
+	}
+	var exporter Exporter
+	for _, factory := range exporters {
+		if e := factory(config); e.Name() == job.Type {
+			exporter = e
+		}
+	}
+	if v, ok := exporter.(validator); ok {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(job.Output), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	err := runExport(config, exporter, r.service)
+	if err != nil && classifyError(err) != ErrPartial {
+		removeFailedOutput(config, job.Output)
+	}
+	return err
+}
+
+// failed retries a scheduled job after its interval, but at most 15
+// minutes later.
+func (r *jobRunner) failed(job exportJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retryAt[job.Name] = time.Now().Add(min(job.interval, 15*time.Minute))
+}
+
+// succeeded records a successful run of a scheduled job.
+func (r *jobRunner) succeeded(job exportJob, started time.Time) {
+	if job.interval == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state[job.Name] = started.UTC()
+	if err := saveWatchState(r.statePath, r.state); err != nil {
+		warnf("Failed to save job state: %v", err)
+	}
+}
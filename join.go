@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type JoinOptions struct {
+	On string
+}
+
+// joinKey describes a supported --on key: how to read it from a record and
+// the fields the match is added as.
+type joinKey struct {
+	key   func(record map[string]any) string
+	flag  string
+	field string
+}
+
+var joinKeys = map[string]joinKey{
+	"channelId": {key: recordOwnerChannelID, flag: "subscribed", field: "channel"},
+	"videoId":   {key: recordVideoID, flag: "matched", field: "video"},
+}
+
+func newJoinCmd(config *Config) *cobra.Command {
+	var opts JoinOptions
+
+	cmd := &cobra.Command{
+		Use:   "join FILE OTHER_FILE",
+		Short: "Annotate one export with matching records of another",
+		Long: `Join two JSONL exports offline. Every record of FILE is written with a
+flag telling whether OTHER_FILE has a record with the same key, and a
+summary (ID, title and statistics) of that record.
+
+With --on channelId (the default), records are matched by channel: joining
+liked videos with a subscriptions export adds "subscribed" and "channel" to
+every video. With --on videoId, "matched" and "video" are added.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata join liked.jsonl subscriptions.jsonl
+  ytdata join liked.jsonl subscriptions.jsonl --filter '!subscribed' -o unsubscribed-likes.jsonl
+  ytdata join liked.jsonl trending.jsonl --on videoId`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return joinExports(config, args[0], args[1], opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.On, "on", "channelId", "Key to join on: "+strings.Join(joinKeyNames(), "|"))
+	addOutputFlag(cmd, "", "Write joined records to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only write joined records matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("on", staticCompletion(joinKeyNames()...)))
+
+	return cmd
+}
+
+func joinKeyNames() []string {
+	names := make([]string, 0, len(joinKeys))
+	for name := range joinKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recordOwnerChannelID extracts the channel a record belongs to: the channel
+// itself for channel and subscription records, the uploader for videos and
+// playlist items.
+func recordOwnerChannelID(record map[string]any) string {
+	if id := recordChannelID(record); id != "" {
+		return id
+	}
+	if id := lookupString(record, "snippet", "videoOwnerChannelId"); id != "" {
+		return id
+	}
+	return lookupString(record, "snippet", "channelId")
+}
+
+// joinSummary keeps the identifying fields and statistics of a record.
+func joinSummary(record map[string]any) map[string]any {
+	summary := map[string]any{"id": lookupString(record, "id")}
+	if title := lookupString(record, "snippet", "title"); title != "" {
+		summary["title"] = title
+	}
+	if country := lookupString(record, "snippet", "country"); country != "" {
+		summary["country"] = country
+	}
+	if statistics, ok := record["statistics"]; ok {
+		summary["statistics"] = statistics
+	}
+	return summary
+}
+
+func joinExports(config Config, path, otherPath string, opts JoinOptions) (err error) {
+	key, ok := joinKeys[opts.On]
+	if !ok {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported join key %q (supported: %s)", opts.On, strings.Join(joinKeyNames(), ", ")))
+	}
+
+	records, err := readJSONLRecords(path)
+	if err != nil {
+		return err
+	}
+	others, err := readJSONLRecords(otherPath)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]map[string]any, len(others))
+	for _, other := range others {
+		if k := key.key(other); k != "" {
+			if _, seen := index[k]; !seen {
+				index[k] = joinSummary(other)
+			}
+		}
+	}
+	if len(index) == 0 {
+		return fmt.Errorf("no records with a %s found in %s", opts.On, otherPath)
+	}
+
+	out, err := openOutput(config, "joined")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	matched := 0
+	for _, record := range records {
+		match, ok := index[key.key(record)]
+		record[key.flag] = ok
+		if ok {
+			record[key.field] = match
+			matched++
+		}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write joined data: %w", err)
+		}
+	}
+
+	infof("Matched %d of %d records", matched, len(records))
+	return nil
+}
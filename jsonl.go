@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// readJSONLRecords reads every line of a JSONL export as a generic record.
+func readJSONLRecords(path string) ([]map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			warnf("Failed to close file: %v", err)
+		}
+	}()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d of %s: %w", line, path, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// lookupString walks a dotted path through nested records and returns the
+// string found at the end, if any.
+func lookupString(record map[string]any, path ...string) string {
+	var current any = record
+	for _, key := range path {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return ""
+		}
+		current = m[key]
+	}
+	s, _ := current.(string)
+	return s
+}
+
+// recordVideoID extracts the video ID from a video, playlist item or
+// search result record.
+func recordVideoID(record map[string]any) string {
+	if kind := lookupString(record, "kind"); kind == "" || kind == "youtube#video" {
+		if id := lookupString(record, "id"); id != "" {
+			return id
+		}
+	}
+	if id := lookupString(record, "contentDetails", "videoId"); id != "" {
+		return id
+	}
+	if id := lookupString(record, "snippet", "resourceId", "videoId"); id != "" {
+		return id
+	}
+	return lookupString(record, "id", "videoId")
+}
+
+// readVideoIDs returns the unique video IDs referenced by a JSONL export,
+// in file order.
+func readVideoIDs(path string) ([]string, error) {
+	records, err := readJSONLRecords(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, record := range records {
+		id := recordVideoID(record)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// idRecord is the compact record written with --ids-only.
+type idRecord struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// toRecord converts an API resource into a generic record so derived
+// fields can be added next to the fields returned by the API.
+func toRecord(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var record map[string]any
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
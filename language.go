@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// undeterminedLanguage is the BCP-47 code for videos whose language is
+// unknown, so they can still be selected with --language und.
+const undeterminedLanguage = "und"
+
+// Sources of the contentLanguage field.
+const (
+	languageSourceAudio    = "audio"
+	languageSourceMetadata = "metadata"
+	languageSourceDetected = "detected"
+)
+
+// scriptLanguages maps writing systems used by essentially one language to
+// that language. Han is handled separately, as Japanese mixes it with kana.
+// When two scripts are equally frequent the first one listed wins.
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// stopwords are frequent short words of languages written in Latin script.
+// Titles are short, so a handful per language is enough to tell them apart.
+// It is a list rather than a map so that scoring visits the languages in
+// the same order on every run.
+var stopwords = []struct {
+	language string
+	words    []string
+}{
+	{"en", []string{"the", "and", "of", "to", "in", "is", "you", "for", "with", "on", "how", "my", "this", "what"}},
+	{"de", []string{"der", "die", "das", "und", "ist", "nicht", "mit", "ich", "ein", "eine", "für", "auf", "wie", "zu"}},
+	{"fr", []string{"le", "la", "les", "et", "des", "est", "un", "une", "pour", "du", "dans", "avec", "sur", "je"}},
+	{"es", []string{"el", "la", "los", "las", "y", "de", "que", "en", "un", "una", "por", "con", "para", "es", "cómo"}},
+	{"pt", []string{"o", "os", "as", "e", "do", "da", "que", "em", "um", "uma", "para", "com", "não", "como"}},
+	{"it", []string{"il", "lo", "gli", "e", "di", "che", "un", "una", "per", "con", "non", "della", "come", "sono"}},
+	{"nl", []string{"de", "het", "een", "en", "van", "is", "niet", "met", "ik", "op", "voor", "dat", "hoe", "zijn"}},
+}
+
+// videoLanguage returns the content language of a video and where it came
+// from: the declared audio language, the declared metadata language, or,
+// with detect, a guess from the title and description.
+func videoLanguage(video *youtube.Video, detect bool) (language, source string) {
+	if video.Snippet == nil {
+		return undeterminedLanguage, ""
+	}
+	if video.Snippet.DefaultAudioLanguage != "" {
+		return video.Snippet.DefaultAudioLanguage, languageSourceAudio
+	}
+	if video.Snippet.DefaultLanguage != "" {
+		return video.Snippet.DefaultLanguage, languageSourceMetadata
+	}
+	if detect {
+		if language := detectLanguage(video.Snippet.Title + "\n" + video.Snippet.Description); language != undeterminedLanguage {
+			return language, languageSourceDetected
+		}
+	}
+	return undeterminedLanguage, ""
+}
+
+// detectLanguage guesses the language of text from its script, and for
+// Latin script from stopwords. It returns "und" when unsure.
+func detectLanguage(text string) string {
+	counts := make(map[string]int)
+	var han, letters int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Han, r) {
+			han++
+			continue
+		}
+		for _, script := range scriptLanguages {
+			if unicode.Is(script.table, r) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return undeterminedLanguage
+	}
+
+	best, bestCount := "", 0
+	for _, script := range scriptLanguages {
+		if count := counts[script.language]; count > bestCount {
+			best, bestCount = script.language, count
+		}
+	}
+	switch {
+	case counts["ja"] > 0 && counts["ja"]+han >= letters/3:
+		// Kana next to Han characters means Japanese
+		return "ja"
+	case han >= letters/3 && han > bestCount:
+		return "zh"
+	case bestCount >= letters/3:
+		if best == "ru" && strings.ContainsAny(strings.ToLower(text), "іїєґ") {
+			return "uk"
+		}
+		return best
+	}
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage guesses the language of Latin-script text from its
+// stopwords. A tie between the top languages is "und", not a guess.
+func detectLatinLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	best, bestScore, tie := undeterminedLanguage, 0, false
+	for _, list := range stopwords {
+		score := 0
+		for _, word := range words {
+			if slices.Contains(list.words, word) {
+				score++
+			}
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = list.language, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	// One shared word such as "de" or "la" is no evidence
+	if bestScore < 2 || tie {
+		return undeterminedLanguage
+	}
+	return best
+}
+
+// matchesLanguage reports whether language is one of the requested ones,
+// comparing primary subtags so "en" matches "en-US" and "en-GB".
+func matchesLanguage(language string, requested []string) bool {
+	primary, _, _ := strings.Cut(strings.ToLower(language), "-")
+	for _, want := range requested {
+		wantPrimary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(want)), "-")
+		if wantPrimary == primary {
+			return true
+		}
+	}
+	return false
+}
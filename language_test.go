@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", undeterminedLanguage},
+		{"no letters", "2024 - 10/10 !!!", undeterminedLanguage},
+		{"korean", "안녕하세요 여러분", "ko"},
+		{"japanese kana and kanji", "東京の夜を歩く", "ja"},
+		{"chinese", "我们今天去北京", "zh"},
+		{"russian", "Привет всем друзья", "ru"},
+		{"ukrainian letters", "Привіт усім друзі", "uk"},
+		{"greek", "Καλημέρα σε όλους", "el"},
+		{"script tie goes to the first listed script", "한국어 שלם", "ko"},
+		{"arabic before hebrew", "سلام שלום", "ar"},
+		{"english", "How to make the best bread in my kitchen", "en"},
+		{"german", "Wie man das Brot backt und nicht verbrennt", "de"},
+		{"one shared word", "Live de Paris", undeterminedLanguage},
+		{"stopword tie", "the and der die", undeterminedLanguage},
+		{"latin script without stopwords", "Minecraft speedrun 100%", undeterminedLanguage},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Repeat to catch answers that depend on map iteration order
+			for range 20 {
+				if got := detectLanguage(tt.text); got != tt.want {
+					t.Fatalf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+				}
+			}
+		})
+	}
+}
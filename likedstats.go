@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// likedPlaylistID is the liked videos playlist of the authenticated user.
+// Its items carry the time each video was liked, which the videos
+// themselves do not.
+const likedPlaylistID = "LL"
+
+var likedGroupings = []string{"channel", "year", "month"}
+
+type LikedStatsOptions struct {
+	GroupBy string
+	Top     int
+}
+
+// likedStatsEntry is one group of liked videos.
+type likedStatsEntry struct {
+	Type            string `json:"type"`
+	Name            string `json:"name"`
+	ChannelID       string `json:"channelId,omitempty"`
+	Likes           int    `json:"likes"`
+	DurationSeconds int64  `json:"durationSeconds"`
+	FirstLikedAt    string `json:"firstLikedAt,omitempty"`
+	LastLikedAt     string `json:"lastLikedAt,omitempty"`
+}
+
+func newLikedStatsCmd(config *Config) *cobra.Command {
+	var opts LikedStatsOptions
+
+	cmd := &cobra.Command{
+		Use:   "liked",
+		Short: "Aggregate liked videos per channel or period",
+		Long: `Aggregate your liked videos per channel (default), year or month: the number
+of likes, their total duration, and when the first and last of them were
+liked. Per channel, this shows which creators you actually engage with.
+
+Like dates come from the liked videos playlist, which YouTube caps at the
+most recent 5,000 likes.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata stats liked --format csv -o liked-channels.csv
+  ytdata stats liked --group-by year
+  ytdata stats liked --top 20`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return likedStats(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.GroupBy, "group-by", "channel", "Group liked videos by "+strings.Join(likedGroupings, "|"))
+	cmd.Flags().IntVar(&opts.Top, "top", 0, "Only report the N groups with the most likes (0 = all)")
+	addOutputFlag(cmd, "", "Write report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("group-by", staticCompletion(likedGroupings...)))
+
+	return cmd
+}
+
+func likedStats(config Config, opts LikedStatsOptions) (err error) {
+	if !slices.Contains(likedGroupings, opts.GroupBy) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported --group-by %q (supported: %s)", opts.GroupBy, strings.Join(likedGroupings, ", ")))
+	}
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	items, err := fetchPlaylistItems(context.Background(), service, likedPlaylistID)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, item := range items {
+		if id := playlistItemVideoID(item); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	videos, err := fetchVideosByID(service, config, ids, []string{"contentDetails"})
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]*likedStatsEntry)
+	for _, item := range items {
+		if item.Snippet == nil {
+			continue
+		}
+		likedAt, err := time.Parse(time.RFC3339, item.Snippet.PublishedAt)
+		if err != nil {
+			continue
+		}
+		var key, name, channelID string
+		switch opts.GroupBy {
+		case "channel":
+			channelID, name = item.Snippet.VideoOwnerChannelId, item.Snippet.VideoOwnerChannelTitle
+			if channelID == "" {
+				// Deleted and private videos have no channel
+				name = "unknown"
+			}
+			key = channelID
+		case "year":
+			key = likedAt.UTC().Format("2006")
+			name = key
+		case "month":
+			key = likedAt.UTC().Format("2006-01")
+			name = key
+		}
+		entry, ok := groups[key]
+		if !ok {
+			entry = &likedStatsEntry{Type: opts.GroupBy, Name: name, ChannelID: channelID}
+			groups[key] = entry
+		}
+		entry.Likes++
+		if video, ok := videos[playlistItemVideoID(item)]; ok {
+			if d, ok := videoDuration(video); ok {
+				entry.DurationSeconds += int64(d / time.Second)
+			}
+		}
+		// RFC 3339 times in UTC compare as strings
+		liked := likedAt.UTC().Format(time.RFC3339)
+		if entry.FirstLikedAt == "" || liked < entry.FirstLikedAt {
+			entry.FirstLikedAt = liked
+		}
+		if liked > entry.LastLikedAt {
+			entry.LastLikedAt = liked
+		}
+	}
+
+	entries := make([]likedStatsEntry, 0, len(groups))
+	for _, entry := range groups {
+		entries = append(entries, *entry)
+	}
+	// The most liked groups first; periods are then put back in order
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Likes != entries[j].Likes {
+			return entries[i].Likes > entries[j].Likes
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if opts.Top > 0 && len(entries) > opts.Top {
+		entries = entries[:opts.Top]
+	}
+	if opts.GroupBy != "channel" {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	}
+
+	out, err := openOutput(config, "liked stats")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, entry := range entries {
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	}
+	return nil
+}
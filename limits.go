@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// apiPageSize is the largest page the list endpoints return.
+const apiPageSize = 50
+
+// pageLimit caps a paginated fetch at --max records and --max-pages pages,
+// so quick samples do not cost the quota of a full export. A nil limit
+// fetches everything.
+type pageLimit struct {
+	max      int
+	maxPages int
+	fetched  int
+	pages    int
+}
+
+// newPageLimit returns the limit set in config, or nil when there is none.
+func newPageLimit(config Config) *pageLimit {
+	if config.MaxResults <= 0 && config.MaxPages <= 0 {
+		return nil
+	}
+	return &pageLimit{max: config.MaxResults, maxPages: config.MaxPages}
+}
+
+// size returns the page size to request: a full page, or only the records
+// still missing for --max.
+func (l *pageLimit) size() int64 {
+	if l == nil || l.max <= 0 {
+		return apiPageSize
+	}
+	return int64(min(apiPageSize, l.max-l.fetched))
+}
+
+// page records a fetched page of n records and returns how many of them to
+// keep and whether to fetch another page.
+func (l *pageLimit) page(n int) (keep int, more bool) {
+	if l == nil {
+		return n, true
+	}
+	l.pages++
+	keep = n
+	if l.max > 0 {
+		keep = min(n, l.max-l.fetched)
+	}
+	l.fetched += keep
+	more = (l.max <= 0 || l.fetched < l.max) && (l.maxPages <= 0 || l.pages < l.maxPages)
+	return keep, more
+}
+
+// addLimitFlags adds --max and --max-pages to an exporter command.
+func addLimitFlags(cmd *cobra.Command, config *Config) {
+	cmd.Flags().IntVar(&config.MaxResults, "max", 0, "Only fetch this many records, e.g. the 100 most recent liked videos (0 = all)")
+	cmd.Flags().IntVar(&config.MaxPages, "max-pages", 0, "Only fetch this many pages of 50 records (0 = all)")
+}
+
+// addOldestFirstFlag adds --oldest-first to an exporter command.
+func addOldestFirstFlag(cmd *cobra.Command, config *Config) {
+	cmd.Flags().BoolVar(&config.OldestFirst, "oldest-first", false, "Write records in reverse API order, e.g. liked videos oldest first (buffered on disk)")
+}
+
+func validateLimits(config Config) error {
+	switch {
+	case config.MaxResults < 0 || config.MaxPages < 0:
+		return withKind(ErrInvalidConfig, fmt.Errorf("--max and --max-pages must not be negative"))
+	case config.Count && (config.MaxResults > 0 || config.MaxPages > 0):
+		return withKind(ErrInvalidConfig, fmt.Errorf("--count cannot be combined with --max or --max-pages"))
+	}
+	return nil
+}
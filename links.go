@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+)
+
+// channelLink is an entry of the links field added with --links.
+type channelLink struct {
+	URL      string `json:"url"`
+	Platform string `json:"platform"`
+	Source   string `json:"source"`
+}
+
+// linkPlatforms classifies links by host; subdomains match too. Other
+// hosts are a "website".
+var linkPlatforms = map[string]string{
+	"twitter.com":      "twitter",
+	"x.com":            "twitter",
+	"instagram.com":    "instagram",
+	"facebook.com":     "facebook",
+	"fb.com":           "facebook",
+	"tiktok.com":       "tiktok",
+	"threads.net":      "threads",
+	"bsky.app":         "bluesky",
+	"patreon.com":      "patreon",
+	"ko-fi.com":        "kofi",
+	"buymeacoffee.com": "buymeacoffee",
+	"paypal.com":       "paypal",
+	"paypal.me":        "paypal",
+	"twitch.tv":        "twitch",
+	"discord.gg":       "discord",
+	"discord.com":      "discord",
+	"reddit.com":       "reddit",
+	"github.com":       "github",
+	"linkedin.com":     "linkedin",
+	"spotify.com":      "spotify",
+	"soundcloud.com":   "soundcloud",
+	"bandcamp.com":     "bandcamp",
+	"substack.com":     "substack",
+	"linktr.ee":        "linktree",
+	"youtube.com":      "youtube",
+	"youtu.be":         "youtube",
+	"amazon.com":       "amazon",
+	"amzn.to":          "amazon",
+	"t.me":             "telegram",
+	"whatsapp.com":     "whatsapp",
+	"snapchat.com":     "snapchat",
+	"pinterest.com":    "pinterest",
+}
+
+// linksField is the field added with --links, also selected with
+// --fields links.
+const linksField = "links"
+
+// linkSources are the channel fields links are extracted from.
+var linkSources = [][]string{
+	{"snippet", "description"},
+	{"brandingSettings", "channel", "description"},
+}
+
+// linkSourceFields are linkSources as --fields paths.
+func linkSourceFields() []string {
+	var fields []string
+	for _, path := range linkSources {
+		fields = append(fields, strings.Join(path, "."))
+	}
+	return fields
+}
+
+func addLinksFlag(cmd *cobra.Command, config *Config) {
+	cmd.Flags().BoolVar(&config.Links, "links", false, "Add a links field with the URLs and email addresses in the channel description, classified by platform")
+}
+
+// linksWriter implements --links: channel records get a links array of the
+// URLs and email addresses found in their "about" text, e.g. to build a
+// contact list of the creators you follow.
+type linksWriter struct {
+	output.Writer
+}
+
+func (w linksWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	if lookupString(m, "kind") == "youtube#channel" {
+		m[linksField] = channelLinks(m)
+	}
+	return w.Writer.Write(m)
+}
+
+// channelLinks extracts the links of a channel record, without duplicates.
+func channelLinks(record map[string]any) []channelLink {
+	links := []channelLink{}
+	seen := make(map[string]bool)
+	add := func(link channelLink) {
+		if !seen[strings.ToLower(link.URL)] {
+			seen[strings.ToLower(link.URL)] = true
+			links = append(links, link)
+		}
+	}
+	for _, path := range linkSources {
+		text := lookupString(record, path...)
+		source := strings.Join(path, ".")
+		for _, match := range linkPattern.FindAllString(text, -1) {
+			if link, ok := parseChannelLink(match); ok {
+				link.Source = source
+				add(link)
+			}
+		}
+		for _, email := range emailPattern.FindAllString(text, -1) {
+			add(channelLink{URL: "mailto:" + email, Platform: "email", Source: source})
+		}
+	}
+	return links
+}
+
+// parseChannelLink normalizes a URL found in text and classifies it.
+// YouTube's redirect links are resolved to their target.
+func parseChannelLink(text string) (channelLink, bool) {
+	text = strings.TrimRight(text, ".,;:!?)]}>'\"")
+	if !strings.Contains(text, "://") {
+		text = "https://" + text
+	}
+	u, err := url.Parse(text)
+	if err != nil || u.Host == "" {
+		return channelLink{}, false
+	}
+	u.Host = strings.ToLower(u.Host)
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if (host == "youtube.com" || host == "m.youtube.com") && u.Path == "/redirect" {
+		if target := u.Query().Get("q"); target != "" {
+			return parseChannelLink(target)
+		}
+	}
+	return channelLink{URL: u.String(), Platform: linkPlatform(host)}, true
+}
+
+// linkPlatform returns the platform of host, checking its parent domains
+// as well (e.g. artist.bandcamp.com).
+func linkPlatform(host string) string {
+	for {
+		if platform, ok := linkPlatforms[host]; ok {
+			return platform
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return "website"
+		}
+		host = parent
+	}
+}
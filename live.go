@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+var broadcastStatuses = []string{"all", "upcoming", "active", "completed"}
+
+type LiveOptions struct {
+	Status       string
+	Streams      bool
+	VideoDetails bool
+}
+
+func newLiveCmd(config *Config) *cobra.Command {
+	var opts LiveOptions
+
+	cmd := &cobra.Command{
+		Use:   "live",
+		Short: "Fetch your channel's live broadcasts",
+		Long: `Fetch the live broadcasts of your channel (upcoming, active and completed)
+and export to JSONL format. Each broadcast includes the details of its bound
+live stream in a stream field, and the liveStreamingDetails of the broadcast's
+video (scheduled and actual start and end times, concurrent viewers while
+live) in a liveStreamingDetails field.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata live
+  ytdata live --status upcoming
+  ytdata live --status completed -o broadcasts.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return fetchLiveBroadcasts(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Status, "status", "all", "Broadcast status to export ("+strings.Join(broadcastStatuses, ", ")+")")
+	cmd.Flags().BoolVar(&opts.Streams, "streams", true, "Include bound live stream details")
+	cmd.Flags().BoolVar(&opts.VideoDetails, "video-details", true, "Include the liveStreamingDetails of each broadcast's video")
+	addOutputFlag(cmd, "", "Write broadcasts to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export broadcasts matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("status", staticCompletion(broadcastStatuses...)))
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	return cmd
+}
+
+func fetchLiveBroadcasts(config Config, opts LiveOptions) (err error) {
+	if !slices.Contains(broadcastStatuses, opts.Status) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported status %q (supported: %s)", opts.Status, strings.Join(broadcastStatuses, ", ")))
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var broadcasts []*youtube.LiveBroadcast
+	pageToken := ""
+	for {
+		call := service.LiveBroadcasts.List([]string{"id", "snippet", "contentDetails", "status", "statistics", "monetizationDetails"}).
+			BroadcastStatus(opts.Status).
+			BroadcastType("all").
+			MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return fmt.Errorf("failed to fetch live broadcasts: %w", err)
+		}
+		broadcasts = append(broadcasts, response.Items...)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	streams := make(map[string]*youtube.LiveStream)
+	if opts.Streams {
+		var streamIDs []string
+		for _, broadcast := range broadcasts {
+			if broadcast.ContentDetails == nil || broadcast.ContentDetails.BoundStreamId == "" {
+				continue
+			}
+			if !slices.Contains(streamIDs, broadcast.ContentDetails.BoundStreamId) {
+				streamIDs = append(streamIDs, broadcast.ContentDetails.BoundStreamId)
+			}
+		}
+		for i := 0; i < len(streamIDs); i += 50 {
+			end := min(i+50, len(streamIDs))
+			response, err := service.LiveStreams.List([]string{"id", "snippet", "cdn", "contentDetails", "status"}).
+				Id(streamIDs[i:end]...).
+				MaxResults(50).
+				Do()
+			if err != nil {
+				return fmt.Errorf("failed to fetch live streams: %w", err)
+			}
+			for _, stream := range response.Items {
+				streams[stream.Id] = stream
+			}
+		}
+	}
+
+	// A broadcast's ID is the ID of its video
+	details := make(map[string]*youtube.VideoLiveStreamingDetails)
+	if opts.VideoDetails {
+		for i := 0; i < len(broadcasts); i += 50 {
+			end := min(i+50, len(broadcasts))
+			var ids []string
+			for _, broadcast := range broadcasts[i:end] {
+				ids = append(ids, broadcast.Id)
+			}
+			response, err := service.Videos.List([]string{"id", "liveStreamingDetails"}).
+				Id(ids...).
+				MaxResults(50).
+				Do()
+			if err != nil {
+				return fmt.Errorf("failed to fetch live streaming details: %w", err)
+			}
+			for _, video := range response.Items {
+				if video.LiveStreamingDetails != nil {
+					details[video.Id] = video.LiveStreamingDetails
+				}
+			}
+		}
+	}
+
+	out, err := openOutput(config, "broadcasts")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	for _, broadcast := range broadcasts {
+		var record any = broadcast
+		var stream *youtube.LiveStream
+		if broadcast.ContentDetails != nil {
+			stream = streams[broadcast.ContentDetails.BoundStreamId]
+		}
+		if detail, ok := details[broadcast.Id]; ok || stream != nil {
+			withDetails, err := toRecord(broadcast)
+			if err != nil {
+				return fmt.Errorf("failed to process broadcast data: %w", err)
+			}
+			if stream != nil {
+				withDetails["stream"] = stream
+			}
+			if ok {
+				withDetails["liveStreamingDetails"] = detail
+			}
+			record = withDetails
+		}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write broadcast data: %w", err)
+		}
+	}
+
+	return nil
+}
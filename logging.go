@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+// Formats of --log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// logger receives warnings, errors and status messages of long-running
+// commands. Until setupLogging runs, and by default, it prints them for
+// humans on stderr.
+var logger = slog.New(newConsoleHandler(os.Stderr, slog.LevelInfo))
+
+// logFile is the file opened for --log-file, closed by closeLogging.
+var logFile *os.File
+
+// setupLogging configures logger from --log-level, --log-file and
+// --log-format. Text logs on stderr keep the familiar "Warning: ..." lines;
+// text logs in a file are logfmt lines with timestamps.
+func setupLogging(config Config) error {
+	level, ok := logLevels[strings.ToLower(config.LogLevel)]
+	if !ok {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported log level %q (supported: debug, info, warn, error)", config.LogLevel))
+	}
+	if config.LogFormat != logFormatText && config.LogFormat != logFormatJSON {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported log format %q (supported: %s, %s)", config.LogFormat, logFormatText, logFormatJSON))
+	}
+
+	var w io.Writer = os.Stderr
+	if config.LogFile != "" {
+		f, err := os.OpenFile(config.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return withKind(ErrInvalidConfig, fmt.Errorf("failed to open log file: %w", err))
+		}
+		logFile = f
+		w = f
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch {
+	case config.LogFormat == logFormatJSON:
+		logger = slog.New(slog.NewJSONHandler(w, options))
+	case config.LogFile != "":
+		logger = slog.New(slog.NewTextHandler(w, options))
+	default:
+		logger = slog.New(newConsoleHandler(w, level))
+	}
+	output.Warnf = warnf
+	return nil
+}
+
+func closeLogging() {
+	if logFile != nil {
+		_ = logFile.Close()
+	}
+}
+
+// warnf logs a formatted warning.
+func warnf(format string, args ...any) {
+	logger.Warn(fmt.Sprintf(format, args...))
+}
+
+// infof logs a formatted status message.
+func infof(format string, args ...any) {
+	logger.Info(fmt.Sprintf(format, args...))
+}
+
+// consoleHandler writes records as plain lines for a terminal: the message,
+// prefixed with "Warning: " or "error: " by level, and its attributes as
+// key=value pairs.
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Level
+	attrs  []slog.Attr
+	prefix string
+}
+
+func newConsoleHandler(w io.Writer, level slog.Level) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var buf bytes.Buffer
+	switch {
+	case record.Level >= slog.LevelError:
+		buf.WriteString("error: ")
+	case record.Level >= slog.LevelWarn:
+		buf.WriteString("Warning: ")
+	case record.Level < slog.LevelInfo:
+		buf.WriteString("Debug: ")
+	}
+	buf.WriteString(record.Message)
+	for _, attr := range h.attrs {
+		fmt.Fprintf(&buf, " %s=%v", attr.Key, attr.Value)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		if !attr.Equal(slog.Attr{}) {
+			fmt.Fprintf(&buf, " %s%s=%v", h.prefix, attr.Key, attr.Value)
+		}
+		return true
+	})
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		clone.attrs = append(slices.Clip(clone.attrs), attr)
+	}
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
@@ -3,17 +3,25 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/rtzll/ytdata/output"
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -26,6 +34,26 @@ const (
 	credentialsFile     = "youtube_credentials.json"
 	clientSecretsPrefix = "client_secret_"
 	clientSecretsSuffix = ".apps.googleusercontent.com.json"
+	formatAnnotation    = "ytdata_formats"
+	publicAnnotation    = "ytdata_public"
+	localAnnotation     = "ytdata_local"
+	accountsAnnotation  = "ytdata_accounts"
+)
+
+const (
+	authModeOAuth          = "oauth"
+	authModeADC            = "adc"
+	authModeServiceAccount = "service-account"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+	formatRSS   = "rss"
+	formatAtom  = "atom"
+	formatIDs   = "ids"
+	// formatTemplate is selected by --template rather than --format
+	formatTemplate = "template"
 )
 
 var (
@@ -36,6 +64,76 @@ type Config struct {
 	ClientSecret string
 	Credentials  string
 	OutputFile   string
+	OutputDir    string
+	Format       string
+	Template     string
+	Filter       string
+	Transform    string
+	Extract      string
+	Fields       []string
+	Provenance   bool
+	Canonical    bool
+	Redact       bool
+	Local        bool
+	NoStatistics bool
+	SplitSize    string
+	SplitCount   int
+	Append       bool
+	Throttle     string
+	Proxy        string
+	ChannelID    string
+	OnBehalfOf   string
+	IDsOnly      bool
+	Count        bool
+	MaxResults   int
+	MaxPages     int
+	OldestFirst  bool
+	Command      string
+	Scopes       []string
+	Region       string
+	Language     string
+	APIKey       string
+	AuthMode     string
+
+	ServiceAccountFile string
+	RecordDir          string
+	ReplayDir          string
+	APIEndpoint        string
+	MetricsDir         string
+	LogLevel           string
+	LogFile            string
+	LogFormat          string
+	HooksFile          string
+	Encrypt            string
+	NoHooks            bool
+	HTTPTimeout        time.Duration
+	ConnectTimeout     time.Duration
+
+	Parts          []string
+	ChannelParts   []string
+	FlagRestricted bool
+	Localizations  bool
+	IncludeSpecial bool
+	DetectLanguage bool
+	Languages      []string
+
+	DownloadBanners bool
+	Links           bool
+	AssetsDir       string
+
+	ContinueOnError bool
+	ErrorsFile      string
+
+	Subscriptions SubscriptionFilter
+	Videos        VideoFilter
+
+	// recordCount counts the records written, e.g. per job of 'ytdata run'
+	recordCount *atomic.Int64
+	// writtenFiles collects the files written by directory formats, for
+	// the manifest of --output-dir
+	writtenFiles *[]string
+	hooks        *exportHooks
+	encryption   *encryption
 }
 
 func getConfigDir() string {
@@ -57,7 +155,7 @@ func saveCredentials(path string, token *oauth2.Token) error {
 		return fmt.Errorf("failed to create credentials directory: %w", err)
 	}
 
-	tokenData, err := json.Marshal(token)
+	tokenData, err := json.Marshal(tokenRecord(path, token))
 	if err != nil {
 		return fmt.Errorf("failed to serialize token: %w", err)
 	}
@@ -82,6 +180,35 @@ func main() {
 
 	rootCmd.PersistentFlags().StringVarP(&config.ClientSecret, "client-secret", "s", "", "Path to client secrets JSON file (auto-detected if not specified)")
 	rootCmd.PersistentFlags().StringVarP(&config.Credentials, "credentials", "c", getDefaultCredentialsPath(), "Path to credentials JSON file")
+	rootCmd.PersistentFlags().StringVar(&config.Region, "region", "", "ISO 3166-1 alpha-2 region code, e.g. DE (passed to API calls that accept it)")
+	rootCmd.PersistentFlags().StringVar(&config.Language, "hl", "", "Language for localized snippets, e.g. de or pt-BR")
+	rootCmd.PersistentFlags().StringVar(&config.APIKey, "api-key", "", "API key for commands that only read public data (skips OAuth)")
+	rootCmd.PersistentFlags().StringVar(&config.AuthMode, "auth-mode", authModeOAuth, "Authentication mode: oauth, adc (application default credentials) or service-account")
+	rootCmd.PersistentFlags().StringVar(&config.ServiceAccountFile, "service-account-file", "", "Service account JSON key for --auth-mode service-account")
+	rootCmd.PersistentFlags().StringVar(&config.ChannelID, "channel-id", "", "Act as this channel of your Google account (see 'ytdata accounts')")
+	rootCmd.PersistentFlags().StringVar(&config.OnBehalfOf, "on-behalf-of", "", "Content owner ID to act for (YouTube content partners)")
+	rootCmd.PersistentFlags().StringVar(&config.RecordDir, "record", "", "Store raw API responses in this directory")
+	rootCmd.PersistentFlags().StringVar(&config.ReplayDir, "replay", "", "Serve API responses recorded with --record from this directory (no network or credentials)")
+	rootCmd.PersistentFlags().StringVar(&config.APIEndpoint, "api-endpoint", "", "Send API requests to this server instead of Google, without credentials (e.g. a fake API in tests)")
+	_ = rootCmd.PersistentFlags().MarkHidden("api-endpoint")
+	rootCmd.PersistentFlags().StringVar(&config.MetricsDir, "metrics-dir", "", "Write Prometheus textfile metrics for the command to this directory")
+	rootCmd.PersistentFlags().StringVar(&config.LogLevel, "log-level", "info", "Log messages of this level and above: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&config.LogFile, "log-file", "", "Append log messages to this file instead of stderr")
+	rootCmd.PersistentFlags().StringVar(&config.LogFormat, "log-format", logFormatText, "Log format: text or json")
+	rootCmd.PersistentFlags().StringVar(&config.HooksFile, "hooks", "", "Run the pre_export and post_export commands of this YAML file around exports (default: hooks.yaml in the config directory)")
+	rootCmd.PersistentFlags().BoolVar(&config.NoHooks, "no-hooks", false, "Do not run export hooks")
+	rootCmd.PersistentFlags().BoolVar(&config.Provenance, "provenance", false, "Stamp records with _exportedAt, _tool_version, _account_channel_id and _source_command")
+	rootCmd.PersistentFlags().BoolVar(&config.Redact, "redact", false, "Hash your channel ID, mask email addresses and drop playlist descriptions for sharing")
+	rootCmd.PersistentFlags().BoolVar(&config.Canonical, "canonical", false, "Write canonical records: sorted keys, no etags, timestamps in UTC")
+	rootCmd.PersistentFlags().BoolVar(&config.NoStatistics, "no-statistics", false, "Drop fetch-time statistics (view, like, subscriber counts) from records")
+	rootCmd.PersistentFlags().StringVar(&config.Throttle, "throttle", "", "Limit API requests to this rate, e.g. 2/s or 30/m")
+	rootCmd.PersistentFlags().DurationVar(&config.HTTPTimeout, "http-timeout", 0, "Give up on an HTTP request after this long, e.g. 2m (0 = no limit)")
+	rootCmd.PersistentFlags().DurationVar(&config.ConnectTimeout, "connect-timeout", defaultConnectTimeout, "Timeout for connecting to a server, including the TLS handshake")
+	rootCmd.PersistentFlags().StringVar(&config.Proxy, "proxy", "", "Send requests through this proxy, e.g. http://proxy.example.com:8080 (default: HTTPS_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&config.SplitSize, "split-size", "", "Rotate output into numbered part files of at most this size, e.g. 100MB")
+	rootCmd.PersistentFlags().IntVar(&config.SplitCount, "split-count", 0, "Rotate output into numbered part files of at most this many records")
+	rootCmd.PersistentFlags().StringVar(&config.Encrypt, "encrypt", "", "Encrypt the output with age or gpg for these recipients, e.g. age:age1... or gpg:you@example.com")
+	rootCmd.PersistentFlags().BoolVar(&config.Append, "append", false, "Append to the JSONL output file, skipping records it already holds")
 
 	cobra.OnInitialize(func() {
 		if config.ClientSecret == "" {
@@ -92,14 +219,66 @@ func main() {
 		if v := os.Getenv("YTDATA_CREDENTIALS"); v != "" {
 			config.Credentials = v
 		}
+		if config.Region == "" {
+			config.Region = os.Getenv("YTDATA_REGION")
+		}
+		if config.Language == "" {
+			config.Language = os.Getenv("YTDATA_HL")
+		}
+		if config.APIKey == "" {
+			config.APIKey = os.Getenv("YTDATA_API_KEY")
+		}
+		if config.MetricsDir == "" {
+			config.MetricsDir = os.Getenv("YTDATA_METRICS_DIR")
+		}
+		if config.ChannelID == "" {
+			config.ChannelID = os.Getenv("YTDATA_CHANNEL_ID")
+		}
+		if v := os.Getenv("YTDATA_AUTH_MODE"); v != "" && !rootCmd.PersistentFlags().Changed("auth-mode") {
+			config.AuthMode = v
+		}
+		if v := os.Getenv("YTDATA_LOG_LEVEL"); v != "" && !rootCmd.PersistentFlags().Changed("log-level") {
+			config.LogLevel = v
+		}
+		if config.LogFile == "" {
+			config.LogFile = os.Getenv("YTDATA_LOG_FILE")
+		}
+		if config.HooksFile == "" {
+			config.HooksFile = os.Getenv("YTDATA_HOOKS")
+		}
 	})
+	updateNotice := func() {}
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(config); err != nil {
+			return err
+		}
+		updateNotice = startUpdateNotice(cmd)
+		return nil
+	}
 
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "Show version")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withKind(ErrInvalidConfig, err)
+	})
 
 	// Register completion functions for file flags
 	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("client-secret", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
 	}))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("service-account-file", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("auth-mode", staticCompletion(authModeOAuth, authModeADC, authModeServiceAccount)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("log-level", staticCompletion("debug", "info", "warn", "error")))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("log-format", staticCompletion(logFormatText, logFormatJSON)))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("hooks", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+	for _, name := range []string{"record", "replay", "metrics-dir"} {
+		cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		}))
+	}
 
 	setupCmd := &cobra.Command{
 		Use:     "init",
@@ -121,9 +300,17 @@ func main() {
 		SilenceUsage: true,
 		Example: `  ytdata liked
   ytdata liked -o liked.jsonl
-  ytdata liked | jq .`,
+  ytdata liked | jq .
+  ytdata liked --format rss -o liked.xml
+  ytdata liked --parts status,topicDetails
+  ytdata liked --ids-only > liked.txt
+  ytdata liked --count
+  ytdata liked --region DE --flag-restricted
+  ytdata liked --language de -o liked-de.jsonl`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return createCommandHandler(cmd, &config, fetchLikedVideos)
+			return createCommandHandler(cmd, &config, func(config Config) error {
+				return runExporter(config, likedExporter{config})
+			})
 		},
 	}
 
@@ -134,9 +321,15 @@ func main() {
 		Args:         cobra.NoArgs,
 		SilenceUsage: true,
 		Example: `  ytdata subscriptions
-  ytdata subscriptions -o subscriptions.jsonl`,
+  ytdata subscriptions -o subscriptions.jsonl
+  ytdata subscriptions --sort subscribers --min-subscribers 100000
+  ytdata subscriptions --country DE --topic music
+  ytdata subscriptions --include-playlists | jq 'select(.kind == "youtube#playlist")'
+  ytdata subscriptions --continue-on-error -o subscriptions.jsonl`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return createCommandHandler(cmd, &config, fetchSubscriptions)
+			return createCommandHandler(cmd, &config, func(config Config) error {
+				return runExporter(config, subscriptionsExporter{config})
+			})
 		},
 	}
 
@@ -147,15 +340,52 @@ func main() {
 		Args:         cobra.NoArgs,
 		SilenceUsage: true,
 		Example: `  ytdata playlists
-  ytdata playlists -o playlists.jsonl`,
+  ytdata playlists -o playlists.jsonl
+  ytdata playlists --include-special
+  ytdata playlists --format markdown --output-dir ./playlists`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return createCommandHandler(cmd, &config, fetchPlaylists)
+			return createCommandHandler(cmd, &config, func(config Config) error {
+				return runExporter(config, playlistsExporter{config})
+			})
 		},
 	}
 
 	addOutputFlag(likedCmd, "", "Write liked videos to stdout (or file with -o)")
 	addOutputFlag(subscriptionsCmd, "", "Write subscriptions to stdout (or file with -o)")
 	addOutputFlag(playlistsCmd, "", "Write playlists to stdout (or file with -o)")
+	addFormatFlag(likedCmd, append(recordFormats(), formatRSS, formatAtom)...)
+	addFormatFlag(subscriptionsCmd, recordFormats()...)
+	addFormatFlag(playlistsCmd, append(recordFormats(), formatMarkdown)...)
+	for _, cmd := range []*cobra.Command{likedCmd, subscriptionsCmd, playlistsCmd} {
+		addFilterFlag(cmd, "Only export records matching this expression")
+		addTransformFlag(cmd)
+		addFieldsFlag(cmd)
+		cmd.Flags().BoolVar(&config.IDsOnly, "ids-only", false, "Only request and write IDs, one per line (use --format for compact records)")
+		cmd.Flags().BoolVar(&config.Count, "count", false, "Print the number of records instead of exporting them")
+		addLimitFlags(cmd, &config)
+		addOldestFirstFlag(cmd, &config)
+	}
+	addExtractFlag(likedCmd)
+	likedCmd.Flags().StringSliceVar(&config.Parts, "parts", nil, "Additional video parts to request (e.g. status,topicDetails,liveStreamingDetails)")
+	likedCmd.Flags().BoolVar(&config.FlagRestricted, "flag-restricted", false, "Add a regionBlocked field for videos blocked in --region")
+	likedCmd.Flags().BoolVar(&config.Localizations, "localizations", false, "Include all localized titles and descriptions")
+	likedCmd.Flags().BoolVar(&config.DetectLanguage, "detect-language", false, "Add a contentLanguage field, guessed from title and description when the video declares none")
+	likedCmd.Flags().StringSliceVar(&config.Languages, "language", nil, "Only export videos in these languages, e.g. de,en (und for unknown; implies --detect-language)")
+	likedCmd.Flags().DurationVar(&config.Videos.MinDuration, "min-duration", 0, "Only export videos at least this long (e.g. 20m)")
+	likedCmd.Flags().DurationVar(&config.Videos.MaxDuration, "max-duration", 0, "Only export videos at most this long (e.g. 1h30m)")
+	likedCmd.Flags().BoolVar(&config.Videos.ShortsOnly, "shorts-only", false, "Only export Shorts (under 60 seconds, or vertical up to 3 minutes)")
+	likedCmd.Flags().BoolVar(&config.Videos.ExcludeShorts, "exclude-shorts", false, "Skip Shorts (under 60 seconds, or vertical up to 3 minutes)")
+	likedCmd.Flags().StringSliceVar(&config.Videos.Types, "type", nil, "Only export videos of these types: "+strings.Join(videoTypes, ","))
+	playlistsCmd.Flags().BoolVar(&config.Localizations, "localizations", false, "Include all localized titles and descriptions")
+	playlistsCmd.Flags().StringVar(&config.OutputDir, "output-dir", "", "Directory for --format markdown, one file per playlist plus index.md")
+	playlistsCmd.Flags().BoolVar(&config.IncludeSpecial, "include-special", false, "Also export special playlists (uploads, liked videos) with a specialPlaylist field")
+	subscriptionsCmd.Flags().StringVar(&config.Subscriptions.Sort, "sort", "", "Sort channels by "+strings.Join(subscriptionSortKeys, "|"))
+	subscriptionsCmd.Flags().Uint64Var(&config.Subscriptions.MinSubscribers, "min-subscribers", 0, "Only include channels with at least this many subscribers")
+	subscriptionsCmd.Flags().StringVar(&config.Subscriptions.Country, "country", "", "Only include channels from this country code")
+	subscriptionsCmd.Flags().StringVar(&config.Subscriptions.Topic, "topic", "", "Only include channels with a matching topic category (e.g. music)")
+	subscriptionsCmd.Flags().BoolVar(&config.Subscriptions.IncludePlaylists, "include-playlists", false, "Also export the public playlists of each subscribed channel")
+	subscriptionsCmd.Flags().BoolVar(&config.ContinueOnError, "continue-on-error", false, "Log failed requests to the errors file and continue")
+	subscriptionsCmd.Flags().StringVar(&config.ErrorsFile, "errors-file", defaultErrorsFile, "File failed requests are logged to with --continue-on-error")
 
 	// Register completion for output flags
 	outputCompletion := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -164,21 +394,100 @@ func main() {
 	cobra.CheckErr(likedCmd.RegisterFlagCompletionFunc("output", outputCompletion))
 	cobra.CheckErr(subscriptionsCmd.RegisterFlagCompletionFunc("output", outputCompletion))
 	cobra.CheckErr(playlistsCmd.RegisterFlagCompletionFunc("output", outputCompletion))
+	cobra.CheckErr(subscriptionsCmd.RegisterFlagCompletionFunc("sort", staticCompletion(subscriptionSortKeys...)))
+	addAssetFlags(subscriptionsCmd, &config)
+	addLinksFlag(subscriptionsCmd, &config)
+	addAssetFlags(playlistsCmd, &config)
+
+	rootCmd.AddCommand(setupCmd, likedCmd, subscriptionsCmd, playlistsCmd, newMeCmd(&config), newChannelCmd(&config), newPlaylistItemsCmd(&config), newVideoCommentsCmd(&config), newSecretsCmd())
+	rootCmd.AddCommand(newArchiveCmd(), newOpenCmd(), newCheckCmd(&config), newMembershipsCmd(&config), newSuperChatsCmd(&config), newLiveCmd(&config), newAnalyticsCmd(&config), newRetryCmd(&config), newAllCmd(&config), newCategoriesCmd(&config), newMetaCmd(&config), newTrendingCmd(&config), newJoinCmd(&config), newSchemaCmd(&config), newExportCmd(&config), newStatsCmd(&config), newPruneCmd(&config), newUnsubscribeCmd(&config), newDedupePlaylistsCmd(&config), newUndoCmd(&config), newAccountsCmd(&config), newAuthCmd(&config), newWatchCmd(&config), newWebSubCmd(&config), newCommentedCmd(&config), newReconcileCmd(&config), newConvertCmd(&config), newSiteCmd(&config), newIndexCmd(&config), newFindCmd(&config), newChaptersCmd(&config), newTakeoutCmd(&config), newCompareCmd(&config), newMigrateCmd(&config), newSyncCmd(&config), newPlaylistAuditCmd(&config), newRewindCmd(&config), newVideosCmd(&config), newChannelsCmd(&config), newPlaylistCmd(&config), newParseURLCmd(&config), newPlaylistVideosCmd(&config), newProjectsCmd(&config), newSelfUpdateCmd(&config), newDoctorCmd(&config), newRunCmd(&config), newVerifyCmd(&config), newDigestCmd(&config))
+
+	err := rootCmd.Execute()
+	saveProjectUsage()
+	if config.MetricsDir != "" {
+		if err := writeMetrics(config.MetricsDir, err); err != nil {
+			warnf("%v", err)
+		}
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		closeLogging()
+		os.Exit(exitCode(err))
+	}
+	updateNotice()
+	closeLogging()
+}
 
-	rootCmd.AddCommand(setupCmd, likedCmd, subscriptionsCmd, playlistsCmd)
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+// publicYouTube returns a service for reading public data, using the API
+// key when one is configured and OAuth otherwise.
+func publicYouTube(config Config) (*youtube.Service, error) {
+	if config.APIKey == "" || config.ReplayDir != "" || config.APIEndpoint != "" {
+		return authenticateYouTube(config)
+	}
+	client := withThrottle(withMetrics(withHTTPTimeout(&http.Client{Transport: &apiKeyTransport{key: config.APIKey, base: http.DefaultTransport}}, config)))
+	if config.RecordDir != "" {
+		var err error
+		if client, err = withRecording(client, config.RecordDir); err != nil {
+			return nil, err
+		}
+	}
+	service, err := youtube.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create youtube service: %w", err)
 	}
+	return service, nil
 }
 
 func authenticateYouTube(config Config) (*youtube.Service, error) {
+	client, err := authenticateClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := youtube.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create youtube service: %w", err)
+	}
+
+	return service, nil
+}
+
+// authenticateClient returns an HTTP client authorized for the configured
+// scopes, so services other than the Data API can share the auth flow.
+// With --replay or --api-endpoint no credentials are used at all.
+func authenticateClient(config Config) (*http.Client, error) {
+	if config.ReplayDir != "" {
+		return withContentOwner(withThrottle(withMetrics(&http.Client{Transport: &replayTransport{dir: config.ReplayDir}})), config), nil
+	}
+	if config.APIEndpoint != "" {
+		client := withContentOwner(withThrottle(withMetrics(withHTTPTimeout(&http.Client{Transport: &endpointTransport{endpoint: config.APIEndpoint, base: http.DefaultTransport}}, config))), config)
+		if config.RecordDir != "" {
+			return withRecording(client, config.RecordDir)
+		}
+		return client, nil
+	}
+
+	client, err := authorizeClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client = withContentOwner(withThrottle(withMetrics(withHTTPTimeout(withProjectRotation(client, config), config))), config)
+	if config.RecordDir != "" {
+		return withRecording(client, config.RecordDir)
+	}
+	return client, nil
+}
+
+func authorizeClient(config Config) (*http.Client, error) {
 	ctx := context.Background()
 
-	oauthConfig, err := getOAuthConfig(config.ClientSecret)
+	if config.AuthMode == authModeADC || config.AuthMode == authModeServiceAccount {
+		return authenticateServerClient(ctx, config)
+	}
+
+	oauthConfig, err := getOAuthConfig(config.ClientSecret, config.Scopes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get oauth config: %w", err)
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to get oauth config: %w", err))
 	}
 
 	// Load existing token if available
@@ -187,7 +496,7 @@ func authenticateYouTube(config Config) (*youtube.Service, error) {
 		tokenData, err := os.ReadFile(config.Credentials)
 		if err == nil {
 			if err := json.Unmarshal(tokenData, &token); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to unmarshal token: %v\n", err)
+				warnf("Failed to unmarshal token: %v", err)
 			}
 		}
 	}
@@ -202,14 +511,15 @@ func authenticateYouTube(config Config) (*youtube.Service, error) {
 		if err == nil {
 			// Save the potentially refreshed token
 			if err := saveCredentials(config.Credentials, freshToken); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to save refreshed credentials: %v\n", err)
+				warnf("Failed to save refreshed credentials: %v", err)
 			}
 
 			// Create client with the fresh token
-			client := oauthConfig.Client(ctx, freshToken)
-			service, err := youtube.NewService(ctx, option.WithHTTPClient(client))
-			if err == nil {
-				return service, nil
+			return oauthConfig.Client(ctx, freshToken), nil
+		}
+		if isInvalidGrant(err) {
+			if err := confirmReauthorization(err); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -217,30 +527,68 @@ func authenticateYouTube(config Config) (*youtube.Service, error) {
 	// Only do full OAuth flow if no token or refresh failed
 	token, err = performOAuthFlow(oauthConfig)
 	if err != nil {
-		return nil, fmt.Errorf("oauth flow failed: %w", err)
+		return nil, withKind(ErrAuth, fmt.Errorf("oauth flow failed: %w", err))
+	}
+	if config.ChannelID != "" && config.OnBehalfOf == "" {
+		if err := verifyTokenChannel(ctx, oauthConfig, token, config); err != nil {
+			return nil, err
+		}
 	}
 
 	// Save new token
 	if err := saveCredentials(config.Credentials, token); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to save credentials: %v\n", err)
+		warnf("Failed to save credentials: %v", err)
 	}
 
-	client := oauthConfig.Client(ctx, token)
-	service, err := youtube.NewService(ctx, option.WithHTTPClient(client))
+	return oauthConfig.Client(ctx, token), nil
+}
+
+// authenticateServerClient authorizes with application default credentials
+// or a service account key, for deployments inside managed Google Cloud
+// environments where no browser is available.
+func authenticateServerClient(ctx context.Context, config Config) (*http.Client, error) {
+	requested := config.Scopes
+	if len(requested) == 0 {
+		requested = scopes
+	}
+
+	var creds *google.Credentials
+	var err error
+	if config.AuthMode == authModeServiceAccount {
+		data, readErr := os.ReadFile(config.ServiceAccountFile)
+		if readErr != nil {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("unable to read service account file: %w", readErr))
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, requested...)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, requested...)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create youtube service: %w", err)
+		return nil, withKind(ErrAuth, fmt.Errorf("failed to load %s credentials: %w", config.AuthMode, err))
 	}
 
-	return service, nil
+	return oauth2.NewClient(ctx, creds.TokenSource), nil
+}
+
+// withScopes returns a copy of config requesting additional OAuth scopes.
+// Tokens for extended scopes are stored next to the default credentials
+// file, so granting extra access never replaces the read-only token.
+func withScopes(config Config, name string, extra ...string) Config {
+	config.Scopes = append(slices.Clone(scopes), extra...)
+	config.Credentials = strings.TrimSuffix(config.Credentials, ".json") + "_" + name + ".json"
+	return config
 }
 
-func getOAuthConfig(clientSecretsFile string) (*oauth2.Config, error) {
-	b, err := os.ReadFile(clientSecretsFile)
+func getOAuthConfig(clientSecretsFile string, requested []string) (*oauth2.Config, error) {
+	b, err := readClientSecrets(clientSecretsFile)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read client secret file: %w", err)
 	}
 
-	config, err := google.ConfigFromJSON(b, scopes...)
+	if len(requested) == 0 {
+		requested = scopes
+	}
+	config, err := google.ConfigFromJSON(b, requested...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse client secret file: %w", err)
 	}
@@ -248,22 +596,7 @@ func getOAuthConfig(clientSecretsFile string) (*oauth2.Config, error) {
 	return config, nil
 }
 
-func performOAuthFlow(config *oauth2.Config) (*oauth2.Token, error) {
-	codeChan := make(chan string, 1)
-	errChan := make(chan error, 1)
-
-	// Start local server on port 8080 for OAuth callback
-	config.RedirectURL = "http://localhost:8080/"
-
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		if code == "" {
-			errChan <- fmt.Errorf("no authorization code received")
-			return
-		}
-
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if _, err := fmt.Fprintf(w, `<!DOCTYPE html>
+const oauthCompletePage = `<!DOCTYPE html>
 <html>
 <head>
 <title>Authorization Complete</title>
@@ -273,23 +606,124 @@ func performOAuthFlow(config *oauth2.Config) (*oauth2.Token, error) {
 <h2>Authorization Complete</h2>
 <p>You can close this window and return to the terminal.</p>
 </body>
-</html>`); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to write response: %v\n", err)
+</html>`
+
+// randomToken returns n random bytes, hex encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// oauthCallback returns the loopback address to listen on and the redirect
+// URL for the consent screen. Desktop clients accept any loopback port and
+// path, so an unpredictable path on a free port is used; web clients only
+// accept their registered redirect URI (e.g. http://localhost:8080/).
+func oauthCallback(registered string) (addr string, redirect *url.URL, err error) {
+	if u, parseErr := url.Parse(registered); parseErr == nil && u.Port() != "" {
+		return "127.0.0.1:" + u.Port(), u, nil
+	}
+	path, err := randomToken(16)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create callback path: %w", err)
+	}
+	return "127.0.0.1:0", &url.URL{Scheme: "http", Host: "127.0.0.1", Path: "/oauth/" + path}, nil
+}
+
+func performOAuthFlow(config *oauth2.Config) (*oauth2.Token, error) {
+	codeChan := make(chan string, 1)
+	errChan := make(chan error, 1)
+
+	state, err := randomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state: %w", err)
+	}
+	addr, redirect, err := oauthCallback(config.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := listenOAuthCallback(addr)
+	if err != nil || remoteSession() {
+		if err != nil {
+			warnf("Cannot listen for the OAuth callback: %v", err)
+		} else {
+			_ = listener.Close()
+		}
+		return manualOAuthFlow(config, redirect, state)
+	}
+	if redirect.Port() == "" {
+		redirect.Host = listener.Addr().String()
+	}
+	callbackPath := redirect.Path
+	if callbackPath == "" {
+		callbackPath = "/"
+	}
+	// Copy so the caller's config keeps its registered redirect URL
+	flowConfig := *config
+	flowConfig.RedirectURL = redirect.String()
+
+	// A dedicated mux keeps repeated flows in one process from registering
+	// handlers on the default mux twice
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != callbackPath {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			// Not a response to our request, e.g. a forged callback
+			http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+			return
+		}
+		if reason := query.Get("error"); reason != "" {
+			http.Error(w, "Authorization failed: "+reason, http.StatusBadRequest)
+			select {
+			case errChan <- fmt.Errorf("authorization failed: %s", reason):
+			default:
+			}
+			return
+		}
+		code := query.Get("code")
+		if code == "" {
+			http.Error(w, "No authorization code received", http.StatusBadRequest)
+			select {
+			case errChan <- fmt.Errorf("no authorization code received"):
+			default:
+			}
+			return
 		}
 
-		codeChan <- code
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := fmt.Fprint(w, oauthCompletePage); err != nil {
+			warnf("Failed to write response: %v", err)
+		}
+		select {
+		case codeChan <- code:
+		default:
+		}
 	})
 
-	server := &http.Server{Addr: ":8080"}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("failed to serve OAuth callback: %w", err)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			select {
+			case errChan <- fmt.Errorf("failed to serve OAuth callback: %w", err):
+			default:
+			}
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			warnf("Failed to shutdown server gracefully: %v", err)
 		}
 	}()
 
-	time.Sleep(100 * time.Millisecond)
-
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	authURL := flowConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
 	fmt.Println("Opening authorization URL in browser...")
 
 	if err := openBrowser(authURL); err != nil {
@@ -306,13 +740,7 @@ func performOAuthFlow(config *oauth2.Config) (*oauth2.Token, error) {
 		return nil, fmt.Errorf("authorization timeout - please try again")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to shutdown server gracefully: %v\n", err)
-	}
-
-	token, err := config.Exchange(context.Background(), authCode)
+	token, err := flowConfig.Exchange(context.Background(), authCode)
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve token from web: %w", err)
 	}
@@ -335,7 +763,7 @@ func findClientSecretsFile() (string, error) {
 		files, err := filepath.Glob(fullPattern)
 		if err != nil {
 			// Log the error but continue searching in other directories
-			fmt.Fprintf(os.Stderr, "Warning: Error searching in %s: %v\n", dir, err)
+			warnf("Error searching in %s: %v", dir, err)
 			continue
 		}
 		if len(files) > 0 {
@@ -348,25 +776,33 @@ func findClientSecretsFile() (string, error) {
 
 func ensureSetup(config *Config) error {
 	if config.ClientSecret == "" {
+		// Secrets imported into the keyring win over files found on disk
+		if data := readKeyringClientSecrets(); data != nil {
+			if err := validateClientSecrets(data); err != nil {
+				return withKind(ErrInvalidConfig, fmt.Errorf("invalid client secrets in keyring: %w", err))
+			}
+			return nil
+		}
+
 		detected, err := findClientSecretsFile()
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "error: no client secrets file found")
-			fmt.Fprintln(os.Stderr, "Run 'ytdata init' for guided setup instructions")
-			return fmt.Errorf("setup required: %w", err)
+			logger.Error("no client secrets file found")
+			infof("Run 'ytdata init' for guided setup instructions")
+			return withKind(ErrInvalidConfig, fmt.Errorf("setup required: %w", err))
 		}
 		config.ClientSecret = detected
 	}
 
 	if _, err := os.Stat(config.ClientSecret); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "error: client secrets file not found at: %s\n", config.ClientSecret)
-		fmt.Fprintln(os.Stderr, "Run 'ytdata init' for guided setup instructions")
-		return fmt.Errorf("setup required: client secrets file not found")
+		logger.Error("client secrets file not found at: " + config.ClientSecret)
+		infof("Run 'ytdata init' for guided setup instructions")
+		return withKind(ErrInvalidConfig, fmt.Errorf("setup required: client secrets file not found"))
 	}
 
 	if err := validateClientSecretsFile(config.ClientSecret); err != nil {
-		fmt.Fprintf(os.Stderr, "error: invalid client secrets file: %v\n", err)
-		fmt.Fprintln(os.Stderr, "Run 'ytdata init' for guided setup instructions")
-		return fmt.Errorf("setup required: %w", err)
+		logger.Error(fmt.Sprintf("invalid client secrets file: %v", err))
+		infof("Run 'ytdata init' for guided setup instructions")
+		return withKind(ErrInvalidConfig, fmt.Errorf("setup required: %w", err))
 	}
 
 	return nil
@@ -377,23 +813,30 @@ func validateClientSecretsFile(path string) error {
 	if err != nil {
 		return fmt.Errorf("cannot read client secrets file: %w", err)
 	}
+	return validateClientSecrets(data)
+}
 
+func validateClientSecrets(data []byte) error {
+	type client struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
 	var secrets struct {
-		Web struct {
-			ClientID     string `json:"client_id"`
-			ClientSecret string `json:"client_secret"`
-		} `json:"web"`
+		Web       client `json:"web"`
+		Installed client `json:"installed"`
 	}
 
 	if err := json.Unmarshal(data, &secrets); err != nil {
 		return fmt.Errorf("invalid JSON format: %w", err)
 	}
 
-	if secrets.Web.ClientID != "" && secrets.Web.ClientSecret != "" {
-		return nil
+	for _, c := range []client{secrets.Installed, secrets.Web} {
+		if c.ClientID != "" && c.ClientSecret != "" {
+			return nil
+		}
 	}
 
-	return fmt.Errorf("must be web application type with valid client_id and client_secret")
+	return fmt.Errorf("must be a desktop app or web application client with valid client_id and client_secret")
 }
 
 func openBrowser(url string) error {
@@ -414,11 +857,16 @@ func openBrowser(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
+// stdin is shared by all prompts so input buffered by one prompt is not
+// lost for the next.
+var stdin = bufio.NewReader(os.Stdin)
+
+// promptUser asks on stderr, so prompts never end up in output written to
+// stdout.
 func promptUser(message string) string {
-	fmt.Print(message)
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return strings.TrimSpace(scanner.Text())
+	fmt.Fprint(os.Stderr, message)
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(line)
 }
 
 func runSetup() error {
@@ -465,9 +913,8 @@ func runSetup() error {
 	fmt.Println("   - Fill in required fields (app name, user support email)")
 	fmt.Println("   - Add your email to test users")
 	fmt.Println("4. For OAuth client ID:")
-	fmt.Println("   - Application type: 'Web application'")
+	fmt.Println("   - Application type: 'Desktop app'")
 	fmt.Println("   - Name: 'YouTube Data CLI' (or any name)")
-	fmt.Println("   - Add http://localhost:8080 to 'Authorized redirect URIs'")
 	fmt.Println("5. Click 'Create'")
 	fmt.Println("6. Download the JSON file")
 	fmt.Println()
@@ -489,7 +936,7 @@ func runSetup() error {
 
 	detected, err := findClientSecretsFile()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		logger.Error(err.Error())
 		fmt.Println("Please ensure you've downloaded and placed the client secrets file correctly.")
 		return err
 	}
@@ -497,12 +944,13 @@ func runSetup() error {
 	fmt.Printf("Found client secrets file: %s\n", detected)
 
 	if err := validateClientSecretsFile(detected); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		logger.Error(err.Error())
 		fmt.Println("Please ensure you downloaded the correct OAuth2 client credentials.")
 		return err
 	}
 
 	fmt.Println("Client secrets file is valid")
+	fmt.Printf("Tip: run 'ytdata secrets import %s' to move it into the OS keyring\n", detected)
 	fmt.Println()
 	fmt.Println("Step 5: Test Authentication")
 	fmt.Println("Let's verify everything works by completing the OAuth flow...")
@@ -516,7 +964,7 @@ func runSetup() error {
 
 	_, err = authenticateYouTube(config)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		logger.Error(err.Error())
 		fmt.Println("Please check your OAuth2 configuration and try again.")
 		return err
 	}
@@ -532,19 +980,70 @@ func runSetup() error {
 	return nil
 }
 
-func fetchLikedVideos(config Config) error {
-	service, err := authenticateYouTube(config)
+// likedExporter exports the videos the user rated with "like".
+type likedExporter struct {
+	config Config
+}
+
+func (e likedExporter) Name() string {
+	return "liked"
+}
+
+func (e likedExporter) Scopes() []string {
+	return scopes
+}
+
+func (e likedExporter) validate() error {
+	if e.config.IDsOnly && (len(e.config.Parts) > 0 || e.config.FlagRestricted || e.config.Localizations || e.config.DetectLanguage || len(e.config.Languages) > 0 || e.config.Videos.active()) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--ids-only cannot be combined with --parts, --flag-restricted, --localizations, --detect-language, --language or duration or type filters"))
+	}
+	if err := e.config.Videos.validate(); err != nil {
+		return err
+	}
+	if _, err := videoParts(e.config); err != nil {
+		return err
+	}
+	if e.config.FlagRestricted && e.config.Region == "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--flag-restricted requires a region (use --region or YTDATA_REGION)"))
+	}
+	return nil
+}
+
+func (e likedExporter) Fetch(ctx context.Context, service *youtube.Service, sink output.Writer) error {
+	config := e.config
+	parts, err := videoParts(config)
 	if err != nil {
-		return fmt.Errorf("authentication failed: %w", err)
+		return err
+	}
+	if config.IDsOnly {
+		parts = []string{"id"}
+	}
+	fields, err := apiFieldMask(config, youtube.Video{}, videoDerivedFields, likedRequiredFields(config))
+	if err != nil {
+		return err
 	}
 
 	var allVideos []*youtube.Video
+	limit := newPageLimit(config)
 	pageToken := ""
 
 	for {
-		call := service.Videos.List([]string{"snippet", "contentDetails", "statistics"}).
+		call := service.Videos.List(parts).
+			Context(ctx).
 			MyRating("like").
-			MaxResults(50)
+			MaxResults(limit.size())
+
+		if slices.Contains(parts, "player") {
+			call = call.MaxHeight(playerMaxHeight)
+		}
+
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+
+		if fields != "" {
+			call = call.Fields(fields)
+		}
 
 		if pageToken != "" {
 			call = call.PageToken(pageToken)
@@ -555,32 +1054,37 @@ func fetchLikedVideos(config Config) error {
 			return fmt.Errorf("failed to fetch liked videos: %w", err)
 		}
 
-		allVideos = append(allVideos, response.Items...)
+		keep, more := limit.page(len(response.Items))
+		allVideos = append(allVideos, response.Items[:keep]...)
 
-		if response.NextPageToken == "" {
+		if !more || response.NextPageToken == "" {
 			break
 		}
 		pageToken = response.NextPageToken
 	}
 
-	var writer io.Writer
-	if config.OutputFile == "" {
-		writer = os.Stdout
-	} else {
-		f, err := os.Create(config.OutputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
-		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to close file: %v\n", err)
+	if config.IDsOnly {
+		for _, video := range allVideos {
+			if err := sink.Write(idRecord{Kind: video.Kind, ID: video.Id}); err != nil {
+				return fmt.Errorf("failed to write video data: %w", err)
 			}
-		}()
-		writer = f
+		}
+		return nil
 	}
-	encoder := json.NewEncoder(writer)
+
+	enrichment := newVideoEnrichment(service, config)
 	for _, video := range allVideos {
-		if err := encoder.Encode(video); err != nil {
+		if !config.Videos.matches(video) {
+			continue
+		}
+		record, err := enrichment.enrichVideo(video)
+		if err != nil {
+			return fmt.Errorf("failed to process video data: %w", err)
+		}
+		if len(config.Languages) > 0 && !matchesLanguage(lookupString(record, "contentLanguage"), config.Languages) {
+			continue
+		}
+		if err := sink.Write(record); err != nil {
 			return fmt.Errorf("failed to write video data: %w", err)
 		}
 	}
@@ -588,87 +1092,357 @@ func fetchLikedVideos(config Config) error {
 	return nil
 }
 
-func fetchSubscriptions(config Config) error {
-	service, err := authenticateYouTube(config)
-	if err != nil {
-		return fmt.Errorf("authentication failed: %w", err)
-	}
+// subscriptionsExporter exports the channels the user is subscribed to.
+type subscriptionsExporter struct {
+	config Config
+}
 
-	var subscriptions []*youtube.Subscription
-	pageToken := ""
+func (e subscriptionsExporter) Name() string {
+	return "subscriptions"
+}
 
-	for {
-		call := service.Subscriptions.List([]string{"snippet"}).
-			Mine(true).
-			MaxResults(50)
+func (e subscriptionsExporter) Scopes() []string {
+	return scopes
+}
 
-		if pageToken != "" {
-			call = call.PageToken(pageToken)
-		}
+func (e subscriptionsExporter) validate() error {
+	if e.config.IDsOnly && e.config.Subscriptions != (SubscriptionFilter{}) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--ids-only cannot be combined with sorting, channel filters or --include-playlists"))
+	}
+	return e.config.Subscriptions.validate()
+}
 
-		response, err := call.Do()
+func (e subscriptionsExporter) Fetch(ctx context.Context, service *youtube.Service, sink output.Writer) error {
+	config := e.config
+
+	// The subscription list already names every channel, so the channel
+	// details lookup is skipped
+	if config.IDsOnly {
+		subscriptions, err := fetchSubscriptionList(ctx, service, newPageLimit(config))
 		if err != nil {
-			return fmt.Errorf("failed to fetch subscriptions: %w", err)
+			return err
+		}
+		for _, sub := range subscriptions {
+			record := idRecord{Kind: "youtube#channel", ID: sub.Snippet.ResourceId.ChannelId}
+			if err := sink.Write(record); err != nil {
+				return fmt.Errorf("failed to write channel data: %w", err)
+			}
 		}
+		return nil
+	}
 
-		subscriptions = append(subscriptions, response.Items...)
+	// Checked before the first request, not per batch
+	if _, err := channelFieldMask(config); err != nil {
+		return err
+	}
 
-		if response.NextPageToken == "" {
-			break
+	failures := newErrorLog(config.ErrorsFile, "subscriptions")
+	allChannels, subscribedAt, err := fetchSubscribedChannels(ctx, service, config, failures)
+	if err != nil {
+		return err
+	}
+
+	allChannels = config.Subscriptions.apply(allChannels, subscribedAt)
+
+	for _, channel := range allChannels {
+		if err := sink.Write(channel); err != nil {
+			return fmt.Errorf("failed to write channel data: %w", err)
 		}
-		pageToken = response.NextPageToken
 	}
 
-	var channelIDs []string
-	for _, sub := range subscriptions {
-		channelIDs = append(channelIDs, sub.Snippet.ResourceId.ChannelId)
+	// Channel playlists follow the channels; snippet.channelId links them
+	if config.Subscriptions.IncludePlaylists {
+		for _, channel := range allChannels {
+			playlists, failedPage, err := fetchChannelPlaylists(service, config, channel.Id, "")
+			if err != nil && !config.ContinueOnError {
+				return fmt.Errorf("failed to fetch playlists of channel %s: %w", channel.Id, err)
+			}
+			for _, playlist := range playlists {
+				if err := sink.Write(playlist); err != nil {
+					return fmt.Errorf("failed to write playlist data: %w", err)
+				}
+			}
+			if err != nil {
+				params := requestParams{ChannelID: channel.Id, PageToken: failedPage, Hl: config.Language}
+				if err := failures.record(operationChannelPlaylistsList, params, err); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
-	var allChannels []*youtube.Channel
-	batchSize := 50
+	return failures.close()
+}
+
+// Helper function to open the output file, falling back to stdout when no
+// file is given, and encrypted with --encrypt. Close it with closeOutput.
+func createOutputWriter(config Config) (io.WriteCloser, error) {
+	opts := output.Options{Path: config.OutputFile}
+	if config.encryption != nil {
+		opts.Wrap = config.encryption.wrap
+	}
+	return opts.Open()
+}
 
-	for i := 0; i < len(channelIDs); i += batchSize {
-		end := i + batchSize
-		if end > len(channelIDs) {
-			end = len(channelIDs)
+// openOutput creates the writer selected with --format. dataset names the
+// exported data for writers that need it, e.g. as a feed title.
+func openOutput(config Config, dataset string) (output.Writer, error) {
+	opts := output.Options{Path: config.OutputFile, Dataset: dataset, Dir: config.OutputDir, Template: config.Template}
+	if config.encryption != nil {
+		opts.Wrap = config.encryption.wrap
+	}
+	if config.writtenFiles != nil {
+		opts.Written = func(path string) {
+			*config.writtenFiles = append(*config.writtenFiles, path)
 		}
+	}
+	if output.IsS3(config.OutputFile) && (config.Append || config.Format == "sqlite") {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("--append and sqlite output need a local file, not %s", config.OutputFile))
+	}
+	var out output.Writer
+	var err error
+	switch {
+	case config.Append:
+		out, err = openAppendOutput(config)
+	case config.SplitSize != "" || config.SplitCount != 0:
+		out, err = openSplitOutput(config, opts)
+	default:
+		out, err = output.New(config.Format, opts)
+	}
+	if err != nil {
+		return nil, withKind(ErrInvalidConfig, err)
+	}
+	return wrapOutput(config, out)
+}
 
-		batch := channelIDs[i:end]
-		call := service.Channels.List([]string{
-			"snippet", "contentDetails", "statistics", "topicDetails",
-			"status", "brandingSettings", "localizations",
-		}).Id(batch...)
+// openAppendOutput creates the writer for --append.
+func openAppendOutput(config Config) (output.Writer, error) {
+	switch {
+	case config.SplitSize != "" || config.SplitCount != 0:
+		return nil, fmt.Errorf("--append cannot be combined with --split-size or --split-count")
+	case config.Format != formatJSONL:
+		return nil, fmt.Errorf("--append only supports the jsonl format (sqlite output already updates rows by ID)")
+	}
+	out, err := output.NewAppend(config.OutputFile)
+	if err != nil {
+		return nil, err
+	}
+	return appendWriter{out}, nil
+}
 
-		response, err := call.Do()
+// appendWriter reports the records --append skipped.
+type appendWriter struct {
+	*output.Append
+}
+
+func (w appendWriter) Close() error {
+	if skipped := w.Skipped(); skipped > 0 {
+		infof("Skipped %d records already in the output", skipped)
+	}
+	return w.Append.Close()
+}
+
+// openSplitOutput creates the writer for --split-size and --split-count.
+func openSplitOutput(config Config, opts output.Options) (output.Writer, error) {
+	if config.SplitCount < 0 {
+		return nil, fmt.Errorf("--split-count must be positive")
+	}
+	var maxBytes int64
+	if config.SplitSize != "" {
+		size, err := parseByteSize(config.SplitSize)
 		if err != nil {
-			return fmt.Errorf("failed to fetch channel details: %w", err)
+			return nil, fmt.Errorf("invalid --split-size: %w", err)
 		}
+		maxBytes = size
+	}
+	return output.NewSplit(config.Format, opts, maxBytes, config.SplitCount)
+}
+
+// byteUnits are the suffixes accepted by parseByteSize; KB, MB and GB are
+// decimal, KiB, MiB and GiB binary.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+	{"K", 1e3}, {"M", 1e6}, {"G", 1e9},
+	{"B", 1},
+}
 
-		allChannels = append(allChannels, response.Items...)
+// parseByteSize parses sizes such as 100MB, 1.5GB or 512KiB.
+func parseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value, unit = strings.TrimSpace(strings.TrimSuffix(value, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(n) || n <= 0 {
+		return 0, fmt.Errorf("%q is not a size like 100MB", s)
 	}
+	// float64(math.MaxInt64) rounds up to 2^63, which no longer fits
+	size := n * float64(unit)
+	if size >= float64(math.MaxInt64) {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	if size < 1 {
+		return 0, fmt.Errorf("%q is less than a byte", s)
+	}
+	return int64(size), nil
+}
 
-	var writer io.Writer
-	if config.OutputFile == "" {
-		writer = os.Stdout
-	} else {
-		f, err := os.Create(config.OutputFile)
+// wrapOutput adds the record processing selected in config (--filter,
+// --transform, --provenance, ...) in front of a writer.
+func wrapOutput(config Config, out output.Writer) (output.Writer, error) {
+	// Wrappers run outermost first: filter, links, fields, assets,
+	// extract, transform, provenance, redact, canonical, statistics,
+	// reverse, counting
+	out = countingWriter{Writer: out, count: config.recordCount}
+	if config.OldestFirst {
+		reverse, err := newReverseWriter(out)
 		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
+			_ = out.Close()
+			return nil, err
 		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to close file: %v\n", err)
-			}
-		}()
-		writer = f
+		out = reverse
 	}
-	encoder := json.NewEncoder(writer)
-	for _, channel := range allChannels {
-		if err := encoder.Encode(channel); err != nil {
-			return fmt.Errorf("failed to write channel data: %w", err)
+	if config.NoStatistics {
+		out = statisticsWriter{Writer: out}
+	}
+	if config.Canonical {
+		out = canonicalWriter{Writer: out}
+	}
+	if config.Redact {
+		redact, err := newRedactWriter(out, config)
+		if err != nil {
+			_ = out.Close()
+			return nil, err
+		}
+		out = redact
+	}
+	if config.Provenance {
+		out = newProvenanceWriter(out, config)
+	}
+	if config.Transform != "" {
+		code, err := parseTransform(config.Transform)
+		if err != nil {
+			_ = out.Close()
+			return nil, withKind(ErrInvalidConfig, err)
+		}
+		out = transformWriter{out, code}
+	}
+	if config.Extract == extractDescriptions {
+		out = extractWriter{out}
+	}
+	if config.DownloadBanners {
+		assets, err := newAssetWriter(out, config.AssetsDir)
+		if err != nil {
+			_ = out.Close()
+			return nil, err
+		}
+		out = assets
+	}
+	if len(config.Fields) > 0 {
+		fields := config.Fields
+		if config.Links && !hasField(fields, linksField) {
+			fields = append(slices.Clone(fields), linksField)
+		}
+		out = newFieldsWriter(out, fields)
+	}
+	// Links are extracted before --fields drops the descriptions
+	if config.Links || hasField(config.Fields, linksField) {
+		out = linksWriter{out}
+	}
+	if config.Filter != "" {
+		filter, err := parseFilter(config.Filter)
+		if err != nil {
+			_ = out.Close()
+			return nil, withKind(ErrInvalidConfig, err)
 		}
+		out = filterWriter{out, filter}
+	}
+	return out, nil
+}
+
+// closeOutput closes out and reports its error unless err is already set.
+// Buffering writers (csv, sqlite, feeds) only write their data on Close.
+func closeOutput(out io.Closer, err *error) {
+	if closeErr := out.Close(); closeErr != nil && *err == nil {
+		*err = fmt.Errorf("failed to write output: %w", closeErr)
+	}
+}
+
+// recordFormats lists the registered writers that accept any record, with
+// the default jsonl first. Feed formats only apply to videos.
+func recordFormats() []string {
+	formats := []string{formatJSONL}
+	for _, name := range output.Names() {
+		if name != formatJSONL && name != formatRSS && name != formatAtom && name != formatTemplate && name != formatMarkdown {
+			formats = append(formats, name)
+		}
+	}
+	return formats
+}
+
+// formatExtensions are the file extensions of formats whose name is not
+// the usual extension of their files.
+var formatExtensions = map[string]string{
+	"ids":    ".txt",
+	"table":  ".txt",
+	"sqlite": ".db",
+}
+
+// formatExtension returns the extension of files written in format.
+func formatExtension(format string) string {
+	if ext, ok := formatExtensions[format]; ok {
+		return ext
+	}
+	return "." + format
+}
+
+// Helper function to add the --filter expression flag to commands
+func addFilterFlag(cmd *cobra.Command, description string) {
+	cmd.Flags().String("filter", "", description+" (see README for the syntax)")
+}
+
+// Helper function to get filter flag value, validate it and set it in config
+func getFilterFlag(cmd *cobra.Command, config *Config) error {
+	flag := cmd.Flags().Lookup("filter")
+	if flag == nil {
+		return nil
+	}
+	config.Filter = flag.Value.String()
+	if config.Filter == "" {
+		return nil
+	}
+	if _, err := parseFilter(config.Filter); err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid --filter: %w", err))
 	}
+	return nil
+}
+
+// Helper function to add the --transform jq expression flag to commands
+func addTransformFlag(cmd *cobra.Command) {
+	cmd.Flags().String("transform", "", "jq expression applied to each record before writing, e.g. '{id, title: .snippet.title}'")
+}
 
+// Helper function to get transform flag value, validate it and set it in config
+func getTransformFlag(cmd *cobra.Command, config *Config) error {
+	flag := cmd.Flags().Lookup("transform")
+	if flag == nil {
+		return nil
+	}
+	config.Transform = flag.Value.String()
+	if config.Transform == "" {
+		return nil
+	}
+	if _, err := parseTransform(config.Transform); err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid --transform: %w", err))
+	}
 	return nil
 }
 
@@ -679,40 +1453,200 @@ func addOutputFlag(cmd *cobra.Command, defaultValue, description string) {
 
 // Helper function to get output flag value and set it in config
 func getOutputFlag(cmd *cobra.Command, config *Config) error {
-	output, err := cmd.Flags().GetString("output")
-	if err != nil {
-		return fmt.Errorf("failed to get output flag: %w", err)
+	flag := cmd.Flags().Lookup("output")
+	if flag == nil {
+		return nil
 	}
-	config.OutputFile = output
+	config.OutputFile = flag.Value.String()
 	return nil
 }
 
+// Helper function to add format flag listing the formats a command supports
+func addFormatFlag(cmd *cobra.Command, formats ...string) {
+	cmd.Flags().StringP("format", "f", formats[0], fmt.Sprintf("Output format (%s)", strings.Join(formats, ", ")))
+	cobra.CheckErr(cmd.Flags().SetAnnotation("format", formatAnnotation, formats))
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("format", staticCompletion(formats...)))
+	cmd.Flags().String("template", "", `Write each record through a Go template instead, e.g. '{{.Id}}\t{{.Snippet.Title}}'`)
+}
+
+// Helper function to get format flag value and set it in config
+func getFormatFlag(cmd *cobra.Command, config *Config) error {
+	config.Format = formatJSONL
+	flag := cmd.Flags().Lookup("format")
+	if flag == nil {
+		return nil
+	}
+	if tmpl := cmd.Flags().Lookup("template"); tmpl != nil && tmpl.Value.String() != "" {
+		if flag.Changed {
+			return withKind(ErrInvalidConfig, fmt.Errorf("--template cannot be combined with --format"))
+		}
+		if _, err := output.ParseTemplate(tmpl.Value.String()); err != nil {
+			return withKind(ErrInvalidConfig, fmt.Errorf("invalid --template: %w", err))
+		}
+		config.Format = formatTemplate
+		config.Template = tmpl.Value.String()
+		return nil
+	}
+	format := strings.ToLower(flag.Value.String())
+	supported := flag.Annotations[formatAnnotation]
+	for _, f := range supported {
+		if f == format {
+			config.Format = format
+			return nil
+		}
+	}
+	return withKind(ErrInvalidConfig, fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(supported, ", ")))
+}
+
+func staticCompletion(formats ...string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return formats, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
 // Common command handler that handles setup and flag parsing
 func createCommandHandler(cmd *cobra.Command, config *Config, fetchFunc func(Config) error) error {
-	if err := ensureSetup(config); err != nil {
-		cmd.SilenceUsage = true
+	config.Command = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	config.Local = cmd.Annotations[localAnnotation] == "true"
+	metrics.start(strings.ReplaceAll(config.Command, " ", "_"))
+	if err := getFormatFlag(cmd, config); err != nil {
+		return err
+	}
+	if config.IDsOnly && !cmd.Flags().Changed("format") && config.Template == "" {
+		config.Format = formatIDs
+	}
+	if err := getFilterFlag(cmd, config); err != nil {
 		return err
 	}
+	if err := getTransformFlag(cmd, config); err != nil {
+		return err
+	}
+	if err := getExtractFlag(cmd, config); err != nil {
+		return err
+	}
+	if err := getFieldsFlag(cmd, config); err != nil {
+		return err
+	}
+	if err := getEncryptFlag(config); err != nil {
+		return err
+	}
+	switch config.AuthMode {
+	case authModeOAuth, authModeADC:
+	case authModeServiceAccount:
+		if config.ServiceAccountFile == "" {
+			return withKind(ErrInvalidConfig, fmt.Errorf("--auth-mode service-account requires --service-account-file"))
+		}
+	default:
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported auth mode %q (supported: %s, %s, %s)", config.AuthMode, authModeOAuth, authModeADC, authModeServiceAccount))
+	}
+
+	if config.RecordDir != "" && config.ReplayDir != "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--record and --replay cannot be used together"))
+	}
+	if config.APIEndpoint != "" {
+		if config.ReplayDir != "" {
+			return withKind(ErrInvalidConfig, fmt.Errorf("--api-endpoint and --replay cannot be used together"))
+		}
+		if u, err := url.Parse(config.APIEndpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return withKind(ErrInvalidConfig, fmt.Errorf("invalid --api-endpoint %q: expected a URL like http://localhost:8080", config.APIEndpoint))
+		}
+	}
+	if config.Throttle != "" {
+		interval, err := parseRate(config.Throttle)
+		if err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		setThrottle(interval)
+	}
+	if err := setHTTPTransport(*config); err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+
+	switch {
+	case config.ReplayDir != "", config.APIEndpoint != "", cmd.Annotations[localAnnotation] == "true":
+		// Replayed responses, fake APIs and commands that only read local
+		// files need neither credentials nor setup
+	case config.APIKey != "":
+		// API keys only grant access to public data, so OAuth setup is
+		// skipped for commands that never read private data
+		if cmd.Annotations[publicAnnotation] != "true" {
+			return withKind(ErrInvalidConfig, fmt.Errorf("'%s' reads private data and requires OAuth; --api-key only works for public commands", cmd.CommandPath()))
+		}
+	case config.AuthMode == authModeOAuth:
+		// Server credentials (adc, service-account) need no client secrets file
+		if err := ensureSetup(config); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if cmd.Annotations[accountsAnnotation] != "true" {
+			if err := selectChannel(config); err != nil {
+				return err
+			}
+		}
+	}
 	if err := getOutputFlag(cmd, config); err != nil {
 		return err
 	}
-	return fetchFunc(*config)
+	hooks, err := loadHooks(*config)
+	if err != nil {
+		return err
+	}
+	config.hooks = hooks
+	export := fetchFunc
+	if config.OutputDir != "" {
+		export = func(config Config) error {
+			return withDirManifest(config, cmd.Name(), fetchFunc)
+		}
+	}
+	if hooks != nil && runsHooks(cmd) {
+		return hooks.around(*config, "", export)
+	}
+	return export(*config)
+}
+
+// playlistsExporter exports the playlists created by the user.
+type playlistsExporter struct {
+	config Config
+}
+
+func (e playlistsExporter) Name() string {
+	return "playlists"
+}
+
+func (e playlistsExporter) Scopes() []string {
+	return scopes
 }
 
-func fetchPlaylists(config Config) error {
-	service, err := authenticateYouTube(config)
+func (e playlistsExporter) Fetch(ctx context.Context, service *youtube.Service, sink output.Writer) error {
+	config := e.config
+
+	// Fetch user-created playlists; special playlists (uploads, liked videos)
+	// are only included with --include-special
+	parts := []string{"snippet", "contentDetails", "status"}
+	if config.Localizations {
+		parts = append(parts, "localizations")
+	}
+	if config.IDsOnly {
+		parts = []string{"id"}
+	}
+	fields, err := apiFieldMask(config, youtube.Playlist{}, playlistDerivedFields, nil)
 	if err != nil {
-		return fmt.Errorf("authentication failed: %w", err)
+		return err
 	}
 
-	// Fetch user-created playlists only
-	// Note: Special playlists (uploads, liked videos) could be fetched via:
-	// service.Channels.List([]string{"contentDetails"}).Mine(true) -> RelatedPlaylists
 	var allPlaylists []*youtube.Playlist
+	limit := newPageLimit(config)
 	pageToken := ""
 	for {
-		call := service.Playlists.List([]string{"snippet", "contentDetails", "status"}).
-			Mine(true).MaxResults(50)
+		call := service.Playlists.List(parts).
+			Context(ctx).
+			Mine(true).MaxResults(limit.size())
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		if fields != "" {
+			call = call.Fields(fields)
+		}
 		if pageToken != "" {
 			call = call.PageToken(pageToken)
 		}
@@ -720,32 +1654,48 @@ func fetchPlaylists(config Config) error {
 		if err != nil {
 			return fmt.Errorf("failed to fetch playlists: %w", err)
 		}
-		allPlaylists = append(allPlaylists, response.Items...)
-		if response.NextPageToken == "" {
+		keep, more := limit.page(len(response.Items))
+		allPlaylists = append(allPlaylists, response.Items[:keep]...)
+		if !more || response.NextPageToken == "" {
 			break
 		}
 		pageToken = response.NextPageToken
 	}
 
-	var writer io.Writer
-	if config.OutputFile == "" {
-		writer = os.Stdout
-	} else {
-		f, err := os.Create(config.OutputFile)
+	for _, playlist := range allPlaylists {
+		var record any = playlist
+		if config.IDsOnly {
+			record = idRecord{Kind: playlist.Kind, ID: playlist.Id}
+		}
+		if err := sink.Write(record); err != nil {
+			return fmt.Errorf("failed to write playlist data: %w", err)
+		}
+		if err := writeMarkdownItems(ctx, service, config, playlist.Id, sink); err != nil {
+			return err
+		}
+	}
+
+	if config.IncludeSpecial {
+		special, err := fetchSpecialPlaylists(service, config, parts)
 		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
+			return err
 		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to close file: %v\n", err)
+		for _, s := range special {
+			var playlist any = s.Playlist
+			if config.IDsOnly {
+				playlist = idRecord{Kind: s.Playlist.Kind, ID: s.Playlist.Id}
+			}
+			record, err := toRecord(playlist)
+			if err != nil {
+				return fmt.Errorf("failed to process playlist data: %w", err)
+			}
+			record["specialPlaylist"] = s.Kind
+			if err := sink.Write(record); err != nil {
+				return fmt.Errorf("failed to write playlist data: %w", err)
+			}
+			if err := writeMarkdownItems(ctx, service, config, s.Playlist.Id, sink); err != nil {
+				return err
 			}
-		}()
-		writer = f
-	}
-	encoder := json.NewEncoder(writer)
-	for _, playlist := range allPlaylists {
-		if err := encoder.Encode(playlist); err != nil {
-			return fmt.Errorf("failed to write playlist data: %w", err)
 		}
 	}
 
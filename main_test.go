@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  int64
+	}{
+		{"100", 100},
+		{"100B", 100},
+		{"512KiB", 512 << 10},
+		{"1.5GB", 1_500_000_000},
+		{"100mb", 100_000_000},
+		{" 2 M ", 2_000_000},
+		{"8GiB", 8 << 30},
+		{"8000000000GB", 8_000_000_000_000_000_000},
+	} {
+		got, err := parseByteSize(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1MB", "0", "0.5B", "ten MB", "NaN", "Inf", "9300000000GB", "1e30", "9223372036854775807"} {
+		if got, err := parseByteSize(input); err == nil {
+			t.Errorf("parseByteSize(%q) = %d, want an error", input, got)
+		}
+	}
+}
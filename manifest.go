@@ -0,0 +1,284 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	manifestFileName = "manifest.json"
+	// manifestVersion is bumped when the layout of manifest.json changes.
+	manifestVersion = 1
+	// schemaVersion is bumped when the records ytdata writes change
+	// incompatibly, e.g. an added field is renamed.
+	schemaVersion = 1
+)
+
+// exportManifest is the manifest.json written next to the files of `ytdata
+// all` and --output-dir, so consumers can verify them and detect partial
+// syncs.
+type exportManifest struct {
+	ManifestVersion int              `json:"manifestVersion"`
+	SchemaVersion   int              `json:"schemaVersion"`
+	ToolVersion     string           `json:"toolVersion"`
+	Command         string           `json:"command"`
+	StartedAt       time.Time        `json:"startedAt"`
+	FinishedAt      time.Time        `json:"finishedAt"`
+	Complete        bool             `json:"complete"`
+	Exports         []manifestExport `json:"exports"`
+}
+
+// manifestExport is one dataset of a manifest.
+type manifestExport struct {
+	Dataset    string         `json:"dataset"`
+	Format     string         `json:"format"`
+	Status     string         `json:"status"`
+	Records    int64          `json:"records"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	Files      []manifestFile `json:"files"`
+}
+
+// manifestFile is a written file, relative to the manifest.
+type manifestFile struct {
+	Path   string `json:"path"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+func newManifest(config Config) *exportManifest {
+	return &exportManifest{
+		ManifestVersion: manifestVersion,
+		SchemaVersion:   schemaVersion,
+		ToolVersion:     version,
+		Command:         config.Command,
+		StartedAt:       time.Now().UTC(),
+		Exports:         []manifestExport{},
+	}
+}
+
+// add records an export that finished with err, with the files in paths.
+// Files that were not written (e.g. removed after a failure) are left out.
+func (m *exportManifest) add(dir string, export manifestExport, paths []string, err error) error {
+	export.FinishedAt = time.Now().UTC()
+	export.Status = jobOK
+	if err != nil {
+		export.Status, export.Error = jobFailed, err.Error()
+		if classifyError(err) == ErrPartial {
+			export.Status = jobPartial
+		}
+	}
+	export.Files = []manifestFile{}
+	for _, path := range paths {
+		file, err := checksumFile(dir, path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		export.Files = append(export.Files, file)
+	}
+	m.Exports = append(m.Exports, export)
+	return nil
+}
+
+// write replaces dir/manifest.json atomically. The batch is complete when
+// every export in it succeeded.
+func (m *exportManifest) write(dir string) error {
+	m.FinishedAt = time.Now().UTC()
+	m.Complete = !slices.ContainsFunc(m.Exports, func(e manifestExport) bool {
+		return e.Status != jobOK
+	})
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, manifestFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+func checksumFile(dir, path string) (manifestFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return manifestFile{}, fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = path
+	}
+	return manifestFile{Path: filepath.ToSlash(rel), Bytes: n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// outputFiles returns the files written for path, which are numbered parts
+// with --split-size or --split-count.
+func outputFiles(config Config, path string) []string {
+	if config.SplitSize == "" && config.SplitCount == 0 {
+		return []string{path}
+	}
+	var parts []string
+	for n := 1; ; n++ {
+		part := output.PartPath(path, n)
+		if _, err := os.Stat(part); err != nil {
+			return parts
+		}
+		parts = append(parts, part)
+	}
+}
+
+// readManifest reads dir/manifest.json.
+func readManifest(dir string) (*exportManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest exportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.ManifestVersion > manifestVersion {
+		return nil, fmt.Errorf("manifest version %d is newer than this ytdata supports (%d); run 'ytdata self-update'", manifest.ManifestVersion, manifestVersion)
+	}
+	return &manifest, nil
+}
+
+// withDirManifest runs export for --output-dir and records the files it
+// wrote in the manifest of the directory. The entries of other datasets
+// exported to the same directory are kept; an earlier export of the same
+// dataset is replaced.
+func withDirManifest(config Config, dataset string, export func(Config) error) error {
+	if config.recordCount == nil {
+		config.recordCount = &atomic.Int64{}
+	}
+	var written []string
+	config.writtenFiles = &written
+	entry := manifestExport{Dataset: dataset, Format: config.Format, StartedAt: time.Now().UTC()}
+	err := export(config)
+	entry.Records = config.recordCount.Load()
+	if _, statErr := os.Stat(config.OutputDir); os.IsNotExist(statErr) {
+		return err
+	}
+
+	manifest, manifestErr := readManifest(config.OutputDir)
+	if os.IsNotExist(manifestErr) {
+		manifest, manifestErr = newManifest(config), nil
+	}
+	if manifestErr == nil {
+		manifest.ToolVersion, manifest.SchemaVersion, manifest.Command = version, schemaVersion, config.Command
+		manifest.Exports = slices.DeleteFunc(manifest.Exports, func(e manifestExport) bool {
+			return e.Dataset == dataset
+		})
+		manifestErr = manifest.add(config.OutputDir, entry, written, err)
+	}
+	if manifestErr == nil {
+		manifestErr = manifest.write(config.OutputDir)
+	}
+	if manifestErr != nil {
+		warnf("Failed to write manifest: %v", manifestErr)
+	}
+	return err
+}
+
+// VerifyOptions configures the verify command.
+type VerifyOptions struct {
+	Dir string
+}
+
+func newVerifyCmd(config *Config) *cobra.Command {
+	var opts VerifyOptions
+
+	cmd := &cobra.Command{
+		Use:   "verify [DIR]",
+		Short: "Check exported files against their manifest",
+		Long: `Check the files listed in DIR/manifest.json (default: the current
+directory), written by 'ytdata all' and --output-dir: every file must exist
+with the recorded size and SHA-256 checksum.
+
+Each problem is printed to stderr. The command fails if a file is missing or
+changed, and exits with code 6 if the files are intact but the export that
+wrote them was incomplete.`,
+		Args: cobra.MaximumNArgs(1),
+		Example: `  ytdata verify exports
+  ytdata all --dest exports && ytdata verify exports`,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		SilenceUsage: true,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Dir = "."
+			if len(args) == 1 {
+				opts.Dir = args[0]
+			}
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runVerify(opts)
+			})
+		},
+	}
+	return cmd
+}
+
+func runVerify(opts VerifyOptions) error {
+	manifest, err := readManifest(opts.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("failed to read manifest: %w", err)
+		}
+		return withKind(ErrInvalidConfig, err)
+	}
+
+	files, problems := 0, 0
+	for _, export := range manifest.Exports {
+		if export.Status != jobOK {
+			warnf("%s export is %s: %s", export.Dataset, export.Status, export.Error)
+		}
+		for _, want := range export.Files {
+			files++
+			got, err := checksumFile(opts.Dir, filepath.Join(opts.Dir, filepath.FromSlash(want.Path)))
+			switch {
+			case os.IsNotExist(err):
+				warnf("%s is missing", want.Path)
+				problems++
+			case err != nil:
+				return err
+			case got.Bytes != want.Bytes || got.SHA256 != want.SHA256:
+				warnf("%s has changed (%d bytes, sha256 %s; manifest: %d bytes, sha256 %s)", want.Path, got.Bytes, got.SHA256, want.Bytes, want.SHA256)
+				problems++
+			}
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d of %d files failed verification", problems, files)
+	}
+	if !manifest.Complete {
+		return withKind(ErrPartial, fmt.Errorf("%d files verified, but the export was incomplete", files))
+	}
+	infof("Verified %d files exported at %s", files, manifest.FinishedAt.Local().Format(time.DateTime))
+	return nil
+}
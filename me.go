@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+// myChannelParts are all channel parts readable by the channel owner.
+// auditDetails needs the youtubepartner-channel-audit scope and is dropped
+// when access is denied.
+var myChannelParts = []string{
+	"snippet", "contentDetails", "statistics", "topicDetails", "status",
+	"brandingSettings", "localizations", "contentOwnerDetails", "auditDetails",
+}
+
+// meExporter exports the channel(s) of the authenticated account followed
+// by their channel sections.
+type meExporter struct {
+	config Config
+}
+
+func (e meExporter) Name() string {
+	return "me"
+}
+
+func (e meExporter) Scopes() []string {
+	return scopes
+}
+
+func (e meExporter) Fetch(ctx context.Context, service *youtube.Service, sink output.Writer) error {
+	config := e.config
+
+	listChannels := func(parts []string) (*youtube.ChannelListResponse, error) {
+		call := service.Channels.List(parts).Context(ctx).Mine(true)
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		return call.Do()
+	}
+
+	response, err := listChannels(myChannelParts)
+	if auditDetailsForbidden(err) {
+		warnf("auditDetails not permitted, exporting without it")
+		response, err = listChannels(slices.DeleteFunc(slices.Clone(myChannelParts), func(part string) bool {
+			return part == "auditDetails"
+		}))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch your channel: %w", err)
+	}
+	if len(response.Items) == 0 {
+		infof("Your account has no YouTube channel")
+		return nil
+	}
+	for _, channel := range response.Items {
+		if err := sink.Write(channel); err != nil {
+			return fmt.Errorf("failed to write channel data: %w", err)
+		}
+	}
+
+	// Sections follow the channels; snippet.channelId links them
+	sections, err := fetchChannelSections(service, config, "")
+	if err != nil {
+		return fmt.Errorf("failed to fetch channel sections: %w", err)
+	}
+	for _, section := range sections {
+		if err := sink.Write(section); err != nil {
+			return fmt.Errorf("failed to write channel section data: %w", err)
+		}
+	}
+	return nil
+}
+
+func newMeCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "me",
+		Short: "Fetch your own channel",
+		Long: `Fetch the channel of the authenticated account with all parts available to
+its owner (including auditDetails where permitted), followed by its
+channel sections, as a baseline snapshot of your channel.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata me
+  ytdata me -o me.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runExporter(config, meExporter{config})
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write your channel to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export records matching this expression")
+	addTransformFlag(cmd)
+	addAssetFlags(cmd, config)
+	addLinksFlag(cmd, config)
+
+	return cmd
+}
+
+// auditDetailsForbidden reports whether err means the auditDetails part
+// was refused, which needs the youtubepartner-channel-audit scope. Other
+// 403s, e.g. quotaExceeded, are real failures.
+func auditDetailsForbidden(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "forbidden", "insufficientPermissions":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+type MembershipsOptions struct {
+	Levels bool
+	Mode   string
+}
+
+func newMembershipsCmd(config *Config) *cobra.Command {
+	var opts MembershipsOptions
+
+	cmd := &cobra.Command{
+		Use:   "memberships",
+		Short: "Fetch the members of your channel (creators only)",
+		Long: `Fetch the members of your channel, or its membership levels with --levels,
+and export to JSONL format.
+
+This uses the Members and MembershipsLevels APIs, which require the
+youtube.channel-memberships.creator scope and approval from YouTube for
+your Google Cloud project. The first run asks for this additional access.
+
+Memberships you hold as a viewer are not supported: the YouTube Data API
+does not expose them, and subscriptions do not tell whether you are a
+member. That data is only available via Google Takeout.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata memberships
+  ytdata memberships --levels
+  ytdata memberships --mode updates -o members.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				config = withScopes(config, "memberships", youtube.YoutubeChannelMembershipsCreatorScope)
+				return fetchMemberships(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Levels, "levels", false, "Export membership levels instead of members")
+	cmd.Flags().StringVar(&opts.Mode, "mode", "all_current", "Members to list: all_current or updates")
+	addOutputFlag(cmd, "", "Write members to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export records matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("mode", staticCompletion("all_current", "updates")))
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	return cmd
+}
+
+// explainMembershipsError turns the errors returned for channels without
+// memberships or projects without API approval into actionable messages.
+func explainMembershipsError(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 {
+		return err
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "channelMembershipsNotEnabled":
+			return fmt.Errorf("channel memberships are not enabled for your channel: %w", err)
+		case "insufficientPermissions", "forbidden":
+			return fmt.Errorf("access to the memberships API was denied; it must be approved by YouTube for your Google Cloud project: %w", err)
+		}
+	}
+	return err
+}
+
+func fetchMemberships(config Config, opts MembershipsOptions) (err error) {
+	if opts.Mode != "all_current" && opts.Mode != "updates" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported mode %q (supported: all_current, updates)", opts.Mode))
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var records []any
+	if opts.Levels {
+		response, err := service.MembershipsLevels.List([]string{"id", "snippet"}).Do()
+		if err != nil {
+			return fmt.Errorf("failed to fetch membership levels: %w", explainMembershipsError(err))
+		}
+		for _, level := range response.Items {
+			records = append(records, level)
+		}
+	} else {
+		pageToken := ""
+		for {
+			call := service.Members.List([]string{"snippet"}).
+				Mode(opts.Mode).
+				MaxResults(1000)
+			if pageToken != "" {
+				call = call.PageToken(pageToken)
+			}
+			response, err := call.Do()
+			if err != nil {
+				return fmt.Errorf("failed to fetch members: %w", explainMembershipsError(err))
+			}
+			for _, member := range response.Items {
+				records = append(records, member)
+			}
+			if response.NextPageToken == "" {
+				break
+			}
+			pageToken = response.NextPageToken
+		}
+	}
+
+	out, err := openOutput(config, "memberships")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	for _, record := range records {
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write membership data: %w", err)
+		}
+	}
+
+	if len(records) == 0 {
+		infof("No memberships found")
+	}
+	return nil
+}
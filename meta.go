@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newMetaCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "meta",
+		Short: "Dump YouTube reference data",
+		Long: `Dump reference data of the YouTube API, such as the supported regions and
+interface languages, for joining exports in analytics pipelines.`,
+	}
+
+	regionsCmd := &cobra.Command{
+		Use:          "regions",
+		Short:        "List the content regions supported by YouTube",
+		Long:         "List the content regions supported by YouTube with their names in the --hl language.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata meta regions
+  ytdata meta regions --hl de -f csv -o regions.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, listRegions)
+		},
+	}
+
+	languagesCmd := &cobra.Command{
+		Use:          "languages",
+		Short:        "List the interface languages supported by YouTube",
+		Long:         "List the interface languages supported by YouTube with their names in the --hl language.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata meta languages
+  ytdata meta languages --hl de -o languages.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, listLanguages)
+		},
+	}
+
+	for _, sub := range []*cobra.Command{regionsCmd, languagesCmd} {
+		addOutputFlag(sub, "", "Write reference data to stdout (or file with -o)")
+		addFormatFlag(sub, recordFormats()...)
+		addFilterFlag(sub, "Only list entries matching this expression")
+		addTransformFlag(sub)
+	}
+
+	cmd.AddCommand(regionsCmd, languagesCmd)
+	return cmd
+}
+
+func listRegions(config Config) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	call := service.I18nRegions.List([]string{"snippet"})
+	if config.Language != "" {
+		call = call.Hl(config.Language)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return fmt.Errorf("failed to fetch regions: %w", err)
+	}
+
+	out, err := openOutput(config, "regions")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, region := range response.Items {
+		if err := out.Write(region); err != nil {
+			return fmt.Errorf("failed to write region data: %w", err)
+		}
+	}
+	return nil
+}
+
+func listLanguages(config Config) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	call := service.I18nLanguages.List([]string{"snippet"})
+	if config.Language != "" {
+		call = call.Hl(config.Language)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return fmt.Errorf("failed to fetch languages: %w", err)
+	}
+
+	out, err := openOutput(config, "languages")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, language := range response.Items {
+		if err := out.Write(language); err != nil {
+			return fmt.Errorf("failed to write language data: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+// Estimated quota cost of Data API requests. Reads cost 1 unit, search 100
+// and writes (insert, update, delete, rate) 50.
+const (
+	quotaCostRead   = 1
+	quotaCostSearch = 100
+	quotaCostWrite  = 50
+)
+
+// runMetrics collects the numbers written to the Prometheus textfile with
+// --metrics-dir.
+type runMetrics struct {
+	mu       sync.Mutex
+	command  string
+	started  time.Time
+	records  int
+	requests int
+	quota    int
+	failures int
+}
+
+var metrics runMetrics
+
+func (m *runMetrics) start(command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.command = command
+	m.started = time.Now()
+}
+
+func (m *runMetrics) addRecord() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records++
+}
+
+func (m *runMetrics) addFailure() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures++
+}
+
+func (m *runMetrics) addRequest(req *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.quota += requestQuotaCost(req)
+}
+
+// requestQuotaCost estimates the Data API quota a request uses; other
+// APIs are not counted.
+func requestQuotaCost(req *http.Request) int {
+	if !strings.Contains(req.URL.Path, "/youtube/v3/") {
+		return 0
+	}
+	switch {
+	case req.Method != http.MethodGet:
+		return quotaCostWrite
+	case strings.HasSuffix(req.URL.Path, "/search"):
+		return quotaCostSearch
+	default:
+		return quotaCostRead
+	}
+}
+
+// metricsTransport counts API requests and their estimated quota cost.
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	metrics.addRequest(req)
+	return t.base.RoundTrip(req)
+}
+
+// withMetrics wraps client so its requests are counted.
+func withMetrics(client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	counted := *client
+	counted.Transport = &metricsTransport{base: base}
+	return &counted
+}
+
+// countingWriter counts the records written to an output, in the metrics
+// and in count if set.
+type countingWriter struct {
+	output.Writer
+	count *atomic.Int64
+}
+
+func (w countingWriter) Write(record any) error {
+	if err := w.Writer.Write(record); err != nil {
+		return err
+	}
+	metrics.addRecord()
+	if w.count != nil {
+		w.count.Add(1)
+	}
+	return nil
+}
+
+// writeMetrics writes the metrics of the finished command to
+// DIR/ytdata_<command>.prom for the node_exporter textfile collector. The
+// last success timestamp is carried over from the previous file on failure.
+func writeMetrics(dir string, runErr error) error {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.command == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	path := filepath.Join(dir, "ytdata_"+metrics.command+".prom")
+
+	now := time.Now()
+	lastSuccess := readLastSuccess(path)
+	if runErr == nil {
+		lastSuccess = float64(now.Unix())
+	}
+	success := 0
+	if runErr == nil {
+		success = 1
+	}
+
+	label := fmt.Sprintf("{command=%q}", metrics.command)
+	var b strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s%s %s\n", name, help, name, name, label, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	gauge("ytdata_records_exported", "Records written by the last run.", float64(metrics.records))
+	gauge("ytdata_export_duration_seconds", "Duration of the last run.", now.Sub(metrics.started).Seconds())
+	gauge("ytdata_api_requests", "API requests made by the last run.", float64(metrics.requests))
+	gauge("ytdata_quota_units_estimate", "Estimated Data API quota units used by the last run.", float64(metrics.quota))
+	gauge("ytdata_failed_requests", "Requests logged to the errors file by the last run.", float64(metrics.failures))
+	gauge("ytdata_last_run_success", "Whether the last run succeeded (1) or failed (0).", float64(success))
+	gauge("ytdata_last_run_exit_code", "Exit code of the last run.", float64(exitCode(runErr)))
+	gauge("ytdata_last_run_timestamp_seconds", "Unix time the last run finished.", float64(now.Unix()))
+	if lastSuccess > 0 {
+		gauge("ytdata_last_success_timestamp_seconds", "Unix time of the last successful run.", lastSuccess)
+	}
+
+	// Write atomically so the collector never reads a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+func readLastSuccess(path string) float64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "ytdata_last_success_timestamp_seconds{") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if value, err := strconv.ParseFloat(fields[len(fields)-1], 64); err == nil {
+			return value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+// defaultDailyQuota is the Data API quota of a new Google Cloud project.
+const defaultDailyQuota = 10000
+
+var migrateDatasets = []string{"subscriptions", "playlists"}
+
+type MigrateOptions struct {
+	From       string
+	To         string
+	State      string
+	Only       []string
+	DailyQuota int
+	Wait       bool
+	DryRun     bool
+}
+
+// migrationState is the progress of a migration, saved after every change
+// so an interrupted or quota-limited migration continues where it stopped.
+type migrationState struct {
+	From          string                  `json:"from"`
+	To            string                  `json:"to"`
+	StartedAt     string                  `json:"startedAt"`
+	QuotaDay      string                  `json:"quotaDay"`
+	QuotaUsed     int                     `json:"quotaUsed"`
+	Subscriptions []migrationSubscription `json:"subscriptions"`
+	Playlists     []migrationPlaylist     `json:"playlists"`
+}
+
+type migrationSubscription struct {
+	ChannelID string `json:"channelId"`
+	Title     string `json:"title"`
+	Done      bool   `json:"done,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type migrationPlaylist struct {
+	SourceID    string   `json:"sourceId"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Privacy     string   `json:"privacy,omitempty"`
+	TargetID    string   `json:"targetId,omitempty"`
+	Videos      []string `json:"videos"`
+	// Added counts the videos handled so far, including failed ones
+	Added  int `json:"added"`
+	Failed int `json:"failed,omitempty"`
+}
+
+// steps returns the number of changes a migration makes and how many of
+// them are done.
+func (s *migrationState) steps() (done, total int) {
+	for _, sub := range s.Subscriptions {
+		total++
+		if sub.Done {
+			done++
+		}
+	}
+	for _, p := range s.Playlists {
+		total += 1 + len(p.Videos)
+		if p.TargetID != "" {
+			done++
+		}
+		done += p.Added
+	}
+	return done, total
+}
+
+func newMigrateCmd(config *Config) *cobra.Command {
+	var opts MigrateOptions
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Copy subscriptions and playlists to another account",
+		Long: `Copy the subscriptions and playlists of one account to another: subscribe
+the target account to every channel of the source, and recreate the
+playlists with their videos in order. Profiles are channels authorized with
+'ytdata accounts add' (ID or title) or "default"; the target needs write
+access, which is requested on first use.
+
+Every change costs 50 quota units, so large accounts take several days on
+the default quota of 10,000 units. The migration keeps to --daily-quota per
+day (quota resets at midnight Pacific time) and stops when it is used up,
+or waits for the reset with --wait. Progress is saved to --state after every
+change; run the same command again to continue. Channels the target already
+follows are skipped.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{accountsAnnotation: "true"},
+		Example: `  ytdata migrate --from default --to UCxxxxxxxxxxxxxxxxxxxxxx --dry-run
+  ytdata migrate --from Personal --to Work --only subscriptions
+  ytdata migrate --from Personal --to Work --wait`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runMigration(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.From, "from", "", "Source account: channel ID or title, or default")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Target account: channel ID or title, or default")
+	cmd.Flags().StringVar(&opts.State, "state", "", "Migration state file (default: per account pair in the config directory)")
+	cmd.Flags().StringSliceVar(&opts.Only, "only", migrateDatasets, "Data to migrate: "+strings.Join(migrateDatasets, ", "))
+	cmd.Flags().IntVar(&opts.DailyQuota, "daily-quota", defaultDailyQuota, "Quota units the migration may use per day")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", false, "Wait for the quota reset instead of stopping when the daily quota is used")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Export the source and show the plan without changing the target")
+
+	cobra.CheckErr(cmd.MarkFlagRequired("from"))
+	cobra.CheckErr(cmd.MarkFlagRequired("to"))
+	cobra.CheckErr(cmd.MarkFlagFilename("state", "json"))
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("only", staticCompletion(migrateDatasets...)))
+
+	return cmd
+}
+
+// stateFileName turns a profile or ID into a file name.
+func stateFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// migrationStatePath is the default state file of a migration.
+func migrationStatePath(from, to string) string {
+	return filepath.Join(getConfigDir(), "migrations", stateFileName(from)+"-to-"+stateFileName(to)+".json")
+}
+
+// quotaDay returns the day the Data API quota is counted for: quota resets
+// at midnight Pacific time.
+func quotaDay(t time.Time) (string, time.Time) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	t = t.In(loc)
+	reset := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+	return t.Format(time.DateOnly), reset
+}
+
+// migration applies a migration state to the target account.
+type migration struct {
+	state   *migrationState
+	path    string
+	opts    MigrateOptions
+	service *youtube.Service
+}
+
+func (m *migration) save() error {
+	return saveWatchState(m.path, m.state)
+}
+
+// spend accounts for quota units before a change. When the daily budget is
+// used up, it waits for the reset with --wait and stops otherwise.
+func (m *migration) spend(units int) error {
+	day, reset := quotaDay(time.Now())
+	if m.state.QuotaDay != day {
+		m.state.QuotaDay, m.state.QuotaUsed = day, 0
+	}
+	if m.state.QuotaUsed+units <= m.opts.DailyQuota {
+		m.state.QuotaUsed += units
+		return nil
+	}
+	if err := m.save(); err != nil {
+		return err
+	}
+	done, total := m.state.steps()
+	if !m.opts.Wait {
+		return withKind(ErrQuota, fmt.Errorf("daily quota of %d units used after %d of %d changes; run the same command after %s to continue",
+			m.opts.DailyQuota, done, total, reset.Local().Format("2006-01-02 15:04")))
+	}
+	infof("Daily quota used after %d of %d changes, waiting until %s", done, total, reset.Local().Format("2006-01-02 15:04"))
+	time.Sleep(time.Until(reset) + time.Minute)
+	return m.spend(units)
+}
+
+// apply runs a change. Exhausted API quota despite the budget, e.g. when
+// other applications share the project, is handled like a used up budget.
+func (m *migration) apply(change func() error) error {
+	if err := m.spend(quotaCostWrite); err != nil {
+		return err
+	}
+	err := change()
+	if err != nil && isQuotaError(err) {
+		m.state.QuotaUsed = m.opts.DailyQuota
+		if err := m.spend(quotaCostWrite); err != nil {
+			return err
+		}
+		err = change()
+	}
+	return err
+}
+
+// progress reports a change with the overall progress.
+func (m *migration) progress(format string, args ...any) {
+	done, total := m.state.steps()
+	infof("[%d/%d] %s", done, total, fmt.Sprintf(format, args...))
+}
+
+// apiErrorReason returns the reason of an API error, e.g.
+// subscriptionDuplicate.
+func apiErrorReason(err error) string {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && len(apiErr.Errors) > 0 {
+		return apiErr.Errors[0].Reason
+	}
+	return ""
+}
+
+func (m *migration) migrateSubscriptions(ctx context.Context) error {
+	existing, err := fetchSubscriptionList(ctx, m.service, nil)
+	if err != nil {
+		return err
+	}
+	following := make(map[string]bool, len(existing))
+	for _, sub := range existing {
+		following[sub.Snippet.ResourceId.ChannelId] = true
+	}
+
+	for i := range m.state.Subscriptions {
+		sub := &m.state.Subscriptions[i]
+		if sub.Done {
+			continue
+		}
+		if following[sub.ChannelID] {
+			sub.Done = true
+			m.progress("Already subscribed to %s", sub.Title)
+			continue
+		}
+		err := m.apply(func() error {
+			_, err := m.service.Subscriptions.Insert([]string{"snippet"}, &youtube.Subscription{
+				Snippet: &youtube.SubscriptionSnippet{
+					ResourceId: &youtube.ResourceId{Kind: "youtube#channel", ChannelId: sub.ChannelID},
+				},
+			}).Context(ctx).Do()
+			return err
+		})
+		switch {
+		case err == nil, apiErrorReason(err) == "subscriptionDuplicate":
+			sub.Done = true
+			m.progress("Subscribed to %s", sub.Title)
+		case classifyError(err) == ErrQuota:
+			return err
+		default:
+			// Terminated channels cannot be subscribed to; keep going
+			sub.Done, sub.Error = true, err.Error()
+			warnf("Failed to subscribe to %s: %v", sub.Title, err)
+		}
+		if err := m.save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *migration) migratePlaylists(ctx context.Context) error {
+	for i := range m.state.Playlists {
+		p := &m.state.Playlists[i]
+		if p.TargetID == "" {
+			err := m.apply(func() error {
+				playlist := &youtube.Playlist{Snippet: &youtube.PlaylistSnippet{Title: p.Title, Description: p.Description}}
+				parts := []string{"snippet"}
+				if p.Privacy != "" {
+					playlist.Status = &youtube.PlaylistStatus{PrivacyStatus: p.Privacy}
+					parts = append(parts, "status")
+				}
+				created, err := m.service.Playlists.Insert(parts, playlist).Context(ctx).Do()
+				if err == nil {
+					p.TargetID = created.Id
+				}
+				return err
+			})
+			if err != nil {
+				if classifyError(err) == ErrQuota {
+					return err
+				}
+				return fmt.Errorf("failed to create playlist %s: %w", p.Title, err)
+			}
+			m.progress("Created playlist %s", p.Title)
+			if err := m.save(); err != nil {
+				return err
+			}
+		}
+		for p.Added < len(p.Videos) {
+			videoID := p.Videos[p.Added]
+			err := m.apply(func() error {
+				_, err := m.service.PlaylistItems.Insert([]string{"snippet"}, &youtube.PlaylistItem{
+					Snippet: &youtube.PlaylistItemSnippet{
+						PlaylistId: p.TargetID,
+						ResourceId: &youtube.ResourceId{Kind: "youtube#video", VideoId: videoID},
+					},
+				}).Context(ctx).Do()
+				return err
+			})
+			if err != nil {
+				if classifyError(err) == ErrQuota {
+					return err
+				}
+				// Deleted and private videos cannot be added
+				p.Failed++
+				warnf("Failed to add %s to %s: %v", videoID, p.Title, err)
+			}
+			p.Added++
+			if err == nil {
+				m.progress("Added %s to %s", videoID, p.Title)
+			}
+			if err := m.save(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportMigration reads the data to migrate from the source account.
+func exportMigration(ctx context.Context, config Config, opts MigrateOptions) (*migrationState, error) {
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed for %s: %w", opts.From, err)
+	}
+	state := &migrationState{From: opts.From, To: opts.To, StartedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	if slices.Contains(opts.Only, "subscriptions") {
+		subscriptions, err := fetchSubscriptionList(ctx, service, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subscriptions {
+			state.Subscriptions = append(state.Subscriptions, migrationSubscription{
+				ChannelID: sub.Snippet.ResourceId.ChannelId,
+				Title:     sub.Snippet.Title,
+			})
+		}
+		infof("Found %d subscriptions of %s", len(subscriptions), opts.From)
+	}
+	if slices.Contains(opts.Only, "playlists") {
+		collector := &recordCollector{}
+		if err := (playlistsExporter{config}).Fetch(ctx, service, collector); err != nil {
+			return nil, err
+		}
+		videos := 0
+		for _, record := range collector.records {
+			playlist := migrationPlaylist{
+				SourceID:    lookupString(record, "id"),
+				Title:       lookupString(record, "snippet", "title"),
+				Description: lookupString(record, "snippet", "description"),
+				Privacy:     lookupString(record, "status", "privacyStatus"),
+				Videos:      []string{},
+			}
+			items, err := fetchPlaylistItems(ctx, service, playlist.SourceID)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				if id := playlistItemVideoID(item); id != "" {
+					playlist.Videos = append(playlist.Videos, id)
+				}
+			}
+			videos += len(playlist.Videos)
+			state.Playlists = append(state.Playlists, playlist)
+		}
+		infof("Found %d playlists with %d videos of %s", len(state.Playlists), videos, opts.From)
+	}
+	return state, nil
+}
+
+func runMigration(config Config, opts MigrateOptions) error {
+	for _, name := range opts.Only {
+		if !slices.Contains(migrateDatasets, name) {
+			return withKind(ErrInvalidConfig, fmt.Errorf("unsupported --only value %q (supported: %s)", name, strings.Join(migrateDatasets, ", ")))
+		}
+	}
+	if opts.From == opts.To {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--from and --to are the same account"))
+	}
+	if opts.DailyQuota < quotaCostWrite {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--daily-quota must be at least %d", quotaCostWrite))
+	}
+	source, err := profileConfig(config, opts.From)
+	if err != nil {
+		return err
+	}
+	target, err := profileConfig(config, opts.To)
+	if err != nil {
+		return err
+	}
+	if opts.State == "" {
+		opts.State = migrationStatePath(opts.From, opts.To)
+	}
+	ctx := context.Background()
+
+	state := &migrationState{}
+	resumed, err := readWatchState(opts.State, state)
+	if err != nil {
+		return err
+	}
+	if resumed {
+		if state.From != opts.From || state.To != opts.To {
+			return withKind(ErrInvalidConfig, fmt.Errorf("%s belongs to the migration from %s to %s", opts.State, state.From, state.To))
+		}
+		done, total := state.steps()
+		infof("Resuming migration from %s: %d of %d changes done", opts.State, done, total)
+	} else {
+		if state, err = exportMigration(ctx, source, opts); err != nil {
+			return err
+		}
+	}
+
+	done, total := state.steps()
+	units := (total - done) * quotaCostWrite
+	days := (units + opts.DailyQuota - 1) / opts.DailyQuota
+	if opts.DryRun {
+		if days > 1 {
+			infof("Would make %d changes using %d quota units, about %d days at --daily-quota %d", total-done, units, days, opts.DailyQuota)
+		} else {
+			infof("Would make %d changes using %d quota units", total-done, units)
+		}
+		return nil
+	}
+	m := &migration{state: state, path: opts.State, opts: opts}
+	if err := m.save(); err != nil {
+		return err
+	}
+	if days > 1 {
+		infof("%d changes left, about %d days at --daily-quota %d", total-done, days, opts.DailyQuota)
+	}
+
+	m.service, err = authenticateYouTube(withScopes(target, "write", youtube.YoutubeForceSslScope))
+	if err != nil {
+		return fmt.Errorf("authentication failed for %s: %w", opts.To, err)
+	}
+	err = m.migrateSubscriptions(ctx)
+	if err == nil {
+		err = m.migratePlaylists(ctx)
+	}
+	if saveErr := m.save(); err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, sub := range state.Subscriptions {
+		if sub.Error != "" {
+			failed++
+		}
+	}
+	for _, p := range state.Playlists {
+		failed += p.Failed
+	}
+	infof("Migration from %s to %s complete; state kept in %s", opts.From, opts.To, opts.State)
+	if failed > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d changes failed (see %s)", failed, opts.State))
+	}
+	return nil
+}
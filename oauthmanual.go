@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// listenOAuthCallback listens on the loopback address for the OAuth
+// redirect, falling back to the IPv6 loopback on hosts without 127.0.0.1.
+func listenOAuthCallback(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err == nil {
+		return listener, nil
+	}
+	host, port, splitErr := net.SplitHostPort(addr)
+	if splitErr != nil || host != "127.0.0.1" {
+		return nil, err
+	}
+	if listener, v6Err := net.Listen("tcp", net.JoinHostPort("::1", port)); v6Err == nil {
+		return listener, nil
+	}
+	return nil, err
+}
+
+// remoteSession reports whether ytdata runs where a browser on the user's
+// machine cannot reach its loopback callback: over SSH or in a container.
+func remoteSession() bool {
+	for _, name := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	for _, path := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// manualOAuthFlow authorizes without a callback server. The consent screen
+// still redirects to the loopback URL, which fails to load in the browser;
+// the user pastes that URL (or just its code parameter) into the terminal.
+func manualOAuthFlow(config *oauth2.Config, redirect *url.URL, state string) (*oauth2.Token, error) {
+	if !stdinIsTerminal() {
+		return nil, errors.New("cannot receive the OAuth callback and stdin is not a terminal; authorize once in an interactive session and copy the credentials file")
+	}
+	flowConfig := *config
+	flowConfig.RedirectURL = redirect.String()
+
+	authURL := flowConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	fmt.Println("Open this URL in a browser on any machine and grant access:")
+	fmt.Printf("\n  %s\n\n", authURL)
+	fmt.Printf("The browser is then sent to %s, which will fail to load.\n", redirect.Redacted())
+	fmt.Println("Copy the full URL from the address bar and paste it here.")
+
+	input := promptUser("Redirect URL or code: ")
+	if input == "" {
+		return nil, errors.New("no authorization code entered")
+	}
+	code, err := pastedAuthCode(input, state)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := flowConfig.Exchange(context.Background(), code)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve token from web: %w", err)
+	}
+	return token, nil
+}
+
+// pastedAuthCode returns the authorization code of a pasted redirect URL,
+// checking its state, or the input itself when it is a bare code.
+func pastedAuthCode(input, state string) (string, error) {
+	if !strings.Contains(input, "code=") && !strings.Contains(input, "error=") {
+		return input, nil
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect URL: %w", err)
+	}
+	query := u.Query()
+	if u.RawQuery == "" {
+		// Only the query string was copied
+		if query, err = url.ParseQuery(strings.TrimPrefix(input, "?")); err != nil {
+			return "", fmt.Errorf("invalid redirect URL: %w", err)
+		}
+	}
+	if reason := query.Get("error"); reason != "" {
+		return "", fmt.Errorf("authorization failed: %s", reason)
+	}
+	if query.Get("state") != state {
+		return "", errors.New("the pasted URL does not belong to this authorization request (state mismatch)")
+	}
+	code := query.Get("code")
+	if code == "" {
+		return "", errors.New("no authorization code in the pasted URL")
+	}
+	return code, nil
+}
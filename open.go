@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type OpenOptions struct {
+	From  string
+	Index int
+	Print bool
+}
+
+func newOpenCmd() *cobra.Command {
+	var opts OpenOptions
+
+	cmd := &cobra.Command{
+		Use:   "open [video-id|channel-id|playlist-id|@handle]",
+		Short: "Open a video, channel or playlist in the browser",
+		Long: `Open a video, channel or playlist on YouTube in the default browser.
+
+The kind of ID is detected from its shape: channel IDs start with UC,
+playlist IDs with PL, UU, LL, FL, OL or RD, and handles with @. With --from,
+the entry at --index (1 = first record) of a JSONL export is opened.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		Example: `  ytdata open dQw4w9WgXcQ
+  ytdata open UC_x5XG1OV2P6uZZ5FSM9Ttw
+  ytdata open --from liked.jsonl --index 3
+  ytdata open --from playlists.jsonl --index 1 --print`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOpen(args, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.From, "from", "", "JSONL export to open an entry from")
+	cmd.Flags().IntVar(&opts.Index, "index", 1, "Entry of the export to open (1 = first record)")
+	cmd.Flags().BoolVar(&opts.Print, "print", false, "Print the URL instead of opening it")
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("from", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	return cmd
+}
+
+func playlistURL(id string) string {
+	return "https://www.youtube.com/playlist?list=" + id
+}
+
+// urlForID builds the YouTube URL for a bare video, channel or playlist ID.
+func urlForID(id string) string {
+	switch {
+	case strings.HasPrefix(id, "@"):
+		return "https://www.youtube.com/" + id
+	case strings.HasPrefix(id, "UC") && len(id) == 24:
+		return channelURL(id)
+	case len(id) > 11 && hasPlaylistPrefix(id):
+		return playlistURL(id)
+	default:
+		return videoURL(id)
+	}
+}
+
+func hasPlaylistPrefix(id string) bool {
+	for _, prefix := range []string{"PL", "UU", "LL", "FL", "OL", "RD"} {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlForRecord builds the YouTube URL for an exported resource.
+func urlForRecord(record map[string]any) (string, error) {
+	switch lookupString(record, "kind") {
+	case "youtube#channel":
+		return channelURL(lookupString(record, "id")), nil
+	case "youtube#playlist":
+		return playlistURL(lookupString(record, "id")), nil
+	case "youtube#subscription":
+		return channelURL(lookupString(record, "snippet", "resourceId", "channelId")), nil
+	}
+	if id := recordVideoID(record); id != "" {
+		return videoURL(id), nil
+	}
+	return "", fmt.Errorf("cannot determine a YouTube URL for this record")
+}
+
+func runOpen(args []string, opts OpenOptions) error {
+	var url string
+	switch {
+	case opts.From != "" && len(args) > 0:
+		return fmt.Errorf("pass either an ID or --from, not both")
+	case opts.From != "":
+		records, err := readJSONLRecords(opts.From)
+		if err != nil {
+			return err
+		}
+		if opts.Index < 1 || opts.Index > len(records) {
+			return fmt.Errorf("index %d out of range (export has %d records)", opts.Index, len(records))
+		}
+		if url, err = urlForRecord(records[opts.Index-1]); err != nil {
+			return err
+		}
+	case len(args) == 1 && strings.Contains(args[0], "/"):
+		ref, err := parseYouTubeRef(args[0])
+		if err != nil {
+			return err
+		}
+		if ref.Kind == refUser {
+			url = "https://www.youtube.com/user/" + ref.ID
+		} else {
+			url = urlForID(ref.ID)
+		}
+	case len(args) == 1:
+		url = urlForID(args[0])
+	default:
+		return fmt.Errorf("an ID or --from is required")
+	}
+
+	if opts.Print {
+		fmt.Println(url)
+		return nil
+	}
+	if err := openBrowser(url); err != nil {
+		fmt.Printf("Go to: %s\n", url)
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// indexMagic starts an append index file; the version is part of it.
+const indexMagic = "YTIDX001"
+
+// indexHeaderSize is the magic followed by the covered size of the output.
+const indexHeaderSize = len(indexMagic) + 8
+
+// Append adds JSONL records to the end of an existing file and skips records
+// the file already holds, so incremental exports can be appended to one
+// file. Records are identified by kind and id, see recordKey.
+//
+// The IDs of the file are kept in an index next to it (path + ".idx"): a
+// header with the size of the file the index covers, followed by sorted
+// 8-byte hashes that are binary searched on disk, so neither the file nor
+// the index is loaded into memory. The index is updated on Close. When a
+// run is interrupted, the next run reads the IDs of the records written
+// after the covered size and drops an incomplete last line.
+type Append struct {
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	encoder *json.Encoder
+	index   *os.File
+	entries int64
+	added   map[uint64]bool
+	skipped int
+}
+
+// IndexPath returns the path of the append index of path.
+func IndexPath(path string) string {
+	return path + ".idx"
+}
+
+// NewAppend opens path for appending, creating it if needed.
+func NewAppend(path string) (*Append, error) {
+	if path == "" || path == "-" {
+		return nil, errors.New("appending needs an output file (-o)")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	a := &Append{path: path, f: f, added: make(map[uint64]bool)}
+	if err := a.load(); err != nil {
+		_ = a.close()
+		return nil, err
+	}
+	a.w = bufio.NewWriter(f)
+	a.encoder = json.NewEncoder(a.w)
+	return a, nil
+}
+
+// load repairs the end of the file, opens the index and reads the IDs the
+// index does not cover yet.
+func (a *Append) load() error {
+	size, err := a.trimIncomplete()
+	if err != nil {
+		return err
+	}
+
+	covered := int64(0)
+	index, err := os.Open(IndexPath(a.path))
+	switch {
+	case err == nil:
+		header := make([]byte, indexHeaderSize)
+		info, statErr := index.Stat()
+		_, readErr := index.ReadAt(header, 0)
+		if statErr != nil || readErr != nil || string(header[:len(indexMagic)]) != indexMagic ||
+			(info.Size()-int64(indexHeaderSize))%8 != 0 {
+			// Unusable index: rebuild it from the file
+			_ = index.Close()
+			break
+		}
+		covered = int64(binary.BigEndian.Uint64(header[len(indexMagic):]))
+		if covered > size {
+			// The file was replaced or truncated since
+			_ = index.Close()
+			covered = 0
+			break
+		}
+		a.index = index
+		a.entries = (info.Size() - int64(indexHeaderSize)) / 8
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+
+	if _, err := a.f.Seek(covered, io.SeekStart); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(a.f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("%s is not a JSONL file: %w", a.path, err)
+		}
+		key, err := recordKey(record)
+		if err != nil {
+			return fmt.Errorf("%s: %w", a.path, err)
+		}
+		if !a.indexed(key) {
+			a.added[key] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", a.path, err)
+	}
+	_, err = a.f.Seek(0, io.SeekEnd)
+	return err
+}
+
+// trimIncomplete truncates the file after its last newline, dropping a
+// record cut off by an interrupted run, and returns the resulting size.
+func (a *Append) trimIncomplete() (int64, error) {
+	info, err := a.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	end := size
+	buf := make([]byte, 4096)
+	for end > 0 {
+		n := int64(len(buf))
+		if end < n {
+			n = end
+		}
+		if _, err := a.f.ReadAt(buf[:n], end-n); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = end - n + int64(i) + 1
+			break
+		}
+		end -= n
+	}
+	if end == size {
+		return size, nil
+	}
+	if err := a.f.Truncate(end); err != nil {
+		return 0, fmt.Errorf("failed to remove incomplete record: %w", err)
+	}
+	Warnf("Removed an incomplete record at the end of %s", a.path)
+	return end, nil
+}
+
+// identityFields identify records without an id, e.g. derived records
+// describing a video or channel, in order of preference.
+var identityFields = []string{"snippet.resourceId", "videoId", "channelId", "playlistId"}
+
+// recordKey hashes the kind and id of a record. Records without an id are
+// identified by their first identityFields value; their content changes
+// between runs, so records with neither cannot be appended.
+func recordKey(record map[string]any) (uint64, error) {
+	kind, _ := record["kind"].(string)
+	identity := record["id"]
+	for _, field := range identityFields {
+		if identity != nil {
+			break
+		}
+		identity = lookupPath(record, field)
+	}
+	if identity == nil {
+		return 0, errors.New("cannot append a record without an id")
+	}
+	id, ok := identity.(string)
+	if !ok {
+		data, err := json.Marshal(identity)
+		if err != nil {
+			return 0, err
+		}
+		id = string(data)
+	}
+	sum := sha256.Sum256([]byte(kind + "\x00" + id))
+	return binary.BigEndian.Uint64(sum[:8]), nil
+}
+
+// indexed binary searches the index for key.
+func (a *Append) indexed(key uint64) bool {
+	if a.index == nil {
+		return false
+	}
+	buf := make([]byte, 8)
+	lo, hi := int64(0), a.entries
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if _, err := a.index.ReadAt(buf, int64(indexHeaderSize)+mid*8); err != nil {
+			return false
+		}
+		switch v := binary.BigEndian.Uint64(buf); {
+		case v == key:
+			return true
+		case v < key:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false
+}
+
+func (a *Append) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	key, err := recordKey(m)
+	if err != nil {
+		return err
+	}
+	if a.added[key] || a.indexed(key) {
+		a.skipped++
+		return nil
+	}
+	if err := a.encoder.Encode(record); err != nil {
+		return err
+	}
+	a.added[key] = true
+	return nil
+}
+
+// Skipped returns the number of records not written as duplicates.
+func (a *Append) Skipped() int {
+	return a.skipped
+}
+
+func (a *Append) Close() error {
+	if err := a.w.Flush(); err != nil {
+		_ = a.close()
+		return err
+	}
+	info, err := a.f.Stat()
+	if err != nil {
+		_ = a.close()
+		return err
+	}
+	if err := a.writeIndex(info.Size()); err != nil {
+		_ = a.close()
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	return a.close()
+}
+
+// writeIndex merges the new keys into the index, covering size bytes of the
+// file, and replaces the index atomically.
+func (a *Append) writeIndex(size int64) error {
+	keys := make([]uint64, 0, len(a.added))
+	for key := range a.added {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	tmp, err := os.CreateTemp(filepath.Dir(a.path), ".ytdata-idx-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	w := bufio.NewWriter(tmp)
+	header := make([]byte, indexHeaderSize)
+	copy(header, indexMagic)
+	binary.BigEndian.PutUint64(header[len(indexMagic):], uint64(size))
+	if _, err := w.Write(header); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	var existing *bufio.Reader
+	if a.index != nil {
+		existing = bufio.NewReader(io.NewSectionReader(a.index, int64(indexHeaderSize), a.entries*8))
+	}
+	next := func() (uint64, bool) {
+		if existing == nil {
+			return 0, false
+		}
+		buf := make([]byte, 8)
+		if _, err := io.ReadFull(existing, buf); err != nil {
+			return 0, false
+		}
+		return binary.BigEndian.Uint64(buf), true
+	}
+	buf := make([]byte, 8)
+	put := func(key uint64) error {
+		binary.BigEndian.PutUint64(buf, key)
+		_, err := w.Write(buf)
+		return err
+	}
+	old, ok := next()
+	for _, key := range keys {
+		for ok && old < key {
+			if err := put(old); err != nil {
+				_ = tmp.Close()
+				return err
+			}
+			old, ok = next()
+		}
+		if ok && old == key {
+			continue
+		}
+		if err := put(key); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+	for ok {
+		if err := put(old); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		old, ok = next()
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Windows cannot replace a file that is still open
+	if a.index != nil {
+		_ = a.index.Close()
+		a.index = nil
+	}
+	return os.Rename(tmp.Name(), IndexPath(a.path))
+}
+
+func (a *Append) close() error {
+	if a.index != nil {
+		_ = a.index.Close()
+	}
+	return a.f.Close()
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendSkipsKnownRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	appendRecords := func(records ...map[string]any) (int, error) {
+		a, err := NewAppend(path)
+		if err != nil {
+			return 0, err
+		}
+		for _, record := range records {
+			if err := a.Write(record); err != nil {
+				_ = a.Close()
+				return 0, err
+			}
+		}
+		return a.Skipped(), a.Close()
+	}
+
+	video := map[string]any{"kind": "youtube#video", "id": "a"}
+	derived := func(exportedAt string) map[string]any {
+		return map[string]any{"videoId": "a", "views": 1, "_exportedAt": exportedAt}
+	}
+	if _, err := appendRecords(video, derived("2026-01-01")); err != nil {
+		t.Fatal(err)
+	}
+	skipped, err := appendRecords(video, derived("2026-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 2 {
+		t.Errorf("skipped %d records, want 2", skipped)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("file has %d records, want 2:\n%s", lines, data)
+	}
+
+	if _, err := appendRecords(map[string]any{"views": 1}); err == nil {
+		t.Error("a record without an id was appended")
+	}
+}
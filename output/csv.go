@@ -0,0 +1,136 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+func init() {
+	Register("csv", func(opts Options) (Writer, error) {
+		return NewCSV(opts)
+	})
+}
+
+// CSV writes records as comma-separated values. Nested objects are
+// flattened into dotted column names and arrays are stored as JSON. Since
+// the columns are the union over all records, rows are written on Close.
+type CSV struct {
+	w    io.WriteCloser
+	rows []map[string]string
+}
+
+// NewCSV creates a CSV writer for opts.Path, or stdout when it is empty.
+func NewCSV(opts Options) (*CSV, error) {
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &CSV{w: w}, nil
+}
+
+func (c *CSV) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	row := make(map[string]string)
+	flatten("", m, row)
+	c.rows = append(c.rows, row)
+	return nil
+}
+
+func (c *CSV) Close() error {
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range c.rows {
+		for column := range row {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return columnRank(columns[i]) < columnRank(columns[j]) ||
+			columnRank(columns[i]) == columnRank(columns[j]) && columns[i] < columns[j]
+	})
+
+	if len(columns) == 0 {
+		return c.w.Close()
+	}
+
+	writer := csv.NewWriter(c.w)
+	if err := writer.Write(columns); err != nil {
+		_ = c.w.Close()
+		return err
+	}
+	fields := make([]string, len(columns))
+	for _, row := range c.rows {
+		for i, column := range columns {
+			fields[i] = row[column]
+		}
+		if err := writer.Write(fields); err != nil {
+			_ = c.w.Close()
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		_ = c.w.Close()
+		return err
+	}
+	return c.w.Close()
+}
+
+// columnRank keeps identifying columns first.
+func columnRank(column string) int {
+	switch column {
+	case "id":
+		return 0
+	case "kind":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func flatten(prefix string, value any, row map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flatten(name, nested, row)
+		}
+	case []any:
+		data, _ := json.Marshal(v)
+		row[prefix] = string(data)
+	case nil:
+		row[prefix] = ""
+	case string:
+		row[prefix] = v
+	default:
+		row[prefix] = fmt.Sprint(v)
+	}
+}
+
+// ToMap converts a record into a generic JSON object.
+func ToMap(record any) (map[string]any, error) {
+	if m, ok := record.(map[string]any); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
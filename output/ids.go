@@ -0,0 +1,44 @@
+package output
+
+import (
+	"fmt"
+	"io"
+)
+
+func init() {
+	Register("ids", func(opts Options) (Writer, error) {
+		return NewIDs(opts)
+	})
+}
+
+// IDs writes the id field of each record on its own line. Records without
+// an id are skipped.
+type IDs struct {
+	w io.WriteCloser
+}
+
+// NewIDs creates an ID list writer for opts.Path, or stdout when it is empty.
+func NewIDs(opts Options) (*IDs, error) {
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &IDs{w: w}, nil
+}
+
+func (l *IDs) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	id, ok := m["id"].(string)
+	if !ok || id == "" {
+		return nil
+	}
+	_, err = fmt.Fprintln(l.w, id)
+	return err
+}
+
+func (l *IDs) Close() error {
+	return l.w.Close()
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func init() {
+	Register("json", func(opts Options) (Writer, error) {
+		return NewJSON(opts)
+	})
+}
+
+// JSON writes all records as one JSON array, one record per line, for tools
+// that cannot read JSONL.
+type JSON struct {
+	w       io.WriteCloser
+	written int
+}
+
+// NewJSON creates a JSON array writer for opts.Path, or stdout when it is
+// empty.
+func NewJSON(opts Options) (*JSON, error) {
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &JSON{w: w}, nil
+}
+
+func (j *JSON) Write(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if j.written == 0 {
+		separator = "[\n"
+	}
+	if _, err := io.WriteString(j.w, separator); err != nil {
+		return err
+	}
+	if _, err := j.w.Write(data); err != nil {
+		return err
+	}
+	j.written++
+	return nil
+}
+
+func (j *JSON) Close() error {
+	closing := "\n]\n"
+	if j.written == 0 {
+		closing = "[]\n"
+	}
+	if _, err := io.WriteString(j.w, closing); err != nil {
+		_ = j.w.Close()
+		return err
+	}
+	return j.w.Close()
+}
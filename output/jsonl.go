@@ -0,0 +1,35 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func init() {
+	Register("jsonl", func(opts Options) (Writer, error) {
+		return NewJSONL(opts)
+	})
+}
+
+// JSONL writes one JSON object per line.
+type JSONL struct {
+	w       io.WriteCloser
+	encoder *json.Encoder
+}
+
+// NewJSONL creates a JSONL writer for opts.Path, or stdout when it is empty.
+func NewJSONL(opts Options) (*JSONL, error) {
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &JSONL{w: w, encoder: json.NewEncoder(w)}, nil
+}
+
+func (j *JSONL) Write(record any) error {
+	return j.encoder.Encode(record)
+}
+
+func (j *JSONL) Close() error {
+	return j.w.Close()
+}
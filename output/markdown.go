@@ -0,0 +1,197 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+func init() {
+	Register("markdown", func(opts Options) (Writer, error) {
+		m, err := NewMarkdown(opts.Dir)
+		if err != nil {
+			return nil, err
+		}
+		m.written = opts.Written
+		return m, nil
+	})
+}
+
+// Markdown renders playlists and their items as Markdown pages, one file
+// per playlist in a directory plus an index.md linking them, e.g. for
+// publishing a "my playlists" page. Items are matched to their playlist by
+// snippet.playlistId, or follow their playlist, and are listed in the order
+// they are written, with their titles linking to the video. Pages are written on Close.
+type Markdown struct {
+	dir       string
+	playlists map[string]*markdownPlaylist
+	order     []string
+	written   func(path string)
+}
+
+type markdownPlaylist struct {
+	id          string
+	title       string
+	description string
+	items       []markdownItem
+}
+
+type markdownItem struct {
+	videoID string
+	title   string
+	channel string
+}
+
+// NewMarkdown creates a Markdown writer for the pages in dir.
+func NewMarkdown(dir string) (*Markdown, error) {
+	if dir == "" {
+		return nil, errors.New("markdown output is written to a directory; set --output-dir")
+	}
+	return &Markdown{dir: dir, playlists: make(map[string]*markdownPlaylist)}, nil
+}
+
+func (m *Markdown) playlist(id string) *markdownPlaylist {
+	p, ok := m.playlists[id]
+	if !ok {
+		p = &markdownPlaylist{id: id}
+		m.playlists[id] = p
+		m.order = append(m.order, id)
+	}
+	return p
+}
+
+func (m *Markdown) Write(record any) error {
+	r, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	snippet, _ := r["snippet"].(map[string]any)
+	str := func(key string) string {
+		s, _ := snippet[key].(string)
+		return s
+	}
+	switch r["kind"] {
+	case "youtube#playlist":
+		id, _ := r["id"].(string)
+		p := m.playlist(id)
+		p.title, p.description = str("title"), str("description")
+	case "youtube#playlistItem":
+		id := str("playlistId")
+		if id == "" && len(m.order) > 0 {
+			id = m.order[len(m.order)-1]
+		}
+		p := m.playlist(id)
+		item := markdownItem{title: str("title"), channel: str("videoOwnerChannelTitle")}
+		if details, ok := r["contentDetails"].(map[string]any); ok {
+			item.videoID, _ = details["videoId"].(string)
+		}
+		p.items = append(p.items, item)
+	}
+	return nil
+}
+
+func (m *Markdown) Close() error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var index strings.Builder
+	index.WriteString("# Playlists\n\n")
+	used := map[string]bool{"index.md": true}
+	for _, id := range m.order {
+		p := m.playlists[id]
+		title := p.title
+		if title == "" {
+			title = p.id
+		}
+		name := markdownFileName(title)
+		if used[name] {
+			name = markdownFileName(title + "-" + p.id)
+		}
+		used[name] = true
+
+		if err := m.writeFile(name, p.render(title)); err != nil {
+			return err
+		}
+		fmt.Fprintf(&index, "- [%s](%s) (%s)\n", MarkdownEscape(title), name, videoCount(len(p.items)))
+	}
+	return m.writeFile("index.md", index.String())
+}
+
+func (m *Markdown) writeFile(name, content string) error {
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if m.written != nil {
+		m.written(path)
+	}
+	return nil
+}
+
+func (p *markdownPlaylist) render(title string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", MarkdownEscape(title))
+	if description := strings.TrimSpace(p.description); description != "" {
+		b.WriteString(description + "\n\n")
+	}
+	fmt.Fprintf(&b, "[Open on YouTube](https://www.youtube.com/playlist?list=%s) · %s\n", p.id, videoCount(len(p.items)))
+	if len(p.items) > 0 {
+		b.WriteString("\n")
+	}
+	for i, item := range p.items {
+		text := MarkdownEscape(item.title)
+		if item.videoID != "" {
+			text = fmt.Sprintf("[%s](https://www.youtube.com/watch?v=%s&list=%s)", text, item.videoID, p.id)
+		}
+		if item.channel != "" {
+			text += " — " + MarkdownEscape(item.channel)
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, text)
+	}
+	return b.String()
+}
+
+func videoCount(n int) string {
+	if n == 1 {
+		return "1 video"
+	}
+	return fmt.Sprintf("%d videos", n)
+}
+
+// markdownEscaper escapes the characters that would start Markdown syntax
+// inside titles.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// MarkdownEscape escapes text for Markdown and joins its lines.
+func MarkdownEscape(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
+
+// markdownFileName derives a file name from a title: lowercase letters and
+// digits, joined by dashes.
+func markdownFileName(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "playlist.md"
+	}
+	return b.String() + ".md"
+}
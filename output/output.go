@@ -0,0 +1,159 @@
+// Package output provides the record sinks ytdata exports are written to.
+//
+// Sinks implement Writer and are registered by name, so a new format only
+// needs a Factory registered with Register to be usable by every export.
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Writer writes exported records to a sink. Records are API resources or
+// generic maps; Close flushes buffered data and must always be called.
+type Writer interface {
+	Write(record any) error
+	Close() error
+}
+
+// Options configures a Writer created by New.
+type Options struct {
+	// Path is the destination file, or empty for stdout.
+	Path string
+	// Dataset names the exported data, e.g. "liked" or "subscriptions".
+	Dataset string
+	// Dir is the destination directory of formats written as several
+	// files (markdown).
+	Dir string
+	// Template is the text/template of the template format.
+	Template string
+	// Wrap, if set, wraps the file or stdout the records are written to,
+	// e.g. to encrypt the stream. Closing the wrapper must flush it but not
+	// close the underlying writer.
+	Wrap func(io.Writer) (io.WriteCloser, error)
+	// Written, if set, is called with every file a directory format
+	// writes, e.g. to list them in a manifest.
+	Written func(path string)
+}
+
+// Warnf reports a problem a writer recovered from, e.g. a truncated record.
+// ytdata sends it to its log.
+var Warnf = func(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+}
+
+// Factory creates a Writer for the given options.
+type Factory func(opts Options) (Writer, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a Writer available by name. It panics if the name is
+// already registered.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("output: writer %q registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// New creates the Writer registered under name. With opts.Wrap set, it
+// fails unless the writer applied it, so a format that cannot be wrapped
+// never writes e.g. unencrypted output instead.
+func New(name string, opts Options) (Writer, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", name)
+	}
+	if opts.Wrap == nil {
+		return factory(opts)
+	}
+	wrap, wrapped := opts.Wrap, false
+	opts.Wrap = func(w io.Writer) (io.WriteCloser, error) {
+		wrapped = true
+		return wrap(w)
+	}
+	w, err := factory(opts)
+	if err != nil {
+		return nil, err
+	}
+	if !wrapped {
+		_ = w.Close()
+		return nil, fmt.Errorf("the %s format does not support wrapping its output", name)
+	}
+	return w, nil
+}
+
+// Names returns the names of all registered writers, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens path for writing, or returns stdout when path is empty. An
+// s3:// path is uploaded to S3 on Close. The returned closer never closes
+// stdout.
+func Open(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	if IsS3(path) {
+		return openS3(path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return f, nil
+}
+
+// Open opens opts.Path like Open, wrapped with opts.Wrap.
+func (o Options) Open() (io.WriteCloser, error) {
+	w, err := Open(o.Path)
+	if err != nil || o.Wrap == nil {
+		return w, err
+	}
+	wrapped, err := o.Wrap(w)
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	return wrapCloser{wrapped, w}, nil
+}
+
+// wrapCloser closes the wrapper, then the writer it wraps.
+type wrapCloser struct {
+	io.WriteCloser
+	under io.Closer
+}
+
+func (w wrapCloser) Close() error {
+	err := w.WriteCloser.Close()
+	if closeErr := w.under.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
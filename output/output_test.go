@@ -0,0 +1,28 @@
+package output
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewRefusesUnwrappedOutput(t *testing.T) {
+	Register("test-unwrapped", func(opts Options) (Writer, error) {
+		return NewJSONL(Options{Path: opts.Path})
+	})
+	wrap := func(w io.Writer) (io.WriteCloser, error) {
+		return nopCloser{w}, nil
+	}
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+
+	if _, err := New("test-unwrapped", Options{Path: path, Wrap: wrap}); err == nil {
+		t.Error("a writer ignoring Wrap was created")
+	}
+	w, err := New("jsonl", Options{Path: path, Wrap: wrap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,307 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+func init() {
+	Register("parquet", func(opts Options) (Writer, error) {
+		return NewParquet(opts)
+	})
+}
+
+// Parquet writes records as an Apache Parquet file. Records are flattened
+// like CSV rows and every column is an optional UTF-8 string, so the file
+// loads into DuckDB, pandas or a warehouse without a schema. Like CSV, the
+// columns are the union over all records and the file is written on Close
+// as a single uncompressed row group.
+type Parquet struct {
+	w    io.WriteCloser
+	rows []map[string]string
+}
+
+// Values of the Parquet format specification used by the writer.
+const (
+	parquetMagic         = "PAR1"
+	parquetVersion       = 1
+	parquetByteArray     = 6
+	parquetOptional      = 1
+	parquetUTF8          = 0
+	parquetPlain         = 0
+	parquetRLE           = 3
+	parquetUncompressed  = 0
+	parquetDataPage      = 0
+	parquetCreatedBy     = "ytdata"
+	parquetShortListSize = 14
+)
+
+// Type IDs of the Thrift compact protocol.
+const (
+	thriftStop   = 0
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// NewParquet creates a Parquet writer for opts.Path, or stdout when it is
+// empty.
+func NewParquet(opts Options) (*Parquet, error) {
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &Parquet{w: w}, nil
+}
+
+func (p *Parquet) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	row := make(map[string]string)
+	flatten("", m, row)
+	p.rows = append(p.rows, row)
+	return nil
+}
+
+func (p *Parquet) Close() error {
+	data, err := p.encode()
+	if err != nil {
+		_ = p.w.Close()
+		return err
+	}
+	if _, err := p.w.Write(data); err != nil {
+		_ = p.w.Close()
+		return err
+	}
+	return p.w.Close()
+}
+
+// parquetColumn is the metadata of a written column chunk.
+type parquetColumn struct {
+	name   string
+	offset int64
+	size   int64
+	values int64
+}
+
+func (p *Parquet) encode() ([]byte, error) {
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range p.rows {
+		for column := range row {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return columnRank(columns[i]) < columnRank(columns[j]) ||
+			columnRank(columns[i]) == columnRank(columns[j]) && columns[i] < columns[j]
+	})
+
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+	chunks := make([]parquetColumn, 0, len(columns))
+	for _, column := range columns {
+		page := p.encodePage(column)
+		var header thriftWriter
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(len(p.rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetColumn{name: column, offset: int64(file.Len()), values: int64(len(p.rows))}
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+		chunk.size = int64(file.Len()) - chunk.offset
+		chunks = append(chunks, chunk)
+	}
+
+	footer := p.encodeFooter(chunks)
+	file.Write(footer)
+	if err := binary.Write(&file, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return nil, err
+	}
+	file.WriteString(parquetMagic)
+	return file.Bytes(), nil
+}
+
+// encodePage encodes a data page holding one column of all rows: the
+// definition levels (1 for a value, 0 for null) as RLE runs, then the
+// values in plain encoding.
+func (p *Parquet) encodePage(column string) []byte {
+	var levels bytes.Buffer
+	for i := 0; i < len(p.rows); {
+		_, defined := p.rows[i][column]
+		run := 1
+		for i+run < len(p.rows) {
+			if _, ok := p.rows[i+run][column]; ok != defined {
+				break
+			}
+			run++
+		}
+		levels.Write(binary.AppendUvarint(nil, uint64(run)<<1))
+		if defined {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		i += run
+	}
+
+	var page bytes.Buffer
+	_ = binary.Write(&page, binary.LittleEndian, uint32(levels.Len()))
+	page.Write(levels.Bytes())
+	for _, row := range p.rows {
+		if value, ok := row[column]; ok {
+			_ = binary.Write(&page, binary.LittleEndian, uint32(len(value)))
+			page.WriteString(value)
+		}
+	}
+	return page.Bytes()
+}
+
+func (p *Parquet) encodeFooter(chunks []parquetColumn) []byte {
+	var meta thriftWriter
+	meta.i32(1, parquetVersion)
+
+	meta.beginList(2, thriftStruct, len(chunks)+1)
+	meta.beginElement()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(chunks)))
+	meta.endStruct()
+	for _, chunk := range chunks {
+		meta.beginElement()
+		meta.i32(1, parquetByteArray)
+		meta.i32(3, parquetOptional)
+		meta.binary(4, chunk.name)
+		meta.i32(6, parquetUTF8)
+		meta.endStruct()
+	}
+
+	meta.i64(3, int64(len(p.rows)))
+
+	var total int64
+	for _, chunk := range chunks {
+		total += chunk.size
+	}
+	meta.beginList(4, thriftStruct, 1)
+	meta.beginElement()
+	meta.beginList(1, thriftStruct, len(chunks))
+	for _, chunk := range chunks {
+		meta.beginElement()
+		meta.i64(2, chunk.offset)
+		meta.beginStruct(3)
+		meta.i32(1, parquetByteArray)
+		meta.beginList(2, thriftI32, 2)
+		meta.listI32(parquetPlain)
+		meta.listI32(parquetRLE)
+		meta.beginList(3, thriftBinary, 1)
+		meta.listBinary(chunk.name)
+		meta.i32(4, parquetUncompressed)
+		meta.i64(5, chunk.values)
+		meta.i64(6, chunk.size)
+		meta.i64(7, chunk.size)
+		meta.i64(9, chunk.offset)
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(p.rows)))
+	meta.endStruct()
+
+	meta.binary(6, parquetCreatedBy)
+	meta.stop()
+	return meta.buf.Bytes()
+}
+
+// thriftWriter encodes the Thrift compact protocol Parquet metadata uses.
+// It tracks the last field ID per open struct, as field headers store the
+// delta to the previous field.
+type thriftWriter struct {
+	buf    bytes.Buffer
+	last   int16
+	parent []int16
+}
+
+func (t *thriftWriter) field(id int16, kind byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		t.buf.WriteByte(kind)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64((v<<1)^(v>>63))))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.listBinary(v)
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+// beginElement starts a struct inside a list, which has no field header.
+func (t *thriftWriter) beginElement() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.parent[len(t.parent)-1]
+	t.parent = t.parent[:len(t.parent)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(thriftStop)
+}
+
+func (t *thriftWriter) beginList(id int16, kind byte, size int) {
+	t.field(id, thriftList)
+	if size <= parquetShortListSize {
+		t.buf.WriteByte(byte(size)<<4 | kind)
+		return
+	}
+	t.buf.WriteByte(0xf0 | kind)
+	t.buf.Write(binary.AppendUvarint(nil, uint64(size)))
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) listBinary(v string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(v))))
+	t.buf.WriteString(v)
+}
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "videos.parquet")
+	w, err := NewParquet(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []map[string]any{
+		{"id": "a", "snippet": map[string]any{"title": "First", "tags": []any{"go", "cli"}}},
+		{"id": "b", "statistics": map[string]any{"viewCount": "42"}},
+		{"id": "c", "snippet": map[string]any{"title": "Grüße, \"quoted\""}},
+	}
+	// More columns than fit the short list header of the metadata
+	wide := map[string]any{"id": "d"}
+	for i := range 20 {
+		wide[fmt.Sprintf("field%02d", i)] = fmt.Sprint(i)
+	}
+	records = append(records, wide)
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(f, stat.Size())
+	if err != nil {
+		t.Fatalf("not a valid Parquet file: %v", err)
+	}
+	if file.NumRows() != int64(len(records)) {
+		t.Fatalf("%d rows, want %d", file.NumRows(), len(records))
+	}
+
+	columns := file.Schema().Fields()
+	var got []map[string]string
+	for _, group := range file.RowGroups() {
+		rows := group.Rows()
+		buf := make([]parquet.Row, group.NumRows())
+		n, err := rows.ReadRows(buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatalf("failed to read rows: %v", err)
+		}
+		_ = rows.Close()
+		for _, row := range buf[:n] {
+			values := make(map[string]string)
+			for _, value := range row {
+				if !value.IsNull() {
+					values[columns[value.Column()].Name()] = value.String()
+				}
+			}
+			got = append(got, values)
+		}
+	}
+
+	if len(got) != len(records) {
+		t.Fatalf("read %d rows, want %d", len(got), len(records))
+	}
+	for i, record := range records {
+		want := make(map[string]string)
+		flatten("", record, want)
+		if !maps.Equal(got[i], want) {
+			t.Errorf("row %d = %v, want %v", i, got[i], want)
+		}
+	}
+}
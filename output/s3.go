@@ -0,0 +1,258 @@
+package output
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Scheme prefixes output paths that name an S3 object, e.g.
+// s3://bucket/exports/liked.jsonl.
+const s3Scheme = "s3://"
+
+// IsS3 reports whether path names an S3 object.
+func IsS3(path string) bool {
+	return strings.HasPrefix(path, s3Scheme)
+}
+
+// s3Object is an object in an S3 bucket and how to reach it. Credentials,
+// region and endpoint are read from the standard AWS environment variables:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION
+// (or AWS_DEFAULT_REGION, default us-east-1) and, for S3-compatible stores,
+// AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL).
+type s3Object struct {
+	bucket       string
+	key          string
+	region       string
+	endpoint     string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func parseS3Object(path string) (s3Object, error) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(path, s3Scheme), "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return s3Object{}, fmt.Errorf("%s is no S3 object: use s3://BUCKET/KEY", path)
+	}
+	o := s3Object{
+		bucket:       bucket,
+		key:          key,
+		region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		endpoint:     strings.TrimSuffix(firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"), "/"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if o.region == "" {
+		o.region = "us-east-1"
+	}
+	if o.accessKey == "" || o.secretKey == "" {
+		return s3Object{}, errors.New("writing to S3 needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return o, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// url returns the object URL: virtual-hosted style on AWS, path style on a
+// custom endpoint.
+func (o s3Object) url() string {
+	if o.endpoint != "" {
+		return o.endpoint + "/" + o.bucket + "/" + s3EscapePath(o.key)
+	}
+	return "https://" + o.bucket + ".s3." + o.region + ".amazonaws.com/" + s3EscapePath(o.key)
+}
+
+// s3Upload buffers the output in a temporary file and uploads it as one
+// object on Close, since S3 objects cannot be appended to.
+type s3Upload struct {
+	object s3Object
+	f      *os.File
+}
+
+func openS3(path string) (io.WriteCloser, error) {
+	object, err := parseS3Object(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp("", "ytdata-s3-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload buffer: %w", err)
+	}
+	return &s3Upload{object: object, f: f}, nil
+}
+
+func (u *s3Upload) Write(p []byte) (int, error) {
+	return u.f.Write(p)
+}
+
+func (u *s3Upload) Close() error {
+	defer os.Remove(u.f.Name())
+	defer u.f.Close()
+
+	hash := sha256.New()
+	if _, err := u.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	size, err := io.Copy(hash, u.f)
+	if err != nil {
+		return err
+	}
+	if _, err := u.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, u.object.url(), io.NopCloser(u.f))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	u.object.sign(req, hex.EncodeToString(hash.Sum(nil)), time.Now())
+	return u.object.do(req)
+}
+
+// RemoveS3 deletes the object path names, e.g. the output of a failed
+// export.
+func RemoveS3(path string) error {
+	object, err := parseS3Object(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, object.url(), nil)
+	if err != nil {
+		return err
+	}
+	object.sign(req, emptySHA256, time.Now())
+	return object.do(req)
+}
+
+func (o s3Object) do(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach S3: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 %s of s3://%s/%s failed: %s: %s", req.Method, o.bucket, o.key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// emptySHA256 is the payload hash of requests without a body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds an AWS Signature Version 4 Authorization header to req, signing
+// the host and every header already set.
+func (o s3Object) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if o.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", o.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := now.Format("20060102") + "/" + o.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+o.secretKey), now.Format("20060102"))
+	for _, part := range []string{o.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		o.accessKey, scope, signedHeaders, signature))
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath escapes every segment of an object key like S3 expects in
+// the signed path.
+func s3EscapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes everything but the unreserved characters of
+// RFC 3986.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Split rotates records into numbered part files (liked-00001.jsonl,
+// liked-00002.jsonl, ...) once a part reaches a record count or size. Sizes
+// are measured as encoded JSON, which is exact for jsonl and an estimate
+// for other formats.
+type Split struct {
+	name       string
+	opts       Options
+	maxBytes   int64
+	maxRecords int
+
+	current Writer
+	part    int
+	bytes   int64
+	records int
+}
+
+// NewSplit creates a Split writing parts with the writer registered under
+// name. A zero maxBytes or maxRecords disables that limit.
+func NewSplit(name string, opts Options, maxBytes int64, maxRecords int) (*Split, error) {
+	if opts.Path == "" || opts.Path == "-" {
+		return nil, errors.New("splitting output needs an output file (-o)")
+	}
+	mu.RLock()
+	_, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", name)
+	}
+	return &Split{name: name, opts: opts, maxBytes: maxBytes, maxRecords: maxRecords}, nil
+}
+
+// PartPath returns the path of part n of path, e.g. liked-00002.jsonl.
+func PartPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%05d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+func (s *Split) open() error {
+	s.part++
+	opts := s.opts
+	opts.Path = PartPath(s.opts.Path, s.part)
+	w, err := New(s.name, opts)
+	if err != nil {
+		return err
+	}
+	s.current, s.bytes, s.records = w, 0, 0
+	return nil
+}
+
+func (s *Split) Write(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data)) + 1
+
+	if s.current != nil && s.records > 0 &&
+		(s.maxRecords > 0 && s.records >= s.maxRecords || s.maxBytes > 0 && s.bytes+size > s.maxBytes) {
+		err := s.current.Close()
+		s.current = nil
+		if err != nil {
+			return fmt.Errorf("failed to close part %d: %w", s.part, err)
+		}
+	}
+	if s.current == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.current.Write(record); err != nil {
+		return err
+	}
+	s.bytes += size
+	s.records++
+	return nil
+}
+
+// Close closes the last part. An export without records still writes an
+// empty first part, so downstream loaders always find one.
+func (s *Split) Close() error {
+	if s.current == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	return s.current.Close()
+}
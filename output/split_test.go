@@ -0,0 +1,97 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPartPath(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		n    int
+		want string
+	}{
+		{"liked.jsonl", 1, "liked-00001.jsonl"},
+		{"out/liked.csv", 12, "out/liked-00012.csv"},
+		{"liked", 3, "liked-00003"},
+		{"liked.jsonl.age", 2, "liked.jsonl-00002.age"},
+	} {
+		if got := PartPath(tt.path, tt.n); got != tt.want {
+			t.Errorf("PartPath(%q, %d) = %q, want %q", tt.path, tt.n, got, tt.want)
+		}
+	}
+}
+
+// splitParts writes records through a Split and returns the line counts of
+// the part files.
+func splitParts(t *testing.T, records []map[string]any, maxBytes int64, maxRecords int) []int {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "liked.jsonl")
+	s, err := NewSplit("jsonl", Options{Path: path}, maxBytes, maxRecords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := s.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var counts []int
+	for n := 1; ; n++ {
+		data, err := os.ReadFile(PartPath(path, n))
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, strings.Count(string(data), "\n"))
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unsplit %s written: %v", path, err)
+	}
+	return counts
+}
+
+func TestSplitRotation(t *testing.T) {
+	records := make([]map[string]any, 5)
+	for i := range records {
+		// {"id":"0"} and a newline: 11 bytes each
+		records[i] = map[string]any{"id": string(rune('0' + i))}
+	}
+
+	if got, want := splitParts(t, records, 0, 2), []int{2, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("parts by count = %v, want %v", got, want)
+	}
+	if got, want := splitParts(t, records, 33, 0), []int{3, 2}; !slices.Equal(got, want) {
+		t.Errorf("parts by size = %v, want %v", got, want)
+	}
+	// A record larger than the limit still gets a part of its own
+	if got, want := splitParts(t, records, 5, 0), []int{1, 1, 1, 1, 1}; !slices.Equal(got, want) {
+		t.Errorf("parts of oversized records = %v, want %v", got, want)
+	}
+	// Whichever limit is reached first rotates
+	if got, want := splitParts(t, records, 22, 3), []int{2, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("parts by size and count = %v, want %v", got, want)
+	}
+	if got, want := splitParts(t, nil, 0, 2), []int{0}; !slices.Equal(got, want) {
+		t.Errorf("parts without records = %v, want %v", got, want)
+	}
+}
+
+func TestSplitNeedsFile(t *testing.T) {
+	if _, err := NewSplit("jsonl", Options{}, 0, 10); err == nil {
+		t.Error("splitting stdout succeeded")
+	}
+	if _, err := NewSplit("nope", Options{Path: "out.jsonl"}, 0, 10); err == nil {
+		t.Error("splitting into an unknown format succeeded")
+	}
+}
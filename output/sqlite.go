@@ -0,0 +1,138 @@
+package output
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	Register("sqlite", func(opts Options) (Writer, error) {
+		return NewSQLite(opts.Path)
+	})
+}
+
+// SQLite stores records in a SQLite database with one table per resource
+// kind (youtube#video → videos). Each row holds the full record as JSON and
+// re-exports replace rows with the same ID. Statistics (view, like or
+// subscriber counts) are also kept per export in a history table, e.g.
+// videos_statistics, so repeated exports build a time series. Existing
+// databases are migrated to the current schema version when opened.
+type SQLite struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	tables map[string]bool
+}
+
+// NewSQLite opens or creates the database at path.
+func NewSQLite(path string) (*SQLite, error) {
+	if path == "" || path == "-" {
+		return nil, fmt.Errorf("sqlite output requires a file (-o)")
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := migrate(tx); err != nil {
+		_ = tx.Rollback()
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLite{db: db, tx: tx, tables: make(map[string]bool)}, nil
+}
+
+// tableName derives a table from a resource kind, e.g. "youtube#playlistItem"
+// becomes "playlistItems".
+func tableName(kind string) string {
+	if i := strings.LastIndex(kind, "#"); i >= 0 {
+		kind = kind[i+1:]
+	}
+	if kind == "" {
+		return "records"
+	}
+	return kind + "s"
+}
+
+func (s *SQLite) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	kind, _ := m["kind"].(string)
+	var id string
+	switch v := m["id"].(type) {
+	case string:
+		id = v
+	case nil:
+		sum := sha256.Sum256(data)
+		id = hex.EncodeToString(sum[:])
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		id = string(encoded)
+	}
+
+	table := tableName(kind)
+	if !s.tables[table] {
+		if err := createRecordTable(s.tx, table); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table, err)
+		}
+		s.tables[table] = true
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = s.tx.Exec(fmt.Sprintf(`INSERT INTO %q (id, kind, data, exported_at, first_exported_at) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET kind = excluded.kind, data = excluded.data, exported_at = excluded.exported_at`, table),
+		id, kind, string(data), now, now)
+	if err != nil {
+		return fmt.Errorf("failed to write to table %s: %w", table, err)
+	}
+
+	statistics, ok := m["statistics"].(map[string]any)
+	if !ok || len(statistics) == 0 {
+		return nil
+	}
+	history := statisticsTable(table)
+	if !s.tables[history] {
+		if err := createStatisticsTable(s.tx, table); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", history, err)
+		}
+		s.tables[history] = true
+	}
+	encoded, err := json.Marshal(statistics)
+	if err != nil {
+		return err
+	}
+	_, err = s.tx.Exec(fmt.Sprintf(`INSERT INTO %q (id, captured_at, statistics) VALUES (?, ?, ?)
+ON CONFLICT(id, captured_at) DO UPDATE SET statistics = excluded.statistics`, history), id, now, string(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to write to table %s: %w", history, err)
+	}
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	if err := s.tx.Commit(); err != nil {
+		_ = s.db.Close()
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,178 @@
+package output
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// schemaTable records the migrations applied to a database written by
+	// the SQLite writer.
+	schemaTable = "schema_migrations"
+	// statisticsSuffix names the statistics history of a record table.
+	statisticsSuffix = "_statistics"
+)
+
+// sqliteMigration upgrades a database to version. Record tables are created
+// per resource kind on demand, so a migration changing them is given every
+// existing record table; tables created later already have the latest
+// layout (createRecordTable).
+type sqliteMigration struct {
+	version     int
+	description string
+	migrate     func(tx *sql.Tx, recordTables []string) error
+}
+
+// sqliteMigrations are applied in order. Append new ones with the next
+// version and update createRecordTable or createStatisticsTable to match;
+// never change a released migration.
+var sqliteMigrations = []sqliteMigration{
+	{1, "record tables with id, kind, data and exported_at", func(tx *sql.Tx, recordTables []string) error {
+		return nil
+	}},
+	{2, "first_exported_at keeps when a record was first exported", func(tx *sql.Tx, recordTables []string) error {
+		for _, table := range recordTables {
+			// SQLite only adds NOT NULL columns with a default; the rows are
+			// backfilled right away
+			if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q ADD COLUMN first_exported_at TEXT NOT NULL DEFAULT ''`, table)); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(fmt.Sprintf(`UPDATE %q SET first_exported_at = exported_at`, table)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{3, "statistics history tables with a snapshot per export", func(tx *sql.Tx, recordTables []string) error {
+		for _, table := range recordTables {
+			var hasStatistics bool
+			if err := tx.QueryRow(fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %q WHERE json_type(data, '$.statistics') = 'object')`, table)).Scan(&hasStatistics); err != nil {
+				return err
+			}
+			if !hasStatistics {
+				continue
+			}
+			if err := createStatisticsTable(tx, table); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(fmt.Sprintf(`INSERT OR IGNORE INTO %q (id, captured_at, statistics)
+SELECT id, exported_at, json_extract(data, '$.statistics') FROM %q WHERE json_type(data, '$.statistics') = 'object'`, statisticsTable(table), table)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+}
+
+// SQLiteSchemaVersion is the schema version of databases written by the
+// SQLite writer.
+func SQLiteSchemaVersion() int {
+	return sqliteMigrations[len(sqliteMigrations)-1].version
+}
+
+// createRecordTable creates a record table in the latest layout, column
+// for column the layout the migrations give older tables.
+func createRecordTable(tx *sql.Tx, table string) error {
+	_, err := tx.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (
+	id TEXT PRIMARY KEY,
+	kind TEXT,
+	data TEXT NOT NULL,
+	exported_at TEXT NOT NULL,
+	first_exported_at TEXT NOT NULL DEFAULT ''
+)`, table))
+	return err
+}
+
+// statisticsTable is the table keeping the statistics history of the
+// records in table, e.g. videos_statistics.
+func statisticsTable(table string) string {
+	return table + statisticsSuffix
+}
+
+// createStatisticsTable creates the statistics history of a record table in
+// the latest layout.
+func createStatisticsTable(tx *sql.Tx, table string) error {
+	_, err := tx.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (
+	id TEXT NOT NULL,
+	captured_at TEXT NOT NULL,
+	statistics TEXT NOT NULL,
+	PRIMARY KEY (id, captured_at)
+)`, statisticsTable(table)))
+	return err
+}
+
+// migrate brings the database up to the latest schema version. Databases
+// written before versioning have record tables but no schema table and are
+// at version 1.
+func migrate(tx *sql.Tx) error {
+	var hasSchema bool
+	if err := tx.QueryRow(`SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`, schemaTable).Scan(&hasSchema); err != nil {
+		return err
+	}
+	if !hasSchema {
+		if _, err := tx.Exec(fmt.Sprintf(`CREATE TABLE %q (
+	version INTEGER PRIMARY KEY,
+	description TEXT NOT NULL,
+	applied_at TEXT NOT NULL
+)`, schemaTable)); err != nil {
+			return err
+		}
+	}
+
+	tables, err := recordTables(tx)
+	if err != nil {
+		return err
+	}
+	current := 0
+	if err := tx.QueryRow(fmt.Sprintf(`SELECT COALESCE(MAX(version), 0) FROM %q`, schemaTable)).Scan(&current); err != nil {
+		return err
+	}
+	if current == 0 && len(tables) > 0 {
+		if err := recordMigration(tx, sqliteMigrations[0]); err != nil {
+			return err
+		}
+		current = sqliteMigrations[0].version
+	}
+	if latest := SQLiteSchemaVersion(); current > latest {
+		return fmt.Errorf("database schema version %d is newer than this ytdata supports (%d); update ytdata", current, latest)
+	}
+
+	for _, m := range sqliteMigrations {
+		if m.version <= current {
+			continue
+		}
+		if err := m.migrate(tx, tables); err != nil {
+			return fmt.Errorf("failed to migrate database to version %d (%s): %w", m.version, m.description, err)
+		}
+		if err := recordMigration(tx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordMigration(tx *sql.Tx, m sqliteMigration) error {
+	_, err := tx.Exec(fmt.Sprintf(`INSERT INTO %q (version, description, applied_at) VALUES (?, ?, ?)`, schemaTable),
+		m.version, m.description, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// recordTables lists the tables holding records, i.e. all but SQLite's,
+// the schema table and statistics histories.
+func recordTables(tx *sql.Tx) ([]string, error) {
+	rows, err := tx.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != ? AND name NOT LIKE ? ESCAPE '\' ORDER BY name`, schemaTable, `%\`+statisticsSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
@@ -0,0 +1,245 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	Register("table", func(opts Options) (Writer, error) {
+		return NewTable(opts)
+	})
+}
+
+// defaultPager pages tables taller than the terminal; -F quits right away
+// when the table fits after all.
+const defaultPager = "less -FRSX"
+
+// tableColumn is a column of the table format and the record field it shows.
+type tableColumn struct {
+	header string
+	field  string
+	right  bool
+	// date keeps only the day of a timestamp
+	date bool
+	// shrink marks columns truncated first when the table is too wide
+	shrink bool
+}
+
+var tableColumns = []tableColumn{
+	{header: "ID", field: "id"},
+	{header: "TITLE", field: "snippet.title", shrink: true},
+	{header: "CHANNEL", field: "snippet.channelTitle", shrink: true},
+	{header: "DATE", field: "snippet.publishedAt", date: true},
+	{header: "VIEWS", field: "statistics.viewCount", right: true},
+}
+
+// minShrinkWidth is the narrowest a title or channel column is truncated to.
+const minShrinkWidth = 12
+
+// Table prints records as an aligned table for reading in a terminal: ID,
+// title, channel, date and views. Columns that are empty for every record
+// are left out, and titles and channels are truncated to fit the terminal
+// width (or $COLUMNS). Like CSV, rows are buffered to size the columns and
+// written on Close. When the table is taller than the terminal, it is
+// shown in $YTDATA_PAGER, $PAGER or less; set YTDATA_PAGER=cat to disable
+// paging.
+type Table struct {
+	w    io.WriteCloser
+	tty  bool
+	rows [][]string
+}
+
+// NewTable creates a table writer for opts.Path, or stdout when it is empty.
+func NewTable(opts Options) (*Table, error) {
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &Table{w: w, tty: opts.Path == "" || opts.Path == "-"}, nil
+}
+
+func (t *Table) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(tableColumns))
+	for i, column := range tableColumns {
+		row[i] = tableCell(lookupPath(m, column.field))
+		if column.date && len(row[i]) > len(time.DateOnly) {
+			row[i] = row[i][:len(time.DateOnly)]
+		}
+	}
+	t.rows = append(t.rows, row)
+	return nil
+}
+
+func (t *Table) Close() error {
+	width, height, tty := 0, 0, false
+	if t.tty {
+		width, height, tty = terminalSize(os.Stdout)
+	}
+	if !tty {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	lines := t.render(width)
+
+	if tty && len(lines) >= height {
+		if pager := tablePager(); pager != "" {
+			if err := page(pager, lines); err == nil {
+				return t.w.Close()
+			}
+		}
+	}
+	bw := bufio.NewWriter(t.w)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(bw, line); err != nil {
+			_ = t.w.Close()
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		_ = t.w.Close()
+		return err
+	}
+	return t.w.Close()
+}
+
+// render sizes the columns to their content, shrinks the title and channel
+// columns until the table fits width (when positive) and returns the lines.
+func (t *Table) render(width int) []string {
+	var columns []int
+	widths := make([]int, len(tableColumns))
+	for i, column := range tableColumns {
+		used := false
+		widths[i] = utf8.RuneCountInString(column.header)
+		for _, row := range t.rows {
+			if row[i] != "" {
+				used = true
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+		if used {
+			columns = append(columns, i)
+		}
+	}
+
+	if width > 0 {
+		total := 2 * (len(columns) - 1)
+		for _, i := range columns {
+			total += widths[i]
+		}
+		// Take from the widest shrinkable column until the table fits
+		for total > width {
+			widest := -1
+			for _, i := range columns {
+				if tableColumns[i].shrink && widths[i] > minShrinkWidth && (widest < 0 || widths[i] > widths[widest]) {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			widths[widest]--
+			total--
+		}
+	}
+
+	lines := make([]string, 0, len(t.rows)+1)
+	header := make([]string, len(tableColumns))
+	for i, column := range tableColumns {
+		header[i] = column.header
+	}
+	for _, row := range append([][]string{header}, t.rows...) {
+		var line strings.Builder
+		for n, i := range columns {
+			if n > 0 {
+				line.WriteString("  ")
+			}
+			cell := truncateCell(row[i], widths[i])
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			switch {
+			case tableColumns[i].right:
+				line.WriteString(pad + cell)
+			case n == len(columns)-1:
+				line.WriteString(cell)
+			default:
+				line.WriteString(cell + pad)
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// tableCell formats a field value for a cell on a single line.
+func tableCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// truncateCell shortens s to width runes, marking the cut with an ellipsis.
+func truncateCell(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// lookupPath returns the value at a dotted path of nested maps.
+func lookupPath(m map[string]any, path string) any {
+	var v any = m
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+// tablePager returns the pager command, or "" when paging is disabled.
+func tablePager() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	pager, ok := os.LookupEnv("YTDATA_PAGER")
+	if !ok {
+		pager, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		pager = defaultPager
+	}
+	if pager = strings.TrimSpace(pager); pager == "cat" {
+		return ""
+	}
+	return pager
+}
+
+// page shows lines in the pager.
+func page(pager string, lines []string) error {
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,137 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register("template", func(opts Options) (Writer, error) {
+		return NewTemplate(opts)
+	})
+}
+
+// Template writes each record through a Go text/template, for ad-hoc
+// listings such as '{{.Id}}\t{{.Snippet.Title}}'. Fields are available by
+// their JSON name (.snippet.title) and capitalized like the API structs
+// (.Snippet.Title); missing fields render empty. A newline is added after
+// each record unless the template ends with one.
+type Template struct {
+	w    io.WriteCloser
+	tmpl *template.Template
+	buf  bytes.Buffer
+}
+
+// templateFuncs are the functions available in templates besides the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, v any) string {
+		items, _ := v.([]any)
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			data, _ := json.Marshal(item)
+			if s, ok := item.(string); ok {
+				data = []byte(s)
+			}
+			parts = append(parts, string(data))
+		}
+		return strings.Join(parts, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"truncate": func(n int, s string) string {
+		if utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n])
+	},
+}
+
+// ParseTemplate parses a record template. The escapes \t and \n are
+// replaced, as shells pass them literally.
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, errors.New("empty template")
+	}
+	text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return template.New("record").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// NewTemplate creates a template writer for opts.Path, or stdout when it is
+// empty.
+func NewTemplate(opts Options) (*Template, error) {
+	tmpl, err := ParseTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	w, err := opts.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &Template{w: w, tmpl: tmpl}, nil
+}
+
+func (t *Template) Write(record any) error {
+	m, err := ToMap(record)
+	if err != nil {
+		return err
+	}
+	t.buf.Reset()
+	if err := t.tmpl.Execute(&t.buf, templateData(m)); err != nil {
+		return err
+	}
+	_, err = t.w.Write(bytes.ReplaceAll(t.buf.Bytes(), []byte("<no value>"), nil))
+	return err
+}
+
+func (t *Template) Close() error {
+	return t.w.Close()
+}
+
+// templateData copies a record, adding a capitalized alias for every key
+// so templates can use the field names of the API structs.
+func templateData(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, 2*len(v))
+		for key, value := range v {
+			value = templateData(value)
+			m[key] = value
+			if alias := capitalize(key); alias != key {
+				if _, exists := v[alias]; !exists {
+					m[alias] = value
+				}
+			}
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = templateData(item)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError || unicode.IsUpper(r) {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
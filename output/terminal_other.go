@@ -0,0 +1,11 @@
+//go:build !unix
+
+package output
+
+import "os"
+
+// terminalSize reports no terminal on platforms without TIOCGWINSZ; the
+// table writer then relies on $COLUMNS.
+func terminalSize(f *os.File) (width, height int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	auditFlagBlocked        = "blocked"
+	auditFlagUnknownChannel = "unknown_channel"
+	auditFlagUnavailable    = "unavailable"
+	auditFlagAddedByOther   = "added_by_other"
+)
+
+type PlaylistAuditOptions struct {
+	Blocklist   string
+	Known       string
+	FlaggedOnly bool
+}
+
+// playlistAuditEntry is one item of the audit report. AddedBy is the
+// channel that added the item, which differs from the owner in shared
+// playlists.
+type playlistAuditEntry struct {
+	PlaylistID             string   `json:"playlistId"`
+	Position               int64    `json:"position"`
+	VideoID                string   `json:"videoId"`
+	Title                  string   `json:"title"`
+	VideoOwnerChannelID    string   `json:"videoOwnerChannelId,omitempty"`
+	VideoOwnerChannelTitle string   `json:"videoOwnerChannelTitle,omitempty"`
+	AddedBy                string   `json:"addedBy,omitempty"`
+	AddedByTitle           string   `json:"addedByTitle,omitempty"`
+	AddedAt                string   `json:"addedAt,omitempty"`
+	Flags                  []string `json:"flags,omitempty"`
+}
+
+func newPlaylistAuditCmd(config *Config) *cobra.Command {
+	var opts PlaylistAuditOptions
+
+	cmd := &cobra.Command{
+		Use:   "playlist-audit PLAYLIST_ID",
+		Short: "Audit the items of a shared playlist",
+		Long: `List every item of a playlist with the channel of its video
+(videoOwnerChannelId), the channel that added it and when, and flag items
+worth a look:
+
+  blocked          the video's channel is on --blocklist
+  unknown_channel  the video's channel is not on --known
+  unavailable      the video is deleted or private, so its channel is unknown
+  added_by_other   someone other than the playlist owner added the item
+
+Channel lists are text files with one channel ID per line (# starts a
+comment) or exports such as a subscriptions export. A summary of the flags
+is printed to stderr.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata playlist-audit PLxxxxxxxxxxxxxxxx
+  ytdata playlist-audit PLxxxxxxxxxxxxxxxx --known subscriptions.jsonl --flagged-only
+  ytdata playlist-audit PLxxxxxxxxxxxxxxxx --blocklist blocked.txt -f csv -o audit.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				id, err := idFromArg(args[0], refPlaylist)
+				if err != nil {
+					return err
+				}
+				return auditPlaylist(config, id, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Blocklist, "blocklist", "", "Flag videos of the channels in this file")
+	cmd.Flags().StringVar(&opts.Known, "known", "", "Flag videos of channels missing from this file")
+	cmd.Flags().BoolVar(&opts.FlaggedOnly, "flagged-only", false, "Only report flagged items")
+	addOutputFlag(cmd, "", "Write the audit report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report items matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// readChannelList reads the channel IDs of a text file with one ID per line
+// or of an export.
+func readChannelList(path string) (map[string]bool, error) {
+	channels := make(map[string]bool)
+	if ext := filepath.Ext(path); ext == ".jsonl" || ext == ".json" {
+		records, err := readExportRecords(path)
+		if err != nil {
+			return nil, withKind(ErrInvalidConfig, err)
+		}
+		for _, record := range records {
+			if id := recordChannelID(record); id != "" {
+				channels[id] = true
+			}
+		}
+		return channels, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to open channel list: %w", err))
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		channels[strings.Fields(text)[0]] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read channel list: %w", err)
+	}
+	return channels, nil
+}
+
+func auditPlaylist(config Config, playlistID string, opts PlaylistAuditOptions) (err error) {
+	var blocked, known map[string]bool
+	if opts.Blocklist != "" {
+		if blocked, err = readChannelList(opts.Blocklist); err != nil {
+			return err
+		}
+	}
+	if opts.Known != "" {
+		if known, err = readChannelList(opts.Known); err != nil {
+			return err
+		}
+	}
+
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	response, err := service.Playlists.List([]string{"snippet"}).Id(playlistID).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to fetch playlist %s: %w", playlistID, err)
+	}
+	if len(response.Items) == 0 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("playlist %s not found", playlistID))
+	}
+	owner := response.Items[0].Snippet.ChannelId
+
+	items, err := fetchPlaylistItems(ctx, service, playlistID)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "playlist audit")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	counts := make(map[string]int)
+	for _, item := range items {
+		if item.Snippet == nil {
+			continue
+		}
+		snippet := item.Snippet
+		entry := playlistAuditEntry{
+			PlaylistID:             playlistID,
+			Position:               snippet.Position,
+			VideoID:                playlistItemVideoID(item),
+			Title:                  snippet.Title,
+			VideoOwnerChannelID:    snippet.VideoOwnerChannelId,
+			VideoOwnerChannelTitle: snippet.VideoOwnerChannelTitle,
+			AddedBy:                snippet.ChannelId,
+			AddedByTitle:           snippet.ChannelTitle,
+			AddedAt:                snippet.PublishedAt,
+		}
+		switch {
+		case entry.VideoOwnerChannelID == "":
+			entry.Flags = append(entry.Flags, auditFlagUnavailable)
+		case blocked[entry.VideoOwnerChannelID]:
+			entry.Flags = append(entry.Flags, auditFlagBlocked)
+		case known != nil && !known[entry.VideoOwnerChannelID]:
+			entry.Flags = append(entry.Flags, auditFlagUnknownChannel)
+		}
+		if entry.AddedBy != "" && entry.AddedBy != owner {
+			entry.Flags = append(entry.Flags, auditFlagAddedByOther)
+		}
+		for _, flag := range entry.Flags {
+			counts[flag]++
+		}
+		if opts.FlaggedOnly && len(entry.Flags) == 0 {
+			continue
+		}
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write audit: %w", err)
+		}
+	}
+
+	infof("Audited %d items: %d blocked, %d from unknown channels, %d unavailable, %d added by others",
+		len(items), counts[auditFlagBlocked], counts[auditFlagUnknownChannel], counts[auditFlagUnavailable], counts[auditFlagAddedByOther])
+	return nil
+}
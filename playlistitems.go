@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+var playlistItemParts = []string{"snippet", "contentDetails", "status"}
+
+// fetchPlaylistItems fetches every item of a playlist in playlist order.
+func fetchPlaylistItems(ctx context.Context, service *youtube.Service, playlistID string) ([]*youtube.PlaylistItem, error) {
+	var items []*youtube.PlaylistItem
+	pageToken := ""
+	for {
+		call := service.PlaylistItems.List(playlistItemParts).
+			Context(ctx).
+			PlaylistId(playlistID).
+			MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch items of playlist %s: %w", playlistID, err)
+		}
+		items = append(items, response.Items...)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+	return items, nil
+}
+
+// playlistItemVideoID returns the ID of the video a playlist item refers to.
+func playlistItemVideoID(item *youtube.PlaylistItem) string {
+	if item.ContentDetails != nil && item.ContentDetails.VideoId != "" {
+		return item.ContentDetails.VideoId
+	}
+	if item.Snippet != nil && item.Snippet.ResourceId != nil {
+		return item.Snippet.ResourceId.VideoId
+	}
+	return ""
+}
+
+var playlistItemSortKeys = []string{"position", "added", "title"}
+
+type PlaylistItemsOptions struct {
+	Sort string
+}
+
+func newPlaylistItemsCmd(config *Config) *cobra.Command {
+	var opts PlaylistItemsOptions
+
+	cmd := &cobra.Command{
+		Use:   "playlist-items [PLAYLIST_ID...]",
+		Short: "Fetch the items of playlists",
+		Long: `Fetch the items of the given playlists, or of all your playlists when no
+ID is given. Items are written playlist by playlist in playlist order, each
+with its snippet.position and the time it was added (snippet.publishedAt).
+
+Use --sort to order the items of each playlist by when they were added
+(newest first) or by title instead.`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		Example: `  ytdata playlist-items -o items.jsonl
+  ytdata playlist-items PLxxxxxxxxxxxxxxxx --sort added`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := idsFromArgs(args, refPlaylist)
+				if err != nil {
+					return err
+				}
+				return fetchAllPlaylistItems(config, ids, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Sort, "sort", "position", "Order items by "+strings.Join(playlistItemSortKeys, "|"))
+	addOutputFlag(cmd, "", "Write playlist items to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export items matching this expression")
+	addTransformFlag(cmd)
+	addExtractFlag(cmd)
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("sort", staticCompletion(playlistItemSortKeys...)))
+
+	return cmd
+}
+
+// sortPlaylistItems orders the items of one playlist in place.
+func sortPlaylistItems(items []*youtube.PlaylistItem, key string) {
+	snippet := func(item *youtube.PlaylistItem) *youtube.PlaylistItemSnippet {
+		if item.Snippet == nil {
+			return &youtube.PlaylistItemSnippet{}
+		}
+		return item.Snippet
+	}
+
+	switch key {
+	case "position":
+		sort.SliceStable(items, func(i, j int) bool {
+			return snippet(items[i]).Position < snippet(items[j]).Position
+		})
+	case "added":
+		// RFC 3339 timestamps in UTC sort lexically; newest first
+		sort.SliceStable(items, func(i, j int) bool {
+			return snippet(items[i]).PublishedAt > snippet(items[j]).PublishedAt
+		})
+	case "title":
+		sort.SliceStable(items, func(i, j int) bool {
+			return strings.ToLower(snippet(items[i]).Title) < strings.ToLower(snippet(items[j]).Title)
+		})
+	}
+}
+
+// playlistItemRecord converts a playlist item into a record. The API omits
+// a position of 0, so it is always set explicitly.
+func playlistItemRecord(item *youtube.PlaylistItem) (map[string]any, error) {
+	record, err := toRecord(item)
+	if err != nil {
+		return nil, err
+	}
+	if snippet, ok := record["snippet"].(map[string]any); ok {
+		snippet["position"] = item.Snippet.Position
+	}
+	return record, nil
+}
+
+func fetchAllPlaylistItems(config Config, playlistIDs []string, opts PlaylistItemsOptions) (err error) {
+	if !slices.Contains(playlistItemSortKeys, opts.Sort) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported sort key %q (supported: %s)", opts.Sort, strings.Join(playlistItemSortKeys, ", ")))
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	if len(playlistIDs) == 0 {
+		collector := &recordCollector{}
+		if err := (playlistsExporter{config: Config{Language: config.Language, IDsOnly: true}}).Fetch(ctx, service, collector); err != nil {
+			return err
+		}
+		for _, playlist := range collector.records {
+			playlistIDs = append(playlistIDs, lookupString(playlist, "id"))
+		}
+	}
+
+	out, err := openOutput(config, "playlist items")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, playlistID := range playlistIDs {
+		items, err := fetchPlaylistItems(ctx, service, playlistID)
+		if err != nil {
+			return err
+		}
+		sortPlaylistItems(items, opts.Sort)
+		for _, item := range items {
+			record, err := playlistItemRecord(item)
+			if err != nil {
+				return fmt.Errorf("failed to process playlist item data: %w", err)
+			}
+			if err := out.Write(record); err != nil {
+				return fmt.Errorf("failed to write playlist item data: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeMarkdownItems writes the items of a playlist after the playlist for
+// --format markdown, which renders each playlist with its videos.
+func writeMarkdownItems(ctx context.Context, service *youtube.Service, config Config, playlistID string, sink output.Writer) error {
+	if config.Format != formatMarkdown || config.IDsOnly {
+		return nil
+	}
+	items, err := fetchPlaylistItems(ctx, service, playlistID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		record, err := playlistItemRecord(item)
+		if err != nil {
+			return fmt.Errorf("failed to process playlist item data: %w", err)
+		}
+		if err := sink.Write(record); err != nil {
+			return fmt.Errorf("failed to write playlist item data: %w", err)
+		}
+	}
+	return nil
+}
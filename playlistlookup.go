@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type PlaylistOptions struct {
+	Items bool
+}
+
+func newPlaylistCmd(config *Config) *cobra.Command {
+	var opts PlaylistOptions
+
+	cmd := &cobra.Command{
+		Use:   "playlist PLAYLIST_ID|URL...",
+		Short: "Fetch any public playlists and their items",
+		Long: `Fetch the metadata of public playlists, not just your own, each followed by
+its items in playlist order. Playlists are given as IDs or as YouTube URLs
+with a list parameter, e.g. a playlist page or a video watched in a
+playlist. Playlists that do not exist or are private produce a warning.
+
+Item records carry snippet.playlistId to link them to their playlist.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata playlist PLBCF2DAC6FFB574DE
+  ytdata playlist "https://www.youtube.com/playlist?list=PLBCF2DAC6FFB574DE" -o playlist.jsonl
+  ytdata playlist PLBCF2DAC6FFB574DE --items=false -f csv
+  ytdata playlist PLBCF2DAC6FFB574DE --format markdown --output-dir ./playlists`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := lookupIDs(args, "", refPlaylist)
+				if err != nil {
+					return err
+				}
+				return lookupPlaylists(config, ids, opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Items, "items", true, "Write the items of every playlist after it")
+	cmd.Flags().StringVar(&config.OutputDir, "output-dir", "", "Directory for --format markdown, one file per playlist plus index.md")
+	addOutputFlag(cmd, "", "Write playlists to stdout (or file with -o)")
+	addFormatFlag(cmd, append(recordFormats(), formatMarkdown)...)
+	addFilterFlag(cmd, "Only write records matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// fetchPlaylistsByID looks up playlists in batches, returning the ones
+// that exist and are visible to the caller by ID.
+func fetchPlaylistsByID(service *youtube.Service, config Config, ids []string) (map[string]*youtube.Playlist, error) {
+	found := make(map[string]*youtube.Playlist)
+	for i := 0; i < len(ids); i += 50 {
+		call := service.Playlists.List([]string{"snippet", "contentDetails", "status"}).
+			Id(ids[i:min(i+50, len(ids))]...).
+			MaxResults(50)
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+		}
+		for _, playlist := range response.Items {
+			found[playlist.Id] = playlist
+		}
+	}
+	return found, nil
+}
+
+func lookupPlaylists(config Config, ids []string, opts PlaylistOptions) (err error) {
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	found, err := fetchPlaylistsByID(service, config, ids)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "playlists")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	ctx := context.Background()
+	for _, id := range ids {
+		playlist, ok := found[id]
+		if !ok {
+			warnf("Playlist %s not found or private", id)
+			continue
+		}
+		if err := out.Write(playlist); err != nil {
+			return fmt.Errorf("failed to write playlist data: %w", err)
+		}
+		if !opts.Items {
+			continue
+		}
+		items, err := fetchPlaylistItems(ctx, service, id)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			record, err := playlistItemRecord(item)
+			if err != nil {
+				return fmt.Errorf("failed to process playlist item data: %w", err)
+			}
+			if err := out.Write(record); err != nil {
+				return fmt.Errorf("failed to write playlist item data: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// specialPlaylist is a system playlist of the authenticated channel, such as
+// its uploads or liked videos.
+type specialPlaylist struct {
+	Kind     string
+	Playlist *youtube.Playlist
+}
+
+// fetchSpecialPlaylists resolves the related playlists of the authenticated
+// channel. Deprecated related playlists (favorites, watch history, watch
+// later) are no longer returned by the API and are skipped.
+func fetchSpecialPlaylists(service *youtube.Service, config Config, parts []string) ([]specialPlaylist, error) {
+	response, err := service.Channels.List([]string{"contentDetails"}).Mine(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channel details: %w", err)
+	}
+
+	ids := make(map[string]string)
+	var order []string
+	for _, channel := range response.Items {
+		if channel.ContentDetails == nil || channel.ContentDetails.RelatedPlaylists == nil {
+			continue
+		}
+		related := channel.ContentDetails.RelatedPlaylists
+		for _, p := range []struct{ kind, id string }{
+			{"uploads", related.Uploads},
+			{"likes", related.Likes},
+			{"favorites", related.Favorites},
+		} {
+			if p.id == "" {
+				continue
+			}
+			if _, seen := ids[p.id]; !seen {
+				order = append(order, p.id)
+			}
+			ids[p.id] = p.kind
+		}
+	}
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	call := service.Playlists.List(parts).Id(order...).MaxResults(50)
+	if config.Language != "" {
+		call = call.Hl(config.Language)
+	}
+	playlists, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch special playlists: %w", err)
+	}
+
+	var special []specialPlaylist
+	for _, playlist := range playlists.Items {
+		special = append(special, specialPlaylist{Kind: ids[playlist.Id], Playlist: playlist})
+	}
+	return special, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type PlaylistVideosOptions struct {
+	All bool
+}
+
+// playlistVideo is a record of playlist-videos: one video with every
+// playlist it appears in.
+type playlistVideo struct {
+	VideoID   string               `json:"videoId"`
+	Title     string               `json:"title,omitempty"`
+	Channel   string               `json:"channel,omitempty"`
+	ChannelID string               `json:"channelId,omitempty"`
+	Playlists []playlistAppearance `json:"playlists"`
+}
+
+// playlistAppearance is one occurrence of a video in a playlist.
+type playlistAppearance struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Position int64  `json:"position"`
+	AddedAt  string `json:"addedAt,omitempty"`
+}
+
+func newPlaylistVideosCmd(config *Config) *cobra.Command {
+	var opts PlaylistVideosOptions
+
+	cmd := &cobra.Command{
+		Use:   "playlist-videos [PLAYLIST_ID...]",
+		Short: "Export the unique videos across playlists",
+		Long: `Write every video found in the given playlists, or with --all in all your
+playlists, once, with a playlists field listing each playlist it appears in
+(ID, title, position and when it was added). This is the inverse of the
+per-playlist view of playlist-items: videos come in the order they are
+first found, playlist by playlist.`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		Example: `  ytdata playlist-videos --all -o playlist-videos.jsonl
+  ytdata playlist-videos --all --filter 'len(playlists) > 1'
+  ytdata playlist-videos PLxxxxxxxxxxxxxxxx PLyyyyyyyyyyyyyyyy`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := idsFromArgs(args, refPlaylist)
+				if err != nil {
+					return err
+				}
+				return exportPlaylistVideos(config, ids, opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Use all your playlists")
+	addOutputFlag(cmd, "", "Write videos to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only write videos matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+func exportPlaylistVideos(config Config, ids []string, opts PlaylistVideosOptions) (err error) {
+	switch {
+	case opts.All && len(ids) > 0:
+		return withKind(ErrInvalidConfig, fmt.Errorf("pass either playlist IDs or --all, not both"))
+	case !opts.All && len(ids) == 0:
+		return withKind(ErrInvalidConfig, fmt.Errorf("playlist IDs or --all are required"))
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	var playlists []*youtube.Playlist
+	if opts.All {
+		collector := &recordCollector{}
+		if err := (playlistsExporter{config: Config{Language: config.Language, Format: formatJSONL}}).Fetch(ctx, service, collector); err != nil {
+			return err
+		}
+		for _, record := range collector.records {
+			playlists = append(playlists, &youtube.Playlist{
+				Id:      lookupString(record, "id"),
+				Snippet: &youtube.PlaylistSnippet{Title: lookupString(record, "snippet", "title")},
+			})
+		}
+	} else {
+		found, err := fetchPlaylistsByID(service, config, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			playlist, ok := found[id]
+			if !ok {
+				warnf("Playlist %s not found or private", id)
+				continue
+			}
+			playlists = append(playlists, playlist)
+		}
+	}
+
+	var videos []*playlistVideo
+	byID := make(map[string]*playlistVideo)
+	items := 0
+	for _, playlist := range playlists {
+		playlistItems, err := fetchPlaylistItems(ctx, service, playlist.Id)
+		if err != nil {
+			return err
+		}
+		for _, item := range playlistItems {
+			id := playlistItemVideoID(item)
+			if id == "" || item.Snippet == nil {
+				continue
+			}
+			items++
+			video, ok := byID[id]
+			if !ok {
+				video = &playlistVideo{
+					VideoID:   id,
+					Title:     item.Snippet.Title,
+					Channel:   item.Snippet.VideoOwnerChannelTitle,
+					ChannelID: item.Snippet.VideoOwnerChannelId,
+				}
+				byID[id] = video
+				videos = append(videos, video)
+			}
+			video.Playlists = append(video.Playlists, playlistAppearance{
+				ID:       playlist.Id,
+				Title:    playlist.Snippet.Title,
+				Position: item.Snippet.Position,
+				AddedAt:  item.Snippet.PublishedAt,
+			})
+		}
+	}
+
+	out, err := openOutput(config, "playlist videos")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, video := range videos {
+		if err := out.Write(video); err != nil {
+			return fmt.Errorf("failed to write video: %w", err)
+		}
+	}
+	infof("%d unique videos in %d items of %d playlists", len(videos), items, len(playlists))
+	return nil
+}
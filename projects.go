@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultProject names the project of the client secrets given with
+// --client-secret (or detected), which is always tried first.
+const defaultProject = "default"
+
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// projectsDir holds the client secrets of the additional Google Cloud
+// projects added with 'ytdata projects add', one file per project.
+func projectsDir() string {
+	return filepath.Join(getConfigDir(), "projects")
+}
+
+func projectUsagePath() string {
+	return filepath.Join(getConfigDir(), "project-usage.json")
+}
+
+// cloudProject is a Google Cloud project ytdata can send requests through.
+type cloudProject struct {
+	Name         string
+	ClientSecret string
+}
+
+// configuredProjects returns the default project followed by the added
+// projects in name order.
+func configuredProjects(config Config) []cloudProject {
+	projects := []cloudProject{{Name: defaultProject, ClientSecret: config.ClientSecret}}
+	matches, _ := filepath.Glob(filepath.Join(projectsDir(), "*.json"))
+	for _, match := range matches {
+		projects = append(projects, cloudProject{
+			Name:         strings.TrimSuffix(filepath.Base(match), ".json"),
+			ClientSecret: match,
+		})
+	}
+	return projects
+}
+
+// projectConfig returns config for another project. OAuth tokens only work
+// with the client they were issued to, so every project has its own token
+// next to the credentials of config.
+func projectConfig(config Config, project cloudProject) Config {
+	if project.Name == defaultProject {
+		return config
+	}
+	config.ClientSecret = project.ClientSecret
+	config.Credentials = strings.TrimSuffix(config.Credentials, ".json") + "_project_" + project.Name + ".json"
+	return config
+}
+
+// projectDay is the usage of a project on one quota day.
+type projectDay struct {
+	Day       string `json:"day"`
+	Requests  int    `json:"requests"`
+	QuotaUsed int    `json:"quotaUsed"`
+	Exhausted bool   `json:"exhausted,omitempty"`
+}
+
+// projectUsage tracks the estimated quota used per project and day, so
+// runs started after a project ran out of quota skip it until the reset.
+type projectUsage map[string]*projectDay
+
+func readProjectUsage() projectUsage {
+	usage := make(projectUsage)
+	if data, err := os.ReadFile(projectUsagePath()); err == nil {
+		if err := json.Unmarshal(data, &usage); err != nil {
+			warnf("Failed to read project usage: %v", err)
+		}
+	}
+	return usage
+}
+
+// today returns the usage of project on the current quota day.
+func (u projectUsage) today(project string) *projectDay {
+	day, _ := quotaDay(time.Now())
+	if u[project] == nil || u[project].Day != day {
+		u[project] = &projectDay{Day: day}
+	}
+	return u[project]
+}
+
+func (u projectUsage) save() {
+	if err := saveWatchState(projectUsagePath(), u); err != nil {
+		warnf("Failed to save project usage: %v", err)
+	}
+}
+
+// merge adds the usage counted by this process since the last save to the
+// usage saved by others, so concurrent runs do not lose each other's counts.
+func (u projectUsage) merge(pending projectUsage) {
+	for project, counted := range pending {
+		saved := u[project]
+		switch {
+		case saved == nil || saved.Day < counted.Day:
+			day := *counted
+			u[project] = &day
+		case saved.Day == counted.Day:
+			saved.Requests += counted.Requests
+			saved.QuotaUsed += counted.QuotaUsed
+			saved.Exhausted = saved.Exhausted || counted.Exhausted
+		}
+	}
+}
+
+// projectUsageSaveEvery is how many requests are counted before the usage
+// file is updated; exhausted projects and the end of a run save at once.
+const projectUsageSaveEvery = 50
+
+// projectRotations are the rotations of this run, saved by
+// saveProjectUsage when it ends.
+var projectRotations struct {
+	mu   sync.Mutex
+	list []*projectRotation
+}
+
+// saveProjectUsage saves the usage not yet saved by the rotations of this
+// run.
+func saveProjectUsage() {
+	projectRotations.mu.Lock()
+	defer projectRotations.mu.Unlock()
+	for _, r := range projectRotations.list {
+		r.mu.Lock()
+		r.save()
+		r.mu.Unlock()
+	}
+}
+
+// projectRotation sends requests through one project at a time and moves
+// on to the next project when the current one runs out of daily quota.
+type projectRotation struct {
+	mu       sync.Mutex
+	projects []cloudProject
+	clients  []*http.Client
+	failed   []bool
+	current  int
+	usage    projectUsage
+	// pending is the usage counted since the last save
+	pending projectUsage
+	unsaved int
+}
+
+// withProjectRotation wraps the client of the default project when more
+// projects were added; otherwise client is returned unchanged. The other
+// projects are authorized up front, so no request waits for an
+// authorization; projects that cannot be authorized are skipped.
+func withProjectRotation(client *http.Client, config Config) *http.Client {
+	projects := configuredProjects(config)
+	if config.AuthMode != authModeOAuth || len(projects) < 2 {
+		return client
+	}
+	r := &projectRotation{
+		projects: projects,
+		clients:  make([]*http.Client, len(projects)),
+		failed:   make([]bool, len(projects)),
+		usage:    readProjectUsage(),
+		pending:  make(projectUsage),
+	}
+	r.clients[0] = client
+	for i, project := range projects[1:] {
+		client, err := authorizeClient(projectConfig(config, project))
+		if err != nil {
+			warnf("Skipping project %s: %v", project.Name, err)
+			r.failed[i+1] = true
+			continue
+		}
+		r.clients[i+1] = client
+	}
+	if r.usage.today(projects[0].Name).Exhausted {
+		r.next()
+	}
+	projectRotations.mu.Lock()
+	projectRotations.list = append(projectRotations.list, r)
+	projectRotations.mu.Unlock()
+
+	rotated := *client
+	rotated.Transport = r
+	return &rotated
+}
+
+// client returns the current project and its client.
+func (r *projectRotation) client() (int, *http.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current, r.clients[r.current]
+}
+
+// next moves to the next usable project; it reports false when there is
+// none.
+func (r *projectRotation) next() bool {
+	for i := r.current + 1; i < len(r.projects); i++ {
+		if !r.failed[i] && !r.usage.today(r.projects[i].Name).Exhausted {
+			r.current = i
+			return true
+		}
+	}
+	return false
+}
+
+// record accounts for a request of project i; exhausted marks the project
+// as out of quota and switches to the next one, reporting whether there is
+// one to retry with.
+func (r *projectRotation) record(i int, req *http.Request, exhausted bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := r.projects[i].Name
+	day, pending := r.usage.today(name), r.pending.today(name)
+	cost := requestQuotaCost(req)
+	day.Requests++
+	day.QuotaUsed += cost
+	pending.Requests++
+	pending.QuotaUsed += cost
+	r.unsaved++
+	retry := false
+	if exhausted {
+		day.Exhausted, pending.Exhausted = true, true
+		if i == r.current && r.next() {
+			infof("Quota of project %s exhausted, switching to project %s", name, r.projects[r.current].Name)
+		}
+		retry = r.current != i
+	}
+	if exhausted || r.unsaved >= projectUsageSaveEvery {
+		r.save()
+	}
+	return retry
+}
+
+// save merges the pending usage into the usage file. r.mu must be held.
+func (r *projectRotation) save() {
+	if r.unsaved == 0 {
+		return
+	}
+	usage := readProjectUsage()
+	usage.merge(r.pending)
+	usage.save()
+	// Learn about projects other runs exhausted today
+	for project, saved := range usage {
+		if day := r.usage.today(project); saved.Exhausted && saved.Day == day.Day {
+			day.Exhausted = true
+		}
+	}
+	r.pending = make(projectUsage)
+	r.unsaved = 0
+}
+
+func (r *projectRotation) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		i, client := r.client()
+		if client == nil {
+			return nil, withKind(ErrAuth, fmt.Errorf("no project could be authorized"))
+		}
+		sent := req
+		if attempt > 0 {
+			if req.Body != nil && req.GetBody == nil {
+				return nil, fmt.Errorf("cannot resend request to another project")
+			}
+			sent = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				sent.Body = body
+			}
+		}
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		resp, err := transport.RoundTrip(sent)
+		if err != nil {
+			return nil, err
+		}
+		exhausted, err := quotaExhausted(resp)
+		if err != nil {
+			return nil, err
+		}
+		if !r.record(i, req, exhausted) {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+	}
+}
+
+// quotaExhausted reports whether resp is a daily quota error. The body is
+// read and replaced, so the response can still be returned.
+func quotaExhausted(resp *http.Response) (bool, error) {
+	if resp.StatusCode != http.StatusForbidden {
+		return false, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return bytes.Contains(data, []byte(`"quotaExceeded"`)) || bytes.Contains(data, []byte(`"dailyLimitExceeded"`)), nil
+}
+
+// projectRecord describes a project and its usage today.
+type projectRecord struct {
+	Name         string `json:"name"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	projectDay
+}
+
+func newProjectsCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "projects",
+		Short: "List the Google Cloud projects requests rotate through",
+		Long: `List the Google Cloud projects ytdata sends requests through and their
+estimated quota use today (quota resets at midnight Pacific time).
+
+Requests go through the project of --client-secret first. Once a project
+runs out of daily quota, ytdata switches to the next project added with
+'projects add' and retries the request there; exhausted projects are skipped
+until the reset. Every project has its own OAuth token.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata projects
+  ytdata projects add backup client_secret_backup.json
+  ytdata projects remove backup`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, listProjects)
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write projects to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add NAME CLIENT_SECRET_FILE",
+		Short: "Add a project to rotate to when quota runs out",
+		Long: `Add the OAuth client secrets of another Google Cloud project and authorize
+it for the current account, so its token is ready when requests rotate to
+it. Enable the YouTube Data API v3 in the project first.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return addProject(config, args[0], args[1])
+			})
+		},
+	}, &cobra.Command{
+		Use:          "remove NAME",
+		Short:        "Remove a project and its tokens",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return removeProject(config, args[0])
+			})
+		},
+	})
+
+	return cmd
+}
+
+func listProjects(config Config) (err error) {
+	usage := readProjectUsage()
+	out, err := openOutput(config, "projects")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+	for _, project := range configuredProjects(config) {
+		record := projectRecord{Name: project.Name, ClientSecret: project.ClientSecret, projectDay: *usage.today(project.Name)}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write project data: %w", err)
+		}
+	}
+	return nil
+}
+
+func addProject(config Config, name, clientSecret string) error {
+	if config.AuthMode != authModeOAuth {
+		return withKind(ErrInvalidConfig, fmt.Errorf("projects require --auth-mode %s", authModeOAuth))
+	}
+	if !projectNamePattern.MatchString(name) || name == defaultProject {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid project name %q: use letters, digits, - and _ (and not %q)", name, defaultProject))
+	}
+	data, err := os.ReadFile(clientSecret)
+	if err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("failed to read client secrets: %w", err))
+	}
+	if err := validateClientSecrets(data); err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+	if err := os.MkdirAll(projectsDir(), 0700); err != nil {
+		return fmt.Errorf("failed to create projects directory: %w", err)
+	}
+	project := cloudProject{Name: name, ClientSecret: filepath.Join(projectsDir(), name+".json")}
+	if err := os.WriteFile(project.ClientSecret, data, 0600); err != nil {
+		return fmt.Errorf("failed to store client secrets: %w", err)
+	}
+	if _, err := authorizeClient(projectConfig(config, project)); err != nil {
+		return err
+	}
+	infof("Added project %s; requests rotate to it when earlier projects run out of quota", name)
+	return nil
+}
+
+func removeProject(config Config, name string) error {
+	if !projectNamePattern.MatchString(name) || name == defaultProject {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid project name %q", name))
+	}
+	path := filepath.Join(projectsDir(), name+".json")
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return withKind(ErrInvalidConfig, fmt.Errorf("no project named %s", name))
+		}
+		return err
+	}
+	tokens, _ := filepath.Glob(filepath.Join(filepath.Dir(config.Credentials), "*_project_"+name+".json"))
+	for _, token := range tokens {
+		if err := os.Remove(token); err != nil {
+			warnf("Failed to remove %s: %v", token, err)
+		}
+	}
+	infof("Removed project %s", name)
+	return nil
+}
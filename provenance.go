@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+// accountChannel caches the channel ID of the authenticated account, so
+// commands writing several outputs resolve it only once.
+var accountChannel struct {
+	once sync.Once
+	id   string
+}
+
+// accountChannelID returns the channel of the authenticated account, or ""
+// when it cannot be determined (e.g. with --api-key or for commands that
+// only read local files).
+func accountChannelID(config Config) string {
+	accountChannel.once.Do(func() {
+		if config.Local || config.APIKey != "" && config.ReplayDir == "" {
+			return
+		}
+		service, err := authenticateYouTube(config)
+		if err != nil {
+			warnf("Failed to determine account channel: %v", err)
+			return
+		}
+		response, err := service.Channels.List([]string{"id"}).Mine(true).Do()
+		if err != nil {
+			warnf("Failed to determine account channel: %v", err)
+			return
+		}
+		if len(response.Items) > 0 {
+			accountChannel.id = response.Items[0].Id
+		}
+	})
+	return accountChannel.id
+}
+
+// provenanceWriter stamps every record with where and when it was exported,
+// so datasets merged from several accounts or runs stay distinguishable.
+type provenanceWriter struct {
+	output.Writer
+	fields map[string]any
+}
+
+func newProvenanceWriter(w output.Writer, config Config) provenanceWriter {
+	fields := map[string]any{
+		"_exportedAt":     time.Now().UTC().Format(time.RFC3339),
+		"_tool_version":   version,
+		"_source_command": config.Command,
+	}
+	if id := accountChannelID(config); id != "" {
+		fields["_account_channel_id"] = id
+	}
+	return provenanceWriter{Writer: w, fields: fields}
+}
+
+func (w provenanceWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		// Not an object (e.g. a scalar emitted by --transform)
+		return w.Writer.Write(record)
+	}
+	for key, value := range w.fields {
+		m[key] = value
+	}
+	return w.Writer.Write(m)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type PruneOptions struct {
+	From      string
+	OlderThan string
+	Channel   string
+	DryRun    bool
+	Yes       bool
+	Delay     time.Duration
+	UndoFile  string
+}
+
+func newPruneCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove data from your account in bulk",
+	}
+
+	var opts PruneOptions
+	likedCmd := &cobra.Command{
+		Use:   "liked",
+		Short: "Remove likes matching filters",
+		Long: `Remove your like from the liked videos matching the filters. The liked
+videos are fetched fresh (or read from an export with --from), listed, and
+only removed after confirmation.
+
+--older-than compares when a video was published, not when you liked it:
+the API does not expose the date of a like, so recent likes of old videos
+match too.
+
+Every removed like is appended to the undo file, so 'ytdata undo' can like
+the videos again. Removing likes requires write access to your account;
+the first run asks for it and stores the token separately.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata prune liked --older-than 5y --dry-run
+  ytdata prune liked --channel UCuAXFkgsw1L7xaCfnd5JJOw
+  ytdata prune liked --from liked.jsonl --older-than 18m --yes
+  ytdata prune liked --filter 'statistics.viewCount < 1000' --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return pruneLiked(config, opts)
+			})
+		},
+	}
+	likedCmd.Flags().StringVar(&opts.From, "from", "", "Read liked videos from a JSONL export instead of fetching them")
+	likedCmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "Only videos published before this age, e.g. 90d, 6w, 18m, 5y (not the age of the like, which the API does not expose)")
+	likedCmd.Flags().StringVar(&opts.Channel, "channel", "", "Only videos of this channel (ID or title)")
+	addFilterFlag(likedCmd, "Only videos matching this expression")
+	likedCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List matching videos without removing anything")
+	likedCmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Do not ask for confirmation")
+	likedCmd.Flags().DurationVar(&opts.Delay, "delay", 200*time.Millisecond, "Pause between removals to stay below rate limits")
+	likedCmd.Flags().StringVar(&opts.UndoFile, "undo-file", defaultUndoFile, "File removed likes are appended to")
+
+	cobra.CheckErr(likedCmd.RegisterFlagCompletionFunc("from", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	cmd.AddCommand(likedCmd)
+	return cmd
+}
+
+// parseAge converts an age such as 90d, 6w, 18m or 5y into the cutoff time
+// before now.
+func parseAge(age string, now time.Time) (time.Time, error) {
+	if len(age) < 2 {
+		return time.Time{}, fmt.Errorf("invalid age %q (use e.g. 90d, 6w, 18m, 5y)", age)
+	}
+	n, err := strconv.Atoi(age[:len(age)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid age %q (use e.g. 90d, 6w, 18m, 5y)", age)
+	}
+	switch age[len(age)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	case 'y':
+		return now.AddDate(-n, 0, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid age %q (use e.g. 90d, 6w, 18m, 5y)", age)
+	}
+}
+
+// matchesPrune reports whether a liked video record matches the prune
+// filters. cutoff is zero when --older-than is not set and filter is nil
+// without --filter.
+func matchesPrune(record map[string]any, opts PruneOptions, cutoff time.Time, filter filterExpr) bool {
+	if filter != nil && !matchFilter(filter, record) {
+		return false
+	}
+	if !cutoff.IsZero() {
+		published, ok := parsePublishedAt(lookupString(record, "snippet", "publishedAt"))
+		if !ok || !published.Before(cutoff) {
+			return false
+		}
+	}
+	if opts.Channel != "" {
+		if lookupString(record, "snippet", "channelId") != opts.Channel &&
+			!strings.EqualFold(lookupString(record, "snippet", "channelTitle"), opts.Channel) {
+			return false
+		}
+	}
+	return true
+}
+
+func pruneLiked(config Config, opts PruneOptions) error {
+	var cutoff time.Time
+	if opts.OlderThan != "" {
+		var err error
+		if cutoff, err = parseAge(opts.OlderThan, time.Now()); err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+	}
+	var filter filterExpr
+	if config.Filter != "" {
+		var err error
+		if filter, err = parseFilter(config.Filter); err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		// Filter the candidates, not the liked videos fetched below
+		config.Filter = ""
+	}
+	if !opts.DryRun {
+		config = withScopes(config, "write", youtube.YoutubeForceSslScope)
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var records []map[string]any
+	if opts.From != "" {
+		if records, err = readJSONLRecords(opts.From); err != nil {
+			return err
+		}
+	} else {
+		collector := &recordCollector{}
+		if err := (likedExporter{config}).Fetch(context.Background(), service, collector); err != nil {
+			return err
+		}
+		records = collector.records
+	}
+
+	var matches []map[string]any
+	for _, record := range records {
+		if recordVideoID(record) != "" && matchesPrune(record, opts, cutoff, filter) {
+			matches = append(matches, record)
+		}
+	}
+	if len(matches) == 0 {
+		infof("No liked videos match the filters")
+		return nil
+	}
+
+	for _, record := range matches {
+		infof("%s  %s  %s (%s)", recordVideoID(record),
+			lookupString(record, "snippet", "publishedAt"),
+			lookupString(record, "snippet", "title"),
+			lookupString(record, "snippet", "channelTitle"))
+	}
+	if opts.DryRun {
+		infof("%d of %d liked videos would be removed", len(matches), len(records))
+		return nil
+	}
+	if !opts.Yes && promptUser(fmt.Sprintf("Remove %d likes? (y/N): ", len(matches))) != "y" {
+		infof("Aborted")
+		return nil
+	}
+
+	undo, err := openUndoLog(opts.UndoFile)
+	if err != nil {
+		return err
+	}
+	defer undo.close()
+
+	failed := 0
+	for i, record := range matches {
+		if i > 0 && opts.Delay > 0 {
+			time.Sleep(opts.Delay)
+		}
+		id := recordVideoID(record)
+		if err := service.Videos.Rate(id, "none").Do(); err != nil {
+			if isQuotaError(err) {
+				return withKind(ErrQuota, fmt.Errorf("stopped after %d removals: %w", i-failed, err))
+			}
+			warnf("Failed to remove like from %s: %v", id, err)
+			failed++
+			continue
+		}
+		if err := undo.record(undoEntry{
+			Action:  undoActionUnlike,
+			VideoID: id,
+			Title:   lookupString(record, "snippet", "title"),
+			Channel: lookupString(record, "snippet", "channelTitle"),
+		}); err != nil {
+			return err
+		}
+	}
+
+	infof("Removed %d likes; undo with 'ytdata undo %s'", len(matches)-failed, undo.path)
+	if failed > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d of %d likes could not be removed", failed, len(matches)))
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	reconcileTakeoutOnly = "takeout_only"
+	reconcileAPIOnly     = "api_only"
+)
+
+type ReconcileOptions struct {
+	Liked         string
+	Subscriptions string
+}
+
+// reconcileEntry is one item found in only one of Takeout and the API.
+type reconcileEntry struct {
+	Dataset string `json:"dataset"`
+	ID      string `json:"id"`
+	Title   string `json:"title,omitempty"`
+	Status  string `json:"status"`
+}
+
+// reconcileItem is an item of either side, with a title when known.
+type reconcileItem struct {
+	ID    string
+	Title string
+}
+
+func newReconcileCmd(config *Config) *cobra.Command {
+	var opts ReconcileOptions
+
+	cmd := &cobra.Command{
+		Use:   "reconcile TAKEOUT_DIR",
+		Short: "Cross-check a Google Takeout against the API",
+		Long: `Compare the liked videos and subscriptions of an extracted Google Takeout
+with the API and report every item present on only one side: status
+takeout_only for items the API no longer returns (e.g. deleted or private
+videos, terminated channels) and api_only for items missing from Takeout.
+
+Takeout files are found anywhere below TAKEOUT_DIR (playlists/Liked
+videos.csv and subscriptions/subscriptions.csv). The API side is read from
+existing exports with --liked and --subscriptions, or fetched fresh when
+these are not given. A summary per dataset is printed to stderr.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{},
+		Example: `  ytdata reconcile ~/Downloads/Takeout
+  ytdata reconcile Takeout --liked liked.jsonl --subscriptions subscriptions.jsonl -o discrepancies.jsonl
+  ytdata reconcile Takeout --filter 'status == "takeout_only"'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Liked != "" && opts.Subscriptions != "" {
+				// Comparing files needs neither credentials nor setup
+				cmd.Annotations[localAnnotation] = "true"
+			}
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return reconcileTakeout(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Liked, "liked", "", "Compare with this liked export instead of fetching liked videos")
+	cmd.Flags().StringVar(&opts.Subscriptions, "subscriptions", "", "Compare with this subscriptions export instead of fetching subscriptions")
+	addOutputFlag(cmd, "", "Write discrepancies to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report discrepancies matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// takeoutLikes reads the liked videos playlist of a Takeout.
+func takeoutLikes(path string) ([]reconcileItem, error) {
+	rows, err := readTakeoutCSV(path, "video id")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]reconcileItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, reconcileItem{ID: row["video id"]})
+	}
+	return items, nil
+}
+
+// takeoutSubscriptions reads the subscriptions of a Takeout.
+func takeoutSubscriptions(path string) ([]reconcileItem, error) {
+	rows, err := readTakeoutCSV(path, "channel id")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]reconcileItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, reconcileItem{ID: row["channel id"], Title: row["channel title"]})
+	}
+	return items, nil
+}
+
+// apiItems reads the API side of a dataset from an export, or runs the
+// exporter when path is empty.
+func apiItems(config Config, path string, exporter Exporter, id func(map[string]any) string) ([]reconcileItem, error) {
+	var records []map[string]any
+	if path != "" {
+		var err error
+		if records, err = readJSONLRecords(path); err != nil {
+			return nil, err
+		}
+	} else {
+		service, err := authenticateYouTube(config)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+		collector := &recordCollector{}
+		if err := exporter.Fetch(context.Background(), service, collector); err != nil {
+			return nil, err
+		}
+		records = collector.records
+	}
+	items := make([]reconcileItem, 0, len(records))
+	for _, record := range records {
+		if itemID := id(record); itemID != "" {
+			items = append(items, reconcileItem{ID: itemID, Title: lookupString(record, "snippet", "title")})
+		}
+	}
+	return items, nil
+}
+
+// diffItems returns the entries of a dataset found on only one side, in
+// the order of that side.
+func diffItems(dataset string, takeout, api []reconcileItem) []reconcileEntry {
+	inTakeout := make(map[string]bool, len(takeout))
+	for _, item := range takeout {
+		inTakeout[item.ID] = true
+	}
+	inAPI := make(map[string]bool, len(api))
+	for _, item := range api {
+		inAPI[item.ID] = true
+	}
+
+	var entries []reconcileEntry
+	seen := make(map[string]bool)
+	for _, item := range takeout {
+		if !inAPI[item.ID] && !seen[item.ID] {
+			seen[item.ID] = true
+			entries = append(entries, reconcileEntry{Dataset: dataset, ID: item.ID, Title: item.Title, Status: reconcileTakeoutOnly})
+		}
+	}
+	for _, item := range api {
+		if !inTakeout[item.ID] && !seen[item.ID] {
+			seen[item.ID] = true
+			entries = append(entries, reconcileEntry{Dataset: dataset, ID: item.ID, Title: item.Title, Status: reconcileAPIOnly})
+		}
+	}
+	return entries
+}
+
+func reconcileTakeout(config Config, dir string, opts ReconcileOptions) (err error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return withKind(ErrInvalidConfig, fmt.Errorf("%s is not a directory; extract the Takeout archive first", dir))
+	}
+
+	datasets := []struct {
+		name     string
+		match    func(name string) bool
+		read     func(path string) ([]reconcileItem, error)
+		export   string
+		exporter Exporter
+		id       func(map[string]any) string
+	}{
+		{
+			name: "liked",
+			match: func(name string) bool {
+				return strings.HasPrefix(name, "liked videos") && strings.HasSuffix(name, ".csv")
+			},
+			read:     takeoutLikes,
+			export:   opts.Liked,
+			exporter: likedExporter{config},
+			id:       recordVideoID,
+		},
+		{
+			name:     "subscriptions",
+			match:    func(name string) bool { return name == "subscriptions.csv" },
+			read:     takeoutSubscriptions,
+			export:   opts.Subscriptions,
+			exporter: subscriptionsExporter{config},
+			id:       recordChannelID,
+		},
+	}
+
+	var entries []reconcileEntry
+	compared := 0
+	for _, dataset := range datasets {
+		path, err := findTakeoutFile(dir, dataset.match)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			warnf("No %s file found in %s, skipping", dataset.name, dir)
+			continue
+		}
+		takeout, err := dataset.read(path)
+		if err != nil {
+			return err
+		}
+		api, err := apiItems(config, dataset.export, dataset.exporter, dataset.id)
+		if err != nil {
+			return err
+		}
+		found := diffItems(dataset.name, takeout, api)
+		counts := make(map[string]int)
+		for _, entry := range found {
+			counts[entry.Status]++
+		}
+		infof("%s: %d in Takeout, %d from the API, %d only in Takeout, %d only in the API",
+			dataset.name, len(takeout), len(api), counts[reconcileTakeoutOnly], counts[reconcileAPIOnly])
+		entries = append(entries, found...)
+		compared++
+	}
+	if compared == 0 {
+		return fmt.Errorf("no liked videos or subscriptions found in %s", dir)
+	}
+
+	out, err := openOutput(config, "reconciliation report")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, entry := range entries {
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+const (
+	redactedEmail = "[redacted email]"
+
+	// redactKeyFile holds the secret the redacted IDs are keyed with
+	redactKeyFile = "redact-key"
+)
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// redactWriter removes personal data from records so exports can be
+// shared: the account's channel ID and the IDs derived from it are replaced
+// by stable hashes everywhere they appear, email addresses are masked, and
+// playlist descriptions are dropped.
+type redactWriter struct {
+	output.Writer
+	// channelIDs matches the channel ID (UC...) and the IDs of its uploads
+	// playlists, which share its suffix (UU..., UULF..., UUSH...)
+	channelIDs *regexp.Regexp
+	// key is the secret of this installation the hashes are keyed with
+	key []byte
+}
+
+// newRedactWriter redacts the channel given with --channel-id or the one
+// of the account. Without either, the channel ID would pass through, so it
+// fails instead.
+func newRedactWriter(w output.Writer, config Config) (redactWriter, error) {
+	channelID := config.ChannelID
+	if channelID == "" {
+		channelID = accountChannelID(config)
+	}
+	if channelID == "" {
+		return redactWriter{}, withKind(ErrInvalidConfig, errors.New("--redact cannot determine your channel ID; pass it with --channel-id"))
+	}
+	key, err := loadRedactKey(filepath.Join(getConfigDir(), redactKeyFile))
+	if err != nil {
+		return redactWriter{}, err
+	}
+	return redactWriter{Writer: w, channelIDs: derivedIDsPattern(channelID), key: key}, nil
+}
+
+// loadRedactKey reads the redaction secret, creating it on first use.
+func loadRedactKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("invalid redaction key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read redaction key: %w", err)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save redaction key: %w", err)
+	}
+	return key, nil
+}
+
+// derivedIDsPattern matches channelID and the playlist IDs derived from it,
+// or is nil without a channel ID.
+func derivedIDsPattern(channelID string) *regexp.Regexp {
+	if len(channelID) <= 2 {
+		return nil
+	}
+	return regexp.MustCompile(`(UC|UU[A-Z]{0,2})` + regexp.QuoteMeta(channelID[2:]))
+}
+
+// redactedID replaces an ID with a short HMAC, so records stay joinable
+// without revealing the ID. Keyed with a secret, a guessed public ID cannot
+// be confirmed by hashing it.
+func (w redactWriter) redactedID(id string) string {
+	mac := hmac.New(sha256.New, w.key)
+	mac.Write([]byte(id))
+	return "redacted-" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func (w redactWriter) Write(record any) error {
+	m, err := toRecord(record)
+	if err != nil {
+		// Not an object (e.g. a scalar emitted by --transform)
+		return w.Writer.Write(record)
+	}
+	if lookupString(m, "kind") == "youtube#playlist" {
+		if snippet, ok := m["snippet"].(map[string]any); ok {
+			delete(snippet, "description")
+			if localized, ok := snippet["localized"].(map[string]any); ok {
+				delete(localized, "description")
+			}
+		}
+		if localizations, ok := m["localizations"].(map[string]any); ok {
+			for _, localization := range localizations {
+				if l, ok := localization.(map[string]any); ok {
+					delete(l, "description")
+				}
+			}
+		}
+	}
+	return w.Writer.Write(w.redact(m))
+}
+
+func (w redactWriter) redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = w.redact(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = w.redact(child)
+		}
+		return v
+	case string:
+		if w.channelIDs != nil {
+			v = w.channelIDs.ReplaceAllStringFunc(v, w.redactedID)
+		}
+		return emailPattern.ReplaceAllString(v, redactedEmail)
+	default:
+		return v
+	}
+}
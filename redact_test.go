@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactChannelIDs(t *testing.T) {
+	const channelID = "UCaaaaaaaaaaaaaaaaaaaaaa"
+	w := redactWriter{channelIDs: derivedIDsPattern(channelID)}
+	record := map[string]any{
+		"channelId":    channelID,
+		"uploads":      "UUaaaaaaaaaaaaaaaaaaaaaa",
+		"url":          "https://www.youtube.com/playlist?list=UULFaaaaaaaaaaaaaaaaaaaaaa",
+		"contact":      "me@example.com",
+		"otherChannel": "UCbbbbbbbbbbbbbbbbbbbbbb",
+	}
+	w.redact(record)
+	for _, key := range []string{"channelId", "uploads", "url"} {
+		if s := record[key].(string); strings.Contains(s, "aaaaaaaaaaaaaaaaaaaaaa") || !strings.Contains(s, "redacted-") {
+			t.Errorf("%s = %q, want the channel ID redacted", key, s)
+		}
+	}
+	if record["channelId"] == record["uploads"] {
+		t.Errorf("channel and uploads playlist got the same hash %q", record["uploads"])
+	}
+	if record["contact"] != redactedEmail {
+		t.Errorf("contact = %q, want it masked", record["contact"])
+	}
+	if record["otherChannel"] != "UCbbbbbbbbbbbbbbbbbbbbbb" {
+		t.Errorf("other channel = %q, want it kept", record["otherChannel"])
+	}
+}
+
+func TestRedactKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", redactKeyFile)
+	key, err := loadRedactKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadRedactKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("the redaction key changed between runs")
+	}
+
+	const channelID = "UCaaaaaaaaaaaaaaaaaaaaaa"
+	ours := redactWriter{key: key}.redactedID(channelID)
+	if ours != (redactWriter{key: again}).redactedID(channelID) {
+		t.Error("the same key gave different hashes")
+	}
+	if ours == (redactWriter{key: []byte("another installation")}).redactedID(channelID) {
+		t.Error("different keys gave the same hash")
+	}
+}
+
+func TestRedactNeedsChannelID(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := newRedactWriter(nil, Config{Local: true}); classifyError(err) != ErrInvalidConfig {
+		t.Errorf("newRedactWriter without a channel ID = %v, want a config error", err)
+	}
+	if _, err := newRedactWriter(nil, Config{Local: true, ChannelID: "UCaaaaaaaaaaaaaaaaaaaaaa"}); err != nil {
+		t.Errorf("newRedactWriter with --channel-id: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// recordedExchange is one API request and its raw response as stored in a
+// record directory.
+type recordedExchange struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	RequestBody string `json:"requestBody,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// canonicalURL strips credentials from a request URL and sorts its query so
+// recordings are stable and never contain secrets.
+func canonicalURL(u *url.URL) string {
+	query := u.Query()
+	query.Del("key")
+	query.Del("access_token")
+	canonical := *u
+	canonical.RawQuery = query.Encode()
+	canonical.User = nil
+	return canonical.String()
+}
+
+func exchangeFile(dir, method, canonical string, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", method, canonical)
+	hash.Write(body)
+	return filepath.Join(dir, hex.EncodeToString(hash.Sum(nil))[:20]+".json")
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// recordTransport passes requests through and stores every response.
+type recordTransport struct {
+	dir  string
+	base http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if closeErr := resp.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	canonical := canonicalURL(req.URL)
+	exchange := recordedExchange{
+		Method:      req.Method,
+		URL:         canonical,
+		RequestBody: string(body),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(respBody),
+	}
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(exchangeFile(t.dir, req.Method, canonical, body), data, 0600); err != nil {
+		warnf("Failed to record response: %v", err)
+	}
+
+	return resp, nil
+}
+
+// replayTransport serves responses from a record directory without touching
+// the network.
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical := canonicalURL(req.URL)
+	data, err := os.ReadFile(exchangeFile(t.dir, req.Method, canonical, body))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, canonical, t.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var exchange recordedExchange
+	if err := json.Unmarshal(data, &exchange); err != nil {
+		return nil, fmt.Errorf("invalid recording for %s %s: %w", req.Method, canonical, err)
+	}
+
+	header := make(http.Header)
+	if exchange.ContentType != "" {
+		header.Set("Content-Type", exchange.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(exchange.Body))),
+		ContentLength: int64(len(exchange.Body)),
+		Request:       req,
+	}, nil
+}
+
+// apiKeyTransport adds an API key to every request.
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	query := clone.URL.Query()
+	query.Set("key", t.key)
+	clone.URL.RawQuery = query.Encode()
+	return t.base.RoundTrip(clone)
+}
+
+// endpointTransport sends every request to another server, keeping path
+// and query, so ytdata can run against a fake API.
+type endpointTransport struct {
+	endpoint string
+	base     http.RoundTripper
+}
+
+func (t *endpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint, err := url.Parse(t.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.URL.Scheme = endpoint.Scheme
+	clone.URL.Host = endpoint.Host
+	clone.Host = endpoint.Host
+	return t.base.RoundTrip(clone)
+}
+
+// withRecording wraps client so its responses are stored in dir.
+func withRecording(client *http.Client, dir string) (*http.Client, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create record directory: %w", err)
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	recording := *client
+	recording.Transport = &recordTransport{dir: dir, base: base}
+	return &recording, nil
+}
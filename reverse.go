@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rtzll/ytdata/output"
+)
+
+// reverseWriter implements --oldest-first: the API lists liked videos and
+// playlists newest first and cannot reverse that, so records are spooled to
+// a temporary file and written to the wrapped writer in reverse order on
+// Close. Only the offsets of the records are kept in memory.
+type reverseWriter struct {
+	output.Writer
+	spool   *os.File
+	offsets []int64
+	size    int64
+}
+
+func newReverseWriter(w output.Writer) (*reverseWriter, error) {
+	spool, err := os.CreateTemp("", "ytdata-reverse-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	return &reverseWriter{Writer: w, spool: spool}, nil
+}
+
+func (w *reverseWriter) Write(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := w.spool.Write(data); err != nil {
+		return fmt.Errorf("failed to spool record: %w", err)
+	}
+	w.offsets = append(w.offsets, w.size)
+	w.size += int64(len(data))
+	return nil
+}
+
+func (w *reverseWriter) Close() (err error) {
+	defer func() {
+		_ = w.spool.Close()
+		_ = os.Remove(w.spool.Name())
+		if closeErr := w.Writer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	end := w.size
+	for i := len(w.offsets) - 1; i >= 0; i-- {
+		data := make([]byte, end-w.offsets[i])
+		if _, err := w.spool.ReadAt(data, w.offsets[i]); err != nil {
+			return fmt.Errorf("failed to read spooled record: %w", err)
+		}
+		if err := w.Writer.Write(json.RawMessage(data)); err != nil {
+			return err
+		}
+		end = w.offsets[i]
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const formatHTML = "html"
+
+// rewindTop is the number of channels and videos listed per section.
+const rewindTop = 5
+
+type RewindOptions struct {
+	History string
+}
+
+// rewindCount is a ranked entry of the report, e.g. a channel and its
+// likes.
+type rewindCount struct {
+	Name  string
+	URL   string
+	Count int
+}
+
+// rewindSection summarizes liked videos or watches of the year.
+type rewindSection struct {
+	Count        int
+	Hours        float64
+	Channels     int
+	TopChannels  []rewindCount
+	TopVideos    []rewindCount
+	BusiestMonth string
+	BusiestCount int
+}
+
+type rewindReport struct {
+	Year      int
+	Liked     rewindSection
+	Playlists struct {
+		Created int
+		Added   int
+		Top     []rewindCount
+	}
+	// History is nil without --history
+	History *rewindSection
+}
+
+// rewindEvent is a like or watch of a video.
+type rewindEvent struct {
+	VideoID   string
+	Title     string
+	Channel   string
+	ChannelID string
+	Time      time.Time
+}
+
+func newRewindCmd(config *Config) *cobra.Command {
+	var opts RewindOptions
+
+	cmd := &cobra.Command{
+		Use:   "rewind YEAR",
+		Short: "Summarize a year on YouTube",
+		Long: `Write a summary of a year on YouTube as Markdown or HTML: the videos you
+liked (how many, how many hours, top channels, busiest month), the playlists
+you created and filled, and with --history, what you watched according to
+the watch history of a Google Takeout export (see 'ytdata stats history').
+
+Likes are read from the liked videos playlist, which only holds the most
+recent 5,000 likes. Hours add up the durations of the videos.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Example: `  ytdata rewind 2024
+  ytdata rewind 2024 --history ~/Downloads/Takeout -f html -o rewind-2024.html`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				year, err := strconv.Atoi(args[0])
+				if err != nil || year < 2005 {
+					return withKind(ErrInvalidConfig, fmt.Errorf("invalid year %q", args[0]))
+				}
+				return rewind(config, year, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.History, "history", "", "Takeout directory or watch-history.json to include watches from")
+	addOutputFlag(cmd, "", "Write the summary to stdout (or file with -o)")
+	addFormatFlag(cmd, formatMarkdown, formatHTML)
+
+	return cmd
+}
+
+// summarizeEvents builds a section from the events of a year. durations
+// holds the known video durations.
+func summarizeEvents(events []rewindEvent, durations map[string]time.Duration) rewindSection {
+	section := rewindSection{Count: len(events)}
+	channels := make(map[string]*rewindCount)
+	videos := make(map[string]*rewindCount)
+	var months [12]int
+	var total time.Duration
+	for _, e := range events {
+		total += durations[e.VideoID]
+		months[e.Time.Month()-1]++
+		if e.ChannelID != "" || e.Channel != "" {
+			key := e.ChannelID + e.Channel
+			channel, ok := channels[key]
+			if !ok {
+				channel = &rewindCount{Name: e.Channel}
+				if e.ChannelID != "" {
+					channel.URL = "https://www.youtube.com/channel/" + e.ChannelID
+				}
+				channels[key] = channel
+			}
+			channel.Count++
+		}
+		if e.VideoID != "" {
+			video, ok := videos[e.VideoID]
+			if !ok {
+				video = &rewindCount{Name: e.Title, URL: "https://www.youtube.com/watch?v=" + e.VideoID}
+				videos[e.VideoID] = video
+			}
+			video.Count++
+		}
+	}
+	section.Hours = math.Round(total.Hours()*10) / 10
+	section.Channels = len(channels)
+	section.TopChannels = topCounts(channels)
+	section.TopVideos = topCounts(videos)
+	for month, count := range months {
+		if count > section.BusiestCount {
+			section.BusiestMonth = time.Month(month + 1).String()
+			section.BusiestCount = count
+		}
+	}
+	return section
+}
+
+// topCounts returns the rewindTop entries with the highest counts.
+func topCounts(counts map[string]*rewindCount) []rewindCount {
+	entries := make([]rewindCount, 0, len(counts))
+	for _, c := range counts {
+		entries = append(entries, *c)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > rewindTop {
+		entries = entries[:rewindTop]
+	}
+	return entries
+}
+
+// inYear reports whether an RFC 3339 time lies in year, returning it.
+func inYear(value string, year int) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, value)
+	return t, err == nil && t.Year() == year
+}
+
+// likedEvents returns the likes of a year from the liked videos playlist.
+func likedEvents(ctx context.Context, service *youtube.Service, year int) ([]rewindEvent, error) {
+	items, err := fetchPlaylistItems(ctx, service, likedPlaylistID)
+	if err != nil {
+		return nil, err
+	}
+	var events []rewindEvent
+	for _, item := range items {
+		if item.Snippet == nil {
+			continue
+		}
+		t, ok := inYear(item.Snippet.PublishedAt, year)
+		if !ok {
+			continue
+		}
+		events = append(events, rewindEvent{
+			VideoID:   playlistItemVideoID(item),
+			Title:     item.Snippet.Title,
+			Channel:   item.Snippet.VideoOwnerChannelTitle,
+			ChannelID: item.Snippet.VideoOwnerChannelId,
+			Time:      t,
+		})
+	}
+	return events, nil
+}
+
+// historyEvents returns the watches of a year from a Takeout watch
+// history, with resumed watches counted once.
+func historyEvents(path string, year int) ([]rewindEvent, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		file, err := findTakeoutFile(path, func(name string) bool {
+			return strings.HasPrefix(name, "watch-history.")
+		})
+		if err != nil {
+			return nil, withKind(ErrInvalidConfig, err)
+		}
+		if file == "" {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("no watch-history.json found in %s", path))
+		}
+		path = file
+	}
+	watches, err := readTakeoutWatchHistory(path)
+	if err != nil {
+		return nil, withKind(ErrInvalidConfig, err)
+	}
+	watches, _ = dedupeWatches(watches, 5*time.Minute)
+	var events []rewindEvent
+	for _, w := range watches {
+		if w.Time.Year() == year {
+			events = append(events, rewindEvent{VideoID: w.VideoID, Title: w.Title, Channel: w.Channel, ChannelID: w.ChannelID, Time: w.Time})
+		}
+	}
+	return events, nil
+}
+
+func rewind(config Config, year int, opts RewindOptions) (err error) {
+	var history []rewindEvent
+	if opts.History != "" {
+		if history, err = historyEvents(opts.History, year); err != nil {
+			return err
+		}
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	report := rewindReport{Year: year}
+	liked, err := likedEvents(ctx, service, year)
+	if err != nil {
+		return err
+	}
+
+	// The items are fetched below; the exporter only adds them for markdown
+	playlistConfig := config
+	playlistConfig.Format = formatJSONL
+	collector := &recordCollector{}
+	if err := (playlistsExporter{playlistConfig}).Fetch(ctx, service, collector); err != nil {
+		return err
+	}
+	playlists := make(map[string]*rewindCount)
+	for _, record := range collector.records {
+		playlistID := lookupString(record, "id")
+		if _, ok := inYear(lookupString(record, "snippet", "publishedAt"), year); ok {
+			report.Playlists.Created++
+		}
+		items, err := fetchPlaylistItems(ctx, service, playlistID)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.Snippet == nil {
+				continue
+			}
+			if _, ok := inYear(item.Snippet.PublishedAt, year); !ok {
+				continue
+			}
+			report.Playlists.Added++
+			p, ok := playlists[playlistID]
+			if !ok {
+				p = &rewindCount{Name: lookupString(record, "snippet", "title"), URL: "https://www.youtube.com/playlist?list=" + playlistID}
+				playlists[playlistID] = p
+			}
+			p.Count++
+		}
+	}
+	report.Playlists.Top = topCounts(playlists)
+
+	// Durations of every liked and watched video
+	seen := make(map[string]bool)
+	var ids []string
+	for _, e := range append(liked, history...) {
+		if e.VideoID != "" && !seen[e.VideoID] {
+			seen[e.VideoID] = true
+			ids = append(ids, e.VideoID)
+		}
+	}
+	videos, err := fetchVideosByID(service, config, ids, []string{"contentDetails"})
+	if err != nil {
+		return err
+	}
+	durations := make(map[string]time.Duration, len(videos))
+	for id, video := range videos {
+		if d, ok := videoDuration(video); ok {
+			durations[id] = d
+		}
+	}
+
+	report.Liked = summarizeEvents(liked, durations)
+	if opts.History != "" {
+		section := summarizeEvents(history, durations)
+		report.History = &section
+	}
+
+	w, err := createOutputWriter(config)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(w, &err)
+
+	if config.Format == formatHTML {
+		err = rewindHTML.Execute(w, report)
+	} else {
+		err = rewindMarkdown.Execute(w, report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+var rewindFuncs = map[string]any{"md": output.MarkdownEscape, "inc": inc}
+
+var rewindMarkdown = template.Must(template.New("rewind").Funcs(rewindFuncs).Parse(`# {{.Year}} on YouTube
+{{with .Liked}}
+## Likes
+
+You liked **{{.Count}} videos** from {{.Channels}} channels, {{.Hours}} hours in total.{{if .BusiestMonth}} Your busiest month was {{.BusiestMonth}} with {{.BusiestCount}} likes.{{end}}
+{{if .TopChannels}}
+Top channels:
+
+{{range $i, $c := .TopChannels}}{{inc $i}}. {{if $c.URL}}[{{md $c.Name}}]({{$c.URL}}){{else}}{{md $c.Name}}{{end}} ({{$c.Count}})
+{{end}}{{end}}{{end}}
+## Playlists
+
+You created {{.Playlists.Created}} playlists and added {{.Playlists.Added}} videos to your playlists.
+{{if .Playlists.Top}}
+Most filled:
+
+{{range $i, $p := .Playlists.Top}}{{inc $i}}. [{{md $p.Name}}]({{$p.URL}}) ({{$p.Count}} added)
+{{end}}{{end}}{{with .History}}
+## Watching
+
+You watched **{{.Count}} videos** from {{.Channels}} channels, about {{.Hours}} hours.{{if .BusiestMonth}} Your busiest month was {{.BusiestMonth}} with {{.BusiestCount}} videos.{{end}}
+{{if .TopChannels}}
+Most watched channels:
+
+{{range $i, $c := .TopChannels}}{{inc $i}}. {{if $c.URL}}[{{md $c.Name}}]({{$c.URL}}){{else}}{{md $c.Name}}{{end}} ({{$c.Count}})
+{{end}}{{end}}{{if .TopVideos}}
+Most watched videos:
+
+{{range $i, $v := .TopVideos}}{{inc $i}}. [{{md $v.Name}}]({{$v.URL}}) ({{$v.Count}}×)
+{{end}}{{end}}{{end}}`))
+
+var rewindHTML = htmltemplate.Must(htmltemplate.New("rewind").Funcs(map[string]any{"inc": inc}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Year}} on YouTube</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 0 auto; padding: 1rem; color: #222; }
+.big { font-size: 2.5rem; font-weight: bold; margin: .5rem 0; }
+.note { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Year}} on YouTube</h1>
+{{with .Liked}}<h2>Likes</h2>
+<p class="big">{{.Count}} videos</p>
+<p>from {{.Channels}} channels, {{.Hours}} hours in total.{{if .BusiestMonth}} Busiest month: {{.BusiestMonth}} ({{.BusiestCount}} likes).{{end}}</p>
+{{if .TopChannels}}<ol>{{range .TopChannels}}<li>{{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}} <span class="note">{{.Count}}</span></li>{{end}}</ol>{{end}}{{end}}
+<h2>Playlists</h2>
+<p>{{.Playlists.Created}} playlists created, {{.Playlists.Added}} videos added.</p>
+{{if .Playlists.Top}}<ol>{{range .Playlists.Top}}<li><a href="{{.URL}}">{{.Name}}</a> <span class="note">{{.Count}} added</span></li>{{end}}</ol>{{end}}
+{{with .History}}<h2>Watching</h2>
+<p class="big">{{.Count}} videos</p>
+<p>from {{.Channels}} channels, about {{.Hours}} hours.{{if .BusiestMonth}} Busiest month: {{.BusiestMonth}} ({{.BusiestCount}} videos).{{end}}</p>
+{{if .TopChannels}}<h3>Channels</h3><ol>{{range .TopChannels}}<li>{{if .URL}}<a href="{{.URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}} <span class="note">{{.Count}}</span></li>{{end}}</ol>{{end}}
+{{if .TopVideos}}<h3>Videos</h3><ol>{{range .TopVideos}}<li><a href="{{.URL}}">{{.Name}}</a> <span class="note">{{.Count}}×</span></li>{{end}}</ol>{{end}}{{end}}
+<footer class="note">Generated by ytdata</footer>
+</body>
+</html>
+`))
+
+func inc(i int) int {
+	return i + 1
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"google.golang.org/api/youtube/v3"
+)
+
+func init() {
+	for _, format := range []string{formatRSS, formatAtom} {
+		output.Register(format, func(opts output.Options) (output.Writer, error) {
+			w, err := opts.Open()
+			if err != nil {
+				return nil, err
+			}
+			title := "YouTube videos"
+			if opts.Dataset != "" {
+				title = "YouTube " + opts.Dataset + " videos"
+			}
+			return &feedWriter{w: w, format: format, title: title}, nil
+		})
+	}
+}
+
+// feedWriter collects videos and writes them as an RSS or Atom feed on
+// Close. Enriched video records are converted back into videos.
+type feedWriter struct {
+	w      io.WriteCloser
+	format string
+	title  string
+	videos []*youtube.Video
+}
+
+func (f *feedWriter) Write(record any) error {
+	video, ok := record.(*youtube.Video)
+	if !ok {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		video = &youtube.Video{}
+		if err := json.Unmarshal(data, video); err != nil {
+			return err
+		}
+		if video.Kind != "youtube#video" {
+			return fmt.Errorf("%s feeds only support videos, got %q", f.format, video.Kind)
+		}
+	}
+	f.videos = append(f.videos, video)
+	return nil
+}
+
+func (f *feedWriter) Close() error {
+	if err := writeVideoFeed(f.w, f.format, f.title, f.videos); err != nil {
+		_ = f.w.Close()
+		return fmt.Errorf("failed to write %s feed: %w", f.format, err)
+	}
+	return f.w.Close()
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Generator     string    `xml:"generator"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description,omitempty"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published,omitempty"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Links     []atomLink  `xml:"link"`
+	Summary   string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+func videoURL(id string) string {
+	return "https://www.youtube.com/watch?v=" + id
+}
+
+func channelURL(id string) string {
+	return "https://www.youtube.com/channel/" + id
+}
+
+// bestThumbnail returns the highest resolution thumbnail URL available.
+func bestThumbnail(thumbnails *youtube.ThumbnailDetails) string {
+	if thumbnails == nil {
+		return ""
+	}
+	for _, t := range []*youtube.Thumbnail{
+		thumbnails.Maxres, thumbnails.Standard, thumbnails.High,
+		thumbnails.Medium, thumbnails.Default,
+	} {
+		if t != nil && t.Url != "" {
+			return t.Url
+		}
+	}
+	return ""
+}
+
+// parsePublishedAt parses the RFC 3339 timestamps returned by the API.
+func parsePublishedAt(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func writeVideoFeed(w io.Writer, format, title string, videos []*youtube.Video) error {
+	switch format {
+	case formatRSS:
+		return writeRSSFeed(w, title, videos)
+	case formatAtom:
+		return writeAtomFeed(w, title, videos)
+	default:
+		return fmt.Errorf("unsupported feed format: %s", format)
+	}
+}
+
+func writeRSSFeed(w io.Writer, title string, videos []*youtube.Video) error {
+	feed := rssFeed{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         title,
+			Link:          "https://www.youtube.com/",
+			Description:   title + " exported by ytdata",
+			Generator:     "ytdata " + version,
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, video := range videos {
+		item := rssItem{
+			Link: videoURL(video.Id),
+			GUID: rssGUID{Value: video.Id},
+		}
+		if video.Snippet != nil {
+			item.Title = video.Snippet.Title
+			item.Description = video.Snippet.Description
+			item.Creator = video.Snippet.ChannelTitle
+			if published, ok := parsePublishedAt(video.Snippet.PublishedAt); ok {
+				item.PubDate = published.UTC().Format(time.RFC1123Z)
+			}
+			if thumbnail := bestThumbnail(video.Snippet.Thumbnails); thumbnail != "" {
+				item.Enclosure = &rssEnclosure{URL: thumbnail, Type: "image/jpeg"}
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return encodeXML(w, feed)
+}
+
+func writeAtomFeed(w io.Writer, title string, videos []*youtube.Video) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	feed := atomFeed{
+		Title:   title,
+		ID:      "urn:ytdata:" + title,
+		Updated: now,
+		Links:   []atomLink{{Href: "https://www.youtube.com/"}},
+	}
+
+	for _, video := range videos {
+		entry := atomEntry{
+			ID:      "yt:video:" + video.Id,
+			Updated: now,
+			Links:   []atomLink{{Href: videoURL(video.Id), Rel: "alternate"}},
+		}
+		if video.Snippet != nil {
+			entry.Title = video.Snippet.Title
+			entry.Summary = video.Snippet.Description
+			entry.Author = &atomAuthor{
+				Name: video.Snippet.ChannelTitle,
+				URI:  channelURL(video.Snippet.ChannelId),
+			}
+			if published, ok := parsePublishedAt(video.Snippet.PublishedAt); ok {
+				entry.Published = published.UTC().Format(time.RFC3339)
+				entry.Updated = entry.Published
+			}
+			if thumbnail := bestThumbnail(video.Snippet.Thumbnails); thumbnail != "" {
+				entry.Links = append(entry.Links, atomLink{Href: thumbnail, Rel: "enclosure", Type: "image/jpeg"})
+			}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return encodeXML(w, feed)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	formatJSONSchema = "jsonschema"
+	formatMarkdown   = "markdown"
+)
+
+// injectedField is a field ytdata adds to the records returned by the API.
+type injectedField struct {
+	Name        string
+	Schema      map[string]any
+	Description string
+}
+
+// datasetSchema describes the records of one export: the API resource and
+// the fields added to it.
+type datasetSchema struct {
+	Resource any
+	Title    string
+	Injected []injectedField
+}
+
+// provenanceFields are added to every record with --provenance.
+var provenanceFields = []injectedField{
+	{"_exportedAt", map[string]any{"type": "string", "format": "date-time"}, "Start of the export (--provenance)"},
+	{"_tool_version", map[string]any{"type": "string"}, "ytdata version (--provenance)"},
+	{"_source_command", map[string]any{"type": "string"}, "Command that wrote the record (--provenance)"},
+	{"_account_channel_id", map[string]any{"type": "string"}, "Channel of the authenticated account (--provenance)"},
+}
+
+var datasetSchemas = map[string]datasetSchema{
+	"liked": {
+		Resource: youtube.Video{},
+		Title:    "Liked video",
+		Injected: []injectedField{
+			{"categoryName", map[string]any{"type": "string"}, "Name of snippet.categoryId"},
+			{"regionBlocked", map[string]any{"type": "boolean"}, "Whether the video is blocked in --region (--flag-restricted)"},
+			{"durationSeconds", map[string]any{"type": "integer"}, "contentDetails.duration in seconds"},
+			{"videoType", map[string]any{"type": "string", "enum": []string{"short", "regular", "live"}}, "Classification as short, regular or live (--type)"},
+			{"streamDurationSeconds", map[string]any{"type": "integer"}, "Time a finished stream was live (--parts liveStreamingDetails)"},
+			{"chapters", map[string]any{"type": "array", "items": map[string]any{"type": "object"}}, "Chapters parsed from timestamp lines of the description (start, startSeconds, endSeconds, title)"},
+			{"contentLanguage", map[string]any{"type": "string"}, "BCP-47 content language, und if unknown (--detect-language, --language)"},
+			{"contentLanguageSource", map[string]any{"type": "string"}, "Where contentLanguage came from: audio, metadata or detected"},
+		},
+	},
+	"subscriptions": {
+		Resource: youtube.Channel{},
+		Title:    "Subscribed channel",
+		Injected: []injectedField{
+			{"links", map[string]any{"type": "array", "items": map[string]any{"type": "object"}}, "URLs and email addresses in the channel description, with their platform and source field (--links)"},
+		},
+	},
+	"playlists": {
+		Resource: youtube.Playlist{},
+		Title:    "Playlist",
+		Injected: []injectedField{
+			{"specialPlaylist", map[string]any{"type": "string", "enum": []string{"uploads", "likes", "favorites"}}, "Kind of system playlist (--include-special)"},
+		},
+	},
+}
+
+func datasetNames() []string {
+	names := make([]string, 0, len(datasetSchemas))
+	for name := range datasetSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newSchemaCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema " + strings.Join(datasetNames(), "|"),
+		Short: "Print the schema of an export",
+		Long: `Print the schema of the records an export command writes, derived from the
+YouTube API resource plus the fields ytdata adds, as JSON Schema or as a
+Markdown table of dotted field paths. Use it to build typed loaders.
+
+Large unsigned counts (e.g. statistics.viewCount) are strings in the
+exports, as returned by the API.`,
+		Args:         cobra.ExactArgs(1),
+		ValidArgs:    datasetNames(),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata schema liked
+  ytdata schema subscriptions --format markdown -o SCHEMA.md`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return printSchema(config, args[0])
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write the schema to stdout (or file with -o)")
+	addFormatFlag(cmd, formatJSONSchema, formatMarkdown)
+
+	return cmd
+}
+
+// typeSchema builds the JSON Schema of a Go type as it is encoded to JSON.
+// stringEncoded is set for fields with the ",string" option.
+func typeSchema(t reflect.Type, stringEncoded bool) map[string]any {
+	if stringEncoded {
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), false)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), false)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), false)}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if !field.IsExported() || tag == "" || tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			properties[name] = typeSchema(field.Type, strings.Contains(options, "string"))
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	default:
+		return map[string]any{}
+	}
+}
+
+// buildSchema returns the JSON Schema document of a dataset.
+func buildSchema(name string, dataset datasetSchema) map[string]any {
+	schema := typeSchema(reflect.TypeOf(dataset.Resource), false)
+	properties := schema["properties"].(map[string]any)
+	for _, field := range slices.Concat(dataset.Injected, provenanceFields) {
+		property := make(map[string]any, len(field.Schema)+1)
+		for key, value := range field.Schema {
+			property[key] = value
+		}
+		property["description"] = field.Description
+		properties[field.Name] = property
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = dataset.Title
+	schema["$id"] = "https://github.com/rtzll/ytdata/schema/" + name + ".json"
+	return schema
+}
+
+// writeSchemaMarkdown writes one table row per field path. Array items are
+// marked with [].
+func writeSchemaMarkdown(w io.Writer, name string, schema map[string]any) error {
+	if _, err := fmt.Fprintf(w, "# %s (`ytdata %s`)\n\n| Field | Type | Description |\n| --- | --- | --- |\n", schema["title"], name); err != nil {
+		return err
+	}
+	var walk func(path string, node map[string]any) error
+	walk = func(path string, node map[string]any) error {
+		typ, _ := node["type"].(string)
+		if path != "" {
+			description, _ := node["description"].(string)
+			if _, err := fmt.Fprintf(w, "| `%s` | %s | %s |\n", path, typ, description); err != nil {
+				return err
+			}
+		}
+		switch typ {
+		case "object":
+			properties, _ := node["properties"].(map[string]any)
+			keys := make([]string, 0, len(properties))
+			for key := range properties {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				child := key
+				if path != "" {
+					child = path + "." + key
+				}
+				if err := walk(child, properties[key].(map[string]any)); err != nil {
+					return err
+				}
+			}
+		case "array":
+			if items, ok := node["items"].(map[string]any); ok && items["type"] == "object" {
+				return walk(path+"[]", items)
+			}
+		}
+		return nil
+	}
+	return walk("", schema)
+}
+
+func printSchema(config Config, name string) (err error) {
+	dataset, ok := datasetSchemas[name]
+	if !ok {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unknown dataset %q (supported: %s)", name, strings.Join(datasetNames(), ", ")))
+	}
+	writer, err := createOutputWriter(config)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(writer, &err)
+
+	schema := buildSchema(name, dataset)
+	if config.Format == formatMarkdown {
+		return writeSchemaMarkdown(writer, name, schema)
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	_ "modernc.org/sqlite"
+)
+
+// searchIndexPath is the default location of the search index; the index
+// is rebuilt from the exports, so it lives in the cache directory.
+func searchIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = getConfigDir()
+	}
+	return filepath.Join(dir, "ytdata", "index.db")
+}
+
+// searchSchema stores each record once, plus a full-text index over its
+// title, description and channel name. unicode61 with diacritics removed
+// lets "cafe" find "Café".
+const searchSchema = `
+DROP TABLE IF EXISTS search;
+DROP TABLE IF EXISTS records;
+CREATE TABLE records (kind TEXT NOT NULL, id TEXT NOT NULL, source TEXT NOT NULL, data TEXT NOT NULL, PRIMARY KEY (kind, id));
+CREATE VIRTUAL TABLE search USING fts5(title, description, channel, tokenize = 'unicode61 remove_diacritics 2');
+`
+
+type IndexOptions struct {
+	DB string
+}
+
+func newIndexCmd(config *Config) *cobra.Command {
+	var opts IndexOptions
+
+	cmd := &cobra.Command{
+		Use:   "index [FILE|DIR...]",
+		Short: "Build a local search index over exports",
+		Long: `Build a full-text index over the titles, descriptions and channel names of
+the records in the given exports (JSONL or JSON arrays), or of all exports
+below the given directories (default: the current directory), for searching
+offline with 'ytdata find'.
+
+The index is rebuilt from scratch on every run; records found in several
+exports are indexed once.`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata index ./exports
+  ytdata index liked.jsonl playlist-items.jsonl --db youtube-index.db`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				if len(args) == 0 {
+					args = []string{"."}
+				}
+				return buildSearchIndex(args, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.DB, "db", searchIndexPath(), "Index database")
+
+	return cmd
+}
+
+type FindOptions struct {
+	DB    string
+	Limit int
+	Kind  string
+}
+
+func newFindCmd(config *Config) *cobra.Command {
+	var opts FindOptions
+
+	cmd := &cobra.Command{
+		Use:   "find QUERY",
+		Short: "Search the local index of exports",
+		Long: `Search the index built with 'ytdata index' and write the matching records,
+best matches first. Every word of the query must appear in the title,
+description or channel name; words match as prefixes and case and accents
+are ignored. Title matches rank highest.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata find "never gonna"
+  ytdata find lofi --kind video --limit 50 -f jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return findRecords(config, strings.Join(args, " "), opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.DB, "db", searchIndexPath(), "Index database")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 20, "Maximum number of results (0 = all)")
+	cmd.Flags().StringVar(&opts.Kind, "kind", "", "Only return records of this kind, e.g. video, playlist or channel")
+	addOutputFlag(cmd, "", "Write matching records to stdout (or file with -o)")
+	// Results are mostly read in the terminal, so table comes first
+	formats := []string{"table"}
+	for _, format := range recordFormats() {
+		if format != "table" {
+			formats = append(formats, format)
+		}
+	}
+	addFormatFlag(cmd, formats...)
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+// searchFields returns the indexed text of a record.
+func searchFields(record map[string]any) (title, description, channel string) {
+	title = lookupString(record, "snippet", "title")
+	description = lookupString(record, "snippet", "description")
+	channel = lookupString(record, "snippet", "channelTitle")
+	if channel == "" {
+		channel = lookupString(record, "snippet", "videoOwnerChannelTitle")
+	}
+	if channel == "" && lookupString(record, "kind") == "youtube#channel" {
+		channel = title
+	}
+	return title, description, channel
+}
+
+// exportFiles returns the JSONL and JSON files among paths, walking
+// directories.
+func exportFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if file == path || filepath.Ext(file) == ".jsonl" || filepath.Ext(file) == ".json" {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to read %s: %w", path, err))
+		}
+	}
+	return files, nil
+}
+
+func buildSearchIndex(paths []string, opts IndexOptions) (err error) {
+	files, err := exportFiles(paths)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.DB), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", opts.DB)
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err := tx.Exec(searchSchema); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	insertRecord, err := tx.Prepare(`INSERT OR IGNORE INTO records (kind, id, source, data) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertText, err := tx.Prepare(`INSERT INTO search (rowid, title, description, channel) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	indexed, used := 0, 0
+	for _, file := range files {
+		records, err := readExportRecords(file)
+		if err != nil {
+			warnf("Skipping %s: %v", file, err)
+			continue
+		}
+		count := 0
+		for _, record := range records {
+			kind, id := lookupString(record, "kind"), lookupString(record, "id")
+			title, description, channel := searchFields(record)
+			if kind == "" || id == "" || title+description+channel == "" {
+				continue
+			}
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			result, err := insertRecord.Exec(kind, id, file, string(data))
+			if err != nil {
+				return fmt.Errorf("failed to index %s: %w", file, err)
+			}
+			if n, _ := result.RowsAffected(); n == 0 {
+				// Already indexed from another export
+				continue
+			}
+			rowID, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			if _, err := insertText.Exec(rowID, title, description, channel); err != nil {
+				return fmt.Errorf("failed to index %s: %w", file, err)
+			}
+			count++
+		}
+		if count > 0 {
+			used++
+		}
+		indexed += count
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	infof("Indexed %d records from %d files into %s", indexed, used, opts.DB)
+	return nil
+}
+
+// searchQuery turns free text into an FTS5 query: every word must match,
+// as a prefix. Words are quoted so punctuation in the input is no syntax.
+func searchQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+func findRecords(config Config, text string, opts FindOptions) (err error) {
+	query := searchQuery(text)
+	if query == "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("empty query"))
+	}
+	if _, statErr := os.Stat(opts.DB); statErr != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("no index at %s; build one with 'ytdata index'", opts.DB))
+	}
+	db, err := sql.Open("sqlite", opts.DB)
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	kind := opts.Kind
+	if kind != "" && !strings.Contains(kind, "#") {
+		kind = "youtube#" + kind
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	// bm25 weights: title, description, channel
+	rows, err := db.Query(`SELECT records.data FROM search JOIN records ON records.rowid = search.rowid
+		WHERE search MATCH ? AND (? = '' OR records.kind = ?)
+		ORDER BY bm25(search, 10.0, 1.0, 5.0) LIMIT ?`, query, kind, kind, limit)
+	if err != nil {
+		return fmt.Errorf("failed to search index: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	out, err := openOutput(config, "search results")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return fmt.Errorf("corrupt index record: %w", err)
+		}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to search index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+)
+
+// Client secrets imported with `ytdata secrets import` are stored in the OS
+// keyring (Keychain, Credential Manager, Secret Service) under this entry.
+const (
+	keyringService    = "ytdata"
+	keyringSecretUser = "client_secret"
+)
+
+// readKeyringClientSecrets returns the client secrets stored in the keyring,
+// or nil if none were imported or no keyring is available.
+func readKeyringClientSecrets() []byte {
+	data, err := keyring.Get(keyringService, keyringSecretUser)
+	if err != nil {
+		return nil
+	}
+	return []byte(data)
+}
+
+// readClientSecrets reads the client secrets from path, or from the keyring
+// when path is empty.
+func readClientSecrets(path string) ([]byte, error) {
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	if data := readKeyringClientSecrets(); data != nil {
+		return data, nil
+	}
+	return nil, fmt.Errorf("no client secrets file given and none stored in the keyring")
+}
+
+type SecretsImportOptions struct {
+	Keep bool
+}
+
+func newSecretsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage client secrets in the OS keyring",
+		Long: `Store the OAuth client secrets in the OS keyring instead of a
+client_secret_*.json file on disk.
+
+When no --client-secret is given, secrets in the keyring are used before
+searching for a client secrets file.`,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newSecretsImportCmd(), newSecretsDeleteCmd())
+	return cmd
+}
+
+func newSecretsImportCmd() *cobra.Command {
+	var opts SecretsImportOptions
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Store a client secrets file in the keyring and delete it",
+		Long: `Validate a client secrets JSON file, store it in the OS keyring, and delete
+the file from disk (keep it with --keep).`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Example: `  ytdata secrets import client_secret_123.apps.googleusercontent.com.json
+  ytdata secrets import client_secret.json --keep`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return importClientSecrets(args[0], opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Keep, "keep", false, "Keep the file after importing it")
+
+	return cmd
+}
+
+func newSecretsDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "delete",
+		Short:        "Remove the client secrets from the keyring",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example:      "  ytdata secrets delete",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := keyring.Delete(keyringService, keyringSecretUser); err != nil {
+				if errors.Is(err, keyring.ErrNotFound) {
+					return fmt.Errorf("no client secrets stored in the keyring")
+				}
+				return fmt.Errorf("failed to delete client secrets from keyring: %w", err)
+			}
+			fmt.Println("Client secrets removed from the keyring")
+			return nil
+		},
+	}
+}
+
+func importClientSecrets(path string, opts SecretsImportOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read client secrets file: %w", err)
+	}
+	if err := validateClientSecrets(data); err != nil {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid client secrets file: %w", err))
+	}
+
+	if err := keyring.Set(keyringService, keyringSecretUser, string(data)); err != nil {
+		return fmt.Errorf("failed to store client secrets in keyring: %w", err)
+	}
+	fmt.Println("Client secrets stored in the keyring")
+
+	if opts.Keep {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	fmt.Printf("Deleted %s\n", path)
+	return nil
+}
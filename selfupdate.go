@@ -0,0 +1,415 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	latestReleaseURL    = "https://api.github.com/repos/rtzll/ytdata/releases/latest"
+	updateCheckInterval = 24 * time.Hour
+)
+
+type SelfUpdateOptions struct {
+	Check  bool
+	Notice string
+}
+
+// githubRelease is the part of a GitHub release self-update needs.
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	HTMLURL string        `json:"html_url"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func (r githubRelease) version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// updateCheck is the state of the new version notice, kept in the config
+// directory so GitHub is asked at most once a day.
+type updateCheck struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Latest    string    `json:"latest,omitempty"`
+	// Disabled turns the notice off ('self-update --notice off')
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+func updateCheckPath() string {
+	return filepath.Join(getConfigDir(), "update-check.json")
+}
+
+func readUpdateCheck() updateCheck {
+	var check updateCheck
+	if data, err := os.ReadFile(updateCheckPath()); err == nil {
+		_ = json.Unmarshal(data, &check)
+	}
+	return check
+}
+
+func newSelfUpdateCmd(config *Config) *cobra.Command {
+	var opts SelfUpdateOptions
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update ytdata to the latest release",
+		Long: `Download the latest release of ytdata from GitHub for this platform, check
+it against the SHA-256 checksums published with the release, and replace
+the running binary with it. --check only reports whether a new version is
+available.
+
+The checksums come from the same release, so they catch corrupted
+downloads, not a tampered release; releases are not signed.
+
+Interactive runs print a short notice when a new version is out, checking
+GitHub at most once a day. --notice off turns the notice off for good (as
+does setting YTDATA_NO_UPDATE_NOTICE); --notice on turns it back on.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata self-update
+  ytdata self-update --check
+  ytdata self-update --notice off`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return selfUpdate(opts)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "Only report whether a new version is available")
+	cmd.Flags().StringVar(&opts.Notice, "notice", "", "Turn the new version notice on or off")
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("notice", staticCompletion("on", "off")))
+
+	return cmd
+}
+
+func selfUpdate(opts SelfUpdateOptions) error {
+	if opts.Notice != "" {
+		if opts.Notice != "on" && opts.Notice != "off" {
+			return withKind(ErrInvalidConfig, fmt.Errorf("--notice must be on or off"))
+		}
+		check := readUpdateCheck()
+		check.Disabled = opts.Notice == "off"
+		if err := saveWatchState(updateCheckPath(), check); err != nil {
+			return err
+		}
+		infof("New version notice turned %s", opts.Notice)
+		return nil
+	}
+
+	ctx := context.Background()
+	release, err := fetchLatestRelease(ctx, http.DefaultClient)
+	if err != nil {
+		return err
+	}
+	if !newerVersion(release.version(), version) {
+		infof("ytdata %s is the latest version", version)
+		return nil
+	}
+	if opts.Check {
+		fmt.Printf("ytdata %s is available (installed: %s): %s\n", release.version(), version, release.HTMLURL)
+		return nil
+	}
+
+	archive, checksums, err := releaseAssets(release)
+	if err != nil {
+		return err
+	}
+	sums, err := download(ctx, checksums.URL)
+	if err != nil {
+		return err
+	}
+	data, err := download(ctx, archive.URL)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(archive.Name, data, sums); err != nil {
+		return err
+	}
+	binary, err := extractBinary(archive.Name, data)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the ytdata binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to locate the ytdata binary: %w", err)
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		return err
+	}
+	infof("Updated ytdata from %s to %s", version, release.version())
+	return nil
+}
+
+func fetchLatestRelease(ctx context.Context, client *http.Client) (githubRelease, error) {
+	var release githubRelease
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return release, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return release, withKind(ErrNetwork, fmt.Errorf("failed to check for releases: %w", err))
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return release, fmt.Errorf("failed to check for releases: GitHub returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return release, fmt.Errorf("invalid release information: %w", err)
+	}
+	return release, nil
+}
+
+// releaseAssets picks the archive for this platform, named like
+// ytdata_0.4.0_linux_amd64.tar.gz, and the checksums file of a release.
+func releaseAssets(release githubRelease) (archive, checksums githubAsset, err error) {
+	platform := "_" + runtime.GOOS + "_" + runtime.GOARCH
+	for _, asset := range release.Assets {
+		name := strings.ToLower(asset.Name)
+		switch {
+		case strings.HasSuffix(name, "checksums.txt"):
+			checksums = asset
+		case strings.Contains(name, platform+".") && (strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".zip")):
+			archive = asset
+		}
+	}
+	if archive.URL == "" {
+		return archive, checksums, fmt.Errorf("release %s has no build for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	if checksums.URL == "" {
+		return archive, checksums, fmt.Errorf("release %s publishes no checksums; refusing to install an unverified binary", release.TagName)
+	}
+	return archive, checksums, nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, withKind(ErrNetwork, fmt.Errorf("failed to download %s: %w", path.Base(url), err))
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", path.Base(url), resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, withKind(ErrNetwork, fmt.Errorf("failed to download %s: %w", path.Base(url), err))
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data against its line in a checksums file
+// ("<sha256>  <name>"). This detects corrupted downloads; the checksums file
+// is published next to the archive and is no protection against a
+// replaced release.
+func verifyChecksum(name string, data, sums []byte) error {
+	sum := sha256.Sum256(data)
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s; not installing it", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s in the release; not installing it", name)
+}
+
+// extractBinary returns the ytdata binary from a release archive.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	binary := "ytdata"
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) != binary {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				_ = rc.Close()
+			}()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("no %s in %s", binary, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no %s in %s", binary, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", name, err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binary {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable writes binary next to executable and renames it over
+// it. Windows cannot overwrite a running binary, so the old one is moved
+// aside first.
+func replaceExecutable(executable string, binary []byte) error {
+	dir := filepath.Dir(executable)
+	tmp, err := os.CreateTemp(dir, ".ytdata-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s: %w", dir, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		old := executable + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(executable, old); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", executable, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), executable); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", executable, err)
+	}
+	return nil
+}
+
+// newerVersion reports whether version a is newer than b, comparing their
+// dot-separated numbers (1.10.0 > 1.9.2); pre-release suffixes are ignored.
+func newerVersion(a, b string) bool {
+	numbers := func(v string) []int {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		var parts []int
+		for _, part := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(part)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	x, y := numbers(a), numbers(b)
+	for i := 0; i < max(len(x), len(y)); i++ {
+		var p, q int
+		if i < len(x) {
+			p = x[i]
+		}
+		if i < len(y) {
+			q = y[i]
+		}
+		if p != q {
+			return p > q
+		}
+	}
+	return false
+}
+
+// startUpdateNotice checks for a new release in the background, at most
+// once a day, for interactive runs. The returned function prints a notice
+// when a newer version is known; it never waits for the check, which
+// refreshes the state for the next run instead.
+func startUpdateNotice(cmd *cobra.Command) func() {
+	skip := func() {}
+	if os.Getenv("YTDATA_NO_UPDATE_NOTICE") != "" || !stderrIsTerminal() {
+		return skip
+	}
+	switch cmd.Name() {
+	case "self-update", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return skip
+	}
+	check := readUpdateCheck()
+	if check.Disabled {
+		return skip
+	}
+
+	if time.Since(check.CheckedAt) >= updateCheckInterval {
+		go func() {
+			// A transport of its own, as commands replace the default one
+			client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+			release, err := fetchLatestRelease(context.Background(), client)
+			if err != nil {
+				return
+			}
+			check.CheckedAt, check.Latest = time.Now(), release.version()
+			_ = saveWatchState(updateCheckPath(), check)
+		}()
+	}
+	latest := check.Latest
+	return func() {
+		if latest != "" && newerVersion(latest, version) {
+			infof("ytdata %s is available (installed: %s); run 'ytdata self-update' or turn this notice off with 'ytdata self-update --notice off'", latest, version)
+		}
+	}
+}
+
+// stderrIsTerminal reports whether messages on stderr reach a person.
+func stderrIsTerminal() bool {
+	stat, err := os.Stderr.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
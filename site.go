@@ -0,0 +1,479 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type SiteOptions struct {
+	From string
+	Out  string
+}
+
+func newSiteCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "site",
+		Short: "Build a browsable website from exports",
+		Long: `Build a static website from exported data, to browse an archive of your
+YouTube library offline or publish it on any static host.`,
+	}
+
+	var opts SiteOptions
+	buildCmd := &cobra.Command{
+		Use:   "build",
+		Short: "Generate a static site from a directory of exports",
+		Long: `Generate a static website from the JSONL (or JSON array) exports in a
+directory: liked videos, playlists and their items, and subscriptions. The
+site has an index of all videos by playlist, by channel and by year of
+publication, and a search over titles and channels that runs in the browser
+on a prebuilt JSON index, so no server is needed.
+
+Export playlist items (ytdata playlist-items) next to the playlists to list
+the videos of each playlist. Files that are not exports are skipped.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata all --dest ./exports && ytdata playlist-items -o ./exports/playlist-items.jsonl
+  ytdata site build --from ./exports --out ./public`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return buildSite(opts)
+			})
+		},
+	}
+	buildCmd.Flags().StringVar(&opts.From, "from", ".", "Directory with the exports")
+	buildCmd.Flags().StringVar(&opts.Out, "out", "public", "Directory the site is written to")
+
+	cmd.AddCommand(buildCmd)
+	return cmd
+}
+
+// siteVideo is a video of the archive, from a video or playlist item record.
+type siteVideo struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Channel   string `json:"channel,omitempty"`
+	ChannelID string `json:"-"`
+	Published string `json:"published,omitempty"`
+	Year      string `json:"year,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Liked     bool   `json:"liked,omitempty"`
+}
+
+func (v *siteVideo) URL() string {
+	return "https://www.youtube.com/watch?v=" + v.ID
+}
+
+type sitePlaylist struct {
+	ID          string
+	Title       string
+	Description string
+	Videos      []*siteVideo
+}
+
+type siteChannel struct {
+	ID         string
+	Title      string
+	Subscribed bool
+	Videos     []*siteVideo
+}
+
+// siteArchive is everything read from the exports.
+type siteArchive struct {
+	videos    map[string]*siteVideo
+	order     []string
+	playlists []*sitePlaylist
+	channels  map[string]*siteChannel
+}
+
+// video returns the archived video with id, merging the fields of a new
+// record into it.
+func (a *siteArchive) video(v siteVideo) *siteVideo {
+	existing, ok := a.videos[v.ID]
+	if !ok {
+		existing = &siteVideo{ID: v.ID}
+		a.videos[v.ID] = existing
+		a.order = append(a.order, v.ID)
+	}
+	if existing.Title == "" {
+		existing.Title = v.Title
+	}
+	if existing.Channel == "" {
+		existing.Channel, existing.ChannelID = v.Channel, v.ChannelID
+	}
+	if existing.Published == "" && v.Published != "" {
+		existing.Published = v.Published
+		existing.Year = v.Published[:min(4, len(v.Published))]
+	}
+	if existing.Thumbnail == "" {
+		existing.Thumbnail = v.Thumbnail
+	}
+	existing.Liked = existing.Liked || v.Liked
+	return existing
+}
+
+func (a *siteArchive) channel(id, title string) *siteChannel {
+	c, ok := a.channels[id]
+	if !ok {
+		c = &siteChannel{ID: id}
+		a.channels[id] = c
+	}
+	if c.Title == "" {
+		c.Title = title
+	}
+	return c
+}
+
+// recordThumbnail returns the URL of a medium-sized thumbnail of a record.
+func recordThumbnail(record map[string]any) string {
+	for _, size := range []string{"medium", "high", "default"} {
+		if url := lookupString(record, "snippet", "thumbnails", size, "url"); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// readSiteArchive reads the exports below dir.
+func readSiteArchive(dir string) (*siteArchive, error) {
+	archive := &siteArchive{videos: make(map[string]*siteVideo), channels: make(map[string]*siteChannel)}
+	playlists := make(map[string]*sitePlaylist)
+	playlist := func(id string) *sitePlaylist {
+		p, ok := playlists[id]
+		if !ok {
+			p = &sitePlaylist{ID: id}
+			playlists[id] = p
+			archive.playlists = append(archive.playlists, p)
+		}
+		return p
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir {
+			// Skip a previous build inside the exports directory
+			if _, err := os.Stat(filepath.Join(path, "search.js")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		if d.IsDir() || (filepath.Ext(path) != ".jsonl" && filepath.Ext(path) != ".json") {
+			return nil
+		}
+		records, err := readExportRecords(path)
+		if err != nil {
+			warnf("Skipping %s: %v", path, err)
+			return nil
+		}
+		for _, record := range records {
+			switch lookupString(record, "kind") {
+			case "youtube#video":
+				if lookupString(record, "id") == "" {
+					continue
+				}
+				archive.video(siteVideo{
+					ID:        lookupString(record, "id"),
+					Title:     lookupString(record, "snippet", "title"),
+					Channel:   lookupString(record, "snippet", "channelTitle"),
+					ChannelID: lookupString(record, "snippet", "channelId"),
+					Published: lookupString(record, "snippet", "publishedAt"),
+					Thumbnail: recordThumbnail(record),
+					Liked:     true,
+				})
+			case "youtube#playlistItem":
+				id := recordVideoID(record)
+				if id == "" {
+					continue
+				}
+				v := archive.video(siteVideo{
+					ID:        id,
+					Title:     lookupString(record, "snippet", "title"),
+					Channel:   lookupString(record, "snippet", "videoOwnerChannelTitle"),
+					ChannelID: lookupString(record, "snippet", "videoOwnerChannelId"),
+					Published: lookupString(record, "contentDetails", "videoPublishedAt"),
+					Thumbnail: recordThumbnail(record),
+				})
+				if playlistID := lookupString(record, "snippet", "playlistId"); playlistID != "" {
+					p := playlist(playlistID)
+					p.Videos = append(p.Videos, v)
+				}
+			case "youtube#playlist":
+				p := playlist(lookupString(record, "id"))
+				p.Title = lookupString(record, "snippet", "title")
+				p.Description = lookupString(record, "snippet", "description")
+			case "youtube#channel", "youtube#subscription":
+				c := archive.channel(recordChannelID(record), lookupString(record, "snippet", "title"))
+				c.Subscribed = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exports: %w", err)
+	}
+
+	for _, id := range archive.order {
+		v := archive.videos[id]
+		if v.ChannelID != "" {
+			c := archive.channel(v.ChannelID, v.Channel)
+			c.Videos = append(c.Videos, v)
+		}
+	}
+	// Items of playlists not in the playlists export have no title
+	for _, p := range archive.playlists {
+		if p.Title == "" {
+			p.Title = p.ID
+		}
+	}
+	return archive, nil
+}
+
+// sitePage is the data of a rendered page.
+type sitePage struct {
+	Title string
+	// Root is the relative path back to the site root
+	Root    string
+	Heading string
+	Text    string
+	Videos  []*siteVideo
+	Links   []siteLink
+	Search  bool
+	// Index is the search index, inlined in the page so that search also
+	// works when the site is opened from disk, where fetch is not allowed
+	Index []*siteVideo
+	// List heads the videos on pages that show more than a video list
+	List string
+}
+
+type siteLink struct {
+	Href  string
+	Label string
+	Note  string
+}
+
+var siteTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<nav><a href="{{.Root}}index.html">Archive</a> <a href="{{.Root}}playlists.html">Playlists</a> <a href="{{.Root}}channels.html">Channels</a> <a href="{{.Root}}years.html">Years</a></nav>
+<main>
+<h1>{{.Heading}}</h1>
+{{if .Text}}<p class="text">{{.Text}}</p>{{end}}
+{{if .Search}}<input id="search" type="search" placeholder="Search titles and channels" autofocus>
+<ul id="results" class="videos"></ul>
+<script id="search-index" type="application/json">{{.Index}}</script>
+<script src="{{.Root}}search.js"></script>{{end}}
+{{if .Links}}<ul class="links">{{range .Links}}
+<li><a href="{{.Href}}">{{.Label}}</a>{{if .Note}} <span class="note">{{.Note}}</span>{{end}}</li>{{end}}
+</ul>{{end}}
+{{if and .List .Videos}}<h2>{{.List}}</h2>{{end}}
+{{if .Videos}}<ol class="videos">{{range .Videos}}
+<li>{{if .Thumbnail}}<img src="{{.Thumbnail}}" alt="" loading="lazy">{{end}}<a href="{{.URL}}">{{.Title}}</a>{{if .Channel}} <span class="note">{{.Channel}}{{if .Year}} · {{.Year}}{{end}}</span>{{end}}</li>{{end}}
+</ol>{{end}}
+</main>
+<footer>Generated by ytdata</footer>
+</body>
+</html>
+`))
+
+const siteStyle = `body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 0 auto; padding: 1rem; color: #222; }
+nav a { margin-right: 1rem; }
+ol.videos, ul.videos { padding-left: 0; list-style: none; }
+.videos li { display: flex; align-items: center; gap: .75rem; margin: .5rem 0; }
+.videos img { width: 120px; border-radius: 4px; }
+.note { color: #666; font-size: .9em; }
+#search { width: 100%; font-size: 1.1rem; padding: .5rem; box-sizing: border-box; }
+footer { margin-top: 2rem; color: #888; font-size: .8em; }
+`
+
+// siteSearch filters the search index of the page in the browser.
+const siteSearch = `(() => {
+  const input = document.getElementById("search");
+  const results = document.getElementById("results");
+  const videos = JSON.parse(document.getElementById("search-index").textContent);
+  input.addEventListener("input", () => {
+    const terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.replaceChildren();
+    if (terms.length === 0) return;
+    for (const v of videos) {
+      const text = (v.title + " " + (v.channel || "")).toLowerCase();
+      if (!terms.every(t => text.includes(t))) continue;
+      const li = document.createElement("li");
+      const a = document.createElement("a");
+      a.href = "https://www.youtube.com/watch?v=" + v.id;
+      a.textContent = v.title;
+      li.append(a, " ");
+      const note = document.createElement("span");
+      note.className = "note";
+      note.textContent = [v.channel, v.year].filter(Boolean).join(" · ");
+      li.append(note);
+      results.append(li);
+      if (results.childElementCount >= 200) break;
+    }
+  });
+})();
+`
+
+// siteFileName makes an ID safe to use as a file name.
+func siteFileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id) + ".html"
+}
+
+func countNote(n int) string {
+	if n == 1 {
+		return "1 video"
+	}
+	return fmt.Sprintf("%d videos", n)
+}
+
+func buildSite(opts SiteOptions) error {
+	if info, err := os.Stat(opts.From); err != nil || !info.IsDir() {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--from %s is not a directory", opts.From))
+	}
+	archive, err := readSiteArchive(opts.From)
+	if err != nil {
+		return err
+	}
+	if len(archive.videos) == 0 && len(archive.playlists) == 0 && len(archive.channels) == 0 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("no exports found in %s", opts.From))
+	}
+	for _, dir := range []string{"", "playlists", "channels", "years"} {
+		if err := os.MkdirAll(filepath.Join(opts.Out, dir), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	pages := 0
+	render := func(path string, page sitePage) error {
+		f, err := os.Create(filepath.Join(opts.Out, path))
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		if err := siteTemplate.Execute(f, page); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to render %s: %w", path, err)
+		}
+		pages++
+		return f.Close()
+	}
+
+	videos := make([]*siteVideo, 0, len(archive.order))
+	for _, id := range archive.order {
+		videos = append(videos, archive.videos[id])
+	}
+	var liked []*siteVideo
+	for _, v := range videos {
+		if v.Liked {
+			liked = append(liked, v)
+		}
+	}
+
+	// Playlists
+	var links []siteLink
+	for _, p := range archive.playlists {
+		name := siteFileName(p.ID)
+		links = append(links, siteLink{Href: "playlists/" + name, Label: p.Title, Note: countNote(len(p.Videos))})
+		if err := render(filepath.Join("playlists", name), sitePage{Title: p.Title, Root: "../", Heading: p.Title, Text: p.Description, Videos: p.Videos}); err != nil {
+			return err
+		}
+	}
+	if err := render("playlists.html", sitePage{Title: "Playlists", Heading: "Playlists", Links: links}); err != nil {
+		return err
+	}
+
+	// Channels, by number of archived videos
+	channels := make([]*siteChannel, 0, len(archive.channels))
+	for _, c := range archive.channels {
+		channels = append(channels, c)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if len(channels[i].Videos) != len(channels[j].Videos) {
+			return len(channels[i].Videos) > len(channels[j].Videos)
+		}
+		return strings.ToLower(channels[i].Title) < strings.ToLower(channels[j].Title)
+	})
+	links = nil
+	for _, c := range channels {
+		name := siteFileName(c.ID)
+		note := countNote(len(c.Videos))
+		if c.Subscribed {
+			note += " · subscribed"
+		}
+		title := c.Title
+		if title == "" {
+			title = c.ID
+		}
+		links = append(links, siteLink{Href: "channels/" + name, Label: title, Note: note})
+		text := ""
+		if c.Subscribed {
+			text = "Subscribed"
+		}
+		if err := render(filepath.Join("channels", name), sitePage{Title: title, Root: "../", Heading: title, Text: text, Videos: c.Videos}); err != nil {
+			return err
+		}
+	}
+	if err := render("channels.html", sitePage{Title: "Channels", Heading: "Channels", Links: links}); err != nil {
+		return err
+	}
+
+	// Years of publication, newest first
+	years := make(map[string][]*siteVideo)
+	for _, v := range videos {
+		year := v.Year
+		if year == "" {
+			year = "unknown"
+		}
+		years[year] = append(years[year], v)
+	}
+	keys := make([]string, 0, len(years))
+	for year := range years {
+		keys = append(keys, year)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	links = nil
+	for _, year := range keys {
+		name := siteFileName(year)
+		links = append(links, siteLink{Href: "years/" + name, Label: year, Note: countNote(len(years[year]))})
+		if err := render(filepath.Join("years", name), sitePage{Title: year, Root: "../", Heading: "Published in " + year, Videos: years[year]}); err != nil {
+			return err
+		}
+	}
+	if err := render("years.html", sitePage{Title: "Years", Heading: "Years", Links: links}); err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("%s, %d playlists and %d channels.", countNote(len(videos)), len(archive.playlists), len(archive.channels))
+	if err := render("index.html", sitePage{Title: "YouTube archive", Heading: "YouTube archive", Text: summary, Search: true, Index: videos, List: "Liked videos", Videos: liked}); err != nil {
+		return err
+	}
+
+	for name, data := range map[string][]byte{
+		"search.js": []byte(siteSearch),
+		"style.css": []byte(siteStyle),
+	} {
+		if err := os.WriteFile(filepath.Join(opts.Out, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	infof("Built %d pages for %s in %s", pages, summary[:len(summary)-1], opts.Out)
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSiteInlinesSearchIndex(t *testing.T) {
+	exports, out := t.TempDir(), t.TempDir()
+	liked := `{"kind":"youtube#video","id":"vid1","snippet":{"title":"Closing </script><script>alert(1)</script>","channelTitle":"Chan","channelId":"UC1","publishedAt":"2024-01-02T00:00:00Z"}}
+{"kind":"youtube#video","id":"vid2","snippet":{"title":"Second","channelTitle":"Chan","channelId":"UC1","publishedAt":"2023-05-06T00:00:00Z"}}
+`
+	if err := os.WriteFile(filepath.Join(exports, "liked.jsonl"), []byte(liked), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := buildSite(SiteOptions{From: exports, Out: out}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := regexp.MustCompile(`(?s)<script id="search-index" type="application/json">(.*?)</script>`).FindSubmatch(page)
+	if block == nil {
+		t.Fatalf("index.html has no search index:\n%s", page)
+	}
+	var index []siteVideo
+	if err := json.Unmarshal(block[1], &index); err != nil {
+		t.Fatalf("search index is not JSON: %v\n%s", err, block[1])
+	}
+	if len(index) != 2 || index[0].Title != "Closing </script><script>alert(1)</script>" || index[1].Year != "2023" {
+		t.Errorf("search index = %+v", index)
+	}
+	// Nothing is left to fetch, which browsers refuse for file:// pages
+	if _, err := os.Stat(filepath.Join(out, "search.json")); !os.IsNotExist(err) {
+		t.Errorf("search.json was written: %v", err)
+	}
+}
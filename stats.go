@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type SubscriptionStatsOptions struct {
+	Topics bool
+	Top    int
+}
+
+// distributionEntry is one row of an aggregated report: how many channels
+// share a value and which fraction of all channels that is.
+type distributionEntry struct {
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Channels int     `json:"channels"`
+	Share    float64 `json:"share"`
+}
+
+func newStatsCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Aggregate statistics over your data",
+		Long:  "Aggregate statistics over your YouTube data and export them as records.",
+	}
+
+	var subscriptionOpts SubscriptionStatsOptions
+	subscriptionsCmd := &cobra.Command{
+		Use:   "subscriptions",
+		Short: "Distribution of subscribed channels by country, topic or keyword",
+		Long: `Report how subscribed channels are distributed. By default channels are
+counted per country; with --topics, per topic category (topicDetails) and
+per channel keyword (brandingSettings), showing what your subscriptions
+are actually about.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata stats subscriptions
+  ytdata stats subscriptions --topics --top 20
+  ytdata stats subscriptions --topics -f csv -o topics.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return subscriptionStats(config, subscriptionOpts)
+			})
+		},
+	}
+	subscriptionsCmd.Flags().BoolVar(&subscriptionOpts.Topics, "topics", false, "Aggregate topic categories and channel keywords")
+	subscriptionsCmd.Flags().IntVar(&subscriptionOpts.Top, "top", 0, "Only report the N most common values per type (0 = all)")
+	addOutputFlag(subscriptionsCmd, "", "Write report to stdout (or file with -o)")
+	addFormatFlag(subscriptionsCmd, recordFormats()...)
+	addFilterFlag(subscriptionsCmd, "Only count channels matching this expression")
+	addTransformFlag(subscriptionsCmd)
+
+	cmd.AddCommand(subscriptionsCmd, newCommentStatsCmd(config), newHistoryStatsCmd(config), newLikedStatsCmd(config))
+	return cmd
+}
+
+// splitKeywords splits channel keywords, which are separated by spaces with
+// multi-word keywords in double quotes.
+func splitKeywords(keywords string) []string {
+	var result []string
+	var current strings.Builder
+	quoted := false
+	flush := func() {
+		if keyword := strings.TrimSpace(current.String()); keyword != "" {
+			result = append(result, keyword)
+		}
+		current.Reset()
+	}
+	for _, r := range keywords {
+		switch {
+		case r == '"':
+			flush()
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return result
+}
+
+// distribution counts the channels per value. values returns the distinct
+// values of one channel.
+func distribution(kind string, channels []*youtube.Channel, top int, values func(*youtube.Channel) []string) []distributionEntry {
+	counts := make(map[string]int)
+	for _, channel := range channels {
+		seen := make(map[string]bool)
+		for _, value := range values(channel) {
+			if value != "" && !seen[value] {
+				seen[value] = true
+				counts[value]++
+			}
+		}
+	}
+
+	entries := make([]distributionEntry, 0, len(counts))
+	for name, count := range counts {
+		share := 0.0
+		if len(channels) > 0 {
+			share = math.Round(float64(count)/float64(len(channels))*10000) / 10000
+		}
+		entries = append(entries, distributionEntry{Type: kind, Name: name, Channels: count, Share: share})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Channels != entries[j].Channels {
+			return entries[i].Channels > entries[j].Channels
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if top > 0 && len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+func subscriptionStats(config Config, opts SubscriptionStatsOptions) (err error) {
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	channels, _, err := fetchSubscribedChannels(context.Background(), service, config, newErrorLog(config.ErrorsFile, "stats"))
+	if err != nil {
+		return err
+	}
+
+	// The filter selects channels, not the aggregated entries
+	if config.Filter != "" {
+		filter, err := parseFilter(config.Filter)
+		if err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		var matching []*youtube.Channel
+		for _, channel := range channels {
+			record, err := toRecord(channel)
+			if err != nil {
+				return fmt.Errorf("failed to process channel data: %w", err)
+			}
+			if matchFilter(filter, record) {
+				matching = append(matching, channel)
+			}
+		}
+		channels = matching
+		config.Filter = ""
+	}
+
+	var entries []distributionEntry
+	if opts.Topics {
+		entries = append(entries, distribution("topic", channels, opts.Top, func(channel *youtube.Channel) []string {
+			if channel.TopicDetails == nil {
+				return nil
+			}
+			var topics []string
+			for _, category := range channel.TopicDetails.TopicCategories {
+				topics = append(topics, topicName(category))
+			}
+			return topics
+		})...)
+		entries = append(entries, distribution("keyword", channels, opts.Top, func(channel *youtube.Channel) []string {
+			if channel.BrandingSettings == nil || channel.BrandingSettings.Channel == nil {
+				return nil
+			}
+			var keywords []string
+			for _, keyword := range splitKeywords(channel.BrandingSettings.Channel.Keywords) {
+				keywords = append(keywords, strings.ToLower(keyword))
+			}
+			return keywords
+		})...)
+	} else {
+		entries = distribution("country", channels, opts.Top, func(channel *youtube.Channel) []string {
+			if channel.Snippet == nil || channel.Snippet.Country == "" {
+				return []string{"unknown"}
+			}
+			return []string{channel.Snippet.Country}
+		})
+	}
+
+	out, err := openOutput(config, "subscription stats")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, entry := range entries {
+		if err := out.Write(entry); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+	}
+	return nil
+}
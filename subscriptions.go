@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+var (
+	subscriptionSortKeys = []string{"subscribers", "videos", "title", "subscribedAt"}
+
+	channelParts = []string{
+		"snippet", "contentDetails", "statistics", "topicDetails",
+		"status", "brandingSettings", "localizations",
+	}
+)
+
+// SubscriptionFilter holds the local sorting and filtering applied to
+// subscribed channels after they have been fetched.
+type SubscriptionFilter struct {
+	Sort           string
+	MinSubscribers uint64
+	Country        string
+	Topic          string
+
+	IncludePlaylists bool
+}
+
+func (f SubscriptionFilter) validate() error {
+	if f.Sort != "" && !slices.Contains(subscriptionSortKeys, f.Sort) {
+		return withKind(ErrInvalidConfig, fmt.Errorf("unsupported sort key %q (supported: %s)", f.Sort, strings.Join(subscriptionSortKeys, ", ")))
+	}
+	return nil
+}
+
+// topicName returns the readable name of a topic category URL such as
+// https://en.wikipedia.org/wiki/Music.
+func topicName(category string) string {
+	name := category[strings.LastIndex(category, "/")+1:]
+	return strings.ReplaceAll(name, "_", " ")
+}
+
+func (f SubscriptionFilter) matches(channel *youtube.Channel) bool {
+	if f.MinSubscribers > 0 {
+		if channel.Statistics == nil || channel.Statistics.HiddenSubscriberCount ||
+			channel.Statistics.SubscriberCount < f.MinSubscribers {
+			return false
+		}
+	}
+	if f.Country != "" {
+		if channel.Snippet == nil || !strings.EqualFold(channel.Snippet.Country, f.Country) {
+			return false
+		}
+	}
+	if f.Topic != "" {
+		if channel.TopicDetails == nil {
+			return false
+		}
+		topic := strings.ToLower(f.Topic)
+		found := false
+		for _, category := range channel.TopicDetails.TopicCategories {
+			if strings.Contains(strings.ToLower(topicName(category)), topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// apply filters channels and sorts them by the configured key. subscribedAt
+// maps channel IDs to the time the subscription was created.
+func (f SubscriptionFilter) apply(channels []*youtube.Channel, subscribedAt map[string]string) []*youtube.Channel {
+	var result []*youtube.Channel
+	for _, channel := range channels {
+		if f.matches(channel) {
+			result = append(result, channel)
+		}
+	}
+
+	statistic := func(channel *youtube.Channel, get func(*youtube.ChannelStatistics) uint64) uint64 {
+		if channel.Statistics == nil {
+			return 0
+		}
+		return get(channel.Statistics)
+	}
+	title := func(channel *youtube.Channel) string {
+		if channel.Snippet == nil {
+			return ""
+		}
+		return strings.ToLower(channel.Snippet.Title)
+	}
+
+	switch f.Sort {
+	case "subscribers":
+		sort.SliceStable(result, func(i, j int) bool {
+			get := func(s *youtube.ChannelStatistics) uint64 { return s.SubscriberCount }
+			return statistic(result[i], get) > statistic(result[j], get)
+		})
+	case "videos":
+		sort.SliceStable(result, func(i, j int) bool {
+			get := func(s *youtube.ChannelStatistics) uint64 { return s.VideoCount }
+			return statistic(result[i], get) > statistic(result[j], get)
+		})
+	case "title":
+		sort.SliceStable(result, func(i, j int) bool {
+			return title(result[i]) < title(result[j])
+		})
+	case "subscribedAt":
+		// RFC 3339 timestamps in UTC sort lexically; newest first
+		sort.SliceStable(result, func(i, j int) bool {
+			return subscribedAt[result[i].Id] > subscribedAt[result[j].Id]
+		})
+	}
+
+	return result
+}
+
+// requestedChannelParts returns the channel parts selected with --parts,
+// or all readable parts.
+func requestedChannelParts(config Config) []string {
+	if len(config.ChannelParts) > 0 {
+		return config.ChannelParts
+	}
+	return channelParts
+}
+
+// fetchChannelBatch fetches the details of up to 50 channels.
+func fetchChannelBatch(service *youtube.Service, config Config, ids []string) ([]*youtube.Channel, error) {
+	call := service.Channels.List(requestedChannelParts(config)).Id(ids...)
+
+	if config.Language != "" {
+		call = call.Hl(config.Language)
+	}
+
+	fields, err := channelFieldMask(config)
+	if err != nil {
+		return nil, err
+	}
+	if fields != "" {
+		call = call.Fields(fields)
+	}
+
+	response, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+// fetchChannelPlaylists returns the public playlists of a channel from
+// pageToken on. When a page fails, it returns the playlists fetched so far
+// and the token of the failed page.
+func fetchChannelPlaylists(service *youtube.Service, config Config, channelID, pageToken string) ([]*youtube.Playlist, string, error) {
+	var playlists []*youtube.Playlist
+	for {
+		call := service.Playlists.List([]string{"snippet", "contentDetails", "status"}).
+			ChannelId(channelID).MaxResults(50)
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return playlists, pageToken, err
+		}
+		playlists = append(playlists, response.Items...)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+	return playlists, "", nil
+}
+
+// fetchSubscribedChannels fetches the channels the user is subscribed to and
+// maps their IDs to the time the subscription was created. With
+// --continue-on-error, failed channel batches are logged to failures.
+func fetchSubscribedChannels(ctx context.Context, service *youtube.Service, config Config, failures *errorLog) ([]*youtube.Channel, map[string]string, error) {
+	subscriptions, failedPage, err := fetchSubscriptionPages(ctx, service, newPageLimit(config), "")
+	if err != nil {
+		if !config.ContinueOnError {
+			return nil, nil, fmt.Errorf("failed to fetch subscriptions: %w", err)
+		}
+		// The pages after a failed one are unknown, so the rest of the list
+		// is left to retry
+		params := requestParams{PageToken: failedPage, Hl: config.Language}
+		if err := failures.record(operationSubscriptionsList, params, err); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var channelIDs []string
+	subscribedAt := make(map[string]string)
+	for _, sub := range subscriptions {
+		channelIDs = append(channelIDs, sub.Snippet.ResourceId.ChannelId)
+		subscribedAt[sub.Snippet.ResourceId.ChannelId] = sub.Snippet.PublishedAt
+	}
+
+	var allChannels []*youtube.Channel
+	batchSize := 50
+
+	for i := 0; i < len(channelIDs); i += batchSize {
+		end := i + batchSize
+		if end > len(channelIDs) {
+			end = len(channelIDs)
+		}
+
+		batch := channelIDs[i:end]
+		channels, err := fetchChannelBatch(service, config, batch)
+		if err != nil {
+			if !config.ContinueOnError {
+				return nil, nil, fmt.Errorf("failed to fetch channel details: %w", err)
+			}
+			params := requestParams{IDs: batch, Hl: config.Language}
+			if err := failures.record(operationChannelsList, params, err); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		allChannels = append(allChannels, channels...)
+	}
+
+	return allChannels, subscribedAt, nil
+}
+
+// fetchSubscriptionList fetches the subscription resources of the user, up
+// to limit.
+func fetchSubscriptionList(ctx context.Context, service *youtube.Service, limit *pageLimit) ([]*youtube.Subscription, error) {
+	subscriptions, _, err := fetchSubscriptionPages(ctx, service, limit, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// fetchSubscriptionPages fetches subscriptions from pageToken on, up to
+// limit. When a page fails, it returns the subscriptions fetched so far and
+// the token of the failed page.
+func fetchSubscriptionPages(ctx context.Context, service *youtube.Service, limit *pageLimit, pageToken string) ([]*youtube.Subscription, string, error) {
+	var subscriptions []*youtube.Subscription
+
+	for {
+		call := service.Subscriptions.List([]string{"snippet"}).
+			Context(ctx).
+			Mine(true).
+			MaxResults(limit.size())
+
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		response, err := call.Do()
+		if err != nil {
+			return subscriptions, pageToken, err
+		}
+
+		keep, more := limit.page(len(response.Items))
+		subscriptions = append(subscriptions, response.Items[:keep]...)
+
+		if !more || response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+	return subscriptions, "", nil
+}
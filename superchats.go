@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newSuperChatsCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "superchats",
+		Short: "Fetch Super Chat and Super Sticker events (creators)",
+		Long: `Fetch the Super Chat and Super Sticker events of your channel's live
+streams and export to JSONL format. Each event carries the amount
+(snippet.amountMicros, snippet.currency, snippet.displayString), the
+supporter (snippet.supporterDetails) and the message or sticker.
+
+The API only returns events from the last 30 days, so export regularly to
+keep a complete history.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata superchats
+  ytdata superchats -f csv -o superchats.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return fetchSuperChats(config)
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write events to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export events matching this expression")
+	addTransformFlag(cmd)
+
+	return cmd
+}
+
+func fetchSuperChats(config Config) (err error) {
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	out, err := openOutput(config, "super chat")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	count := 0
+	pageToken := ""
+	for {
+		call := service.SuperChatEvents.List([]string{"snippet"}).MaxResults(50)
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return fmt.Errorf("failed to fetch super chat events: %w", err)
+		}
+		for _, event := range response.Items {
+			if err := out.Write(event); err != nil {
+				return fmt.Errorf("failed to write super chat data: %w", err)
+			}
+		}
+		count += len(response.Items)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	if count == 0 {
+		infof("No Super Chat events in the last 30 days")
+	}
+	return nil
+}
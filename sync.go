@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	syncActionAdd      = "add"
+	syncActionRemove   = "remove"
+	syncActionConflict = "conflict"
+)
+
+type SyncPlaylistOptions struct {
+	FromProfile string
+	ToProfile   string
+	ToPlaylist  string
+	State       string
+	DryRun      bool
+	Force       bool
+	Prune       bool
+	Delay       time.Duration
+	UndoFile    string
+}
+
+// playlistSyncState is kept between syncs of a playlist to tell changes of
+// the source from changes made to the destination by hand.
+type playlistSyncState struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Profile     string `json:"profile"`
+	SyncedAt    string `json:"syncedAt"`
+	// Videos are the source videos in the destination after the last sync
+	Videos []string `json:"videos"`
+}
+
+// syncAction is one line of the sync report.
+type syncAction struct {
+	Action   string `json:"action"`
+	VideoID  string `json:"videoId"`
+	Title    string `json:"title,omitempty"`
+	Position int64  `json:"position,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+
+	itemID string
+}
+
+func newSyncCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Keep data of another account in sync",
+	}
+	cmd.AddCommand(newSyncPlaylistCmd(config))
+	return cmd
+}
+
+func newSyncPlaylistCmd(config *Config) *cobra.Command {
+	var opts SyncPlaylistOptions
+
+	cmd := &cobra.Command{
+		Use:   "playlist SOURCE_PLAYLIST_ID",
+		Short: "Mirror a playlist to another account",
+		Long: `Make a playlist of another account (--to-profile) contain the videos of a
+source playlist: videos new in the source are added at their position and
+videos gone from the source are removed. Each video is mirrored once; the
+order of videos already in the destination is not changed. The destination
+is created on the first run unless --to-playlist is given. Run it from cron
+to keep the copy up to date.
+
+Changes made to the destination by hand are reported as conflicts and kept:
+videos added there stay, and videos removed there are not added again. Use
+--force to add the removed videos again and --prune to remove videos the
+sync did not add, including those of an existing --to-playlist on the first
+run. Removed items are appended to the undo file. The report lists every
+add, remove and conflict.
+
+Profiles are channels authorized with 'ytdata accounts add' (ID or title)
+or "default"; the destination account needs write access.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{accountsAnnotation: "true"},
+		Example: `  ytdata sync playlist PLxxxxxxxxxxxxxxxx --to-profile Work --dry-run
+  ytdata sync playlist PLxxxxxxxxxxxxxxxx --to-profile Work -o sync.jsonl
+  ytdata sync playlist PLxxxxxxxxxxxxxxxx --to-profile Work --to-playlist PLyyyyyyyyyyyyyyyy --prune`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				id, err := idFromArg(args[0], refPlaylist)
+				if err != nil {
+					return err
+				}
+				if opts.ToPlaylist, err = idFromArg(opts.ToPlaylist, refPlaylist); err != nil {
+					return err
+				}
+				return syncPlaylist(config, id, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.FromProfile, "from-profile", defaultProfile, "Account that can read the source playlist")
+	cmd.Flags().StringVar(&opts.ToProfile, "to-profile", "", "Account owning the destination playlist")
+	cmd.Flags().StringVar(&opts.ToPlaylist, "to-playlist", "", "Destination playlist (default: created on the first run)")
+	cmd.Flags().StringVar(&opts.State, "state", "", "Sync state file (default: per playlist and profile in the config directory)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Report the changes without making them")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Resolve conflicts in favor of the source")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "Remove videos of the destination that are not in the source")
+	cmd.Flags().DurationVar(&opts.Delay, "delay", 200*time.Millisecond, "Pause between changes to stay below rate limits")
+	cmd.Flags().StringVar(&opts.UndoFile, "undo-file", defaultUndoFile, "File removed playlist items are appended to")
+	addOutputFlag(cmd, "", "Write the sync report to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report changes matching this expression")
+
+	cobra.CheckErr(cmd.MarkFlagRequired("to-profile"))
+	cobra.CheckErr(cmd.MarkFlagFilename("state", "json"))
+
+	return cmd
+}
+
+// planPlaylistSync compares the source videos with the destination items.
+// synced holds the source videos of the last sync; nil means the first
+// sync, when the destination simply follows the source. Videos the sync did
+// not add are only removed with prune.
+func planPlaylistSync(source []string, titles map[string]string, destination []*youtube.PlaylistItem, synced map[string]bool, force, prune bool) []syncAction {
+	inSource := make(map[string]bool, len(source))
+	for _, videoID := range source {
+		inSource[videoID] = true
+	}
+
+	var actions []syncAction
+	kept := make(map[string]bool)
+	// order is the destination after the removals, to place new videos
+	var order []string
+	for _, item := range destination {
+		videoID := playlistItemVideoID(item)
+		title := ""
+		if item.Snippet != nil {
+			title = item.Snippet.Title
+		}
+		switch {
+		case kept[videoID]:
+			actions = append(actions, syncAction{Action: syncActionRemove, VideoID: videoID, Title: title, Reason: "duplicate", itemID: item.Id})
+			continue
+		case inSource[videoID]:
+		case synced[videoID]:
+			actions = append(actions, syncAction{Action: syncActionRemove, VideoID: videoID, Title: title, Reason: "removed from source", itemID: item.Id})
+			continue
+		case prune:
+			actions = append(actions, syncAction{Action: syncActionRemove, VideoID: videoID, Title: title, Reason: "not in source", itemID: item.Id})
+			continue
+		case synced == nil:
+			actions = append(actions, syncAction{Action: syncActionConflict, VideoID: videoID, Title: title, Reason: "not in source"})
+		default:
+			actions = append(actions, syncAction{Action: syncActionConflict, VideoID: videoID, Title: title, Reason: "added to destination"})
+		}
+		kept[videoID] = true
+		order = append(order, videoID)
+	}
+
+	// New videos go right after the source video preceding them in the
+	// destination, counting the videos kept there
+	previous := ""
+	for _, videoID := range source {
+		if kept[videoID] {
+			previous = videoID
+			continue
+		}
+		if synced[videoID] && !force {
+			actions = append(actions, syncAction{Action: syncActionConflict, VideoID: videoID, Title: titles[videoID], Reason: "removed from destination"})
+			continue
+		}
+		position := 0
+		if previous != "" {
+			position = slices.Index(order, previous) + 1
+		}
+		order = slices.Insert(order, position, videoID)
+		actions = append(actions, syncAction{Action: syncActionAdd, VideoID: videoID, Title: titles[videoID], Position: int64(position)})
+		kept[videoID] = true
+		previous = videoID
+	}
+	return actions
+}
+
+// createSyncDestination creates the destination playlist with the title,
+// description and privacy of the source.
+func createSyncDestination(ctx context.Context, source, destination *youtube.Service, playlistID string) (string, error) {
+	response, err := source.Playlists.List([]string{"snippet", "status"}).Id(playlistID).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch playlist %s: %w", playlistID, err)
+	}
+	if len(response.Items) == 0 {
+		return "", withKind(ErrInvalidConfig, fmt.Errorf("playlist %s not found", playlistID))
+	}
+	original := response.Items[0]
+	playlist := &youtube.Playlist{Snippet: &youtube.PlaylistSnippet{Title: original.Snippet.Title, Description: original.Snippet.Description}}
+	parts := []string{"snippet"}
+	if original.Status != nil && original.Status.PrivacyStatus != "" {
+		playlist.Status = &youtube.PlaylistStatus{PrivacyStatus: original.Status.PrivacyStatus}
+		parts = append(parts, "status")
+	}
+	created, err := destination.Playlists.Insert(parts, playlist).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create destination playlist: %w", err)
+	}
+	infof("Created playlist %s (%s)", original.Snippet.Title, created.Id)
+	return created.Id, nil
+}
+
+func syncPlaylist(config Config, playlistID string, opts SyncPlaylistOptions) (err error) {
+	sourceConfig, err := profileConfig(config, opts.FromProfile)
+	if err != nil {
+		return err
+	}
+	destinationConfig, err := profileConfig(config, opts.ToProfile)
+	if err != nil {
+		return err
+	}
+	if !opts.DryRun {
+		destinationConfig = withScopes(destinationConfig, "write", youtube.YoutubeForceSslScope)
+	}
+	if opts.State == "" {
+		opts.State = filepath.Join(getConfigDir(), "sync", stateFileName(playlistID)+"-"+stateFileName(opts.ToProfile)+".json")
+	}
+
+	var state playlistSyncState
+	known, err := readWatchState(opts.State, &state)
+	if err != nil {
+		return err
+	}
+	if opts.ToPlaylist == "" {
+		opts.ToPlaylist = state.Destination
+	} else if known && state.Destination != opts.ToPlaylist {
+		// Another destination starts from scratch
+		known = false
+	}
+
+	ctx := context.Background()
+	sourceService, err := authenticateYouTube(sourceConfig)
+	if err != nil {
+		return fmt.Errorf("authentication failed for %s: %w", opts.FromProfile, err)
+	}
+	destinationService, err := authenticateYouTube(destinationConfig)
+	if err != nil {
+		return fmt.Errorf("authentication failed for %s: %w", opts.ToProfile, err)
+	}
+
+	sourceItems, err := fetchPlaylistItems(ctx, sourceService, playlistID)
+	if err != nil {
+		return err
+	}
+	var source []string
+	titles := make(map[string]string)
+	for _, item := range sourceItems {
+		videoID := playlistItemVideoID(item)
+		if videoID == "" || titles[videoID] != "" {
+			continue
+		}
+		source = append(source, videoID)
+		titles[videoID] = item.Snippet.Title
+	}
+
+	var destination []*youtube.PlaylistItem
+	if opts.ToPlaylist != "" {
+		if destination, err = fetchPlaylistItems(ctx, destinationService, opts.ToPlaylist); err != nil {
+			return err
+		}
+	}
+	var synced map[string]bool
+	if known {
+		synced = make(map[string]bool, len(state.Videos))
+		for _, videoID := range state.Videos {
+			synced[videoID] = true
+		}
+	}
+	actions := planPlaylistSync(source, titles, destination, synced, opts.Force, opts.Prune)
+
+	out, err := openOutput(config, "sync")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	counts := make(map[string]int)
+	unsynced := 0
+	for _, action := range actions {
+		counts[action.Action]++
+		if action.Action == syncActionConflict && action.Reason == "not in source" {
+			unsynced++
+		}
+	}
+	if unsynced > 0 {
+		infof("Keeping %d videos of the destination that are not in the source; use --prune to remove them", unsynced)
+	}
+	if opts.DryRun {
+		for _, action := range actions {
+			if err := out.Write(action); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		}
+		infof("Would add %d and remove %d videos; %d conflicts", counts[syncActionAdd], counts[syncActionRemove], counts[syncActionConflict])
+		return nil
+	}
+
+	failed := make(map[string]bool)
+	// Remember what the destination holds of the source; videos removed
+	// from the destination by hand stay remembered to keep the conflict,
+	// and videos still to be removed to remove them next time
+	saveState := func() error {
+		state := playlistSyncState{Source: playlistID, Destination: opts.ToPlaylist, Profile: opts.ToProfile, SyncedAt: time.Now().UTC().Format(time.RFC3339), Videos: []string{}}
+		removedByHand := make(map[string]bool)
+		for _, action := range actions {
+			if action.Action == syncActionConflict && action.Reason == "removed from destination" {
+				removedByHand[action.VideoID] = true
+			}
+		}
+		inDestination := make(map[string]bool)
+		for _, item := range destination {
+			inDestination[playlistItemVideoID(item)] = true
+		}
+		for _, videoID := range source {
+			if inDestination[videoID] || removedByHand[videoID] || !failed[videoID] {
+				state.Videos = append(state.Videos, videoID)
+			}
+		}
+		for _, action := range actions {
+			if action.Action == syncActionRemove && action.Reason == "removed from source" && failed[action.VideoID] {
+				state.Videos = append(state.Videos, action.VideoID)
+			}
+		}
+		return saveWatchState(opts.State, state)
+	}
+
+	if opts.ToPlaylist == "" {
+		if opts.ToPlaylist, err = createSyncDestination(ctx, sourceService, destinationService, playlistID); err != nil {
+			return err
+		}
+		// Save the new destination right away, so a run stopped before the
+		// end does not make the next one create another playlist
+		if err := saveWatchState(opts.State, playlistSyncState{Source: playlistID, Destination: opts.ToPlaylist, Profile: opts.ToProfile, Videos: []string{}}); err != nil {
+			return err
+		}
+	}
+	undo, err := openUndoLog(opts.UndoFile)
+	if err != nil {
+		return err
+	}
+	defer undo.close()
+
+	changes := 0
+	for i, action := range actions {
+		if action.Action != syncActionConflict {
+			if changes > 0 && opts.Delay > 0 {
+				time.Sleep(opts.Delay)
+			}
+			changes++
+			err := applySyncAction(ctx, destinationService, opts.ToPlaylist, action, undo)
+			if isQuotaError(err) {
+				// The changes not made yet are picked up by the next run
+				for _, pending := range actions[i:] {
+					if pending.Action != syncActionConflict {
+						failed[pending.VideoID] = true
+					}
+				}
+				if err := saveState(); err != nil {
+					return err
+				}
+				return withKind(ErrQuota, fmt.Errorf("stopped after %d changes: %w", changes-1, err))
+			}
+			if err != nil {
+				warnf("Failed to %s %s: %v", action.Action, action.VideoID, err)
+				failed[action.VideoID] = true
+				continue
+			}
+		}
+		if err := out.Write(action); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if err := saveState(); err != nil {
+		return err
+	}
+
+	infof("Added %d and removed %d videos; %d conflicts",
+		counts[syncActionAdd]-countFailed(actions, failed, syncActionAdd), counts[syncActionRemove]-countFailed(actions, failed, syncActionRemove), counts[syncActionConflict])
+	if len(failed) > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d changes failed", len(failed)))
+	}
+	return nil
+}
+
+func countFailed(actions []syncAction, failed map[string]bool, kind string) int {
+	n := 0
+	for _, action := range actions {
+		if action.Action == kind && failed[action.VideoID] {
+			n++
+		}
+	}
+	return n
+}
+
+// applySyncAction adds or removes a video of the destination playlist.
+func applySyncAction(ctx context.Context, service *youtube.Service, playlistID string, action syncAction, undo *undoLog) error {
+	if action.Action == syncActionRemove {
+		if err := service.PlaylistItems.Delete(action.itemID).Context(ctx).Do(); err != nil {
+			return err
+		}
+		return undo.record(undoEntry{
+			Action:     undoActionRemoveFromPlaylist,
+			VideoID:    action.VideoID,
+			Title:      action.Title,
+			PlaylistID: playlistID,
+		})
+	}
+	_, err := service.PlaylistItems.Insert([]string{"snippet"}, &youtube.PlaylistItem{
+		Snippet: &youtube.PlaylistItemSnippet{
+			PlaylistId:      playlistID,
+			Position:        action.Position,
+			ResourceId:      &youtube.ResourceId{Kind: "youtube#video", VideoId: action.VideoID},
+			ForceSendFields: []string{"Position"},
+		},
+	}).Context(ctx).Do()
+	return err
+}
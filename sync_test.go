@@ -0,0 +1,128 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+func syncItems(videoIDs ...string) []*youtube.PlaylistItem {
+	items := make([]*youtube.PlaylistItem, len(videoIDs))
+	for i, videoID := range videoIDs {
+		items[i] = &youtube.PlaylistItem{Id: "item-" + videoID, ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: videoID}}
+	}
+	return items
+}
+
+// applySyncPlan replays the adds and removes of a plan on a playlist.
+func applySyncPlan(playlist []string, actions []syncAction) []string {
+	playlist = slices.Clone(playlist)
+	for _, action := range actions {
+		switch action.Action {
+		case syncActionRemove:
+			playlist = slices.Delete(playlist, slices.Index(playlist, action.VideoID), slices.Index(playlist, action.VideoID)+1)
+		case syncActionAdd:
+			playlist = slices.Insert(playlist, int(action.Position), action.VideoID)
+		}
+	}
+	return playlist
+}
+
+func TestPlanPlaylistSync(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      []string
+		destination []string
+		synced      []string
+		force       bool
+		prune       bool
+		want        []string
+		conflicts   []string
+	}{
+		{
+			name:   "first sync into a new playlist",
+			source: []string{"a", "b", "c"},
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:        "first sync keeps videos not in the source",
+			source:      []string{"a", "b"},
+			destination: []string{"x", "a"},
+			want:        []string{"x", "a", "b"},
+			conflicts:   []string{"x"},
+		},
+		{
+			name:        "first sync with prune",
+			source:      []string{"a", "b"},
+			destination: []string{"x", "a"},
+			prune:       true,
+			want:        []string{"a", "b"},
+		},
+		{
+			name:        "new videos go after videos added by hand",
+			source:      []string{"a", "b", "c", "d"},
+			destination: []string{"a", "x", "y", "b", "d"},
+			synced:      []string{"a", "b", "d"},
+			want:        []string{"a", "x", "y", "b", "c", "d"},
+			conflicts:   []string{"x", "y"},
+		},
+		{
+			name:        "removed from source",
+			source:      []string{"b"},
+			destination: []string{"a", "x", "b"},
+			synced:      []string{"a", "b"},
+			want:        []string{"x", "b"},
+			conflicts:   []string{"x"},
+		},
+		{
+			name:        "removed from destination",
+			source:      []string{"a", "b", "c"},
+			destination: []string{"x", "c"},
+			synced:      []string{"a", "c"},
+			want:        []string{"b", "x", "c"},
+			conflicts:   []string{"x", "a"},
+		},
+		{
+			name:        "force adds removed videos again",
+			source:      []string{"a", "b", "c"},
+			destination: []string{"x", "c"},
+			synced:      []string{"a", "c"},
+			force:       true,
+			want:        []string{"a", "b", "x", "c"},
+			conflicts:   []string{"x"},
+		},
+		{
+			name:        "duplicates",
+			source:      []string{"a", "b"},
+			destination: []string{"a", "a", "b"},
+			synced:      []string{"a", "b"},
+			want:        []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var synced map[string]bool
+			if tt.synced != nil {
+				synced = make(map[string]bool)
+				for _, videoID := range tt.synced {
+					synced[videoID] = true
+				}
+			}
+			actions := planPlaylistSync(tt.source, nil, syncItems(tt.destination...), synced, tt.force, tt.prune)
+
+			if got := applySyncPlan(tt.destination, actions); !slices.Equal(got, tt.want) {
+				t.Errorf("playlist after sync = %q, want %q", got, tt.want)
+			}
+			var conflicts []string
+			for _, action := range actions {
+				if action.Action == syncActionConflict {
+					conflicts = append(conflicts, action.VideoID)
+				}
+			}
+			if !slices.Equal(conflicts, tt.conflicts) {
+				t.Errorf("conflicts = %q, want %q", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
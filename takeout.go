@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// readTakeoutCSV reads a CSV file of Google Takeout into rows keyed by
+// lowercased column name. Columns are looked up by header, as Takeout has
+// changed their order before, and anything before the header row containing
+// keyColumn is skipped: older playlist files start with a block describing
+// the playlist itself.
+func readTakeoutCSV(path, keyColumn string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	var header []string
+	var rows []map[string]string
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if header == nil {
+			names := make([]string, len(row))
+			for i, name := range row {
+				names[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+			}
+			if slices.Contains(names, keyColumn) {
+				header = names
+			}
+			continue
+		}
+		values := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(row) {
+				values[name] = strings.TrimSpace(row[i])
+			}
+		}
+		if values[keyColumn] != "" {
+			rows = append(rows, values)
+		}
+	}
+	if header == nil {
+		return nil, fmt.Errorf("%s has no %q column; is it a Takeout file?", path, keyColumn)
+	}
+	return rows, nil
+}
+
+// findTakeoutFile returns the first file below dir whose lowercased name
+// matches, so both an extracted archive and its YouTube folder work.
+func findTakeoutFile(dir string, match func(name string) bool) (string, error) {
+	var found string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && match(strings.ToLower(d.Name())) {
+			found = path
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", dir, err)
+	}
+	return found, nil
+}
+
+// readTakeoutComments reads the comments.csv of Google Takeout. Comments on
+// posts have no video and are skipped.
+func readTakeoutComments(path string) ([]myComment, error) {
+	rows, err := readTakeoutCSV(path, "video id")
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]myComment, 0, len(rows))
+	for _, row := range rows {
+		comments = append(comments, myComment{
+			ID:        row["comment id"],
+			VideoID:   row["video id"],
+			CreatedAt: row["comment create timestamp"],
+		})
+	}
+	return comments, nil
+}
+
+// takeoutWatch is a video watch of the Takeout watch history.
+type takeoutWatch struct {
+	VideoID   string
+	Title     string
+	Channel   string
+	ChannelID string
+	Time      time.Time
+}
+
+// takeoutActivity is an entry of the JSON activity files of Takeout (watch
+// and search history).
+type takeoutActivity struct {
+	Header    string `json:"header"`
+	Title     string `json:"title"`
+	TitleURL  string `json:"titleUrl"`
+	Time      string `json:"time"`
+	Subtitles []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"subtitles"`
+	Details []struct {
+		Name string `json:"name"`
+	} `json:"details"`
+}
+
+// readTakeoutActivity reads a JSON activity file of Takeout. Takeout writes
+// HTML by default; JSON has to be selected when creating the export.
+func readTakeoutActivity(path string) ([]takeoutActivity, error) {
+	if strings.EqualFold(filepath.Ext(path), ".html") {
+		return nil, fmt.Errorf("%s is HTML; create the Takeout with the JSON format for history", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var activities []takeoutActivity
+	if err := json.Unmarshal(data, &activities); err != nil {
+		return nil, fmt.Errorf("%s is not a Takeout activity file: %w", path, err)
+	}
+	return activities, nil
+}
+
+// isAd reports whether an activity was an ad rather than something the
+// user did.
+func (a takeoutActivity) isAd() bool {
+	for _, detail := range a.Details {
+		if detail.Name == "From Google Ads" {
+			return true
+		}
+	}
+	return false
+}
+
+// readTakeoutWatchHistory reads watch-history.json in time order. Ads are
+// skipped; videos removed since have no ID.
+func readTakeoutWatchHistory(path string) ([]takeoutWatch, error) {
+	activities, err := readTakeoutActivity(path)
+	if err != nil {
+		return nil, err
+	}
+	watches := make([]takeoutWatch, 0, len(activities))
+	for _, a := range activities {
+		if a.isAd() {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, a.Time)
+		if err != nil {
+			continue
+		}
+		watch := takeoutWatch{Title: strings.TrimPrefix(a.Title, "Watched "), Time: t}
+		if u, err := url.Parse(a.TitleURL); err == nil {
+			watch.VideoID = u.Query().Get("v")
+		}
+		if len(a.Subtitles) > 0 {
+			watch.Channel = a.Subtitles[0].Name
+			watch.ChannelID = strings.TrimPrefix(a.Subtitles[0].URL, "https://www.youtube.com/channel/")
+			if watch.ChannelID == a.Subtitles[0].URL {
+				watch.ChannelID = ""
+			}
+		}
+		watches = append(watches, watch)
+	}
+	sort.SliceStable(watches, func(i, j int) bool {
+		return watches[i].Time.Before(watches[j].Time)
+	})
+	return watches, nil
+}
+
+// takeoutSearch is a search of the Takeout search history.
+type takeoutSearch struct {
+	Query string
+	Time  time.Time
+}
+
+// readTakeoutSearchHistory reads search-history.json in time order. Ads are
+// skipped.
+func readTakeoutSearchHistory(path string) ([]takeoutSearch, error) {
+	activities, err := readTakeoutActivity(path)
+	if err != nil {
+		return nil, err
+	}
+	searches := make([]takeoutSearch, 0, len(activities))
+	for _, a := range activities {
+		if a.isAd() {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, a.Time)
+		if err != nil {
+			continue
+		}
+		query := strings.TrimPrefix(a.Title, "Searched for ")
+		if u, err := url.Parse(a.TitleURL); err == nil && u.Query().Get("search_query") != "" {
+			query = u.Query().Get("search_query")
+		}
+		searches = append(searches, takeoutSearch{Query: query, Time: t})
+	}
+	sort.SliceStable(searches, func(i, j int) bool {
+		return searches[i].Time.Before(searches[j].Time)
+	})
+	return searches, nil
+}
+
+// takeoutText returns the text of a comment or live chat message of
+// Takeout. Newer exports store the text as JSON segments, e.g.
+// {"text":"Nice "},{"text":"video"}; older ones as plain text.
+func takeoutText(value string) string {
+	if !strings.HasPrefix(value, "{") {
+		return value
+	}
+	var segments []struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte("["+value+"]"), &segments); err != nil {
+		return value
+	}
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString(segment.Text)
+	}
+	return b.String()
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type TakeoutOptions struct {
+	Dest string
+}
+
+// takeoutDataset is a file of Google Takeout converted into a dataset of
+// the takeout command.
+type takeoutDataset struct {
+	name  string
+	match func(name string) bool
+	read  func(path string) ([]any, error)
+}
+
+// takeoutDatasets lists the Takeout files the takeout command converts.
+var takeoutDatasets = []takeoutDataset{
+	{"watch-history", takeoutFileName("watch-history."), readWatchHistoryRecords},
+	{"search-history", takeoutFileName("search-history."), readSearchHistoryRecords},
+	{"comments", takeoutFileName("comments.csv"), readCommentRecords},
+	{"live-chats", takeoutFileName("live chats.csv"), readLiveChatRecords},
+	{"subscriptions", takeoutFileName("subscriptions.csv"), readSubscriptionRecords},
+}
+
+// takeoutFileName matches file names starting with prefix.
+func takeoutFileName(prefix string) func(string) bool {
+	return func(name string) bool {
+		return strings.HasPrefix(name, prefix)
+	}
+}
+
+type takeoutWatchRecord struct {
+	VideoID   string `json:"videoId,omitempty"`
+	Title     string `json:"title"`
+	Channel   string `json:"channel,omitempty"`
+	ChannelID string `json:"channelId,omitempty"`
+	WatchedAt string `json:"watchedAt"`
+}
+
+type takeoutSearchRecord struct {
+	Query      string `json:"query"`
+	SearchedAt string `json:"searchedAt"`
+}
+
+type takeoutCommentRecord struct {
+	ID        string `json:"id"`
+	VideoID   string `json:"videoId,omitempty"`
+	PostID    string `json:"postId,omitempty"`
+	ParentID  string `json:"parentId,omitempty"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+type takeoutLiveChatRecord struct {
+	ID        string `json:"id"`
+	VideoID   string `json:"videoId"`
+	Text      string `json:"text"`
+	Price     string `json:"price,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+type takeoutSubscriptionRecord struct {
+	ChannelID string `json:"channelId"`
+	Title     string `json:"title"`
+	URL       string `json:"url,omitempty"`
+}
+
+func newTakeoutCmd(config *Config) *cobra.Command {
+	var opts TakeoutOptions
+
+	cmd := &cobra.Command{
+		Use:   "takeout TAKEOUT_DIR",
+		Short: "Convert a Google Takeout into datasets",
+		Long: `Convert the YouTube files of an extracted Google Takeout into normalized
+datasets, each written to its own file in --dest named after the dataset and
+format (e.g. watch-history.jsonl):
+
+  watch-history   history/watch-history.json (ads skipped)
+  search-history  history/search-history.json
+  comments        comments/comments.csv
+  live-chats      live chats/live chats.csv
+  subscriptions   subscriptions/subscriptions.csv
+
+Files are found anywhere below TAKEOUT_DIR; missing ones are skipped. The
+history files are only readable when the Takeout was created with JSON
+instead of HTML for the history. Timestamps are written in RFC 3339, UTC.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata takeout ~/Downloads/Takeout --dest takeout
+  ytdata takeout Takeout --dest takeout --format csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return convertTakeout(config, args[0], opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dest, "dest", ".", "Directory the datasets are written to")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only write records matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.MarkFlagDirname("dest"))
+
+	return cmd
+}
+
+func convertTakeout(config Config, dir string, opts TakeoutOptions) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return withKind(ErrInvalidConfig, fmt.Errorf("%s is not a directory; extract the Takeout archive first", dir))
+	}
+	if err := os.MkdirAll(opts.Dest, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	converted := 0
+	for _, dataset := range takeoutDatasets {
+		path, err := findTakeoutFile(dir, dataset.match)
+		if err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		if path == "" {
+			infof("No %s in %s, skipping", dataset.name, dir)
+			continue
+		}
+		records, err := dataset.read(path)
+		if err != nil {
+			warnf("Skipping %s: %v", dataset.name, err)
+			continue
+		}
+		datasetConfig := config
+		datasetConfig.OutputFile = filepath.Join(opts.Dest, dataset.name+"."+config.Format)
+		if err := writeTakeoutDataset(datasetConfig, dataset.name, records); err != nil {
+			return err
+		}
+		infof("Converted %d %s records to %s", len(records), dataset.name, datasetConfig.OutputFile)
+		converted++
+	}
+	if converted == 0 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("no Takeout files found in %s", dir))
+	}
+	return nil
+}
+
+func writeTakeoutDataset(config Config, dataset string, records []any) (err error) {
+	out, err := openOutput(config, dataset)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, record := range records {
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write %s: %w", dataset, err)
+		}
+	}
+	return nil
+}
+
+// takeoutTime normalizes a Takeout timestamp to RFC 3339 in UTC, keeping
+// values it cannot parse.
+func takeoutTime(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return formatTakeoutTime(t)
+}
+
+func formatTakeoutTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func readWatchHistoryRecords(path string) ([]any, error) {
+	watches, err := readTakeoutWatchHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]any, 0, len(watches))
+	for _, w := range watches {
+		records = append(records, takeoutWatchRecord{
+			VideoID:   w.VideoID,
+			Title:     w.Title,
+			Channel:   w.Channel,
+			ChannelID: w.ChannelID,
+			WatchedAt: formatTakeoutTime(w.Time),
+		})
+	}
+	return records, nil
+}
+
+func readSearchHistoryRecords(path string) ([]any, error) {
+	searches, err := readTakeoutSearchHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]any, 0, len(searches))
+	for _, s := range searches {
+		records = append(records, takeoutSearchRecord{Query: s.Query, SearchedAt: formatTakeoutTime(s.Time)})
+	}
+	return records, nil
+}
+
+func readCommentRecords(path string) ([]any, error) {
+	rows, err := readTakeoutCSV(path, "comment id")
+	if err != nil {
+		return nil, err
+	}
+	records := make([]any, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, takeoutCommentRecord{
+			ID:        row["comment id"],
+			VideoID:   row["video id"],
+			PostID:    row["post id"],
+			ParentID:  row["parent comment id"],
+			Text:      takeoutText(row["comment text"]),
+			CreatedAt: takeoutTime(row["comment create timestamp"]),
+		})
+	}
+	return records, nil
+}
+
+func readLiveChatRecords(path string) ([]any, error) {
+	rows, err := readTakeoutCSV(path, "live chat id")
+	if err != nil {
+		return nil, err
+	}
+	records := make([]any, 0, len(rows))
+	for _, row := range rows {
+		price := row["price"]
+		if price == "0" {
+			price = ""
+		}
+		records = append(records, takeoutLiveChatRecord{
+			ID:        row["live chat id"],
+			VideoID:   row["video id"],
+			Text:      takeoutText(row["live chat text"]),
+			Price:     price,
+			CreatedAt: takeoutTime(row["live chat create timestamp"]),
+		})
+	}
+	return records, nil
+}
+
+func readSubscriptionRecords(path string) ([]any, error) {
+	rows, err := readTakeoutCSV(path, "channel id")
+	if err != nil {
+		return nil, err
+	}
+	records := make([]any, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, takeoutSubscriptionRecord{
+			ChannelID: row["channel id"],
+			Title:     row["channel title"],
+			URL:       row["channel url"],
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// throttle spaces out all API requests of the process evenly, so every
+// service shares one rate limit.
+var throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+var throttleUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// parseRate parses rates such as 2/s, 30/m or 500/h into the interval
+// between two requests.
+func parseRate(rate string) (time.Duration, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(rate), "/")
+	per, known := throttleUnits[strings.ToLower(unit)]
+	n, err := strconv.ParseFloat(count, 64)
+	if !ok || !known || err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %q (expected e.g. 2/s, 30/m or 500/h)", rate)
+	}
+	return time.Duration(float64(per) / n), nil
+}
+
+// setThrottle limits API requests to one per interval; zero disables the
+// limit.
+func setThrottle(interval time.Duration) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	throttle.interval = interval
+}
+
+// waitThrottle blocks until the next request may be sent.
+func waitThrottle(req *http.Request) error {
+	throttle.mu.Lock()
+	if throttle.interval == 0 {
+		throttle.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	slot := throttle.next
+	if slot.Before(now) {
+		slot = now
+	}
+	throttle.next = slot.Add(throttle.interval)
+	throttle.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// throttleTransport delays requests to honor --throttle.
+type throttleTransport struct {
+	base http.RoundTripper
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := waitThrottle(req); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// withThrottle wraps client so its requests honor --throttle.
+func withThrottle(client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	throttled := *client
+	throttled.Transport = &throttleTransport{base: base}
+	return &throttled
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+	"github.com/rtzll/ytdata/output"
+)
+
+// parseTransform compiles a jq expression for --transform.
+func parseTransform(expression string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(query)
+}
+
+// transformWriter rewrites every record with a jq expression before
+// passing it on. An expression may emit any number of records per input,
+// so `empty` drops a record and `.items[]` splits one.
+type transformWriter struct {
+	output.Writer
+	code *gojq.Code
+}
+
+func (w transformWriter) Write(record any) error {
+	input, err := toRecord(record)
+	if err != nil {
+		return err
+	}
+	iter := w.code.Run(input)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, ok := value.(error); ok {
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				return nil
+			}
+			return fmt.Errorf("--transform failed: %w", err)
+		}
+		if err := w.Writer.Write(value); err != nil {
+			return err
+		}
+	}
+}
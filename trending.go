@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type TrendingOptions struct {
+	Category string
+	Max      int
+	Dest     string
+}
+
+func newTrendingCmd(config *Config) *cobra.Command {
+	var opts TrendingOptions
+
+	cmd := &cobra.Command{
+		Use:   "trending",
+		Short: "Snapshot the most popular videos of a region",
+		Long: `Export the most popular videos (chart=mostPopular) of a region (--region,
+default US), optionally limited to a video category given by ID or name.
+
+Every video is stamped with its rank and the snapshotAt time of the run.
+With --dest, each run writes a new timestamped file (e.g.
+trending_DE_20240101T120000Z.jsonl), so running it from cron builds a
+history of what was trending.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata trending --region DE
+  ytdata trending --region DE --category Music --max 200
+  ytdata trending --region DE --dest snapshots --api-key $YOUTUBE_API_KEY`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return fetchTrending(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Category, "category", "", "Only videos of this category (ID or name, see 'ytdata categories')")
+	cmd.Flags().IntVar(&opts.Max, "max", 50, "Maximum number of videos (up to 200)")
+	cmd.Flags().StringVar(&opts.Dest, "dest", "", "Write each snapshot to a new timestamped file in this directory")
+	addOutputFlag(cmd, "", "Write videos to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only export videos matching this expression")
+	addTransformFlag(cmd)
+
+	cobra.CheckErr(cmd.MarkFlagDirname("dest"))
+
+	return cmd
+}
+
+// resolveCategory returns the ID of a video category given by ID or
+// (case-insensitive) name.
+func resolveCategory(names map[string]string, category string) (string, error) {
+	if _, err := strconv.Atoi(category); err == nil {
+		return category, nil
+	}
+	for id, name := range names {
+		if strings.EqualFold(name, category) {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("unknown video category %q (see 'ytdata categories')", category)
+}
+
+func fetchTrending(config Config, opts TrendingOptions) (err error) {
+	if opts.Max < 1 || opts.Max > 200 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--max must be between 1 and 200"))
+	}
+	if opts.Dest != "" && config.OutputFile != "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("pass either --dest or -o, not both"))
+	}
+
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	region := categoryRegion(config)
+	snapshotAt := time.Now().UTC()
+
+	enrichment := newVideoEnrichment(service, config)
+	categoryID := ""
+	if opts.Category != "" {
+		if categoryID, err = resolveCategory(enrichment.categories, opts.Category); err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+	}
+
+	var videos []*youtube.Video
+	pageToken := ""
+	for len(videos) < opts.Max {
+		call := service.Videos.List([]string{"snippet", "contentDetails", "statistics"}).
+			Chart("mostPopular").
+			RegionCode(region).
+			MaxResults(int64(min(opts.Max-len(videos), 50)))
+		if categoryID != "" {
+			call = call.VideoCategoryId(categoryID)
+		}
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return fmt.Errorf("failed to fetch most popular videos: %w", err)
+		}
+		videos = append(videos, response.Items...)
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	if opts.Dest != "" {
+		if err := os.MkdirAll(opts.Dest, 0755); err != nil {
+			return fmt.Errorf("failed to create destination directory: %w", err)
+		}
+		name := "trending_" + region
+		if categoryID != "" {
+			name += "_" + categoryID
+		}
+		config.OutputFile = filepath.Join(opts.Dest, name+"_"+snapshotAt.Format("20060102T150405Z")+"."+config.Format)
+	}
+
+	out, err := openOutput(config, "trending")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for i, video := range videos {
+		record, err := enrichment.enrichVideo(video)
+		if err != nil {
+			return fmt.Errorf("failed to process video data: %w", err)
+		}
+		record["rank"] = i + 1
+		record["snapshotAt"] = snapshotAt.Format(time.RFC3339)
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write video data: %w", err)
+		}
+	}
+
+	if opts.Dest != "" {
+		infof("Wrote %d videos to %s", len(videos), config.OutputFile)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	defaultUndoFile = "undo.jsonl"
+
+	undoActionUnlike      = "unlike"
+	undoActionUnsubscribe = "unsubscribe"
+
+	undoActionRemoveFromPlaylist = "remove_from_playlist"
+)
+
+// undoEntry is one line of an undo file written by destructive commands. It
+// records what was changed so `ytdata undo` can reverse it.
+type undoEntry struct {
+	Time    string `json:"time"`
+	Action  string `json:"action"`
+	VideoID string `json:"videoId,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Channel string `json:"channel,omitempty"`
+
+	ChannelID      string `json:"channelId,omitempty"`
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+
+	PlaylistID string `json:"playlistId,omitempty"`
+	Position   int64  `json:"position,omitempty"`
+}
+
+// undoLog appends entries to an undo file, keeping entries of earlier runs.
+type undoLog struct {
+	path    string
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func openUndoLog(path string) (*undoLog, error) {
+	if path == "" {
+		path = defaultUndoFile
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open undo file: %w", err)
+	}
+	return &undoLog{path: path, file: f, encoder: json.NewEncoder(f)}, nil
+}
+
+func (l *undoLog) record(entry undoEntry) error {
+	entry.Time = time.Now().UTC().Format(time.RFC3339)
+	if err := l.encoder.Encode(entry); err != nil {
+		return fmt.Errorf("failed to write undo file: %w", err)
+	}
+	return nil
+}
+
+func (l *undoLog) close() {
+	if err := l.file.Close(); err != nil {
+		warnf("Failed to close file: %v", err)
+	}
+}
+
+func readUndoEntries(path string) ([]undoEntry, error) {
+	records, err := readJSONLRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]undoEntry, 0, len(records))
+	for _, record := range records {
+		var entry undoEntry
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("invalid entry in %s: %w", path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// sortPlaylistRestores orders the playlist items to add back by playlist
+// and recorded position. Each item then returns in front of the items that
+// followed it, whereas restoring in removal order shifts the positions of
+// items restored earlier. Other entries keep their place.
+func sortPlaylistRestores(entries []undoEntry) {
+	var slots []int
+	var items []undoEntry
+	for i, entry := range entries {
+		if entry.Action == undoActionRemoveFromPlaylist {
+			slots = append(slots, i)
+			items = append(items, entry)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b undoEntry) int {
+		return cmp.Or(strings.Compare(a.PlaylistID, b.PlaylistID), cmp.Compare(a.Position, b.Position))
+	})
+	for i, slot := range slots {
+		entries[slot] = items[i]
+	}
+}
+
+func newUndoCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo UNDO_FILE",
+		Short: "Reverse the changes recorded in an undo file",
+		Long: `Reverse the changes recorded in an undo file by prune, unsubscribe and
+dedupe-playlists: like removed videos again, re-subscribe to channels, and
+add removed playlist items back at their original position. Requires write
+access to your YouTube account.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Example:      "  ytdata undo undo.jsonl",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return runUndo(withScopes(config, "write", youtube.YoutubeForceSslScope), args[0])
+			})
+		},
+	}
+
+	return cmd
+}
+
+func runUndo(config Config, path string) error {
+	entries, err := readUndoEntries(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		infof("Nothing to undo")
+		return nil
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	sortPlaylistRestores(entries)
+	failed := 0
+	for _, entry := range entries {
+		var err error
+		switch entry.Action {
+		case undoActionUnlike:
+			err = service.Videos.Rate(entry.VideoID, "like").Do()
+		case undoActionUnsubscribe:
+			_, err = service.Subscriptions.Insert([]string{"snippet"}, &youtube.Subscription{
+				Snippet: &youtube.SubscriptionSnippet{
+					ResourceId: &youtube.ResourceId{Kind: "youtube#channel", ChannelId: entry.ChannelID},
+				},
+			}).Do()
+		case undoActionRemoveFromPlaylist:
+			_, err = service.PlaylistItems.Insert([]string{"snippet"}, &youtube.PlaylistItem{
+				Snippet: &youtube.PlaylistItemSnippet{
+					PlaylistId:      entry.PlaylistID,
+					Position:        entry.Position,
+					ResourceId:      &youtube.ResourceId{Kind: "youtube#video", VideoId: entry.VideoID},
+					ForceSendFields: []string{"Position"},
+				},
+			}).Do()
+		default:
+			err = fmt.Errorf("unknown action %q", entry.Action)
+		}
+		if err != nil {
+			warnf("Failed to undo %s of %s: %v", entry.Action, entry.Title, err)
+			failed++
+			continue
+		}
+		infof("Restored %s", entry.Title)
+	}
+
+	if failed > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d of %d changes could not be undone", failed, len(entries)))
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortPlaylistRestores(t *testing.T) {
+	entries := []undoEntry{
+		{Action: undoActionRemoveFromPlaylist, PlaylistID: "PL1", Position: 5, VideoID: "e"},
+		{Action: undoActionUnlike, VideoID: "liked"},
+		{Action: undoActionRemoveFromPlaylist, PlaylistID: "PL1", Position: 1, VideoID: "b"},
+		{Action: undoActionRemoveFromPlaylist, PlaylistID: "PL1", Position: 3, VideoID: "d"},
+	}
+	sortPlaylistRestores(entries)
+
+	var order []string
+	for _, entry := range entries {
+		order = append(order, entry.VideoID)
+	}
+	if got, want := order, []string{"b", "liked", "d", "e"}; !slices.Equal(got, want) {
+		t.Errorf("restore order = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+type UnsubscribeOptions struct {
+	From     string
+	DryRun   bool
+	Yes      bool
+	Delay    time.Duration
+	UndoFile string
+}
+
+func newUnsubscribeCmd(config *Config) *cobra.Command {
+	var opts UnsubscribeOptions
+
+	cmd := &cobra.Command{
+		Use:   "unsubscribe",
+		Short: "Unsubscribe from channels matching a filter",
+		Long: `Unsubscribe from every subscribed channel matching --filter. Channels are
+read from a subscriptions export with --from, or fetched fresh. Each
+channel is confirmed interactively unless --yes is given: answer y to
+unsubscribe, n to skip, a to unsubscribe from all remaining channels, or
+q to stop.
+
+Removed subscriptions are appended to the undo file, so 'ytdata undo' can
+subscribe again. Unsubscribing requires write access to your account.
+
+See the README for the filter syntax.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata unsubscribe --from subscriptions.jsonl --filter 'statistics.videoCount == 0' --dry-run
+  ytdata unsubscribe --filter 'snippet.country == "US" && statistics.subscriberCount < 1000'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return unsubscribe(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.From, "from", "", "Read channels from a subscriptions export instead of fetching them")
+	addFilterFlag(cmd, "Expression selecting the channels to unsubscribe from (required)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List matching channels without unsubscribing")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().DurationVar(&opts.Delay, "delay", 200*time.Millisecond, "Pause between requests to stay below rate limits")
+	cmd.Flags().StringVar(&opts.UndoFile, "undo-file", defaultUndoFile, "File removed subscriptions are appended to")
+
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc("from", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+	}))
+
+	return cmd
+}
+
+// recordChannelID extracts the channel ID from a channel or subscription
+// record.
+func recordChannelID(record map[string]any) string {
+	switch lookupString(record, "kind") {
+	case "youtube#channel":
+		return lookupString(record, "id")
+	case "youtube#subscription":
+		return lookupString(record, "snippet", "resourceId", "channelId")
+	}
+	return ""
+}
+
+func unsubscribe(config Config, opts UnsubscribeOptions) error {
+	if config.Filter == "" {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--filter is required (use --filter true to select every channel)"))
+	}
+	filter, err := parseFilter(config.Filter)
+	if err != nil {
+		return withKind(ErrInvalidConfig, err)
+	}
+	if !opts.DryRun {
+		config = withScopes(config, "write", youtube.YoutubeForceSslScope)
+	}
+
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	ctx := context.Background()
+
+	var records []map[string]any
+	if opts.From != "" {
+		if records, err = readJSONLRecords(opts.From); err != nil {
+			return err
+		}
+	} else {
+		channels, _, err := fetchSubscribedChannels(ctx, service, config, newErrorLog(config.ErrorsFile, "unsubscribe"))
+		if err != nil {
+			return err
+		}
+		for _, channel := range channels {
+			record, err := toRecord(channel)
+			if err != nil {
+				return fmt.Errorf("failed to process channel data: %w", err)
+			}
+			records = append(records, record)
+		}
+	}
+
+	// Subscription IDs are needed to unsubscribe and are not part of
+	// channel records, so they are always looked up fresh
+	subscriptions, err := fetchSubscriptionList(ctx, service, nil)
+	if err != nil {
+		return err
+	}
+	subscriptionIDs := make(map[string]string, len(subscriptions))
+	for _, sub := range subscriptions {
+		subscriptionIDs[sub.Snippet.ResourceId.ChannelId] = sub.Id
+	}
+
+	type candidate struct {
+		channelID, subscriptionID, title string
+	}
+	var matches []candidate
+	seen := make(map[string]bool)
+	for _, record := range records {
+		channelID := recordChannelID(record)
+		if channelID == "" || seen[channelID] || !matchFilter(filter, record) {
+			continue
+		}
+		seen[channelID] = true
+		title := lookupString(record, "snippet", "title")
+		subscriptionID, ok := subscriptionIDs[channelID]
+		if !ok {
+			warnf("Skipping %s (%s): not subscribed anymore", title, channelID)
+			continue
+		}
+		matches = append(matches, candidate{channelID, subscriptionID, title})
+	}
+
+	if len(matches) == 0 {
+		infof("No subscriptions match the filter")
+		return nil
+	}
+	if opts.DryRun {
+		for _, match := range matches {
+			infof("Would unsubscribe from %s (%s)", match.title, match.channelID)
+		}
+		infof("Would unsubscribe from %d of %d channels", len(matches), len(records))
+		return nil
+	}
+
+	undo, err := openUndoLog(opts.UndoFile)
+	if err != nil {
+		return err
+	}
+	defer undo.close()
+
+	confirmAll := opts.Yes
+	removed, failed := 0, 0
+	for _, match := range matches {
+		if !confirmAll {
+			answer := strings.ToLower(promptUser(fmt.Sprintf("Unsubscribe from %s (%s)? [y/N/a/q]: ", match.title, match.channelID)))
+			if answer == "q" {
+				break
+			}
+			if answer == "a" {
+				confirmAll = true
+			} else if answer != "y" {
+				continue
+			}
+		}
+
+		if removed+failed > 0 && opts.Delay > 0 {
+			time.Sleep(opts.Delay)
+		}
+		if err := service.Subscriptions.Delete(match.subscriptionID).Do(); err != nil {
+			if isQuotaError(err) {
+				return withKind(ErrQuota, fmt.Errorf("stopped after %d unsubscribes: %w", removed, err))
+			}
+			warnf("Failed to unsubscribe from %s: %v", match.title, err)
+			failed++
+			continue
+		}
+		removed++
+		if err := undo.record(undoEntry{
+			Action:         undoActionUnsubscribe,
+			ChannelID:      match.channelID,
+			SubscriptionID: match.subscriptionID,
+			Title:          match.title,
+		}); err != nil {
+			return err
+		}
+	}
+
+	infof("Unsubscribed from %d channels; undo with 'ytdata undo %s'", removed, undo.path)
+	if failed > 0 {
+		return withKind(ErrPartial, fmt.Errorf("%d unsubscribes failed", failed))
+	}
+	return nil
+}
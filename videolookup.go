@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+type VideosOptions struct {
+	IDsFile string
+}
+
+func newVideosCmd(config *Config) *cobra.Command {
+	var opts VideosOptions
+
+	cmd := &cobra.Command{
+		Use:   "videos [VIDEO_ID...]",
+		Short: "Fetch the metadata of any videos",
+		Long: `Look up videos by ID, in batches of 50, and write their full metadata with
+the same derived fields as the liked export. IDs are given as arguments or
+read from --ids-file, one per line ("-" reads stdin), e.g. to enrich video
+IDs collected elsewhere. Videos that no longer exist produce a warning.`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata videos dQw4w9WgXcQ 9bZkp7q19f0
+  ytdata videos --ids-file ids.txt -o videos.jsonl
+  cut -f1 history.tsv | ytdata videos --ids-file - --parts topicDetails -f csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				ids, err := lookupIDs(args, opts.IDsFile, refVideo)
+				if err != nil {
+					return err
+				}
+				return lookupVideos(config, ids)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.IDsFile, "ids-file", "", `File with one video ID per line ("-" for stdin)`)
+	cmd.Flags().StringSliceVar(&config.Parts, "parts", nil, "Additional video parts to request (e.g. status,topicDetails,liveStreamingDetails)")
+	cmd.Flags().BoolVar(&config.FlagRestricted, "flag-restricted", false, "Add a regionBlocked field for videos blocked in --region")
+	cmd.Flags().BoolVar(&config.DetectLanguage, "detect-language", false, "Add a contentLanguage field, guessed from title and description when the video declares none")
+	addOutputFlag(cmd, "", "Write videos to stdout (or file with -o)")
+	addFormatFlag(cmd, append(recordFormats(), formatRSS, formatAtom)...)
+	addFilterFlag(cmd, "Only write videos matching this expression")
+	addTransformFlag(cmd)
+	addFieldsFlag(cmd)
+
+	return cmd
+}
+
+func lookupVideos(config Config, ids []string) (err error) {
+	parts, err := videoParts(config)
+	if err != nil {
+		return err
+	}
+	service, err := publicYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	videos, err := fetchVideosByID(service, config, ids, parts)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(config, "videos")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	enrichment := newVideoEnrichment(service, config)
+	for _, id := range ids {
+		video, ok := videos[id]
+		if !ok {
+			warnf("Video %s not found", id)
+			continue
+		}
+		record, err := enrichment.enrichVideo(video)
+		if err != nil {
+			return fmt.Errorf("failed to process video data: %w", err)
+		}
+		if err := out.Write(record); err != nil {
+			return fmt.Errorf("failed to write video data: %w", err)
+		}
+	}
+	return nil
+}
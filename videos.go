@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+var (
+	defaultVideoParts = []string{"snippet", "contentDetails", "statistics"}
+
+	// Parts of the video resource readable for videos the user does not own
+	supportedVideoParts = []string{
+		"contentDetails", "id", "liveStreamingDetails", "localizations",
+		"paidProductPlacementDetails", "player", "recordingDetails",
+		"snippet", "statistics", "status", "topicDetails",
+	}
+)
+
+// playerMaxHeight is the maxHeight requested with the player part. The API
+// only reports player.embedHeight and embedWidth, which tell vertical
+// Shorts apart, when maxHeight or maxWidth is set.
+const playerMaxHeight = 720
+
+// videoParts returns the default video parts plus any extra parts
+// requested with --parts.
+func videoParts(config Config) ([]string, error) {
+	parts := slices.Clone(defaultVideoParts)
+	requested := config.Parts
+	if config.Localizations {
+		requested = append(slices.Clone(requested), "localizations")
+	}
+	if len(config.Videos.Types) > 0 {
+		// Past streams are only recognizable by their streaming details
+		requested = append(slices.Clone(requested), "liveStreamingDetails")
+	}
+	if len(config.Videos.Types) > 0 || config.Videos.ShortsOnly || config.Videos.ExcludeShorts {
+		// Vertical Shorts are only recognizable by their embed size
+		requested = append(slices.Clone(requested), "player")
+	}
+	for _, part := range requested {
+		part = strings.TrimSpace(part)
+		if part == "" || slices.Contains(parts, part) {
+			continue
+		}
+		if !slices.Contains(supportedVideoParts, part) {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("unsupported video part %q (supported: %s)", part, strings.Join(supportedVideoParts, ", ")))
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// videoEnrichment holds the lookups derived video fields are computed from.
+type videoEnrichment struct {
+	config     Config
+	categories map[string]string
+}
+
+// newVideoEnrichment prepares the lookups for derived video fields. Category
+// names are best effort: if they cannot be fetched, videos are exported
+// without them.
+func newVideoEnrichment(service *youtube.Service, config Config) videoEnrichment {
+	categories, err := videoCategoryNames(service, config)
+	if err != nil {
+		warnf("Exporting without category names: %v", err)
+	}
+	return videoEnrichment{config: config, categories: categories}
+}
+
+// enrichVideo converts a video into a record with the derived fields
+// enabled in config.
+func (e videoEnrichment) enrichVideo(video *youtube.Video) (map[string]any, error) {
+	record, err := toRecord(video)
+	if err != nil {
+		return nil, err
+	}
+	if e.config.FlagRestricted {
+		record["regionBlocked"] = regionBlocked(video, e.config.Region)
+	}
+	if video.Snippet != nil {
+		if name, ok := e.categories[video.Snippet.CategoryId]; ok {
+			record["categoryName"] = name
+		}
+	}
+	if d, ok := videoDuration(video); ok {
+		record["durationSeconds"] = int64(d / time.Second)
+	}
+	record["videoType"] = videoType(video)
+	if video.Snippet != nil {
+		duration, _ := videoDuration(video)
+		if chapters, _ := parseChapters(video.Snippet.Description, duration); chapters != nil {
+			record["chapters"] = chapters
+		}
+	}
+	if d, ok := streamDuration(video); ok {
+		record["streamDurationSeconds"] = int64(d / time.Second)
+	}
+	if e.config.DetectLanguage || len(e.config.Languages) > 0 {
+		language, source := videoLanguage(video, true)
+		record["contentLanguage"] = language
+		if source != "" {
+			record["contentLanguageSource"] = source
+		}
+	}
+	return record, nil
+}
+
+// fetchVideosByID looks up videos in batches, returning the ones that still
+// exist by ID.
+func fetchVideosByID(service *youtube.Service, config Config, ids []string, parts []string) (map[string]*youtube.Video, error) {
+	fields, err := apiFieldMask(config, youtube.Video{}, videoDerivedFields, nil)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]*youtube.Video)
+	for i := 0; i < len(ids); i += videoLookupBatchSize {
+		end := min(i+videoLookupBatchSize, len(ids))
+		call := service.Videos.List(parts).
+			Id(ids[i:end]...).
+			MaxResults(videoLookupBatchSize)
+		if slices.Contains(parts, "player") {
+			call = call.MaxHeight(playerMaxHeight)
+		}
+		if config.Language != "" {
+			call = call.Hl(config.Language)
+		}
+		if fields != "" {
+			call = call.Fields(fields)
+		}
+		response, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch videos: %w", err)
+		}
+		for _, video := range response.Items {
+			found[video.Id] = video
+		}
+	}
+	return found, nil
+}
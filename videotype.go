@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// Values of the videoType field.
+const (
+	videoTypeShort   = "short"
+	videoTypeRegular = "regular"
+	videoTypeLive    = "live"
+)
+
+var videoTypes = []string{videoTypeShort, videoTypeRegular, videoTypeLive}
+
+// verticalShortMaxDuration is the length up to which a vertical video
+// counts as a Short; YouTube accepts Shorts of up to three minutes.
+const verticalShortMaxDuration = 3 * time.Minute
+
+// videoType classifies a video as a live stream (current, upcoming or
+// past), a Short or a regular video. Past streams are only recognized when
+// the liveStreamingDetails part was requested, and the aspect ratio only
+// when the player part was requested with a maxHeight.
+func videoType(video *youtube.Video) string {
+	if video.LiveStreamingDetails != nil {
+		return videoTypeLive
+	}
+	if video.Snippet != nil && video.Snippet.LiveBroadcastContent != "" && video.Snippet.LiveBroadcastContent != "none" {
+		return videoTypeLive
+	}
+	if isShort(video) {
+		return videoTypeShort
+	}
+	return videoTypeRegular
+}
+
+// isShort applies the Shorts heuristic: anything under a minute, or a
+// vertical video up to three minutes.
+func isShort(video *youtube.Video) bool {
+	d, ok := videoDuration(video)
+	if !ok || d == 0 {
+		return false
+	}
+	if d < shortMaxDuration {
+		return true
+	}
+	vertical := video.Player != nil && video.Player.EmbedHeight > video.Player.EmbedWidth
+	return vertical && d <= verticalShortMaxDuration
+}
+
+// streamDuration returns how long a finished stream was live, from the
+// liveStreamingDetails part.
+func streamDuration(video *youtube.Video) (time.Duration, bool) {
+	details := video.LiveStreamingDetails
+	if details == nil || details.ActualStartTime == "" || details.ActualEndTime == "" {
+		return 0, false
+	}
+	start, err := time.Parse(time.RFC3339, details.ActualStartTime)
+	if err != nil {
+		return 0, false
+	}
+	end, err := time.Parse(time.RFC3339, details.ActualEndTime)
+	if err != nil || end.Before(start) {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
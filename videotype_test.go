@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestVideoType(t *testing.T) {
+	for _, tt := range []struct {
+		duration string
+		player   *youtube.VideoPlayer
+		want     string
+	}{
+		{"PT59S", nil, videoTypeShort},
+		{"PT1M", nil, videoTypeRegular},
+		{"PT2M", nil, videoTypeRegular},
+		{"PT2M", &youtube.VideoPlayer{EmbedHeight: 720, EmbedWidth: 405}, videoTypeShort},
+		{"PT2M", &youtube.VideoPlayer{EmbedHeight: 405, EmbedWidth: 720}, videoTypeRegular},
+		{"PT4M", &youtube.VideoPlayer{EmbedHeight: 720, EmbedWidth: 405}, videoTypeRegular},
+	} {
+		video := &youtube.Video{ContentDetails: &youtube.VideoContentDetails{Duration: tt.duration}, Player: tt.player}
+		if got := videoType(video); got != tt.want {
+			t.Errorf("videoType(%s) = %s, want %s", tt.duration, got, tt.want)
+		}
+	}
+}
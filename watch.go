@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Types of watch events.
+const (
+	watchAdded   = "added"
+	watchRemoved = "removed"
+	watchDeleted = "deleted"
+	watchRenamed = "renamed"
+)
+
+// watchEvent is a change detected by a watch command. It is written to the
+// output and passed to every --notify target.
+type watchEvent struct {
+	Type          string `json:"type"`
+	ChannelID     string `json:"channelId"`
+	Title         string `json:"title,omitempty"`
+	PreviousTitle string `json:"previousTitle,omitempty"`
+	VideoID       string `json:"videoId,omitempty"`
+	VideoTitle    string `json:"videoTitle,omitempty"`
+	PublishedAt   string `json:"publishedAt,omitempty"`
+	DetectedAt    string `json:"detectedAt"`
+}
+
+type WatchOptions struct {
+	Interval time.Duration
+	State    string
+	Notify   []string
+	Once     bool
+}
+
+func newWatchCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Poll for changes and send notifications",
+	}
+	cmd.AddCommand(newWatchSubscriptionsCmd(config), newWatchUploadsCmd(config))
+	return cmd
+}
+
+// addWatchFlags adds the flags shared by all watch commands.
+func addWatchFlags(cmd *cobra.Command, opts *WatchOptions, interval time.Duration, state string) {
+	cmd.Flags().DurationVar(&opts.Interval, "interval", interval, "Time between two checks")
+	cmd.Flags().StringVar(&opts.State, "state", filepath.Join(getConfigDir(), state), "File the last snapshot is kept in")
+	cmd.Flags().StringArrayVar(&opts.Notify, "notify", nil, "Shell command or http(s) webhook URL to notify of changes (repeatable)")
+	cmd.Flags().BoolVar(&opts.Once, "once", false, "Check once and exit, e.g. from cron")
+	addOutputFlag(cmd, "", "Write events to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+	addFilterFlag(cmd, "Only report events matching this expression")
+	cobra.CheckErr(cmd.MarkFlagFilename("state", "json"))
+}
+
+func newWatchSubscriptionsCmd(config *Config) *cobra.Command {
+	var opts WatchOptions
+
+	cmd := &cobra.Command{
+		Use:   "subscriptions",
+		Short: "Notify when your subscriptions change",
+		Long: `Re-fetch your subscriptions periodically, compare them with the last
+snapshot, and report channels you subscribed to (added) or unsubscribed
+from (removed), channels that were deleted, and channels that were renamed.
+
+The first check only saves the snapshot. Events are written to the output
+and sent to every --notify target: http(s) URLs receive a JSON POST with
+all events of a check, shell commands run once per event with the event as
+JSON on stdin and in YTDATA_EVENT_TYPE, YTDATA_CHANNEL_ID and
+YTDATA_CHANNEL_TITLE.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Example: `  ytdata watch subscriptions --notify 'notify-send "$YTDATA_EVENT_TYPE" "$YTDATA_CHANNEL_TITLE"'
+  ytdata watch subscriptions --interval 6h --notify https://example.com/hook
+  ytdata watch subscriptions --once -o changes.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return watchSubscriptions(config, opts)
+			})
+		},
+	}
+
+	addWatchFlags(cmd, &opts, time.Hour, "watch_subscriptions.json")
+	return cmd
+}
+
+// subscriptionSnapshot is the state of watch subscriptions between checks.
+type subscriptionSnapshot struct {
+	CheckedAt string                    `json:"checkedAt"`
+	Channels  map[string]watchedChannel `json:"channels"`
+}
+
+type watchedChannel struct {
+	Title   string `json:"title"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+func readWatchState(path string, state any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return false, fmt.Errorf("invalid watch state %s: %w", path, err)
+	}
+	return true, nil
+}
+
+func saveWatchState(path string, state any) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize watch state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create watch state directory: %w", err)
+	}
+	// Replace atomically so an interrupted write never loses the snapshot
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
+
+// runWatch calls check every interval until interrupted, or once with
+// --once. Failed checks are reported and retried on the next tick.
+func runWatch(config Config, opts WatchOptions, check func(ctx context.Context, out output.Writer) error) (err error) {
+	if opts.Interval <= 0 {
+		return withKind(ErrInvalidConfig, fmt.Errorf("--interval must be positive"))
+	}
+	out, err := openOutput(config, "watch")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		if err := check(ctx, out); err != nil {
+			if opts.Once || ctx.Err() != nil {
+				return err
+			}
+			warnf("Check failed, retrying in %s: %v", opts.Interval, err)
+		}
+		if opts.Once {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+func watchSubscriptions(config Config, opts WatchOptions) error {
+	service, err := authenticateYouTube(config)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	return runWatch(config, opts, func(ctx context.Context, out output.Writer) error {
+		return checkSubscriptions(ctx, service, config, opts, out)
+	})
+}
+
+// currentSubscriptions returns the subscribed channels by ID. Channels the
+// API no longer returns details for are marked deleted.
+func currentSubscriptions(ctx context.Context, service *youtube.Service, config Config) (map[string]watchedChannel, error) {
+	subscriptions, err := fetchSubscriptionList(ctx, service, nil)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]watchedChannel, len(subscriptions))
+	var ids []string
+	for _, sub := range subscriptions {
+		id := sub.Snippet.ResourceId.ChannelId
+		current[id] = watchedChannel{Title: sub.Snippet.Title, Deleted: true}
+		ids = append(ids, id)
+	}
+	for batch := range slices.Chunk(ids, 50) {
+		channels, err := fetchChannelBatch(service, config, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch channel details: %w", err)
+		}
+		for _, channel := range channels {
+			current[channel.Id] = watchedChannel{Title: channel.Snippet.Title}
+		}
+	}
+	return current, nil
+}
+
+// missingChannels returns which of ids no longer exist.
+func missingChannels(service *youtube.Service, config Config, ids []string) (map[string]bool, error) {
+	missing := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		missing[id] = true
+	}
+	for batch := range slices.Chunk(ids, 50) {
+		channels, err := fetchChannelBatch(service, config, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch channel details: %w", err)
+		}
+		for _, channel := range channels {
+			delete(missing, channel.Id)
+		}
+	}
+	return missing, nil
+}
+
+// diffSubscriptions compares two snapshots; missing tells which removed
+// channels no longer exist.
+func diffSubscriptions(previous, current map[string]watchedChannel, missing map[string]bool, detectedAt string) []watchEvent {
+	var events []watchEvent
+	for id, channel := range current {
+		before, known := previous[id]
+		switch {
+		case !known:
+			events = append(events, watchEvent{Type: watchAdded, ChannelID: id, Title: channel.Title})
+		case channel.Deleted && !before.Deleted:
+			events = append(events, watchEvent{Type: watchDeleted, ChannelID: id, Title: before.Title})
+		case !channel.Deleted && !before.Deleted && channel.Title != before.Title:
+			events = append(events, watchEvent{Type: watchRenamed, ChannelID: id, Title: channel.Title, PreviousTitle: before.Title})
+		}
+	}
+	for id, channel := range previous {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		switch {
+		case channel.Deleted:
+			// Already reported; the subscription is gone now too
+		case missing[id]:
+			events = append(events, watchEvent{Type: watchDeleted, ChannelID: id, Title: channel.Title})
+		default:
+			events = append(events, watchEvent{Type: watchRemoved, ChannelID: id, Title: channel.Title})
+		}
+	}
+	slices.SortFunc(events, func(a, b watchEvent) int {
+		if c := strings.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ChannelID, b.ChannelID)
+	})
+	for i := range events {
+		events[i].DetectedAt = detectedAt
+	}
+	return events
+}
+
+func checkSubscriptions(ctx context.Context, service *youtube.Service, config Config, opts WatchOptions, out output.Writer) error {
+	var snapshot subscriptionSnapshot
+	found, err := readWatchState(opts.State, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	current, err := currentSubscriptions(ctx, service, config)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	if found {
+		var removed []string
+		for id, channel := range snapshot.Channels {
+			if _, ok := current[id]; !ok && !channel.Deleted {
+				removed = append(removed, id)
+			}
+		}
+		missing, err := missingChannels(service, config, removed)
+		if err != nil {
+			return err
+		}
+		events := diffSubscriptions(snapshot.Channels, current, missing, now)
+		if err := reportWatchEvents(out, opts, events); err != nil {
+			return err
+		}
+	} else {
+		infof("Saved snapshot of %d subscriptions to %s", len(current), opts.State)
+	}
+
+	return saveWatchState(opts.State, subscriptionSnapshot{CheckedAt: now, Channels: current})
+}
+
+// reportWatchEvents writes events to the output and notifies every target.
+// Notification failures are warnings, so one broken target does not stop
+// the watch.
+func reportWatchEvents(out output.Writer, opts WatchOptions, events []watchEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	for _, event := range events {
+		if err := out.Write(event); err != nil {
+			return fmt.Errorf("failed to write event: %w", err)
+		}
+	}
+	for _, target := range opts.Notify {
+		if err := notifyTarget(target, events); err != nil {
+			warnf("Failed to notify %s: %v", target, err)
+		}
+	}
+	return nil
+}
+
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+func notifyTarget(target string, events []watchEvent) error {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return notifyWebhook(target, events)
+	}
+	for _, event := range events {
+		if err := notifyCommand(target, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func notifyWebhook(url string, events []watchEvent) error {
+	body, err := json.Marshal(map[string]any{"events": events})
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			warnf("Failed to close webhook response: %v", err)
+		}
+	}()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func notifyCommand(command string, event watchEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	// stdout carries the events written by ytdata
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"YTDATA_EVENT_TYPE="+event.Type,
+		"YTDATA_CHANNEL_ID="+event.ChannelID,
+		"YTDATA_CHANNEL_TITLE="+event.Title,
+	)
+	if event.VideoID != "" {
+		cmd.Env = append(cmd.Env,
+			"YTDATA_VIDEO_ID="+event.VideoID,
+			"YTDATA_VIDEO_TITLE="+event.VideoTitle,
+			"YTDATA_VIDEO_URL=https://www.youtube.com/watch?v="+event.VideoID,
+		)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
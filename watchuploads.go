@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rtzll/ytdata/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	watchUpload = "upload"
+
+	// channelFeedURL is the public feed of a channel's latest 15 uploads,
+	// which costs no API quota
+	channelFeedURL = "https://www.youtube.com/feeds/videos.xml?channel_id="
+
+	// maxSeenUploads bounds the video IDs remembered per channel; feeds and
+	// the first page of an uploads playlist never return more
+	maxSeenUploads = 100
+)
+
+type WatchUploadsOptions struct {
+	WatchOptions
+	ChannelsFile string
+	RSS          bool
+}
+
+// watchedUploads is the configuration and state of one channel.
+type watchedUploads struct {
+	ID       string
+	Playlist string
+	Interval time.Duration
+}
+
+// uploadsSnapshot is the state of watch uploads between checks.
+type uploadsSnapshot struct {
+	Channels map[string]*uploadsState `json:"channels"`
+}
+
+type uploadsState struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Seen      []string  `json:"seen"`
+}
+
+// upload is a video found when polling a channel.
+type upload struct {
+	VideoID      string
+	Title        string
+	ChannelTitle string
+	PublishedAt  string
+}
+
+func newWatchUploadsCmd(config *Config) *cobra.Command {
+	var opts WatchUploadsOptions
+
+	cmd := &cobra.Command{
+		Use:   "uploads",
+		Short: "Notify when selected channels upload videos",
+		Long: `Poll the uploads of the channels listed in --channels and report new
+videos. The file lists one channel ID or @handle per line, optionally
+followed by its own poll interval (e.g. "UCxxxxxxxxxxxxxxxxxxxxxx 15m");
+lines starting with # are ignored.
+
+By default the uploads playlists are read with the API (1 quota unit per
+channel and check). With --rss the public channel feeds are polled
+instead, which costs no quota and needs no credentials; only handles are
+resolved with the API, once per run.
+
+The first check of a channel only remembers its current videos. Events and
+--notify targets work as for 'watch subscriptions'; commands additionally
+get YTDATA_VIDEO_ID, YTDATA_VIDEO_TITLE and YTDATA_VIDEO_URL.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{publicAnnotation: "true"},
+		Example: `  ytdata watch uploads --channels channels.txt --rss --notify 'notify-send "$YTDATA_CHANNEL_TITLE" "$YTDATA_VIDEO_TITLE"'
+  ytdata watch uploads --channels channels.txt --interval 30m --api-key $YOUTUBE_API_KEY --notify https://example.com/hook`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.RSS {
+				// Feeds are public and need neither credentials nor setup
+				cmd.Annotations[localAnnotation] = "true"
+			}
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return watchUploads(config, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ChannelsFile, "channels", "", "File listing the channels to watch, one per line")
+	cmd.Flags().BoolVar(&opts.RSS, "rss", false, "Poll public channel feeds instead of the API (no quota)")
+	addWatchFlags(cmd, &opts.WatchOptions, time.Hour, "watch_uploads.json")
+	cobra.CheckErr(cmd.MarkFlagRequired("channels"))
+	cobra.CheckErr(cmd.MarkFlagFilename("channels", "txt"))
+
+	return cmd
+}
+
+// readWatchedChannels parses the --channels file.
+func readWatchedChannels(path string, defaultInterval time.Duration) ([]watchedUploads, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("failed to open channels file: %w", err))
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			warnf("Failed to close channels file: %v", err)
+		}
+	}()
+
+	var channels []watchedUploads
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		id, err := idFromArg(fields[0], refChannel)
+		if err != nil {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("%s:%d: %w", path, line, err))
+		}
+		channel := watchedUploads{ID: id, Interval: defaultInterval}
+		if len(fields) > 1 {
+			interval, err := time.ParseDuration(fields[1])
+			if err != nil || interval <= 0 {
+				return nil, withKind(ErrInvalidConfig, fmt.Errorf("%s:%d: invalid interval %q", path, line, fields[1]))
+			}
+			channel.Interval = interval
+		}
+		channels = append(channels, channel)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read channels file: %w", err)
+	}
+	if len(channels) == 0 {
+		return nil, withKind(ErrInvalidConfig, fmt.Errorf("no channels listed in %s", path))
+	}
+	return channels, nil
+}
+
+// resolveUploadPlaylists replaces handles with channel IDs and looks up the
+// uploads playlist of every channel.
+func resolveUploadPlaylists(service *youtube.Service, config Config, channels []watchedUploads) ([]watchedUploads, error) {
+	var args []string
+	for _, channel := range channels {
+		args = append(args, channel.ID)
+	}
+	resolved, err := resolveChannels(service, config, args)
+	if err != nil {
+		return nil, err
+	}
+	byArg := make(map[string]*youtube.Channel)
+	for _, channel := range resolved {
+		byArg[channel.Id] = channel
+		if channel.Snippet != nil && channel.Snippet.CustomUrl != "" {
+			byArg[strings.ToLower(channel.Snippet.CustomUrl)] = channel
+		}
+	}
+
+	var result []watchedUploads
+	for _, watched := range channels {
+		channel, ok := byArg[watched.ID]
+		if !ok {
+			channel, ok = byArg[strings.ToLower(watched.ID)]
+		}
+		if !ok {
+			// resolveChannels already warned
+			continue
+		}
+		watched.ID = channel.Id
+		if channel.ContentDetails != nil && channel.ContentDetails.RelatedPlaylists != nil {
+			watched.Playlist = channel.ContentDetails.RelatedPlaylists.Uploads
+		}
+		result = append(result, watched)
+	}
+	return result, nil
+}
+
+// resolveFeedChannels replaces handles with the channel IDs the feeds are
+// addressed by. Only handles need the API, one request each.
+func resolveFeedChannels(config Config, channels []watchedUploads) ([]watchedUploads, error) {
+	if slices.ContainsFunc(channels, func(channel watchedUploads) bool { return strings.HasPrefix(channel.ID, "@") }) {
+		service, err := publicYouTube(config)
+		if err != nil {
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+		var resolved []watchedUploads
+		for _, channel := range channels {
+			ids, err := resolveHandles(service, []string{channel.ID})
+			if err != nil {
+				return nil, err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			channel.ID = ids[0]
+			resolved = append(resolved, channel)
+		}
+		channels = resolved
+	}
+	for _, channel := range channels {
+		if !strings.HasPrefix(channel.ID, "UC") {
+			return nil, withKind(ErrInvalidConfig, fmt.Errorf("--rss needs channel IDs or handles, not %q", channel.ID))
+		}
+	}
+	return channels, nil
+}
+
+func watchUploads(config Config, opts WatchUploadsOptions) error {
+	channels, err := readWatchedChannels(opts.ChannelsFile, opts.Interval)
+	if err != nil {
+		return err
+	}
+
+	var fetch func(ctx context.Context, channel watchedUploads) ([]upload, error)
+	if opts.RSS {
+		if channels, err = resolveFeedChannels(config, channels); err != nil {
+			return err
+		}
+		fetch = fetchFeedUploads
+	} else {
+		service, err := publicYouTube(config)
+		if err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+		if channels, err = resolveUploadPlaylists(service, config, channels); err != nil {
+			return err
+		}
+		fetch = func(ctx context.Context, channel watchedUploads) ([]upload, error) {
+			return fetchPlaylistUploads(ctx, service, channel.Playlist)
+		}
+	}
+
+	if len(channels) == 0 {
+		return fmt.Errorf("none of the channels in %s were found", opts.ChannelsFile)
+	}
+
+	// Checks run as often as the most frequently polled channel needs
+	tick := channels[0].Interval
+	for _, channel := range channels[1:] {
+		tick = min(tick, channel.Interval)
+	}
+	watchOpts := opts.WatchOptions
+	watchOpts.Interval = tick
+
+	return runWatch(config, watchOpts, func(ctx context.Context, out output.Writer) error {
+		return checkUploads(ctx, opts, channels, tick, fetch, out)
+	})
+}
+
+func checkUploads(ctx context.Context, opts WatchUploadsOptions, channels []watchedUploads, tick time.Duration, fetch func(context.Context, watchedUploads) ([]upload, error), out output.Writer) error {
+	snapshot := uploadsSnapshot{Channels: make(map[string]*uploadsState)}
+	if _, err := readWatchState(opts.State, &snapshot); err != nil {
+		return err
+	}
+	if snapshot.Channels == nil {
+		snapshot.Channels = make(map[string]*uploadsState)
+	}
+
+	now := time.Now().UTC()
+	var events []watchEvent
+	var polled, failed int
+	for _, channel := range channels {
+		state, known := snapshot.Channels[channel.ID]
+		// A tenth of a tick of slack keeps channels from slipping a whole
+		// tick because a check started slightly early
+		if known && now.Add(tick/10).Before(state.CheckedAt.Add(channel.Interval)) {
+			continue
+		}
+		polled++
+		uploads, err := fetch(ctx, channel)
+		if err != nil {
+			warnf("Failed to check channel %s: %v", channel.ID, err)
+			failed++
+			continue
+		}
+		if !known {
+			state = &uploadsState{}
+			snapshot.Channels[channel.ID] = state
+		}
+		for _, video := range uploads {
+			if known && !slices.Contains(state.Seen, video.VideoID) {
+				events = append(events, watchEvent{
+					Type:        watchUpload,
+					ChannelID:   channel.ID,
+					Title:       video.ChannelTitle,
+					VideoID:     video.VideoID,
+					VideoTitle:  video.Title,
+					PublishedAt: video.PublishedAt,
+					DetectedAt:  now.Format(time.RFC3339),
+				})
+			}
+		}
+		state.CheckedAt = now
+		state.Seen = seenUploads(uploads, state.Seen)
+	}
+
+	// Oldest first, so notifications arrive in upload order
+	slices.SortStableFunc(events, func(a, b watchEvent) int {
+		return strings.Compare(a.PublishedAt, b.PublishedAt)
+	})
+	if err := reportWatchEvents(out, opts.WatchOptions, events); err != nil {
+		return err
+	}
+	if err := saveWatchState(opts.State, snapshot); err != nil {
+		return err
+	}
+	if failed > 0 && failed == polled {
+		return fmt.Errorf("failed to check all %d channels", failed)
+	}
+	return nil
+}
+
+// seenUploads returns the current video IDs followed by previously seen
+// ones, so videos dropping out of a feed are not reported again when they
+// reappear, up to maxSeenUploads.
+func seenUploads(uploads []upload, previous []string) []string {
+	var seen []string
+	for _, video := range uploads {
+		if !slices.Contains(seen, video.VideoID) {
+			seen = append(seen, video.VideoID)
+		}
+	}
+	for _, id := range previous {
+		if len(seen) >= maxSeenUploads {
+			break
+		}
+		if !slices.Contains(seen, id) {
+			seen = append(seen, id)
+		}
+	}
+	return seen
+}
+
+// fetchPlaylistUploads returns the latest videos of an uploads playlist.
+func fetchPlaylistUploads(ctx context.Context, service *youtube.Service, playlistID string) ([]upload, error) {
+	response, err := service.PlaylistItems.List([]string{"snippet", "contentDetails"}).
+		Context(ctx).
+		PlaylistId(playlistID).
+		MaxResults(50).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch uploads: %w", err)
+	}
+	var uploads []upload
+	for _, item := range response.Items {
+		if item.Snippet == nil || item.Snippet.ResourceId == nil {
+			continue
+		}
+		video := upload{
+			VideoID:      item.Snippet.ResourceId.VideoId,
+			Title:        item.Snippet.Title,
+			ChannelTitle: item.Snippet.ChannelTitle,
+			PublishedAt:  item.Snippet.PublishedAt,
+		}
+		if item.ContentDetails != nil && item.ContentDetails.VideoPublishedAt != "" {
+			video.PublishedAt = item.ContentDetails.VideoPublishedAt
+		}
+		uploads = append(uploads, video)
+	}
+	return uploads, nil
+}
+
+// channelFeed is the part of a channel's Atom feed watch uploads reads.
+type channelFeed struct {
+	Entries []struct {
+		VideoID   string `xml:"videoId"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Author    struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+}
+
+var feedClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchFeedUploads returns the latest videos from a channel's public feed.
+func fetchFeedUploads(ctx context.Context, channel watchedUploads) ([]upload, error) {
+	return fetchChannelFeed(ctx, feedClient, channel.ID)
+}
+
+// fetchChannelFeed returns the latest videos from the public feed of a
+// channel ID.
+func fetchChannelFeed(ctx context.Context, client *http.Client, channelID string) ([]upload, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, channelFeedURL+channelID, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			warnf("Failed to close feed response: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed: %s", resp.Status)
+	}
+
+	var feed channelFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	var uploads []upload
+	for _, entry := range feed.Entries {
+		uploads = append(uploads, upload{
+			VideoID:      entry.VideoID,
+			Title:        entry.Title,
+			ChannelTitle: entry.Author.Name,
+			PublishedAt:  entry.Published,
+		})
+	}
+	return uploads, nil
+}
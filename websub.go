@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	watchUpdated = "updated"
+
+	// websubHub is Google's hub for YouTube channel feeds
+	websubHub = "https://pubsubhubbub.appspot.com/subscribe"
+	// websubTopicURL is the topic of a channel's uploads
+	websubTopicURL = "https://www.youtube.com/xml/feeds/videos.xml?channel_id="
+	// websubLease is the requested subscription lifetime; subscriptions are
+	// renewed after half of the lease granted by the hub
+	websubLease = 10 * 24 * time.Hour
+	// websubVerifyWindow is how long the hub has to confirm a request
+	websubVerifyWindow = 10 * time.Minute
+)
+
+type WebSubOptions struct {
+	CallbackURL  string
+	Listen       string
+	ChannelsFile string
+	Store        string
+	Secret       string
+	Notify       []string
+}
+
+func newWebSubCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "websub",
+		Short: "Receive push notifications of new uploads",
+	}
+
+	var opts WebSubOptions
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Subscribe to channel feeds and receive uploads as they happen",
+		Long: `Subscribe to the feeds of the channels in --channels at Google's WebSub
+(PubSubHubbub) hub and serve the callback the hub pushes new and updated
+videos to, instead of polling.
+
+--callback-url must be reachable from the internet (e.g. through a reverse
+proxy or tunnel) and is served on --listen. Every notification is appended
+to the --store JSONL file as an upload, updated or deleted event and sent
+to the --notify targets like 'watch uploads' does. Subscriptions are
+renewed before their lease expires; with --secret, notifications are only
+accepted with a valid signature.
+
+The channels file lists one channel ID per line; lines starting with # are
+ignored. No API quota or credentials are needed.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata websub serve --callback-url https://example.com/websub --channels channels.txt
+  ytdata websub serve --callback-url https://example.com/websub --listen :9000 --channels channels.txt --secret "$SECRET" --notify 'notify-send "$YTDATA_VIDEO_TITLE"'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return serveWebSub(opts)
+			})
+		},
+	}
+
+	serveCmd.Flags().StringVar(&opts.CallbackURL, "callback-url", "", "Public URL the hub sends notifications to")
+	serveCmd.Flags().StringVar(&opts.Listen, "listen", ":8080", "Address to serve the callback on")
+	serveCmd.Flags().StringVar(&opts.ChannelsFile, "channels", "", "File listing the channel IDs to subscribe to, one per line")
+	serveCmd.Flags().StringVar(&opts.Store, "store", "websub.jsonl", "JSONL file notifications are appended to")
+	serveCmd.Flags().StringVar(&opts.Secret, "secret", "", "Shared secret for signed notifications (recommended)")
+	serveCmd.Flags().StringArrayVar(&opts.Notify, "notify", nil, "Shell command or http(s) webhook URL to notify of uploads (repeatable)")
+	cobra.CheckErr(serveCmd.MarkFlagRequired("callback-url"))
+	cobra.CheckErr(serveCmd.MarkFlagRequired("channels"))
+	cobra.CheckErr(serveCmd.MarkFlagFilename("channels", "txt"))
+	cobra.CheckErr(serveCmd.MarkFlagFilename("store", "jsonl"))
+
+	cmd.AddCommand(serveCmd)
+	return cmd
+}
+
+// websubFeed is a notification pushed by the hub: new or updated videos,
+// or deleted ones.
+type websubFeed struct {
+	Entries []struct {
+		VideoID   string `xml:"videoId"`
+		ChannelID string `xml:"channelId"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Author    struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+	Deleted []struct {
+		Ref string `xml:"ref,attr"`
+		By  struct {
+			URI string `xml:"uri"`
+		} `xml:"by"`
+	} `xml:"deleted-entry"`
+}
+
+// websubServer handles hub callbacks and keeps the subscriptions alive.
+type websubServer struct {
+	opts   WebSubOptions
+	topics map[string]string // topic URL -> channel ID
+
+	mu     sync.Mutex
+	store  *os.File
+	seen   map[string]bool
+	leases map[string]time.Time // channel ID -> renewal time
+	// requests are the (un)subscriptions sent to the hub and not yet
+	// confirmed; the hub's verification of anything else is refused
+	requests map[websubRequest]time.Time // -> end of verification window
+}
+
+// websubRequest is a subscription change sent to the hub.
+type websubRequest struct {
+	mode  string
+	topic string
+}
+
+func serveWebSub(opts WebSubOptions) (err error) {
+	callback, err := url.Parse(opts.CallbackURL)
+	if err != nil || callback.Host == "" || (callback.Scheme != "http" && callback.Scheme != "https") {
+		return withKind(ErrInvalidConfig, fmt.Errorf("invalid --callback-url %q", opts.CallbackURL))
+	}
+	channels, err := readWatchedChannels(opts.ChannelsFile, websubLease)
+	if err != nil {
+		return err
+	}
+
+	server := &websubServer{
+		opts:     opts,
+		topics:   make(map[string]string),
+		seen:     make(map[string]bool),
+		leases:   make(map[string]time.Time),
+		requests: make(map[websubRequest]time.Time),
+	}
+	for _, channel := range channels {
+		if !strings.HasPrefix(channel.ID, "UC") {
+			return withKind(ErrInvalidConfig, fmt.Errorf("websub needs channel IDs, not %q", channel.ID))
+		}
+		server.topics[websubTopicURL+channel.ID] = channel.ID
+	}
+	if err := server.openStore(); err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := server.store.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close store: %w", closeErr)
+		}
+	}()
+
+	path := callback.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, server.handle)
+	httpServer := &http.Server{Addr: opts.Listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errChan := make(chan error, 1)
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errChan <- fmt.Errorf("failed to serve callback: %w", err)
+		}
+	}()
+	infof("Serving %s on %s for %d channels", opts.CallbackURL, opts.Listen, len(channels))
+
+	go server.renew(ctx)
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdown)
+}
+
+// openStore opens the store for appending and remembers the videos it
+// already holds, so repeated pushes are reported as updates.
+func (s *websubServer) openStore() error {
+	if f, err := os.Open(s.opts.Store); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var event watchEvent
+			if json.Unmarshal(scanner.Bytes(), &event) == nil && event.VideoID != "" {
+				s.seen[event.VideoID] = true
+			}
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to read store: %w", err)
+		}
+	}
+	if dir := filepath.Dir(s.opts.Store); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create store directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(s.opts.Store, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	s.store = f
+	return nil
+}
+
+// renew subscribes to every topic and renews each subscription when half
+// of its lease has passed. Failed requests are retried after a minute.
+func (s *websubServer) renew(ctx context.Context) {
+	for {
+		now := time.Now()
+		next := now.Add(time.Hour)
+		for topic, channelID := range s.topics {
+			s.mu.Lock()
+			due, ok := s.leases[channelID]
+			s.mu.Unlock()
+			if ok && now.Before(due) {
+				next = minTime(next, due)
+				continue
+			}
+			if err := s.request(ctx, "subscribe", topic); err != nil {
+				warnf("Failed to subscribe to %s: %v", channelID, err)
+				next = minTime(next, now.Add(time.Minute))
+				continue
+			}
+			// Until the hub confirms, retry after the verification window
+			s.mu.Lock()
+			if due, ok := s.leases[channelID]; !ok || !due.After(now) {
+				s.leases[channelID] = now.Add(websubVerifyWindow)
+			}
+			s.mu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// request asks the hub to (un)subscribe the callback to topic; the hub
+// confirms asynchronously with a GET to the callback.
+func (s *websubServer) request(ctx context.Context, mode, topic string) error {
+	form := url.Values{
+		"hub.callback": {s.opts.CallbackURL},
+		"hub.topic":    {topic},
+		"hub.mode":     {mode},
+		"hub.verify":   {"async"},
+	}
+	if mode == "subscribe" {
+		form.Set("hub.lease_seconds", strconv.Itoa(int(websubLease.Seconds())))
+		if s.opts.Secret != "" {
+			form.Set("hub.secret", s.opts.Secret)
+		}
+	}
+	s.mu.Lock()
+	s.requests[websubRequest{mode, topic}] = time.Now().Add(websubVerifyWindow)
+	s.mu.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, websubHub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			warnf("Failed to close hub response: %v", err)
+		}
+	}()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("hub returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *websubServer) handle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.verify(w, r)
+	case http.MethodPost:
+		s.receive(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify answers the hub's confirmation of a (un)subscription request.
+// Only requests this server sent are confirmed, so nobody else can
+// unsubscribe the callback through the hub.
+func (s *websubServer) verify(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	channelID, ok := s.topics[query.Get("hub.topic")]
+	if !ok {
+		http.Error(w, "Unknown topic", http.StatusNotFound)
+		return
+	}
+	mode := query.Get("hub.mode")
+	if mode != "subscribe" && mode != "unsubscribe" {
+		http.Error(w, "Unknown mode", http.StatusBadRequest)
+		return
+	}
+	request := websubRequest{mode, query.Get("hub.topic")}
+	s.mu.Lock()
+	until, requested := s.requests[request]
+	delete(s.requests, request)
+	s.mu.Unlock()
+	if !requested || time.Now().After(until) {
+		http.Error(w, "No such request", http.StatusNotFound)
+		return
+	}
+	switch mode {
+	case "subscribe":
+		lease := websubLease
+		if seconds, err := strconv.Atoi(query.Get("hub.lease_seconds")); err == nil && seconds > 0 {
+			lease = time.Duration(seconds) * time.Second
+		}
+		s.mu.Lock()
+		s.leases[channelID] = time.Now().Add(lease / 2)
+		s.mu.Unlock()
+		infof("Subscribed to %s for %s", channelID, lease)
+	case "unsubscribe":
+		s.mu.Lock()
+		delete(s.leases, channelID)
+		s.mu.Unlock()
+		infof("Unsubscribed from %s", channelID)
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	if _, err := io.WriteString(w, query.Get("hub.challenge")); err != nil {
+		warnf("Failed to write response: %v", err)
+	}
+}
+
+// validSignature checks the X-Hub-Signature header the hub adds when a
+// secret was given on subscription.
+func validSignature(secret string, body []byte, header string) bool {
+	algorithm, signature, ok := strings.Cut(header, "=")
+	if !ok || algorithm != "sha1" {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// receive stores the videos of a pushed notification.
+func (s *websubServer) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	// Per the spec, notifications with a bad signature are acknowledged
+	// but ignored, so the hub does not retry them
+	if s.opts.Secret != "" && !validSignature(s.opts.Secret, body, r.Header.Get("X-Hub-Signature")) {
+		warnf("Ignoring notification with invalid signature")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var feed websubFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		http.Error(w, "Invalid feed", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var events []watchEvent
+	s.mu.Lock()
+	for _, entry := range feed.Entries {
+		event := watchEvent{
+			Type:        watchUpload,
+			ChannelID:   entry.ChannelID,
+			Title:       entry.Author.Name,
+			VideoID:     entry.VideoID,
+			VideoTitle:  entry.Title,
+			PublishedAt: entry.Published,
+			DetectedAt:  now,
+		}
+		if s.seen[entry.VideoID] {
+			event.Type = watchUpdated
+		}
+		s.seen[entry.VideoID] = true
+		events = append(events, event)
+	}
+	for _, deleted := range feed.Deleted {
+		events = append(events, watchEvent{
+			Type:       watchDeleted,
+			ChannelID:  strings.TrimPrefix(deleted.By.URI, "https://www.youtube.com/channel/"),
+			VideoID:    strings.TrimPrefix(deleted.Ref, "yt:video:"),
+			DetectedAt: now,
+		})
+	}
+	err = s.append(events)
+	s.mu.Unlock()
+	if err != nil {
+		warnf("Failed to store notification: %v", err)
+		http.Error(w, "Failed to store notification", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	// Notify in the background so slow targets never delay the hub
+	go func() {
+		for _, target := range s.opts.Notify {
+			if err := notifyTarget(target, events); err != nil {
+				warnf("Failed to notify %s: %v", target, err)
+			}
+		}
+	}()
+}
+
+// append writes events to the store; the caller holds s.mu.
+func (s *websubServer) append(events []watchEvent) error {
+	encoder := json.NewEncoder(s.store)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return s.store.Sync()
+}
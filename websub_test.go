@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWebSubVerifiesOnlyOwnRequests(t *testing.T) {
+	topic := websubTopicURL + "UCaaaaaaaaaaaaaaaaaaaaaa"
+	s := &websubServer{
+		topics:   map[string]string{topic: "UCaaaaaaaaaaaaaaaaaaaaaa"},
+		leases:   make(map[string]time.Time),
+		requests: map[websubRequest]time.Time{{"subscribe", topic}: time.Now().Add(websubVerifyWindow)},
+	}
+	verify := func(mode string) *httptest.ResponseRecorder {
+		query := url.Values{"hub.mode": {mode}, "hub.topic": {topic}, "hub.challenge": {"c123"}}
+		w := httptest.NewRecorder()
+		s.verify(w, httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil))
+		return w
+	}
+
+	if w := verify("unsubscribe"); w.Code != http.StatusNotFound {
+		t.Errorf("unrequested unsubscribe answered with %d, want 404", w.Code)
+	}
+	if w := verify("subscribe"); w.Code != http.StatusOK || w.Body.String() != "c123" {
+		t.Errorf("requested subscribe answered with %d %q, want the challenge", w.Code, w.Body)
+	}
+	if w := verify("subscribe"); w.Code != http.StatusNotFound {
+		t.Errorf("a request was confirmed twice (%d)", w.Code)
+	}
+}
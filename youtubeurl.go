@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Kinds of resources a YouTube URL or ID refers to.
+const (
+	refVideo    = "video"
+	refPlaylist = "playlist"
+	refChannel  = "channel"
+	refHandle   = "handle"
+	refUser     = "user"
+)
+
+var (
+	videoIDPattern    = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	playlistIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{12,}$`)
+)
+
+// youtubeRef is what a YouTube URL or bare ID refers to.
+type youtubeRef struct {
+	Input string `json:"input"`
+	Kind  string `json:"kind"`
+	// ID is a video, playlist or channel ID, an @handle, or a legacy
+	// username
+	ID string `json:"id"`
+	// PlaylistID is the playlist a video was opened in
+	PlaylistID   string `json:"playlistId,omitempty"`
+	StartSeconds int64  `json:"startSeconds,omitempty"`
+}
+
+// parseYouTubeRef parses a YouTube URL (watch, youtu.be, shorts, live,
+// embed, playlist, channel, handle and user URLs, with or without scheme)
+// or recognizes a bare ID by its shape.
+func parseYouTubeRef(input string) (youtubeRef, error) {
+	input = strings.TrimSpace(input)
+	ref := youtubeRef{Input: input}
+	if !strings.Contains(input, "/") {
+		ref.Kind, ref.ID = bareIDKind(input), input
+		if ref.Kind == "" {
+			return ref, fmt.Errorf("%q is no YouTube URL or ID", input)
+		}
+		return ref, nil
+	}
+
+	raw := input
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ref, fmt.Errorf("invalid URL %q: %w", input, err)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	query := u.Query()
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	segment := func(i int) string {
+		if i < len(segments) {
+			return segments[i]
+		}
+		return ""
+	}
+	ref.PlaylistID = query.Get("list")
+	ref.StartSeconds = startSeconds(query.Get("t"))
+
+	switch {
+	case host == "youtu.be":
+		ref.Kind, ref.ID = refVideo, segment(0)
+	case host == "youtube.com" || strings.HasSuffix(host, ".youtube.com") || host == "youtube-nocookie.com":
+		switch first := segment(0); {
+		case first == "watch":
+			ref.Kind, ref.ID = refVideo, query.Get("v")
+		case first == "shorts" || first == "live" || first == "embed" || first == "v" || first == "e":
+			ref.Kind, ref.ID = refVideo, segment(1)
+			if segment(1) == "videoseries" {
+				ref.Kind, ref.ID = refPlaylist, ref.PlaylistID
+			}
+		case first == "playlist":
+			ref.Kind, ref.ID = refPlaylist, ref.PlaylistID
+		case first == "channel":
+			ref.Kind, ref.ID = refChannel, segment(1)
+		case first == "user":
+			ref.Kind, ref.ID = refUser, segment(1)
+		case strings.HasPrefix(first, "@"):
+			ref.Kind, ref.ID = refHandle, first
+		case first == "c":
+			return ref, fmt.Errorf("custom channel URLs such as %s cannot be resolved; use the channel's @handle or ID", input)
+		}
+	default:
+		return ref, fmt.Errorf("%s is no YouTube URL", input)
+	}
+
+	if ref.Kind == refVideo && ref.ID == "" && ref.PlaylistID != "" {
+		ref.Kind, ref.ID = refPlaylist, ref.PlaylistID
+	}
+	if ref.Kind == refPlaylist {
+		ref.PlaylistID = ""
+	}
+	if ref.Kind == "" || ref.ID == "" {
+		return ref, fmt.Errorf("no video, playlist or channel in %s", input)
+	}
+	return ref, nil
+}
+
+// bareIDKind recognizes an ID by its shape, like 'ytdata open' does:
+// channel IDs start with UC, playlist IDs are longer than video IDs and
+// start with a known prefix, handles with @.
+func bareIDKind(id string) string {
+	switch {
+	case strings.HasPrefix(id, "@") && len(id) > 1:
+		return refHandle
+	case channelIDPattern.MatchString(id):
+		return refChannel
+	case hasPlaylistPrefix(id) && playlistIDPattern.MatchString(id):
+		return refPlaylist
+	case videoIDPattern.MatchString(id):
+		return refVideo
+	}
+	return ""
+}
+
+// startSeconds parses the t parameter of a video URL: 90, 90s or 1m30s.
+func startSeconds(t string) int64 {
+	if t == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(t, 10, 64); err == nil {
+		return seconds
+	}
+	if d, err := time.ParseDuration(t); err == nil {
+		return int64(d / time.Second)
+	}
+	return 0
+}
+
+// idFromArg returns the ID of kind an argument refers to. Anything that is
+// not a URL is taken as an ID as it is; URLs must refer to kind. Channel
+// arguments also accept @handles.
+func idFromArg(arg, kind string) (string, error) {
+	if !strings.Contains(arg, "/") {
+		return arg, nil
+	}
+	ref, err := parseYouTubeRef(arg)
+	if err != nil {
+		return "", withKind(ErrInvalidConfig, err)
+	}
+	switch {
+	case ref.Kind == kind:
+		return ref.ID, nil
+	case kind == refChannel && ref.Kind == refHandle:
+		return ref.ID, nil
+	case kind == refPlaylist && ref.PlaylistID != "":
+		// A video watched in a playlist
+		return ref.PlaylistID, nil
+	}
+	return "", withKind(ErrInvalidConfig, fmt.Errorf("%s refers to a %s, not a %s", arg, ref.Kind, kind))
+}
+
+// idsFromArgs applies idFromArg to every argument.
+func idsFromArgs(args []string, kind string) ([]string, error) {
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		id, err := idFromArg(arg, kind)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func newParseURLCmd(config *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "parse-url URL|ID...",
+		Short: "Extract the IDs from YouTube URLs",
+		Long: `Parse YouTube URLs and write what each refers to: its kind (video, playlist,
+channel, handle or user) and ID, plus the playlist a video was opened in
+and the start time of a t parameter. Watch, youtu.be, shorts, live, embed,
+playlist, channel, @handle and user URLs are understood, with or without
+scheme; bare IDs are recognized by their shape.
+
+Every command taking IDs accepts these URLs as well.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Annotations:  map[string]string{localAnnotation: "true"},
+		Example: `  ytdata parse-url "https://youtu.be/dQw4w9WgXcQ?t=42"
+  ytdata parse-url https://www.youtube.com/@GoogleDevelopers -f csv
+  ytdata parse-url "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLBCF2DAC6FFB574DE" --template '{{.id}}'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createCommandHandler(cmd, config, func(config Config) error {
+				return parseURLs(config, args)
+			})
+		},
+	}
+
+	addOutputFlag(cmd, "", "Write the parsed references to stdout (or file with -o)")
+	addFormatFlag(cmd, recordFormats()...)
+
+	return cmd
+}
+
+func parseURLs(config Config, args []string) (err error) {
+	var refs []youtubeRef
+	for _, arg := range args {
+		ref, err := parseYouTubeRef(arg)
+		if err != nil {
+			return withKind(ErrInvalidConfig, err)
+		}
+		refs = append(refs, ref)
+	}
+
+	out, err := openOutput(config, "references")
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out, &err)
+
+	for _, ref := range refs {
+		if err := out.Write(ref); err != nil {
+			return fmt.Errorf("failed to write reference: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestParseYouTubeRef(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  youtubeRef
+	}{
+		{"dQw4w9WgXcQ", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ"}},
+		{"UC_x5XG1OV2P6uZZ5FSM9Ttw", youtubeRef{Kind: refChannel, ID: "UC_x5XG1OV2P6uZZ5FSM9Ttw"}},
+		{"PLBCF2DAC6FFB574DE", youtubeRef{Kind: refPlaylist, ID: "PLBCF2DAC6FFB574DE"}},
+		{"@GoogleDevelopers", youtubeRef{Kind: refHandle, ID: "@GoogleDevelopers"}},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ"}},
+		{"youtube.com/watch?v=dQw4w9WgXcQ&list=PLBCF2DAC6FFB574DE&t=1m30s", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ", PlaylistID: "PLBCF2DAC6FFB574DE", StartSeconds: 90}},
+		{"https://m.youtube.com/watch?v=dQw4w9WgXcQ", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ"}},
+		{"https://youtu.be/dQw4w9WgXcQ?t=42", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ", StartSeconds: 42}},
+		{"https://www.youtube.com/shorts/dQw4w9WgXcQ", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/live/dQw4w9WgXcQ", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ"}},
+		{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", youtubeRef{Kind: refVideo, ID: "dQw4w9WgXcQ"}},
+		{"https://www.youtube.com/embed/videoseries?list=PLBCF2DAC6FFB574DE", youtubeRef{Kind: refPlaylist, ID: "PLBCF2DAC6FFB574DE"}},
+		{"https://www.youtube.com/playlist?list=PLBCF2DAC6FFB574DE", youtubeRef{Kind: refPlaylist, ID: "PLBCF2DAC6FFB574DE"}},
+		{"https://www.youtube.com/watch?list=PLBCF2DAC6FFB574DE", youtubeRef{Kind: refPlaylist, ID: "PLBCF2DAC6FFB574DE"}},
+		{"https://www.youtube.com/channel/UC_x5XG1OV2P6uZZ5FSM9Ttw/videos", youtubeRef{Kind: refChannel, ID: "UC_x5XG1OV2P6uZZ5FSM9Ttw"}},
+		{"https://www.youtube.com/@GoogleDevelopers/videos", youtubeRef{Kind: refHandle, ID: "@GoogleDevelopers"}},
+		{"https://www.youtube.com/user/GoogleDevelopers", youtubeRef{Kind: refUser, ID: "GoogleDevelopers"}},
+	} {
+		got, err := parseYouTubeRef(tt.input)
+		tt.want.Input = tt.input
+		if err != nil || got != tt.want {
+			t.Errorf("parseYouTubeRef(%q) = %+v, %v, want %+v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"https://example.com/watch?v=dQw4w9WgXcQ", "https://www.youtube.com/c/GoogleDevelopers", "https://www.youtube.com/feed/library", "not an id!"} {
+		if ref, err := parseYouTubeRef(input); err == nil {
+			t.Errorf("parseYouTubeRef(%q) = %+v, want an error", input, ref)
+		}
+	}
+}
+
+func TestIDFromArg(t *testing.T) {
+	for _, tt := range []struct {
+		arg, kind, want string
+	}{
+		{"PLBCF2DAC6FFB574DE", refPlaylist, "PLBCF2DAC6FFB574DE"},
+		{"https://www.youtube.com/playlist?list=PLBCF2DAC6FFB574DE", refPlaylist, "PLBCF2DAC6FFB574DE"},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLBCF2DAC6FFB574DE", refPlaylist, "PLBCF2DAC6FFB574DE"},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLBCF2DAC6FFB574DE", refVideo, "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/@GoogleDevelopers", refChannel, "@GoogleDevelopers"},
+		{"", refPlaylist, ""},
+	} {
+		if got, err := idFromArg(tt.arg, tt.kind); err != nil || got != tt.want {
+			t.Errorf("idFromArg(%q, %s) = %q, %v, want %q", tt.arg, tt.kind, got, err, tt.want)
+		}
+	}
+	if _, err := idFromArg("https://youtu.be/dQw4w9WgXcQ", refPlaylist); err == nil {
+		t.Error("a video URL was accepted as a playlist")
+	}
+}